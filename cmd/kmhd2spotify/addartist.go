@@ -0,0 +1,320 @@
+// Package cmd provides the add-artist command implementation for kmhd2spotify.
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/kmhd2spotify/internal/duplicate"
+	"github.com/toozej/kmhd2spotify/internal/nowplaying"
+	"github.com/toozej/kmhd2spotify/internal/playlist"
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+const (
+	// maxArtistCandidates is how many search results are offered when an artist
+	// name is ambiguous.
+	maxArtistCandidates = 5
+
+	// maxArtistTopTracks is the most top tracks Spotify returns for an artist.
+	maxArtistTopTracks = 5
+)
+
+// errAddArtistCancelled is returned when the user declines the preview or doesn't
+// pick an artist.
+var errAddArtistCancelled = errors.New("cancelled")
+
+// addArtistOptions are the options of the add-artist command.
+type addArtistOptions struct {
+	Playlist    string
+	Count       int
+	Force       bool
+	Yes         bool
+	DryRun      bool
+	Interactive bool
+}
+
+// newAddArtistCmd creates the add-artist command for adding an artist's top tracks to a playlist.
+func newAddArtistCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add-artist [artist]",
+		Short: "Add an artist's top tracks to a Spotify playlist",
+		Long: `Add an artist's top tracks on Spotify to one of your playlists, such as an
+incoming playlist (see 'kmhd2spotify playlists list --incoming').
+
+When several Spotify artists match the name, you are asked which one you mean. The
+tracks are previewed and added after you confirm. If the artist already has tracks in
+the playlist nothing is added, showing when they were last added, unless --force is set.
+
+With --now-playing, the artist of the song currently playing on KMHD is added.`,
+		Example: `  kmhd2spotify add-artist "Bill Evans" --playlist "Incoming: Jazz"
+  kmhd2spotify add-artist "Mary Lou Williams" --playlist 37i9dQZF1DX0SM0LYsmbMT --count 3 --yes
+  kmhd2spotify add-artist --now-playing --playlist "Incoming: Jazz" --dry-run`,
+		Args: cobra.MaximumNArgs(1),
+		Run:  runAddArtist,
+	}
+
+	cmd.Flags().String("playlist", "", "Name or ID of the playlist to add the tracks to (required)")
+	cmd.Flags().String("profile", "", "Profile whose playlist to add to (default account if empty)")
+	cmd.Flags().Int("count", maxArtistTopTracks, fmt.Sprintf("Number of top tracks to add (1-%d)", maxArtistTopTracks))
+	cmd.Flags().Bool("force", false, "Add the tracks even if the artist is already in the playlist")
+	cmd.Flags().Bool("now-playing", false, "Add the artist of the song currently playing on KMHD")
+	cmd.Flags().BoolP("yes", "y", false, "Add without asking for confirmation, picking the best match if ambiguous")
+	cmd.Flags().Bool("dry-run", false, "Only preview the tracks that would be added")
+	_ = cmd.MarkFlagRequired("playlist")
+
+	return cmd
+}
+
+// runAddArtist executes the add-artist command.
+func runAddArtist(cmd *cobra.Command, args []string) {
+	profile, _ := cmd.Flags().GetString("profile")
+	nowPlaying, _ := cmd.Flags().GetBool("now-playing")
+	opts := addArtistOptions{Interactive: stdinIsTerminal()}
+	opts.Playlist, _ = cmd.Flags().GetString("playlist")
+	opts.Count, _ = cmd.Flags().GetInt("count")
+	opts.Force, _ = cmd.Flags().GetBool("force")
+	opts.Yes, _ = cmd.Flags().GetBool("yes")
+	opts.DryRun, _ = cmd.Flags().GetBool("dry-run")
+
+	if opts.Count < 1 || opts.Count > maxArtistTopTracks {
+		log.WithField("count", opts.Count).Fatalf("--count must be between 1 and %d", maxArtistTopTracks)
+		return
+	}
+	if nowPlaying == (len(args) == 1) {
+		log.Fatal("Specify either an artist name or --now-playing")
+		return
+	}
+
+	var artistName string
+	if nowPlaying {
+		name, err := nowPlayingArtist()
+		if err != nil {
+			log.WithError(err).Fatal("Failed to get the artist playing on KMHD")
+			return
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "📻 Now playing on KMHD: %s\n", name)
+		artistName = name
+	} else {
+		artistName = args[0]
+	}
+
+	spotifyService, err := newProfileService(profile)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to create Spotify service")
+		return
+	}
+	if err := ensureAuthenticated(spotifyService, profile, false); err != nil {
+		log.WithError(err).Fatal("Spotify authentication failed")
+		return
+	}
+
+	result, err := addArtist(spotifyService, artistName, opts, bufio.NewReader(cmd.InOrStdin()), cmd.OutOrStdout())
+	if errors.Is(err, errAddArtistCancelled) {
+		fmt.Fprintln(cmd.OutOrStdout(), "Nothing added.")
+		return
+	}
+	if err != nil {
+		log.WithError(err).Fatal("Failed to add artist")
+		return
+	}
+	if result != nil {
+		writeAddResult(cmd.OutOrStdout(), result)
+	}
+}
+
+// nowPlayingArtist returns the artist of the song currently playing on KMHD.
+func nowPlayingArtist() (string, error) {
+	kmhdAPIClient, err := initializeKMHDAPIClient()
+	if err != nil {
+		return "", err
+	}
+	song, err := nowplaying.NewWatcher(kmhdAPIClient).Current()
+	if err != nil {
+		return "", err
+	}
+	if song == nil {
+		return "", errors.New("nothing is playing on KMHD right now")
+	}
+	return song.Artist, nil
+}
+
+// addArtist resolves the artist and playlist, previews the artist's top tracks and adds
+// them to the playlist. Unless forced, an artist already in the playlist is reported
+// with its existing tracks instead of being previewed. It returns a nil result for a
+// dry run that would add tracks.
+func addArtist(spotifyService types.SpotifyService, artistName string, opts addArtistOptions, in *bufio.Reader, out io.Writer) (*types.AddResult, error) {
+	target, err := findPlaylist(spotifyService, opts.Playlist)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := spotifyService.SearchArtists(artistName, maxArtistCandidates)
+	if err != nil {
+		return nil, err
+	}
+	artist, err := chooseArtist(artistName, candidates, opts.Interactive && !opts.Yes, in, out)
+	if err != nil {
+		return nil, err
+	}
+
+	service := playlist.NewPlaylistService(spotifyService, duplicate.NewDuplicateService(spotifyService, log.StandardLogger()), log.StandardLogger())
+	tracks, err := service.GetTopTracks(artist.ID, opts.Count)
+	if err != nil {
+		return nil, err
+	}
+	if len(tracks) == 0 {
+		return nil, fmt.Errorf("%s has no tracks available", artist.Name)
+	}
+
+	if !opts.Force {
+		if existing := service.ExistingArtistTracks(artist, target.ID); existing != nil {
+			existing.Playlist = target
+			fmt.Fprintf(out, "🎷 %s\n", describeArtist(artist))
+			return existing, nil
+		}
+	}
+
+	writeAddPreview(out, artist, tracks, target, opts.DryRun)
+	if opts.DryRun {
+		return nil, nil
+	}
+	if opts.Interactive && !opts.Yes {
+		confirmed, err := confirm(in, out, fmt.Sprintf("Add %s to %s?", pluralize(len(tracks), "track"), target.Name))
+		if err != nil {
+			return nil, err
+		}
+		if !confirmed {
+			return nil, errAddArtistCancelled
+		}
+	}
+
+	// The artist was already checked against the playlist before the preview
+	result, err := service.AddArtistTracksToPlaylist(artist, tracks, target.ID, true)
+	if result != nil {
+		result.Playlist = target
+	}
+	return result, err
+}
+
+// chooseArtist picks the artist meant by the name among the search results. An artist
+// whose name is the only exact match is picked; otherwise the plausible artists are
+// offered when prompting, or the most relevant one is picked.
+func chooseArtist(name string, candidates []types.Artist, prompt bool, in *bufio.Reader, out io.Writer) (types.Artist, error) {
+	if len(candidates) == 0 {
+		return types.Artist{}, fmt.Errorf("no artists found for '%s'", name)
+	}
+
+	plausible := plausibleArtists(name, candidates)
+	if len(plausible) == 1 || !prompt {
+		return plausible[0], nil
+	}
+
+	fmt.Fprintf(out, "🔎 Several artists match '%s':\n", name)
+	for i, artist := range plausible {
+		fmt.Fprintf(out, "   %d) %s\n", i+1, describeArtist(artist))
+	}
+	fmt.Fprintf(out, "Which one? [1-%d, empty to cancel]: ", len(plausible))
+
+	line, err := in.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return types.Artist{}, err
+	}
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return types.Artist{}, errAddArtistCancelled
+	}
+	choice, err := strconv.Atoi(line)
+	if err != nil || choice < 1 || choice > len(plausible) {
+		return types.Artist{}, fmt.Errorf("invalid choice '%s'", line)
+	}
+	return plausible[choice-1], nil
+}
+
+// plausibleArtists returns the search results named exactly like the query, ignoring
+// case, or all results if none is.
+func plausibleArtists(name string, candidates []types.Artist) []types.Artist {
+	var exact []types.Artist
+	for _, artist := range candidates {
+		if strings.EqualFold(strings.TrimSpace(artist.Name), strings.TrimSpace(name)) {
+			exact = append(exact, artist)
+		}
+	}
+	if len(exact) == 0 {
+		return candidates
+	}
+	return exact
+}
+
+// describeArtist formats an artist with what tells it apart from namesakes.
+func describeArtist(artist types.Artist) string {
+	description := artist.Name
+	if len(artist.Genres) > 0 {
+		genres := artist.Genres
+		if len(genres) > 3 {
+			genres = genres[:3]
+		}
+		description += " (" + strings.Join(genres, ", ") + ")"
+	}
+	if artist.Popularity > 0 {
+		description += fmt.Sprintf(", popularity %d", artist.Popularity)
+	}
+	return description
+}
+
+// confirm asks a yes/no question, defaulting to no.
+func confirm(in *bufio.Reader, out io.Writer, question string) (bool, error) {
+	fmt.Fprintf(out, "%s [y/N]: ", question)
+	line, err := in.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes", nil
+}
+
+// writeAddPreview prints the tracks that will be added to the playlist.
+func writeAddPreview(out io.Writer, artist types.Artist, tracks []types.Track, target types.Playlist, dryRun bool) {
+	verb := "Adding"
+	if dryRun {
+		verb = "Would add"
+	}
+	fmt.Fprintf(out, "🎷 %s\n", describeArtist(artist))
+	fmt.Fprintf(out, "%s %s to %s:\n", verb, pluralize(len(tracks), "track"), target.Name)
+	for i, track := range tracks {
+		fmt.Fprintf(out, "   %d. %s\n", i+1, dedupeTrackName(track))
+	}
+}
+
+// writeAddResult prints the outcome of adding an artist to a playlist.
+func writeAddResult(out io.Writer, result *types.AddResult) {
+	if result.Success {
+		fmt.Fprintf(out, "✅ Added %s by %s to %s\n", pluralize(len(result.TracksAdded), "track"), result.Artist.Name, result.Playlist.Name)
+		return
+	}
+	if !result.WasDuplicate {
+		fmt.Fprintf(out, "❌ %s\n", result.Message)
+		return
+	}
+
+	lastAdded := "unknown"
+	if result.LastAdded != nil {
+		lastAdded = result.LastAdded.Local().Format("2006-01-02")
+	}
+	fmt.Fprintf(out, "⏭️  %s already has %s in %s (last added %s):\n", result.Artist.Name, pluralize(len(result.ExistingTracks), "track"), result.Playlist.Name, lastAdded)
+	for _, item := range result.ExistingTracks {
+		addedAt := "unknown"
+		if !item.AddedAt.IsZero() {
+			addedAt = item.AddedAt.Local().Format("2006-01-02")
+		}
+		fmt.Fprintf(out, "   %s (added %s)\n", dedupeTrackName(item.Track), addedAt)
+	}
+	fmt.Fprintln(out, "Run again with --force to add the tracks anyway.")
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+// MockAddArtistSpotifyService serves artist search results and a playlist's items, and
+// records the tracks added
+type MockAddArtistSpotifyService struct {
+	MockSpotifyServiceForSync
+	artists []types.Artist
+	items   []types.PlaylistItem
+	added   map[string][]string
+}
+
+func (m *MockAddArtistSpotifyService) SearchArtists(query string, limit int) ([]types.Artist, error) {
+	return m.artists, nil
+}
+
+func (m *MockAddArtistSpotifyService) GetPlaylistItems(playlistID string) ([]types.PlaylistItem, error) {
+	return m.items, nil
+}
+
+func (m *MockAddArtistSpotifyService) AddTracksToPlaylist(playlistID string, trackIDs []string) error {
+	if m.added == nil {
+		m.added = make(map[string][]string)
+	}
+	m.added[playlistID] = append(m.added[playlistID], trackIDs...)
+	return nil
+}
+
+func TestNewAddArtistCmd(t *testing.T) {
+	cmd := newAddArtistCmd()
+
+	assert.Equal(t, "add-artist [artist]", cmd.Use)
+	for _, name := range []string{"playlist", "profile", "count", "force", "now-playing", "yes", "dry-run"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), name)
+	}
+	assert.Equal(t, "5", cmd.Flags().Lookup("count").DefValue)
+	assert.Error(t, cmd.Args(cmd, []string{"one", "two"}))
+}
+
+func TestAddArtist(t *testing.T) {
+	pianist := types.Artist{ID: "evans-piano", Name: "Bill Evans", Genres: []string{"cool jazz", "jazz piano"}, Popularity: 60}
+	saxophonist := types.Artist{ID: "evans-sax", Name: "Bill Evans", Genres: []string{"jazz fusion"}, Popularity: 30}
+	topTracks := []types.Track{
+		{ID: "waltz", Name: "Waltz for Debby", Artists: []types.Artist{pianist}},
+		{ID: "peace", Name: "Peace Piece", Artists: []types.Artist{pianist}},
+		{ID: "nardis", Name: "Nardis", Artists: []types.Artist{pianist}},
+	}
+	inbox := types.Playlist{ID: "inbox", Name: "Incoming: Jazz", IsIncoming: true}
+	newMock := func() *MockAddArtistSpotifyService {
+		return &MockAddArtistSpotifyService{
+			MockSpotifyServiceForSync: MockSpotifyServiceForSync{playlists: []types.Playlist{inbox}, tracks: topTracks},
+			artists:                   []types.Artist{pianist, saxophonist, {ID: "evans-gil", Name: "Gil Evans"}},
+		}
+	}
+	input := func(lines ...string) *bufio.Reader {
+		return bufio.NewReader(strings.NewReader(strings.Join(lines, "\n") + "\n"))
+	}
+
+	t.Run("disambiguate and confirm", func(t *testing.T) {
+		mock := newMock()
+		var out bytes.Buffer
+
+		result, err := addArtist(mock, "bill evans", addArtistOptions{Playlist: "Incoming: Jazz", Count: 2, Interactive: true}, input("2", "y"), &out)
+		require.NoError(t, err)
+
+		assert.True(t, result.Success)
+		assert.Equal(t, "evans-sax", result.Artist.ID)
+		assert.Equal(t, inbox, result.Playlist)
+		assert.Equal(t, []string{"waltz", "peace"}, mock.added["inbox"])
+		assert.Contains(t, out.String(), "1) Bill Evans (cool jazz, jazz piano), popularity 60")
+		assert.Contains(t, out.String(), "2) Bill Evans (jazz fusion), popularity 30")
+		assert.NotContains(t, out.String(), "Gil Evans")
+		assert.Contains(t, out.String(), "Adding 2 tracks to Incoming: Jazz")
+		assert.Contains(t, out.String(), "Add 2 tracks to Incoming: Jazz? [y/N]")
+	})
+
+	t.Run("declined", func(t *testing.T) {
+		mock := newMock()
+		mock.artists = []types.Artist{pianist}
+
+		_, err := addArtist(mock, "Bill Evans", addArtistOptions{Playlist: "inbox", Count: 5, Interactive: true}, input("n"), &bytes.Buffer{})
+		assert.ErrorIs(t, err, errAddArtistCancelled)
+		assert.Empty(t, mock.added)
+	})
+
+	t.Run("cancelled choice", func(t *testing.T) {
+		_, err := addArtist(newMock(), "Bill Evans", addArtistOptions{Playlist: "inbox", Count: 5, Interactive: true}, input(""), &bytes.Buffer{})
+		assert.ErrorIs(t, err, errAddArtistCancelled)
+	})
+
+	t.Run("invalid choice", func(t *testing.T) {
+		_, err := addArtist(newMock(), "Bill Evans", addArtistOptions{Playlist: "inbox", Count: 5, Interactive: true}, input("7"), &bytes.Buffer{})
+		assert.ErrorContains(t, err, "invalid choice '7'")
+	})
+
+	t.Run("non-interactive picks most relevant", func(t *testing.T) {
+		mock := newMock()
+
+		result, err := addArtist(mock, "Bill Evans", addArtistOptions{Playlist: "inbox", Count: 5}, input(), &bytes.Buffer{})
+		require.NoError(t, err)
+		assert.Equal(t, "evans-piano", result.Artist.ID)
+		assert.Equal(t, []string{"waltz", "peace", "nardis"}, mock.added["inbox"])
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		mock := newMock()
+		var out bytes.Buffer
+
+		result, err := addArtist(mock, "Bill Evans", addArtistOptions{Playlist: "inbox", Count: 1, DryRun: true, Interactive: true, Yes: true}, input(), &out)
+		require.NoError(t, err)
+		assert.Nil(t, result)
+		assert.Empty(t, mock.added)
+		assert.Contains(t, out.String(), "Would add 1 track to Incoming: Jazz:\n   1. Bill Evans - Waltz for Debby\n")
+	})
+
+	t.Run("already in playlist", func(t *testing.T) {
+		mock := newMock()
+		mock.artists = []types.Artist{pianist}
+		lastAdded := time.Date(2025, 9, 14, 20, 0, 0, 0, time.UTC)
+		mock.items = []types.PlaylistItem{{Track: topTracks[2], AddedAt: lastAdded}}
+
+		result, err := addArtist(mock, "Bill Evans", addArtistOptions{Playlist: "inbox", Count: 5, Yes: true}, input(), &bytes.Buffer{})
+		require.NoError(t, err)
+		assert.True(t, result.WasDuplicate)
+		assert.Empty(t, mock.added)
+		require.NotNil(t, result.LastAdded)
+		assert.Equal(t, lastAdded, *result.LastAdded)
+
+		var out bytes.Buffer
+		writeAddResult(&out, result)
+		assert.Contains(t, out.String(), "Bill Evans already has 1 track in Incoming: Jazz")
+		assert.Contains(t, out.String(), "Nardis (added 2025-09-1")
+		assert.Contains(t, out.String(), "--force")
+
+		out.Reset()
+		result, err = addArtist(mock, "Bill Evans", addArtistOptions{Playlist: "inbox", Count: 5, DryRun: true}, input(), &out)
+		require.NoError(t, err)
+		assert.True(t, result.WasDuplicate)
+		assert.NotContains(t, out.String(), "Would add")
+
+		out.Reset()
+		result, err = addArtist(mock, "Bill Evans", addArtistOptions{Playlist: "inbox", Count: 5, Interactive: true}, input(), &out)
+		require.NoError(t, err)
+		assert.True(t, result.WasDuplicate)
+		assert.NotContains(t, out.String(), "[y/N]")
+		assert.Empty(t, mock.added)
+
+		result, err = addArtist(mock, "Bill Evans", addArtistOptions{Playlist: "inbox", Count: 5, Yes: true, Force: true}, input(), &bytes.Buffer{})
+		require.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.Len(t, mock.added["inbox"], 3)
+	})
+
+	t.Run("unknown playlist", func(t *testing.T) {
+		_, err := addArtist(newMock(), "Bill Evans", addArtistOptions{Playlist: "Outgoing", Count: 5}, input(), &bytes.Buffer{})
+		assert.ErrorContains(t, err, "no playlist named 'Outgoing' found")
+	})
+}
+
+func TestPlausibleArtists(t *testing.T) {
+	candidates := []types.Artist{{ID: "1", Name: "Bill Evans"}, {ID: "2", Name: "Gil Evans"}}
+
+	assert.Equal(t, candidates[:1], plausibleArtists(" bill evans", candidates))
+	assert.Equal(t, candidates, plausibleArtists("evans", candidates))
+}
+
+func TestWriteAddResult(t *testing.T) {
+	var out bytes.Buffer
+	writeAddResult(&out, &types.AddResult{
+		Success:     true,
+		Artist:      types.Artist{Name: "Mary Lou Williams"},
+		TracksAdded: []types.Track{{ID: "1"}, {ID: "2"}},
+		Playlist:    types.Playlist{Name: "Incoming: Jazz"},
+	})
+	assert.Equal(t, "✅ Added 2 tracks by Mary Lou Williams to Incoming: Jazz\n", out.String())
+
+	out.Reset()
+	writeAddResult(&out, &types.AddResult{WasDuplicate: true, Artist: types.Artist{Name: "Mary Lou Williams"}})
+	assert.Contains(t, out.String(), "last added unknown")
+}
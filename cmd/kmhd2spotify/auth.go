@@ -0,0 +1,260 @@
+// Package cmd provides the auth command implementation for kmhd2spotify.
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+// newAuthCmd creates the auth command for authenticating with Spotify without a local browser.
+func newAuthCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Authenticate with Spotify",
+		Long: `Authenticate with Spotify and store the token for later syncs.
+
+By default the authorization URL is printed; open it in a browser on any machine,
+approve access, and paste the URL you are redirected to (or just its code) back
+into the terminal. The redirect does not need to reach this machine, so this works
+on headless servers behind NAT.
+
+With --pkce (or when SPOTIFY_CLIENT_SECRET is not set), PKCE is used so the client
+secret is not needed on this machine. With --callback, a local server on
+SERVER_HOST:SERVER_PORT receives the redirect instead.
+
+Use 'auth export' and 'auth import' to authenticate on one machine and copy the
+token to another. With --profile, the named profile from SPOTIFY_PROFILES is
+authenticated instead of the default account.`,
+		Args: cobra.NoArgs,
+		Run:  runAuth,
+	}
+
+	cmd.Flags().Bool("pkce", false, "Use PKCE so the client secret is not needed")
+	cmd.Flags().Bool("callback", false, "Receive the redirect on a local callback server instead of pasting it")
+	cmd.Flags().Bool("force", false, "Authenticate again even if a valid token is stored")
+	cmd.PersistentFlags().String("profile", "", "Profile to authenticate (default account if empty)")
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "export",
+			Short: "Print the stored token as a portable blob",
+			Long: `Print the stored Spotify token as a single-line blob that can be imported on another
+machine with 'kmhd2spotify auth import'. The blob grants access to your Spotify account,
+so treat it like a password.`,
+			Args: cobra.NoArgs,
+			Run:  runAuthExport,
+		},
+		&cobra.Command{
+			Use:   "import [blob]",
+			Short: "Import a token blob from 'auth export'",
+			Long:  `Validate and store a token blob produced by 'kmhd2spotify auth export'. The blob is read from stdin if not given as an argument.`,
+			Args:  cobra.MaximumNArgs(1),
+			Run:   runAuthImport,
+		},
+	)
+
+	return cmd
+}
+
+// runAuth executes the auth command.
+func runAuth(cmd *cobra.Command, args []string) {
+	usePKCE, _ := cmd.Flags().GetBool("pkce")
+	useCallback, _ := cmd.Flags().GetBool("callback")
+	force, _ := cmd.Flags().GetBool("force")
+
+	if usePKCE {
+		conf.Spotify.UsePKCE = true
+	}
+
+	profile, _ := cmd.Flags().GetString("profile")
+	spotifyService, err := newProfileService(profile)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to set up Spotify")
+		return
+	}
+	if spotifyService.IsAuthenticated() && !force {
+		fmt.Fprintf(cmd.OutOrStdout(), "✅ Already authenticated with Spotify%s (use --force to authenticate again)\n", profileSuffix(profile))
+		return
+	}
+
+	if useCallback {
+		err = authenticateSpotify(spotifyService)
+	} else {
+		err = authenticateByPaste(spotifyService, cmd.InOrStdin(), cmd.OutOrStdout())
+	}
+	if err != nil {
+		log.WithError(err).Fatal("Failed to authenticate with Spotify")
+		return
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "✅ Spotify authentication completed and token saved%s\n", profileSuffix(profile))
+}
+
+// runAuthExport executes the auth export command.
+func runAuthExport(cmd *cobra.Command, args []string) {
+	profile, _ := cmd.Flags().GetString("profile")
+	spotifyService, err := newProfileService(profile)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to set up Spotify")
+		return
+	}
+
+	blob, err := spotifyService.ExportToken()
+	if err != nil {
+		log.WithError(err).Fatalf("Failed to export Spotify token, run 'kmhd2spotify auth%s' first", profileFlagHint(profile))
+		return
+	}
+
+	fmt.Fprintln(cmd.ErrOrStderr(), "🔑 This blob grants access to your Spotify account, keep it secret:")
+	fmt.Fprintln(cmd.OutOrStdout(), blob)
+}
+
+// runAuthImport executes the auth import command.
+func runAuthImport(cmd *cobra.Command, args []string) {
+	var blob string
+	if len(args) == 1 {
+		blob = args[0]
+	} else {
+		line, err := readLine(cmd.InOrStdin())
+		if err != nil {
+			log.WithError(err).Fatal("Failed to read token blob from stdin")
+			return
+		}
+		blob = line
+	}
+
+	profile, _ := cmd.Flags().GetString("profile")
+	spotifyService, err := newProfileService(profile)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to set up Spotify")
+		return
+	}
+	if err := spotifyService.ImportToken(blob); err != nil {
+		log.WithError(err).Fatal("Failed to import Spotify token")
+		return
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "✅ Spotify token imported and saved%s\n", profileSuffix(profile))
+}
+
+// authenticateByPaste prints the authorization URL and completes authentication with the
+// redirect URL or code pasted by the user.
+func authenticateByPaste(spotifyService types.SpotifyService, in io.Reader, out io.Writer) error {
+	authURL := spotifyService.GetAuthURL()
+	if authURL == "" {
+		return fmt.Errorf("spotify client not available, check SPOTIFY_CLIENT_ID and SPOTIFY_REDIRECT_URI")
+	}
+
+	fmt.Fprintf(out, "\n🔐 Spotify Authentication\n")
+	fmt.Fprintf(out, "1. Open this URL in a browser on any machine:\n\n%s\n\n", authURL)
+	fmt.Fprintf(out, "2. Approve access. The browser is redirected to your redirect URI; it's fine if that page fails to load.\n")
+	fmt.Fprintf(out, "3. Paste the full URL from the address bar (or just the code) here:\n> ")
+
+	input, err := readLine(in)
+	if err != nil {
+		return fmt.Errorf("failed to read redirect URL: %w", err)
+	}
+
+	code, state, err := parseAuthResponse(input, authStateFromURL(authURL))
+	if err != nil {
+		return err
+	}
+
+	return spotifyService.CompleteAuth(code, state)
+}
+
+// parseAuthResponse extracts the authorization code and state from a pasted redirect URL
+// or bare code. If the input carries no state, defaultState is returned.
+func parseAuthResponse(input, defaultState string) (string, string, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return "", "", fmt.Errorf("no redirect URL or code provided")
+	}
+
+	code, state := input, ""
+	if strings.Contains(input, "://") || strings.Contains(input, "code=") || strings.Contains(input, "error=") {
+		rawQuery := input
+		if i := strings.Index(input, "?"); i >= 0 {
+			rawQuery = input[i+1:]
+		}
+		query, err := url.ParseQuery(rawQuery)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to parse redirect URL: %w", err)
+		}
+		if errorParam := query.Get("error"); errorParam != "" {
+			return "", "", fmt.Errorf("spotify authentication error: %s", errorParam)
+		}
+		code, state = query.Get("code"), query.Get("state")
+		if code == "" {
+			return "", "", fmt.Errorf("no authorization code found in redirect URL")
+		}
+	}
+
+	if state == "" {
+		state = defaultState
+	}
+	return code, state, nil
+}
+
+// authStateFromURL returns the state parameter of an authorization URL.
+func authStateFromURL(authURL string) string {
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		return ""
+	}
+	return parsed.Query().Get("state")
+}
+
+// readLine reads a single line from the reader.
+func readLine(in io.Reader) (string, error) {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return strings.TrimSpace(scanner.Text()), nil
+}
+
+// canWaitForAuth reports whether sync may block waiting for interactive authentication.
+// Inside a container or without a terminal nobody can complete the flow, so sync fails
+// fast instead, unless waiting was explicitly requested.
+func canWaitForAuth(waitRequested, inContainer, interactive bool) bool {
+	if waitRequested {
+		return true
+	}
+	return !inContainer && interactive
+}
+
+// runningInContainer reports whether the process appears to run inside a container.
+func runningInContainer() bool {
+	if os.Getenv("container") != "" {
+		return true
+	}
+	for _, marker := range []string{"/.dockerenv", "/run/.containerenv"} {
+		if _, err := os.Stat(marker); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// stdinIsTerminal reports whether stdin is an interactive terminal.
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockAuthSpotifyService records the code and state passed to CompleteAuth
+type MockAuthSpotifyService struct {
+	MockSpotifyServiceForSync
+	authURL     string
+	completeErr error
+	gotCode     string
+	gotState    string
+}
+
+func (m *MockAuthSpotifyService) GetAuthURL() string {
+	return m.authURL
+}
+
+func (m *MockAuthSpotifyService) CompleteAuth(code, state string) error {
+	m.gotCode = code
+	m.gotState = state
+	return m.completeErr
+}
+
+func TestNewAuthCmd(t *testing.T) {
+	cmd := newAuthCmd()
+	assert.Equal(t, "auth", cmd.Use)
+	assert.NotNil(t, cmd.Flags().Lookup("pkce"))
+	assert.NotNil(t, cmd.Flags().Lookup("callback"))
+	assert.NotNil(t, cmd.Flags().Lookup("force"))
+
+	var names []string
+	for _, sub := range cmd.Commands() {
+		names = append(names, sub.Name())
+	}
+	assert.ElementsMatch(t, []string{"export", "import"}, names)
+}
+
+func TestParseAuthResponse(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantCode  string
+		wantState string
+		wantErr   bool
+	}{
+		{
+			name:      "full redirect URL",
+			input:     "http://127.0.0.1:8080/callback?code=abc123&state=xyz",
+			wantCode:  "abc123",
+			wantState: "xyz",
+		},
+		{
+			name:      "redirect URL without state uses default",
+			input:     "http://127.0.0.1:8080/callback?code=abc123",
+			wantCode:  "abc123",
+			wantState: "default-state",
+		},
+		{
+			name:      "query string only",
+			input:     "code=abc123&state=xyz",
+			wantCode:  "abc123",
+			wantState: "xyz",
+		},
+		{
+			name:      "bare code",
+			input:     "  abc123\n",
+			wantCode:  "abc123",
+			wantState: "default-state",
+		},
+		{
+			name:    "denied access",
+			input:   "http://127.0.0.1:8080/callback?error=access_denied&state=xyz",
+			wantErr: true,
+		},
+		{
+			name:    "URL without code",
+			input:   "http://127.0.0.1:8080/callback?state=xyz",
+			wantErr: true,
+		},
+		{
+			name:    "empty input",
+			input:   "   ",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, state, err := parseAuthResponse(tt.input, "default-state")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCode, code)
+			assert.Equal(t, tt.wantState, state)
+		})
+	}
+}
+
+func TestAuthStateFromURL(t *testing.T) {
+	assert.Equal(t, "xyz", authStateFromURL("https://accounts.spotify.com/authorize?client_id=id&state=xyz"))
+	assert.Empty(t, authStateFromURL("https://accounts.spotify.com/authorize"))
+	assert.Empty(t, authStateFromURL("://bad"))
+}
+
+func TestAuthenticateByPaste(t *testing.T) {
+	authURL := "https://accounts.spotify.com/authorize?client_id=id&state=expected-state"
+
+	t.Run("pasted redirect URL", func(t *testing.T) {
+		mock := &MockAuthSpotifyService{authURL: authURL}
+		var out bytes.Buffer
+
+		err := authenticateByPaste(mock, strings.NewReader("http://127.0.0.1:8080/callback?code=abc&state=expected-state\n"), &out)
+		require.NoError(t, err)
+		assert.Equal(t, "abc", mock.gotCode)
+		assert.Equal(t, "expected-state", mock.gotState)
+		assert.Contains(t, out.String(), authURL)
+	})
+
+	t.Run("pasted code uses auth URL state", func(t *testing.T) {
+		mock := &MockAuthSpotifyService{authURL: authURL}
+
+		err := authenticateByPaste(mock, strings.NewReader("abc\n"), &bytes.Buffer{})
+		require.NoError(t, err)
+		assert.Equal(t, "abc", mock.gotCode)
+		assert.Equal(t, "expected-state", mock.gotState)
+	})
+
+	t.Run("complete auth error", func(t *testing.T) {
+		mock := &MockAuthSpotifyService{authURL: authURL, completeErr: errors.New("invalid code")}
+
+		err := authenticateByPaste(mock, strings.NewReader("abc\n"), &bytes.Buffer{})
+		assert.Error(t, err)
+	})
+
+	t.Run("no input", func(t *testing.T) {
+		mock := &MockAuthSpotifyService{authURL: authURL}
+
+		err := authenticateByPaste(mock, strings.NewReader(""), &bytes.Buffer{})
+		assert.Error(t, err)
+		assert.Empty(t, mock.gotCode)
+	})
+
+	t.Run("client unavailable", func(t *testing.T) {
+		mock := &MockAuthSpotifyService{}
+
+		err := authenticateByPaste(mock, strings.NewReader("abc\n"), &bytes.Buffer{})
+		assert.Error(t, err)
+	})
+}
+
+func TestCanWaitForAuth(t *testing.T) {
+	tests := []struct {
+		name          string
+		waitRequested bool
+		inContainer   bool
+		interactive   bool
+		want          bool
+	}{
+		{name: "interactive terminal", interactive: true, want: true},
+		{name: "no terminal", interactive: false, want: false},
+		{name: "container with terminal", inContainer: true, interactive: true, want: false},
+		{name: "wait requested in container", waitRequested: true, inContainer: true, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, canWaitForAuth(tt.waitRequested, tt.inContainer, tt.interactive))
+		})
+	}
+}
@@ -0,0 +1,188 @@
+// Package cmd provides the chart command and chart playlists for kmhd2spotify.
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/kmhd2spotify/internal/chart"
+	"github.com/toozej/kmhd2spotify/internal/history"
+	"github.com/toozej/kmhd2spotify/pkg/config"
+)
+
+// newChartCmd creates the chart command for reporting what KMHD plays most.
+func newChartCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "chart",
+		Short: "Show what KMHD plays most",
+		Long: `Show the tracks, artists and albums KMHD played most over the last week,
+month or year, counted from the play history recorded during syncs.
+With --update, also replace the chart playlist (CHART_PLAYLIST_NAME) of every profile
+with the top CHART_SIZE tracks, most played first. The sync command does this after
+every cycle when CHART_PLAYLIST_NAME is set.`,
+		Args: cobra.NoArgs,
+		Run:  runChart,
+	}
+
+	cmd.Flags().String("period", "", "Period to chart: week, month or year (default CHART_PERIOD)")
+	cmd.Flags().IntP("limit", "n", 10, "Number of tracks, artists and albums to show")
+	cmd.Flags().String("format", "text", "Output format: text or json")
+	cmd.Flags().Bool("update", false, "Update the chart playlist of every profile")
+
+	return cmd
+}
+
+// runChart executes the chart command.
+func runChart(cmd *cobra.Command, args []string) {
+	period, _ := cmd.Flags().GetString("period")
+	limit, _ := cmd.Flags().GetInt("limit")
+	format, _ := cmd.Flags().GetString("format")
+	update, _ := cmd.Flags().GetBool("update")
+
+	if period == "" {
+		period = conf.Chart.Period
+	}
+	if _, ok := config.ChartPeriods[period]; !ok {
+		log.WithField("period", period).Fatal("Unsupported chart period, expected week, month or year")
+		return
+	}
+	if format != "text" && format != "json" {
+		log.WithField("format", format).Fatal("Unsupported output format, expected text or json")
+		return
+	}
+	if update && conf.Chart.PlaylistName == "" {
+		log.Fatal("CHART_PLAYLIST_NAME must be set to update the chart playlist")
+		return
+	}
+
+	store, err := openPlayHistoryReadOnly()
+	if err != nil {
+		log.WithError(err).Fatal("Failed to open play history")
+		return
+	}
+	playHistory = store
+
+	now := time.Now()
+	report := buildChart(store, period, now, nil)
+	if err := writeChart(cmd.OutOrStdout(), format, report.Top(limit)); err != nil {
+		log.WithError(err).Fatal("Failed to write chart")
+		return
+	}
+
+	if update {
+		targets, err := newAccountTargets(false)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to set up Spotify accounts")
+			return
+		}
+		updateChartPlaylists(targets, period, now)
+	}
+}
+
+// buildChart ranks the plays of the period ending at now. If accept is not nil, only
+// the plays it accepts are counted.
+func buildChart(store *history.Store, period string, now time.Time, accept func(history.Play) bool) chart.Report {
+	from := now.Add(-config.ChartPeriods[period])
+	plays := store.Between(from, now)
+	if accept != nil {
+		var accepted []history.Play
+		for _, play := range plays {
+			if accept(play) {
+				accepted = append(accepted, play)
+			}
+		}
+		plays = accepted
+	}
+	return chart.Build(period, from, now, plays)
+}
+
+// updateChartPlaylists replaces every target's chart playlist with the most played
+// tracks of the period, ranked by play count.
+func updateChartPlaylists(targets []syncTarget, period string, now time.Time) {
+	if conf.Chart.PlaylistName == "" || playHistory == nil {
+		return
+	}
+
+	description := fmt.Sprintf("The %d tracks KMHD jazz radio played most in the last %s, most played first.", conf.Chart.Size, period)
+
+	for _, target := range targets {
+		report := buildChart(playHistory, period, now, func(play history.Play) bool {
+			return target.Accepts(play.Song)
+		}).Top(conf.Chart.Size)
+
+		playlist, err := getOrCreateTargetPlaylist(target, conf.Chart.PlaylistName, description)
+		if err != nil {
+			log.WithError(err).WithField("profile", target.Profile).Warn("Failed to get or create chart playlist")
+			continue
+		}
+
+		added, removed, err := syncPlaylistTracks(target.Service, playlist.ID, report.TrackIDs())
+		if err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"profile":  target.Profile,
+				"playlist": playlist.Name,
+			}).Warn("Failed to update chart playlist")
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"profile":  target.Profile,
+			"playlist": playlist.Name,
+			"period":   period,
+			"tracks":   len(report.Tracks),
+			"added":    added,
+			"removed":  removed,
+		}).Debug("Updated chart playlist")
+		if added > 0 || removed > 0 {
+			fmt.Printf("📈 Chart playlist %s%s: %d added, %d removed\n", playlist.Name, profileSuffix(target.Profile), added, removed)
+		}
+	}
+}
+
+// writeChart prints a chart report in the requested format.
+func writeChart(out io.Writer, format string, report chart.Report) error {
+	if format == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	location := kmhdLocation()
+	fmt.Fprintf(out, "📈 KMHD chart for the last %s (%s to %s)\n", report.Period,
+		report.From.In(location).Format("2006-01-02"), report.Until.In(location).Format("2006-01-02"))
+	fmt.Fprintf(out, "   %d plays, %d matched on Spotify\n", report.Plays, report.MatchedPlays)
+	if report.Plays == 0 {
+		fmt.Fprintln(out, "\n📭 No plays recorded in this period yet, run sync to record plays")
+		return nil
+	}
+
+	fmt.Fprintln(out, "\n🎵 Top tracks")
+	for i, track := range report.Tracks {
+		fmt.Fprintf(out, "   %2d. %s - %s (%s)\n", i+1, strings.Join(track.Artists, ", "), track.Name, pluralize(track.Plays, "play"))
+	}
+
+	fmt.Fprintln(out, "\n🎤 Top artists")
+	for i, artist := range report.Artists {
+		fmt.Fprintf(out, "   %2d. %s (%s)\n", i+1, artist.Name, pluralize(artist.Plays, "play"))
+	}
+
+	fmt.Fprintln(out, "\n💿 Top albums")
+	for i, album := range report.Albums {
+		fmt.Fprintf(out, "   %2d. %s by %s (%s)\n", i+1, album.Name, album.Artist, pluralize(album.Plays, "play"))
+	}
+	return nil
+}
+
+// pluralize formats a count of a noun, such as "1 play" or "3 plays".
+func pluralize(count int, noun string) string {
+	if count == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/chart"
+	"github.com/toozej/kmhd2spotify/internal/history"
+	"github.com/toozej/kmhd2spotify/internal/types"
+	"github.com/toozej/kmhd2spotify/pkg/config"
+)
+
+// newTestChartHistory creates a play history where track b is played three times,
+// track a twice and track c (an excluded artist) once, plus a play outside the month
+func newTestChartHistory(t *testing.T, now time.Time) *history.Store {
+	t.Helper()
+	store, err := history.Open(filepath.Join(t.TempDir(), history.FileName))
+	require.NoError(t, err)
+
+	plays := []struct {
+		artist, title, trackID string
+		ago                    time.Duration
+	}{
+		{"Miles Davis", "So What", "a", 5 * time.Hour},
+		{"John Coltrane", "Giant Steps", "b", 4 * time.Hour},
+		{"Miles Davis", "So What", "a", 3 * time.Hour},
+		{"John Coltrane", "Giant Steps", "b", 2 * time.Hour},
+		{"John Coltrane", "Giant Steps", "b", time.Hour},
+		{"Kenny G", "Songbird", "c", 30 * time.Minute},
+		{"Bill Evans", "Peace Piece", "d", 60 * 24 * time.Hour},
+	}
+	for _, p := range plays {
+		song := types.Song{Artist: p.artist, Title: p.title, Album: p.title, PlayedAt: now.Add(-p.ago)}
+		_, err := store.Record([]types.Song{song})
+		require.NoError(t, err)
+		require.NoError(t, store.SetMatch(song, types.Track{ID: p.trackID, Name: p.title, Artists: []types.Artist{{Name: p.artist}}}, 0.9))
+	}
+	return store
+}
+
+func TestBuildChart(t *testing.T) {
+	now := time.Now()
+	store := newTestChartHistory(t, now)
+
+	report := buildChart(store, "month", now, nil)
+	assert.Equal(t, 6, report.Plays)
+	assert.Equal(t, []string{"b", "a", "c"}, report.TrackIDs())
+
+	report = buildChart(store, "year", now, func(play history.Play) bool {
+		return play.Song.Artist != "Kenny G"
+	})
+	assert.Equal(t, []string{"b", "a", "d"}, report.TrackIDs())
+}
+
+func TestUpdateChartPlaylists(t *testing.T) {
+	now := time.Now()
+
+	originalConf, originalHistory := conf, playHistory
+	conf = config.Config{Chart: config.ChartConfig{PlaylistName: "KMHD Top Tracks", Period: "month", Size: 2}}
+	playHistory = newTestChartHistory(t, now)
+	defer func() { conf, playHistory = originalConf, originalHistory }()
+
+	svc := &MockRollingSpotifyService{
+		MockSpotifyServiceForSync: MockSpotifyServiceForSync{
+			playlists: []types.Playlist{{ID: "chart", Name: "KMHD Top Tracks"}},
+		},
+		contents: []string{"old", "a"},
+	}
+	target := syncTarget{Service: svc, ExcludeArtists: []string{"Kenny G"}, playlists: make(map[string]types.Playlist)}
+
+	updateChartPlaylists([]syncTarget{target}, "month", now)
+	assert.Equal(t, []string{"b", "a"}, svc.contents)
+}
+
+func TestWriteChart(t *testing.T) {
+	now := time.Now()
+	report := buildChart(newTestChartHistory(t, now), "month", now, nil)
+
+	var out bytes.Buffer
+	require.NoError(t, writeChart(&out, "text", report.Top(1)))
+	assert.Contains(t, out.String(), "📈 KMHD chart for the last month")
+	assert.Contains(t, out.String(), "6 plays, 6 matched on Spotify")
+	assert.Contains(t, out.String(), " 1. John Coltrane - Giant Steps (3 plays)")
+	assert.Contains(t, out.String(), " 1. Giant Steps by John Coltrane (3 plays)")
+	assert.NotContains(t, out.String(), "So What")
+
+	out.Reset()
+	require.NoError(t, writeChart(&out, "json", report))
+	var decoded chart.Report
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, report.TrackIDs(), decoded.TrackIDs())
+
+	out.Reset()
+	require.NoError(t, writeChart(&out, "text", chart.Build("week", now, now, nil)))
+	assert.Contains(t, out.String(), "No plays recorded")
+}
+
+func TestChartCmdFlags(t *testing.T) {
+	cmd := newChartCmd()
+	for _, name := range []string{"period", "limit", "format", "update"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), "missing --%s flag", name)
+	}
+}
@@ -0,0 +1,120 @@
+// Package cmd provides generated playlist covers for kmhd2spotify.
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/kmhd2spotify/internal/cover"
+	"github.com/toozej/kmhd2spotify/internal/history"
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+// maxCoverArtwork is how many album covers a generated cover's collage uses. Twice as
+// many artwork URLs are collected, so a few failed downloads still leave a full collage.
+const maxCoverArtwork = 4
+
+var (
+	// monthlyNamePattern matches monthly playlist names such as KMHD-2025-10.
+	monthlyNamePattern = regexp.MustCompile(`^(.+)-(\d{4})-(0[1-9]|1[0-2])$`)
+
+	// yearlyNamePattern matches yearly playlist names such as KMHD-2025.
+	yearlyNamePattern = regexp.MustCompile(`^(.+)-(\d{4})$`)
+)
+
+// coverDesign returns the cover design of the named playlist: monthly playlists are
+// labelled with their month and year, yearly playlists with their year, and other
+// playlists with their name.
+func coverDesign(name string) cover.Design {
+	if m := monthlyNamePattern.FindStringSubmatch(name); m != nil {
+		month, _ := strconv.Atoi(m[3])
+		return cover.Design{Label: m[1], Title: time.Month(month).String(), Subtitle: m[2]}
+	}
+	if m := yearlyNamePattern.FindStringSubmatch(name); m != nil {
+		return cover.Design{Label: m[1], Title: m[2], Subtitle: "The year on KMHD"}
+	}
+	return cover.Design{Label: "KMHD", Title: name}
+}
+
+// coverArtworkURLs returns up to limit distinct artwork URLs of the plays, newest play
+// first. With track IDs, only plays matched to one of the tracks are used.
+func coverArtworkURLs(plays []history.Play, trackIDs []string, limit int) []string {
+	var tracks map[string]bool
+	if trackIDs != nil {
+		tracks = make(map[string]bool, len(trackIDs))
+		for _, trackID := range trackIDs {
+			tracks[trackID] = true
+		}
+	}
+
+	var urls []string
+	seen := make(map[string]bool)
+	for i := len(plays) - 1; i >= 0 && len(urls) < limit; i-- {
+		play := plays[i]
+		url := play.Song.ArtworkURL
+		if url == "" || seen[url] {
+			continue
+		}
+		if tracks != nil && (play.Match == nil || !tracks[play.Match.TrackID]) {
+			continue
+		}
+		seen[url] = true
+		urls = append(urls, url)
+	}
+	return urls
+}
+
+// generateCover renders the cover of the named playlist from the artwork at the URLs,
+// falling back to a typographic cover when none of it can be downloaded.
+func generateCover(name string, artworkURLs []string) ([]byte, error) {
+	design := coverDesign(name)
+	design.Artwork = cover.NewFetcher(conf.Cover.ArtworkTimeout).Fetch(artworkURLs, maxCoverArtwork)
+
+	image, err := cover.Generate(design)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate cover: %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"playlist": name,
+		"artwork":  len(design.Artwork),
+		"bytes":    len(image),
+	}).Debug("Generated playlist cover")
+	return image, nil
+}
+
+// setGeneratedCover generates and uploads the cover of a playlist, with the artwork of
+// the plays matched to the tracks, or of the latest plays if trackIDs is nil.
+func setGeneratedCover(spotifyService types.SpotifyService, playlist types.Playlist, plays []history.Play, trackIDs []string) error {
+	urls := coverArtworkURLs(plays, trackIDs, 2*maxCoverArtwork)
+	image, err := generateCover(playlist.Name, urls)
+	if err != nil {
+		return err
+	}
+	if err := spotifyService.SetPlaylistCover(playlist.ID, image); err != nil {
+		return fmt.Errorf("failed to upload cover: %w", err)
+	}
+	return nil
+}
+
+// setNewPlaylistCover gives a playlist that was just created a generated cover, unless
+// covers are disabled. A playlist works without a cover, so failures are only logged.
+func setNewPlaylistCover(spotifyService types.SpotifyService, playlist types.Playlist, trackIDs []string) {
+	if !conf.Cover.Generate {
+		return
+	}
+
+	var plays []history.Play
+	if playHistory != nil {
+		plays = playHistory.Plays()
+	}
+	if err := setGeneratedCover(spotifyService, playlist, plays, trackIDs); err != nil {
+		log.WithError(err).WithField("playlist", playlist.Name).Warn("Failed to set generated playlist cover, run 'kmhd2spotify auth' again if Spotify denied access")
+		return
+	}
+	log.WithField("playlist", playlist.Name).Info("Set generated playlist cover")
+}
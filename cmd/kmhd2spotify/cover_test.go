@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/cover"
+	"github.com/toozej/kmhd2spotify/internal/history"
+	"github.com/toozej/kmhd2spotify/internal/types"
+	"github.com/toozej/kmhd2spotify/pkg/config"
+)
+
+// MockCoverSpotifyService records the cover images uploaded
+type MockCoverSpotifyService struct {
+	MockSpotifyServiceForSync
+	covers   map[string][]byte
+	coverErr error
+}
+
+func (m *MockCoverSpotifyService) SetPlaylistCover(playlistID string, jpeg []byte) error {
+	if m.coverErr != nil {
+		return m.coverErr
+	}
+	if m.covers == nil {
+		m.covers = make(map[string][]byte)
+	}
+	m.covers[playlistID] = jpeg
+	return nil
+}
+
+func TestCoverDesign(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected cover.Design
+	}{
+		{name: "KMHD-2025-10", expected: cover.Design{Label: "KMHD", Title: "October", Subtitle: "2025"}},
+		{name: "Jazz-Radio-2026-01", expected: cover.Design{Label: "Jazz-Radio", Title: "January", Subtitle: "2026"}},
+		{name: "KMHD-2025", expected: cover.Design{Label: "KMHD", Title: "2025", Subtitle: "The year on KMHD"}},
+		{name: "KMHD-2025-13", expected: cover.Design{Label: "KMHD", Title: "KMHD-2025-13"}},
+		{name: "KMHD Top Tracks", expected: cover.Design{Label: "KMHD", Title: "KMHD Top Tracks"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, coverDesign(tt.name))
+		})
+	}
+}
+
+func TestCoverArtworkURLs(t *testing.T) {
+	play := func(artworkURL, trackID string) history.Play {
+		p := history.Play{Song: types.Song{ArtworkURL: artworkURL}}
+		if trackID != "" {
+			p.Match = &history.Match{TrackID: trackID}
+		}
+		return p
+	}
+	plays := []history.Play{
+		play("kind-of-blue.jpg", "so-what"),
+		play("a-love-supreme.jpg", ""),
+		play("", "naima"),
+		play("kind-of-blue.jpg", "blue-in-green"),
+		play("mingus-ah-um.jpg", "fables"),
+	}
+
+	assert.Equal(t, []string{"mingus-ah-um.jpg", "kind-of-blue.jpg", "a-love-supreme.jpg"}, coverArtworkURLs(plays, nil, 4))
+	assert.Equal(t, []string{"mingus-ah-um.jpg", "kind-of-blue.jpg"}, coverArtworkURLs(plays, nil, 2))
+	assert.Equal(t, []string{"kind-of-blue.jpg"}, coverArtworkURLs(plays, []string{"so-what", "naima"}, 4))
+	assert.Empty(t, coverArtworkURLs(plays, []string{}, 4))
+}
+
+func TestSetNewPlaylistCover(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+		for i := range img.Pix {
+			img.Pix[i] = 0xc0
+		}
+		img.Set(0, 0, color.Black)
+		_ = png.Encode(w, img)
+	}))
+	defer server.Close()
+
+	store, err := history.Open(filepath.Join(t.TempDir(), history.FileName))
+	require.NoError(t, err)
+	_, err = store.Record([]types.Song{{Artist: "Miles Davis", Title: "So What", PlayedAt: time.Now(), ArtworkURL: server.URL + "/kind-of-blue.png"}})
+	require.NoError(t, err)
+
+	originalConf, originalHistory := conf, playHistory
+	defer func() { conf, playHistory = originalConf, originalHistory }()
+	conf = config.Config{Cover: config.CoverConfig{Generate: true, ArtworkTimeout: time.Second}}
+	playHistory = store
+	october := types.Playlist{ID: "oct", Name: "KMHD-2025-10"}
+
+	t.Run("uploads generated cover", func(t *testing.T) {
+		mock := &MockCoverSpotifyService{}
+		setNewPlaylistCover(mock, october, nil)
+
+		require.Contains(t, mock.covers, "oct")
+		decoded, err := jpeg.Decode(bytes.NewReader(mock.covers["oct"]))
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, cover.Size, cover.Size), decoded.Bounds())
+	})
+
+	t.Run("typographic without artwork", func(t *testing.T) {
+		playHistory = nil
+		defer func() { playHistory = store }()
+
+		mock := &MockCoverSpotifyService{}
+		setNewPlaylistCover(mock, october, nil)
+		assert.Contains(t, mock.covers, "oct")
+	})
+
+	t.Run("upload failure is not fatal", func(t *testing.T) {
+		mock := &MockCoverSpotifyService{coverErr: errors.New("insufficient client scope")}
+		assert.NotPanics(t, func() { setNewPlaylistCover(mock, october, nil) })
+
+		err := setGeneratedCover(mock, october, store.Plays(), nil)
+		assert.ErrorContains(t, err, "failed to upload cover: insufficient client scope")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		conf.Cover.Generate = false
+		defer func() { conf.Cover.Generate = true }()
+
+		mock := &MockCoverSpotifyService{}
+		setNewPlaylistCover(mock, october, nil)
+		assert.Empty(t, mock.covers)
+	})
+
+	t.Run("created playlists get a cover", func(t *testing.T) {
+		mock := &MockCoverSpotifyService{}
+		playlist, err := getOrCreatePlaylist(mock, "KMHD Top Tracks", "Most played on KMHD")
+		require.NoError(t, err)
+		assert.Contains(t, mock.covers, playlist.ID)
+
+		mock = &MockCoverSpotifyService{MockSpotifyServiceForSync: MockSpotifyServiceForSync{playlists: []types.Playlist{playlist}}}
+		_, err = getOrCreatePlaylist(mock, "KMHD Top Tracks", "Most played on KMHD")
+		require.NoError(t, err)
+		assert.Empty(t, mock.covers)
+	})
+}
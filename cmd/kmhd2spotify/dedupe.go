@@ -0,0 +1,176 @@
+// Package cmd provides the dedupe command implementation for kmhd2spotify.
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/kmhd2spotify/internal/duplicate"
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+// dedupeResult is the outcome of scanning a playlist for duplicates.
+type dedupeResult struct {
+	Playlist types.Playlist
+	Groups   []duplicate.Group
+	Removed  int
+}
+
+// Duplicates returns the number of duplicate occurrences found.
+func (r dedupeResult) Duplicates() int {
+	count := 0
+	for _, group := range r.Groups {
+		count += len(group.Duplicates)
+	}
+	return count
+}
+
+// newDedupeCmd creates the dedupe command for finding duplicate recordings in a playlist.
+func newDedupeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dedupe <playlist>",
+		Short: "Report or remove duplicate recordings in a Spotify playlist",
+		Long: `Scan a Spotify playlist for tracks that are the same recording and report them.
+Recordings are identified by their ISRC when Spotify has one for both tracks, otherwise
+by artist, title (ignoring remaster suffixes) and a duration within
+DUPLICATE_DURATION_TOLERANCE. This finds the same recording added as its album,
+compilation or remastered release, or under a regionally relinked track ID.
+
+With --remove, every duplicate is removed from the playlist and the earliest added
+occurrence of each recording is kept.`,
+		Example: `  kmhd2spotify dedupe KMHD-2025-10
+  kmhd2spotify dedupe "KMHD Favourites" --profile alice --remove`,
+		Args: cobra.ExactArgs(1),
+		Run:  runDedupe,
+	}
+
+	cmd.Flags().String("profile", "", "Profile whose playlist to scan (default account if empty)")
+	cmd.Flags().Bool("remove", false, "Remove the duplicates, keeping the earliest added occurrence")
+
+	return cmd
+}
+
+// runDedupe executes the dedupe command.
+func runDedupe(cmd *cobra.Command, args []string) {
+	profile, _ := cmd.Flags().GetString("profile")
+	remove, _ := cmd.Flags().GetBool("remove")
+
+	spotifyService, err := newProfileService(profile)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to create Spotify service")
+		return
+	}
+	if err := ensureAuthenticated(spotifyService, profile, false); err != nil {
+		log.WithError(err).Fatal("Spotify authentication failed")
+		return
+	}
+
+	result, err := dedupePlaylist(spotifyService, args[0], remove)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to dedupe playlist")
+		return
+	}
+	writeDedupeReport(cmd.OutOrStdout(), result, remove)
+}
+
+// dedupePlaylist finds the duplicate recordings in the named playlist and, with remove,
+// removes every occurrence but the earliest added one.
+func dedupePlaylist(spotifyService types.SpotifyService, name string, remove bool) (dedupeResult, error) {
+	playlists, err := spotifyService.GetUserPlaylists("")
+	if err != nil {
+		return dedupeResult{}, fmt.Errorf("failed to get user playlists: %w", err)
+	}
+
+	var result dedupeResult
+	found := false
+	for _, playlist := range playlists {
+		if playlist.Name == name {
+			result.Playlist = playlist
+			found = true
+			break
+		}
+	}
+	if !found {
+		return dedupeResult{}, fmt.Errorf("no playlist named '%s' found", name)
+	}
+
+	items, err := spotifyService.GetPlaylistItems(result.Playlist.ID)
+	if err != nil {
+		return dedupeResult{}, err
+	}
+	result.Groups = duplicate.FindDuplicates(items, conf.Duplicate.DurationTolerance)
+	if !remove || len(result.Groups) == 0 {
+		return result, nil
+	}
+
+	// Remove by position, so a duplicate with the same track ID as the kept occurrence
+	// doesn't remove the kept one too
+	positions := make(map[string][]int)
+	for _, group := range result.Groups {
+		for _, entry := range group.Duplicates {
+			positions[entry.Track.ID] = append(positions[entry.Track.ID], entry.Position)
+		}
+	}
+	if err := spotifyService.RemoveTrackPositions(result.Playlist.ID, positions); err != nil {
+		return result, err
+	}
+	result.Removed = result.Duplicates()
+
+	log.WithFields(log.Fields{
+		"playlist":   result.Playlist.Name,
+		"recordings": len(result.Groups),
+		"removed":    result.Removed,
+	}).Info("Removed duplicate recordings from playlist")
+	return result, nil
+}
+
+// writeDedupeReport prints the duplicate recordings of a playlist.
+func writeDedupeReport(out io.Writer, result dedupeResult, remove bool) {
+	if len(result.Groups) == 0 {
+		fmt.Fprintf(out, "✅ No duplicates in %s\n", result.Playlist.Name)
+		return
+	}
+
+	for _, group := range result.Groups {
+		fmt.Fprintf(out, "🔁 %s (#%d, added %s, kept)\n", dedupeTrackName(group.Kept.Track), group.Kept.Position+1, dedupeAddedAt(group.Kept))
+		for _, entry := range group.Duplicates {
+			fmt.Fprintf(out, "   ✂️  %s (#%d, added %s)\n", dedupeTrackName(entry.Track), entry.Position+1, dedupeAddedAt(entry))
+		}
+	}
+	fmt.Fprintln(out)
+
+	summary := fmt.Sprintf("%s of %s in %s", pluralize(result.Duplicates(), "duplicate"), pluralize(len(result.Groups), "recording"), result.Playlist.Name)
+	if remove {
+		fmt.Fprintf(out, "✅ Removed %s\n", summary)
+		return
+	}
+	fmt.Fprintf(out, "📋 Found %s. Run again with --remove to remove them, keeping the earliest added.\n", summary)
+}
+
+// dedupeTrackName formats a track with its artists and album.
+func dedupeTrackName(track types.Track) string {
+	artists := make([]string, 0, len(track.Artists))
+	for _, artist := range track.Artists {
+		artists = append(artists, artist.Name)
+	}
+	name := track.Name
+	if len(artists) > 0 {
+		name = strings.Join(artists, ", ") + " - " + name
+	}
+	if track.Album.Name != "" {
+		name += " [" + track.Album.Name + "]"
+	}
+	return name
+}
+
+// dedupeAddedAt formats when a playlist entry was added.
+func dedupeAddedAt(entry duplicate.Entry) string {
+	if entry.AddedAt.IsZero() {
+		return "unknown"
+	}
+	return entry.AddedAt.Local().Format("2006-01-02")
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/types"
+	"github.com/toozej/kmhd2spotify/pkg/config"
+)
+
+// MockDedupeSpotifyService serves a playlist's items and records removed positions
+type MockDedupeSpotifyService struct {
+	MockSpotifyServiceForSync
+	items   []types.PlaylistItem
+	removed map[string][]int
+}
+
+func (m *MockDedupeSpotifyService) GetPlaylistItems(playlistID string) ([]types.PlaylistItem, error) {
+	return m.items, nil
+}
+
+func (m *MockDedupeSpotifyService) RemoveTrackPositions(playlistID string, positions map[string][]int) error {
+	m.removed = positions
+	return nil
+}
+
+func TestNewDedupeCmd(t *testing.T) {
+	cmd := newDedupeCmd()
+
+	assert.Equal(t, "dedupe <playlist>", cmd.Use)
+	assert.NotNil(t, cmd.Flags().Lookup("profile"))
+	assert.NotNil(t, cmd.Flags().Lookup("remove"))
+	assert.Error(t, cmd.Args(cmd, nil))
+}
+
+func TestDedupePlaylist(t *testing.T) {
+	originalConf := conf
+	defer func() { conf = originalConf }()
+	conf = config.Config{Duplicate: config.DuplicateConfig{DurationTolerance: 3 * time.Second}}
+
+	day := time.Date(2025, 10, 1, 20, 0, 0, 0, time.UTC)
+	coltrane := []types.Artist{{Name: "John Coltrane"}}
+	newMock := func() *MockDedupeSpotifyService {
+		return &MockDedupeSpotifyService{
+			MockSpotifyServiceForSync: MockSpotifyServiceForSync{playlists: []types.Playlist{{ID: "oct", Name: "KMHD-2025-10"}}},
+			items: []types.PlaylistItem{
+				{Track: types.Track{ID: "naima", Name: "Naima", Artists: coltrane, Duration: 261000}, AddedAt: day},
+				{Track: types.Track{ID: "giant-steps", Name: "Giant Steps", Artists: coltrane, Duration: 286000}, AddedAt: day},
+				{Track: types.Track{ID: "naima", Name: "Naima", Artists: coltrane, Duration: 261000}, AddedAt: day.Add(24 * time.Hour)},
+				{Track: types.Track{ID: "naima-remaster", Name: "Naima - 2020 Remaster", Artists: coltrane, Duration: 262000}, AddedAt: day.Add(48 * time.Hour)},
+			},
+		}
+	}
+
+	t.Run("report", func(t *testing.T) {
+		mock := newMock()
+		result, err := dedupePlaylist(mock, "KMHD-2025-10", false)
+		require.NoError(t, err)
+		require.Len(t, result.Groups, 1)
+		assert.Equal(t, 2, result.Duplicates())
+		assert.Zero(t, result.Removed)
+		assert.Nil(t, mock.removed)
+
+		var out bytes.Buffer
+		writeDedupeReport(&out, result, false)
+		assert.Contains(t, out.String(), "John Coltrane - Naima (#1, added 2025-10-01, kept)")
+		assert.Contains(t, out.String(), "Found 2 duplicates of 1 recording in KMHD-2025-10")
+	})
+
+	t.Run("remove keeps the earliest add", func(t *testing.T) {
+		mock := newMock()
+		result, err := dedupePlaylist(mock, "KMHD-2025-10", true)
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Removed)
+		assert.Equal(t, map[string][]int{"naima": {2}, "naima-remaster": {3}}, mock.removed)
+	})
+
+	t.Run("unknown playlist", func(t *testing.T) {
+		_, err := dedupePlaylist(newMock(), "KMHD-1999-01", false)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,57 @@
+// Package cmd provides the duplicate policy of sync targets for kmhd2spotify.
+package cmd
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/kmhd2spotify/internal/duplicate"
+	"github.com/toozej/kmhd2spotify/pkg/config"
+)
+
+// openDuplicateIndex opens the index of added tracks in the configured state directory.
+func openDuplicateIndex() (*duplicate.Index, error) {
+	path, err := conf.State.GetFilePath(duplicate.IndexFileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve duplicate index path: %w", err)
+	}
+	return duplicate.OpenIndex(path)
+}
+
+// applyDuplicatePolicy gives every target a duplicate service enforcing DUPLICATE_POLICY.
+// For the prefix and window policies, the target's playlists with its name prefix that
+// are not indexed yet are fetched once. If the index can't be used, targets fall back to
+// only checking the target playlist.
+func applyDuplicatePolicy(targets []syncTarget) {
+	var index *duplicate.Index
+	if conf.Duplicate.Policy != config.DuplicatePolicyPlaylist {
+		var err error
+		index, err = openDuplicateIndex()
+		if err != nil {
+			log.WithError(err).Warn("Failed to open duplicate index, only checking target playlists for duplicates")
+		}
+	}
+
+	for i := range targets {
+		target := &targets[i]
+		target.Duplicates = duplicate.NewDuplicateService(target.Service, log.StandardLogger())
+		if index == nil {
+			continue
+		}
+		if target.Prefix == "" {
+			log.WithField("profile", target.Profile).Warn("Duplicate policy requires a playlist name prefix, only checking target playlists for duplicates")
+			continue
+		}
+
+		target.Duplicates.SetPolicy(conf.Duplicate, index, target.Profile, target.Prefix)
+		indexed, err := target.Duplicates.RefreshIndex()
+		if err != nil {
+			log.WithError(err).WithField("profile", target.Profile).Warn("Failed to index playlists for duplicate checks")
+			continue
+		}
+		if indexed > 0 {
+			fmt.Printf("🗂️  Indexed %s for duplicate checks%s\n", pluralize(indexed, "playlist"), profileSuffix(target.Profile))
+		}
+	}
+}
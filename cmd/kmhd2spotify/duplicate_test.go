@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/types"
+	"github.com/toozej/kmhd2spotify/pkg/config"
+)
+
+// MockMonthlySpotifyService serves monthly playlists and records added tracks
+type MockMonthlySpotifyService struct {
+	MockSpotifyServiceForSync
+	items    map[string][]types.PlaylistItem
+	addedIDs []string
+}
+
+func (m *MockMonthlySpotifyService) GetPlaylistItems(playlistID string) ([]types.PlaylistItem, error) {
+	return m.items[playlistID], nil
+}
+
+func (m *MockMonthlySpotifyService) AddTracksToPlaylist(playlistID string, trackIDs []string) error {
+	m.addedIDs = append(m.addedIDs, trackIDs...)
+	return nil
+}
+
+func TestApplyDuplicatePolicy(t *testing.T) {
+	originalConf := conf
+	defer func() { conf = originalConf }()
+
+	september := types.Playlist{ID: "sep", Name: "KMHD-2025-09"}
+	october := types.Playlist{ID: "oct", Name: "KMHD-2025-10"}
+	song := types.Song{Artist: "John Coltrane", Title: "Naima"}
+	track := &types.Track{ID: "naima", Name: "Naima"}
+
+	for _, tt := range []struct {
+		policy string
+		added  bool
+	}{
+		{policy: config.DuplicatePolicyPlaylist, added: true},
+		{policy: config.DuplicatePolicyPrefix, added: false},
+	} {
+		t.Run(tt.policy, func(t *testing.T) {
+			conf = config.Config{
+				State:     config.StateConfig{Dir: t.TempDir()},
+				Duplicate: config.DuplicateConfig{Policy: tt.policy, Window: "90d"},
+			}
+			mock := &MockMonthlySpotifyService{
+				MockSpotifyServiceForSync: MockSpotifyServiceForSync{playlists: []types.Playlist{september, october}},
+				items: map[string][]types.PlaylistItem{
+					"sep": {{Track: *track, AddedAt: time.Now().AddDate(0, -1, 0)}},
+				},
+			}
+			targets := []syncTarget{{Service: mock, Playlist: october, Prefix: "KMHD"}}
+
+			applyDuplicatePolicy(targets)
+			require.NotNil(t, targets[0].Duplicates)
+
+			assert.Equal(t, tt.added, addTrackToTarget(song, track, targets[0], october))
+		})
+	}
+}
@@ -0,0 +1,335 @@
+// Package cmd provides the export command implementation for kmhd2spotify.
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/kmhd2spotify/internal/export"
+	"github.com/toozej/kmhd2spotify/internal/history"
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+// maxExportDays limits how many days one export may fetch from the KMHD API.
+const maxExportDays = 366
+
+// playlistFetcher fetches the KMHD playlist of a day.
+type playlistFetcher interface {
+	FetchPlaylist(date time.Time) (*types.SongCollection, error)
+}
+
+// newExportCmd creates the export command for writing KMHD plays to playlist files.
+func newExportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export KMHD plays or a Spotify playlist to a playlist file",
+		Long: `Export what KMHD played on a day or range of days, or the tracks of one of your
+Spotify playlists, as M3U8, XSPF, JSPF (ListenBrainz), CSV or JSON.
+Entries include when KMHD played them, the album, the KMHD ID and the Spotify URI
+when the play was matched. Days are KMHD's Pacific days.
+
+Plays are read from the local play history, with their Spotify matches, and
+completed with the KMHD API playlist of the day, so plays missed while kmhd2spotify
+wasn't syncing are included (--source auto). Plays only known to the API have no
+Spotify match. Without --date, --from or --playlist, today is exported.
+The format defaults to the extension of --output, or json when writing to stdout.`,
+		Example: `  kmhd2spotify export --date 2025-10-17 -o kmhd-2025-10-17.m3u8
+  kmhd2spotify export --from 2025-10-01 --until 2025-10-31 --format csv
+  kmhd2spotify export --playlist KMHD-2025-10 -o october.jspf`,
+		Args: cobra.NoArgs,
+		Run:  runExport,
+	}
+
+	cmd.Flags().String("date", "", "Day to export (YYYY-MM-DD)")
+	cmd.Flags().String("from", "", "First day of a range to export (YYYY-MM-DD)")
+	cmd.Flags().String("until", "", "Last day of a range to export (YYYY-MM-DD, default today)")
+	cmd.Flags().String("playlist", "", "Export this Spotify playlist instead of KMHD plays")
+	cmd.Flags().String("profile", "", "Profile whose Spotify playlist to export (default account if empty)")
+	cmd.Flags().String("source", "auto", "Where to read plays from: auto, history or api")
+	cmd.Flags().String("format", "", "Output format: m3u8, xspf, jspf, csv or json")
+	cmd.Flags().StringP("output", "o", "", "File to write (default stdout)")
+
+	return cmd
+}
+
+// runExport executes the export command.
+func runExport(cmd *cobra.Command, args []string) {
+	date, _ := cmd.Flags().GetString("date")
+	from, _ := cmd.Flags().GetString("from")
+	until, _ := cmd.Flags().GetString("until")
+	playlistName, _ := cmd.Flags().GetString("playlist")
+	profile, _ := cmd.Flags().GetString("profile")
+	source, _ := cmd.Flags().GetString("source")
+	formatName, _ := cmd.Flags().GetString("format")
+	output, _ := cmd.Flags().GetString("output")
+
+	format, err := exportFormat(formatName, output)
+	if err != nil {
+		log.WithError(err).Fatal("Invalid export format")
+		return
+	}
+	if source != "auto" && source != "history" && source != "api" {
+		log.WithField("source", source).Fatal("Unsupported source, expected auto, history or api")
+		return
+	}
+
+	store, err := openPlayHistoryReadOnly()
+	if err != nil {
+		if source == "history" {
+			log.WithError(err).Fatal("Failed to open play history")
+			return
+		}
+		log.WithError(err).Warn("Failed to open play history, exporting without it")
+		store = nil
+	}
+
+	var playlist export.Playlist
+	if playlistName != "" {
+		playlist, err = exportSpotifyPlaylist(profile, playlistName, store)
+	} else {
+		var days []time.Time
+		days, err = exportDays(date, from, until, time.Now(), kmhdLocation())
+		if err == nil {
+			playlist, err = exportPlays(days, source, store, func() (playlistFetcher, error) {
+				client, err := initializeKMHDAPIClient()
+				if err != nil {
+					return nil, err
+				}
+				return client, nil
+			})
+		}
+	}
+	if err != nil {
+		log.WithError(err).Fatal("Failed to export")
+		return
+	}
+
+	write := func(w io.Writer) error {
+		return export.Write(w, format, playlist)
+	}
+	if output == "" {
+		err = write(cmd.OutOrStdout())
+	} else {
+		err = writeOutputFile(output, write)
+	}
+	if err != nil {
+		log.WithError(err).Fatal("Failed to write export")
+		return
+	}
+
+	if output != "" {
+		fmt.Printf("💾 Exported %d entries from %s to %s\n", len(playlist.Entries), playlist.Title, output)
+	}
+}
+
+// exportFormat returns the requested format, or the one matching the output file's
+// extension, or JSON when writing to stdout.
+func exportFormat(name, output string) (export.Format, error) {
+	switch {
+	case name != "":
+		return export.ParseFormat(name)
+	case output != "":
+		return export.FormatForPath(output)
+	default:
+		return export.FormatJSON, nil
+	}
+}
+
+// exportDays returns the start of every day to export in the location: the given
+// date, the range from..until (until defaults to today), or today.
+func exportDays(date, from, until string, now time.Time, location *time.Location) ([]time.Time, error) {
+	if date != "" && (from != "" || until != "") {
+		return nil, fmt.Errorf("--date cannot be combined with --from or --until")
+	}
+	if from == "" && until != "" {
+		return nil, fmt.Errorf("--until requires --from")
+	}
+
+	today := now.In(location)
+	first := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, location)
+	last := first
+
+	var err error
+	switch {
+	case date != "":
+		if first, err = time.ParseInLocation("2006-01-02", date, location); err != nil {
+			return nil, fmt.Errorf("invalid --date %q, expected YYYY-MM-DD: %w", date, err)
+		}
+		last = first
+	case from != "":
+		if first, err = time.ParseInLocation("2006-01-02", from, location); err != nil {
+			return nil, fmt.Errorf("invalid --from %q, expected YYYY-MM-DD: %w", from, err)
+		}
+		if until != "" {
+			if last, err = time.ParseInLocation("2006-01-02", until, location); err != nil {
+				return nil, fmt.Errorf("invalid --until %q, expected YYYY-MM-DD: %w", until, err)
+			}
+		}
+	}
+
+	if last.Before(first) {
+		return nil, fmt.Errorf("the range ends on %s, before it starts on %s", last.Format("2006-01-02"), first.Format("2006-01-02"))
+	}
+
+	var days []time.Time
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+		if len(days) > maxExportDays {
+			return nil, fmt.Errorf("cannot export more than %d days at once", maxExportDays)
+		}
+	}
+	return days, nil
+}
+
+// exportPlays collects the plays of the given days. Each day is read from the play
+// history (unless source is api) and, unless source is history, completed with the
+// plays of the day's KMHD API playlist that the history is missing, such as those
+// played while no sync was running. The API client is only created once it is needed.
+func exportPlays(days []time.Time, source string, store *history.Store, newFetcher func() (playlistFetcher, error)) (export.Playlist, error) {
+	playlist := export.Playlist{Title: exportTitle(days), Created: time.Now()}
+	var fetcher playlistFetcher
+
+	for _, day := range days {
+		next := day.AddDate(0, 0, 1)
+		dayName := day.Format("2006-01-02")
+
+		var plays []history.Play
+		if store != nil && source != "api" {
+			plays = store.Between(day, next)
+		}
+		entries := make([]export.Entry, 0, len(plays))
+		for _, play := range plays {
+			entries = append(entries, export.FromPlay(play))
+		}
+		if source == "history" {
+			playlist.Entries = append(playlist.Entries, entries...)
+			log.WithFields(log.Fields{"day": dayName, "plays": len(plays)}).Debug("Exporting plays from history")
+			continue
+		}
+
+		if fetcher == nil {
+			var err error
+			if fetcher, err = newFetcher(); err != nil {
+				return export.Playlist{}, fmt.Errorf("failed to initialize KMHD API client: %w", err)
+			}
+		}
+		collection, err := fetcher.FetchPlaylist(day)
+		if err != nil {
+			if len(plays) == 0 {
+				return export.Playlist{}, fmt.Errorf("failed to fetch KMHD playlist for %s: %w", dayName, err)
+			}
+			log.WithError(err).WithField("day", dayName).Warn("Failed to fetch KMHD playlist, exporting the day from history alone")
+			collection = &types.SongCollection{}
+		}
+
+		missing := 0
+		for _, song := range collection.Songs {
+			if song.IsValid() && !song.PlayedAt.Before(day) && song.PlayedAt.Before(next) && !inHistory(plays, song) {
+				entries = append(entries, export.FromSong(song))
+				missing++
+			}
+		}
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].PlayedAt.Before(entries[j].PlayedAt) })
+		playlist.Entries = append(playlist.Entries, entries...)
+		log.WithFields(log.Fields{"day": dayName, "history_plays": len(plays), "api_plays": missing}).Debug("Exporting plays from history and KMHD API")
+	}
+
+	return playlist, nil
+}
+
+// inHistory reports whether the song played by KMHD is one of the recorded plays: the
+// same play, or for plays imported from Spotify playlists, which are recorded when
+// the track was added, the same recording played up to importMatchWindow earlier.
+func inHistory(plays []history.Play, song types.Song) bool {
+	key := history.Key(song)
+	for _, play := range plays {
+		if history.Key(play.Song) == key {
+			return true
+		}
+		if play.Imported && strings.EqualFold(strings.TrimSpace(play.Song.Artist), strings.TrimSpace(song.Artist)) &&
+			strings.EqualFold(strings.TrimSpace(play.Song.Title), strings.TrimSpace(song.Title)) {
+			if d := play.Song.PlayedAt.Sub(song.PlayedAt); d >= 0 && d < importMatchWindow {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// exportTitle names an export of the given days.
+func exportTitle(days []time.Time) string {
+	first, last := days[0].Format("2006-01-02"), days[len(days)-1].Format("2006-01-02")
+	if first == last {
+		return "KMHD " + first
+	}
+	return fmt.Sprintf("KMHD %s to %s", first, last)
+}
+
+// exportSpotifyPlaylist collects the tracks of the named Spotify playlist of a profile.
+// Tracks KMHD played are annotated with their most recent play from the play history.
+func exportSpotifyPlaylist(profile, name string, store *history.Store) (export.Playlist, error) {
+	spotifyService, err := newProfileService(profile)
+	if err != nil {
+		return export.Playlist{}, err
+	}
+	if err := ensureAuthenticated(spotifyService, profile, false); err != nil {
+		return export.Playlist{}, err
+	}
+	return exportPlaylistTracks(spotifyService, name, store)
+}
+
+// exportPlaylistTracks collects the tracks of the named playlist using the service.
+func exportPlaylistTracks(spotifyService types.SpotifyService, name string, store *history.Store) (export.Playlist, error) {
+	playlists, err := spotifyService.GetUserPlaylists("")
+	if err != nil {
+		return export.Playlist{}, fmt.Errorf("failed to get user playlists: %w", err)
+	}
+
+	var target *types.Playlist
+	for i := range playlists {
+		if playlists[i].Name == name {
+			target = &playlists[i]
+			break
+		}
+	}
+	if target == nil {
+		return export.Playlist{}, fmt.Errorf("no playlist named '%s' found", name)
+	}
+
+	tracks, err := spotifyService.GetPlaylistTracks(target.ID)
+	if err != nil {
+		return export.Playlist{}, err
+	}
+
+	lastPlays := make(map[string]history.Play)
+	if store != nil {
+		for _, play := range store.Plays() {
+			if play.Match == nil {
+				continue
+			}
+			if last, ok := lastPlays[play.Match.TrackID]; !ok || play.Song.PlayedAt.After(last.Song.PlayedAt) {
+				lastPlays[play.Match.TrackID] = play
+			}
+		}
+	}
+
+	playlist := export.Playlist{Title: target.Name, Created: time.Now()}
+	for _, track := range tracks {
+		entry := export.FromTrack(track)
+		if play, ok := lastPlays[track.ID]; ok {
+			entry.PlayedAt = play.Song.PlayedAt
+			entry.KMHDID = play.Song.KMHDID
+			if play.Song.Album != "" {
+				entry.Album = play.Song.Album
+			}
+		}
+		playlist.Entries = append(playlist.Entries, entry)
+	}
+	return playlist, nil
+}
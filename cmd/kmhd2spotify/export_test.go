@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/export"
+	"github.com/toozej/kmhd2spotify/internal/history"
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+// MockPlaylistFetcher returns canned KMHD playlists and records the days requested
+type MockPlaylistFetcher struct {
+	songs   []types.Song
+	err     error
+	fetched []time.Time
+}
+
+func (m *MockPlaylistFetcher) FetchPlaylist(date time.Time) (*types.SongCollection, error) {
+	m.fetched = append(m.fetched, date)
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &types.SongCollection{Songs: m.songs}, nil
+}
+
+func TestExportFormat(t *testing.T) {
+	format, err := exportFormat("", "")
+	require.NoError(t, err)
+	assert.Equal(t, export.FormatJSON, format)
+
+	format, err = exportFormat("", "october.xspf")
+	require.NoError(t, err)
+	assert.Equal(t, export.FormatXSPF, format)
+
+	format, err = exportFormat("csv", "october.xspf")
+	require.NoError(t, err)
+	assert.Equal(t, export.FormatCSV, format, "--format wins over the extension")
+
+	_, err = exportFormat("", "october")
+	assert.Error(t, err)
+}
+
+func TestExportDays(t *testing.T) {
+	location := time.FixedZone("PDT", -7*3600)
+	now := time.Date(2025, time.October, 17, 3, 0, 0, 0, time.UTC) // still the 16th in Pacific time
+
+	days, err := exportDays("", "", "", now, location)
+	require.NoError(t, err)
+	assert.Equal(t, []time.Time{time.Date(2025, time.October, 16, 0, 0, 0, 0, location)}, days)
+
+	days, err = exportDays("2025-10-01", "", "", now, location)
+	require.NoError(t, err)
+	assert.Equal(t, []time.Time{time.Date(2025, time.October, 1, 0, 0, 0, 0, location)}, days)
+
+	days, err = exportDays("", "2025-10-14", "", now, location)
+	require.NoError(t, err)
+	assert.Len(t, days, 3)
+
+	days, err = exportDays("", "2025-09-30", "2025-10-02", now, location)
+	require.NoError(t, err)
+	assert.Len(t, days, 3)
+
+	for name, args := range map[string][3]string{
+		"date and range": {"2025-10-01", "2025-10-01", ""},
+		"until only":     {"", "", "2025-10-01"},
+		"bad date":       {"10/01/2025", "", ""},
+		"reversed range": {"", "2025-10-02", "2025-10-01"},
+		"too long":       {"", "2020-01-01", "2025-01-01"},
+	} {
+		_, err := exportDays(args[0], args[1], args[2], now, location)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestExportPlays(t *testing.T) {
+	location := time.UTC
+	day1 := time.Date(2025, time.October, 16, 0, 0, 0, 0, location)
+	day2 := day1.AddDate(0, 0, 1)
+
+	store, err := history.Open(filepath.Join(t.TempDir(), history.FileName))
+	require.NoError(t, err)
+	recorded := types.Song{Artist: "Miles Davis", Title: "So What", PlayedAt: day1.Add(12 * time.Hour), KMHDID: "a"}
+	_, err = store.Record([]types.Song{recorded})
+	require.NoError(t, err)
+	require.NoError(t, store.SetMatch(recorded, types.Track{ID: "track1", URI: "spotify:track:track1"}, 0.9))
+
+	imported := history.Play{
+		Song:     types.Song{Artist: "Bill Evans", Title: "Peace Piece", PlayedAt: day1.Add(15 * time.Hour)},
+		Match:    &history.Match{TrackID: "track2"},
+		Imported: true,
+	}
+	_, err = store.Import([]history.Play{imported}, importMatchWindow)
+	require.NoError(t, err)
+
+	fetcher := &MockPlaylistFetcher{songs: []types.Song{
+		{Artist: "John Coltrane", Title: "Naima", PlayedAt: day2.Add(2 * time.Hour), KMHDID: "c"},
+		{Artist: "John Coltrane", Title: "Giant Steps", PlayedAt: day2.Add(time.Hour), KMHDID: "b"},
+		{Artist: "Yesterday", Title: "Spillover", PlayedAt: day1},
+		{Artist: "Miles Davis", Title: "So What", PlayedAt: day1.Add(12 * time.Hour), KMHDID: "a"},
+		{Artist: "Bill Evans", Title: "Peace Piece", PlayedAt: day1.Add(14 * time.Hour), KMHDID: "d"},
+		{Artist: "", Title: "Invalid", PlayedAt: day2},
+	}}
+	newFetcher := func() (playlistFetcher, error) { return fetcher, nil }
+
+	playlist, err := exportPlays([]time.Time{day1, day2}, "auto", store, newFetcher)
+	require.NoError(t, err)
+	assert.Equal(t, "KMHD 2025-10-16 to 2025-10-17", playlist.Title)
+	require.Len(t, playlist.Entries, 5)
+	assert.Equal(t, "Spillover", playlist.Entries[0].Title, "day 1 is completed with plays missing from history")
+	assert.Empty(t, playlist.Entries[0].SpotifyURI)
+	assert.Equal(t, "spotify:track:track1", playlist.Entries[1].SpotifyURI, "recorded plays keep their match")
+	assert.Equal(t, "spotify:track:track2", playlist.Entries[2].SpotifyURI, "imported plays aren't repeated from the API")
+	assert.Equal(t, "Giant Steps", playlist.Entries[3].Title, "day 2 comes from the API in play order")
+	assert.Equal(t, "c", playlist.Entries[4].KMHDID)
+	assert.Equal(t, []time.Time{day1, day2}, fetcher.fetched)
+
+	// History only skips days without recorded plays
+	playlist, err = exportPlays([]time.Time{day1, day2}, "history", store, newFetcher)
+	require.NoError(t, err)
+	assert.Len(t, playlist.Entries, 2)
+	assert.Len(t, fetcher.fetched, 2)
+
+	// The API ignores the history
+	playlist, err = exportPlays([]time.Time{day1}, "api", store, newFetcher)
+	require.NoError(t, err)
+	require.Len(t, playlist.Entries, 3)
+	assert.Empty(t, playlist.Entries[1].SpotifyURI)
+
+	// Without the API, recorded days fall back to the history
+	fetcher.err = errors.New("unavailable")
+	playlist, err = exportPlays([]time.Time{day1}, "auto", store, newFetcher)
+	require.NoError(t, err)
+	assert.Len(t, playlist.Entries, 2)
+	_, err = exportPlays([]time.Time{day2}, "auto", nil, newFetcher)
+	assert.Error(t, err)
+}
+
+func TestExportPlaylistTracks(t *testing.T) {
+	store, err := history.Open(filepath.Join(t.TempDir(), history.FileName))
+	require.NoError(t, err)
+	played := types.Song{Artist: "Miles Davis", Title: "So What", Album: "Kind of Blue (KMHD)", PlayedAt: time.Now(), KMHDID: "a"}
+	_, err = store.Record([]types.Song{played})
+	require.NoError(t, err)
+	require.NoError(t, store.SetMatch(played, types.Track{ID: "track1"}, 0.9))
+
+	svc := &MockRollingSpotifyService{
+		MockSpotifyServiceForSync: MockSpotifyServiceForSync{
+			playlists: []types.Playlist{{ID: "p1", Name: "KMHD-2025-10"}},
+		},
+		contents: []string{"track1", "track2"},
+	}
+
+	playlist, err := exportPlaylistTracks(svc, "KMHD-2025-10", store)
+	require.NoError(t, err)
+	assert.Equal(t, "KMHD-2025-10", playlist.Title)
+	require.Len(t, playlist.Entries, 2)
+	assert.Equal(t, "a", playlist.Entries[0].KMHDID)
+	assert.Equal(t, "Kind of Blue (KMHD)", playlist.Entries[0].Album)
+	assert.Equal(t, "spotify:track:track2", playlist.Entries[1].SpotifyURI)
+	assert.True(t, playlist.Entries[1].PlayedAt.IsZero())
+
+	_, err = exportPlaylistTracks(svc, "Missing", store)
+	assert.Error(t, err)
+}
@@ -0,0 +1,87 @@
+// Package cmd provides the healthcheck command implementation for kmhd2spotify.
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// newHealthcheckCmd creates the healthcheck command for probing a running continuous sync.
+func newHealthcheckCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "healthcheck",
+		Short: "Check the health of a running continuous sync",
+		Long: `Query the /healthz (or, with --ready, /readyz) endpoint of a running
+'kmhd2spotify sync --continuous' and exit with status 0 if it is healthy and 1 otherwise.
+Suitable as a Docker HEALTHCHECK.`,
+		Args: cobra.NoArgs,
+		Run:  runHealthcheck,
+	}
+
+	cmd.Flags().Bool("ready", false, "Check readiness (/readyz) instead of liveness (/healthz)")
+	cmd.Flags().String("url", "", "Base URL of the status server (default: derived from SERVER_HOST and SERVER_PORT)")
+	cmd.Flags().Duration("timeout", 5*time.Second, "Timeout for the health request")
+
+	return cmd
+}
+
+// runHealthcheck executes the healthcheck command.
+func runHealthcheck(cmd *cobra.Command, args []string) {
+	ready, _ := cmd.Flags().GetBool("ready")
+	baseURL, _ := cmd.Flags().GetString("url")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+
+	if baseURL == "" {
+		baseURL = statusServerURL()
+	}
+	path := "/healthz"
+	if ready {
+		path = "/readyz"
+	}
+
+	if err := checkHealth(cmd.OutOrStdout(), baseURL+path, timeout); err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "❌ %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// statusServerURL returns the base URL of the local status server. Wildcard listen
+// addresses are replaced with the loopback address so the check works in containers.
+func statusServerURL() string {
+	host := conf.Server.Host
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	port := conf.Server.Port
+	if port == 0 {
+		port = 8080
+	}
+	return "http://" + net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// checkHealth requests the health endpoint, copies its report to out and returns an
+// error unless the endpoint responded 200 OK.
+func checkHealth(out io.Writer, url string, timeout time.Duration) error {
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(url) // #nosec G107 -- URL is the local status server
+	if err != nil {
+		return fmt.Errorf("health request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		return fmt.Errorf("failed to read health report: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+	return nil
+}
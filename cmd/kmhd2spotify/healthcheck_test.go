@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/pkg/config"
+)
+
+func TestNewHealthcheckCmd(t *testing.T) {
+	cmd := newHealthcheckCmd()
+	assert.Equal(t, "healthcheck", cmd.Use)
+	assert.NotNil(t, cmd.Flags().Lookup("ready"))
+	assert.NotNil(t, cmd.Flags().Lookup("url"))
+}
+
+func TestCheckHealth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" {
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"status":"ok"}`))
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"ready":false}`))
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	require.NoError(t, checkHealth(&out, server.URL+"/healthz", time.Second))
+	assert.Equal(t, `{"status":"ok"}`, out.String())
+
+	out.Reset()
+	err := checkHealth(&out, server.URL+"/readyz", time.Second)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 503")
+	assert.Equal(t, `{"ready":false}`, out.String())
+}
+
+func TestCheckHealthUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serverURL := server.URL
+	server.Close()
+
+	err := checkHealth(&bytes.Buffer{}, serverURL+"/healthz", time.Second)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "health request failed")
+}
+
+func TestStatusServerURL(t *testing.T) {
+	originalConf := conf
+	defer func() { conf = originalConf }()
+
+	conf.Server = config.ServerConfig{Host: "0.0.0.0", Port: 9090}
+	assert.Equal(t, "http://127.0.0.1:9090", statusServerURL())
+
+	conf.Server = config.ServerConfig{Host: "::1", Port: 8080}
+	assert.Equal(t, "http://[::1]:8080", statusServerURL())
+}
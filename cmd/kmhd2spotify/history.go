@@ -0,0 +1,81 @@
+// Package cmd provides play history recording for kmhd2spotify.
+package cmd
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/kmhd2spotify/internal/history"
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+// playHistory records every KMHD play and its Spotify match.
+// It is nil when the history could not be opened, in which case plays are not recorded.
+var playHistory *history.Store
+
+// openPlayHistory opens the play history in the configured state directory for
+// recording plays.
+func openPlayHistory() (*history.Store, error) {
+	path, err := playHistoryPath()
+	if err != nil {
+		return nil, err
+	}
+	return history.Open(path)
+}
+
+// openPlayHistoryReadOnly opens the play history in the configured state directory
+// for commands that only read it, so it is never rewritten under a running sync.
+func openPlayHistoryReadOnly() (*history.Store, error) {
+	path, err := playHistoryPath()
+	if err != nil {
+		return nil, err
+	}
+	return history.OpenReadOnly(path)
+}
+
+// playHistoryPath returns the path of the play history in the configured state directory.
+func playHistoryPath() (string, error) {
+	path, err := conf.State.GetFilePath(history.FileName)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve play history path: %w", err)
+	}
+	return path, nil
+}
+
+// recordPlays adds the fetched KMHD plays to the play history.
+func recordPlays(songs []types.Song) {
+	if playHistory == nil {
+		return
+	}
+
+	added, err := playHistory.Record(songs)
+	if err != nil {
+		log.WithError(err).Warn("Failed to record plays in history")
+		return
+	}
+	log.WithField("new_plays", added).Debug("Recorded plays in history")
+}
+
+// recordMatch records the Spotify track a KMHD play was matched to.
+func recordMatch(song types.Song, track *types.Track, confidence float64) {
+	if playHistory == nil || track == nil {
+		return
+	}
+
+	if err := playHistory.SetMatch(song, *track, confidence); err != nil {
+		log.WithError(err).WithField("kmhd_song", song.String()).Warn("Failed to record match in history")
+	}
+}
+
+// recordNoMatch records that a KMHD play was searched for on Spotify without a good
+// enough match, so it counts towards the match failure rate.
+func recordNoMatch(song types.Song) {
+	if playHistory == nil {
+		return
+	}
+
+	if err := playHistory.SetUnmatched(song); err != nil {
+		log.WithError(err).WithField("kmhd_song", song.String()).Warn("Failed to record missing match in history")
+	}
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/types"
+	"github.com/toozej/kmhd2spotify/pkg/config"
+)
+
+func TestRecordPlaysAndMatches(t *testing.T) {
+	originalConf, originalHistory := conf, playHistory
+	conf = config.Config{State: config.StateConfig{Dir: t.TempDir()}}
+	defer func() { conf, playHistory = originalConf, originalHistory }()
+
+	store, err := openPlayHistory()
+	require.NoError(t, err)
+	playHistory = store
+
+	song := types.Song{Artist: "Miles Davis", Title: "So What", PlayedAt: time.Now(), KMHDID: "a"}
+	recordPlays([]types.Song{song})
+	recordMatch(song, &types.Track{ID: "track1", Name: "So What"}, 0.9)
+	recordMatch(song, nil, 0)
+
+	plays := playHistory.Plays()
+	require.Len(t, plays, 1)
+	require.NotNil(t, plays[0].Match)
+	assert.Equal(t, "track1", plays[0].Match.TrackID)
+}
+
+func TestRecordPlaysWithoutHistory(t *testing.T) {
+	originalHistory := playHistory
+	playHistory = nil
+	defer func() { playHistory = originalHistory }()
+
+	assert.NotPanics(t, func() {
+		recordPlays([]types.Song{{Artist: "Miles Davis", Title: "So What"}})
+		recordMatch(types.Song{Artist: "Miles Davis", Title: "So What"}, &types.Track{ID: "track1"}, 0.9)
+	})
+}
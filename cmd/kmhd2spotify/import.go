@@ -0,0 +1,194 @@
+// Package cmd provides the import command implementation for kmhd2spotify.
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/kmhd2spotify/internal/history"
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+// importMatchWindow is how close to a recorded play of the same track an imported
+// playlist track is treated as that play rather than a separate one.
+const importMatchWindow = 24 * time.Hour
+
+// importResult summarizes the import of one playlist.
+type importResult struct {
+	Playlist string
+	Tracks   int
+	Imported int
+}
+
+// newImportCmd creates the import command for seeding the play history from Spotify.
+func newImportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import synced Spotify playlists into the play history",
+		Long: `Import the tracks of every monthly Spotify playlist, named after the playlist prefix
+(SPOTIFY_PLAYLIST_NAME_PREFIX) like KMHD-YYYY-MM, into the local play history. Chart,
+rolling and yearly playlists are skipped, since their tracks were played in other
+months. Charts, statistics and repeat plays then know about everything synced before
+the play history existed, without matching the songs again.
+
+Each track is recorded as played when it was added to its playlist. Tracks that are
+already in the history, or that the history has a play of within a day of being
+added, are skipped, so import can be run again safely.`,
+		Example: `  kmhd2spotify import
+  kmhd2spotify import --profile alice --dry-run`,
+		Args: cobra.NoArgs,
+		Run:  runImport,
+	}
+
+	cmd.Flags().String("profile", "", "Profile whose playlists to import (default account if empty)")
+	cmd.Flags().String("prefix", "", "Import the monthly playlists with this prefix (default the profile's playlist prefix)")
+	cmd.Flags().Bool("dry-run", false, "Show what would be imported without changing the play history")
+
+	return cmd
+}
+
+// runImport executes the import command.
+func runImport(cmd *cobra.Command, args []string) {
+	profile, _ := cmd.Flags().GetString("profile")
+	prefix, _ := cmd.Flags().GetString("prefix")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	if prefix == "" {
+		spotifyConfig, err := conf.SpotifyConfigForProfile(profile)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to load profile")
+			return
+		}
+		prefix = spotifyConfig.PlaylistNamePrefix
+	}
+	if prefix == "" {
+		log.Fatal("No playlist prefix configured, set SPOTIFY_PLAYLIST_NAME_PREFIX or pass --prefix")
+		return
+	}
+
+	store, err := openPlayHistory()
+	if err != nil {
+		log.WithError(err).Fatal("Failed to open play history")
+		return
+	}
+
+	spotifyService, err := newProfileService(profile)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to create Spotify service")
+		return
+	}
+	if err := ensureAuthenticated(spotifyService, profile, false); err != nil {
+		log.WithError(err).Fatal("Spotify authentication failed")
+		return
+	}
+
+	results, err := importPlaylists(spotifyService, prefix, store, dryRun)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to import playlists")
+		return
+	}
+	if len(results) == 0 {
+		fmt.Printf("📭 No monthly %s-YYYY-MM playlists found%s\n", prefix, profileSuffix(profile))
+		return
+	}
+
+	tracks, imported := 0, 0
+	for _, result := range results {
+		fmt.Printf("📥 %s: %s, %d new\n", result.Playlist, pluralize(result.Tracks, "track"), result.Imported)
+		tracks += result.Tracks
+		imported += result.Imported
+	}
+	if dryRun {
+		fmt.Printf("🔎 Dry run: would import %s of %s from %s\n", pluralize(imported, "play"), pluralize(tracks, "track"), pluralize(len(results), "playlist"))
+		return
+	}
+	fmt.Printf("✅ Imported %s of %s from %s\n", pluralize(imported, "play"), pluralize(tracks, "track"), pluralize(len(results), "playlist"))
+}
+
+// importPlaylists imports the tracks of every monthly playlist with the prefix, named
+// like KMHD-2025-10, into the store, oldest month first. With dryRun, an in-memory copy
+// of the store is used so the results show what would be imported.
+func importPlaylists(spotifyService types.SpotifyService, prefix string, store *history.Store, dryRun bool) ([]importResult, error) {
+	playlists, err := spotifyService.GetUserPlaylists("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user playlists: %w", err)
+	}
+
+	var matching []types.Playlist
+	for _, playlist := range playlists {
+		if m := monthlyNamePattern.FindStringSubmatch(playlist.Name); m != nil && m[1] == prefix {
+			matching = append(matching, playlist)
+		}
+	}
+	sort.Slice(matching, func(i, j int) bool { return matching[i].Name < matching[j].Name })
+
+	if dryRun {
+		store = store.Copy()
+	}
+
+	var results []importResult
+	for _, playlist := range matching {
+		items, err := spotifyService.GetPlaylistItems(playlist.ID)
+		if err != nil {
+			return results, fmt.Errorf("failed to get tracks of playlist %s: %w", playlist.Name, err)
+		}
+
+		imported, err := store.Import(importedPlays(items), importMatchWindow)
+		if err != nil {
+			return results, fmt.Errorf("failed to import playlist %s: %w", playlist.Name, err)
+		}
+
+		log.WithFields(log.Fields{
+			"playlist": playlist.Name,
+			"tracks":   len(items),
+			"imported": imported,
+			"dry_run":  dryRun,
+		}).Debug("Imported playlist into play history")
+		results = append(results, importResult{Playlist: playlist.Name, Tracks: len(items), Imported: imported})
+	}
+	return results, nil
+}
+
+// importedPlays converts playlist items to plays at the time each was added. Items
+// without an added time can't be placed in the history and are skipped.
+func importedPlays(items []types.PlaylistItem) []history.Play {
+	var plays []history.Play
+	for _, item := range items {
+		if item.AddedAt.IsZero() || item.Track.ID == "" {
+			continue
+		}
+
+		track := item.Track
+		artists := make([]string, len(track.Artists))
+		for i, artist := range track.Artists {
+			artists[i] = artist.Name
+		}
+		artist := ""
+		if len(artists) > 0 {
+			artist = artists[0]
+		}
+
+		plays = append(plays, history.Play{
+			Song: types.Song{
+				Artist:   artist,
+				Title:    track.Name,
+				Album:    track.Album.Name,
+				PlayedAt: item.AddedAt,
+				Duration: time.Duration(track.Duration) * time.Millisecond,
+			},
+			Match: &history.Match{
+				TrackID:   track.ID,
+				TrackURI:  track.URI,
+				TrackName: track.Name,
+				Artists:   artists,
+				Album:     track.Album.Name,
+			},
+			Imported: true,
+		})
+	}
+	return plays
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/history"
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+// MockImportSpotifyService returns playlist items with the times they were added
+type MockImportSpotifyService struct {
+	MockSpotifyServiceForSync
+	items map[string][]types.PlaylistItem
+}
+
+func (m *MockImportSpotifyService) GetPlaylistItems(playlistID string) ([]types.PlaylistItem, error) {
+	return m.items[playlistID], nil
+}
+
+func importItem(trackID, artist, name string, addedAt time.Time) types.PlaylistItem {
+	return types.PlaylistItem{
+		Track: types.Track{
+			ID:      trackID,
+			Name:    name,
+			URI:     "spotify:track:" + trackID,
+			Artists: []types.Artist{{Name: artist}, {Name: "Sideman"}},
+			Album:   types.Album{Name: name + " (Album)"},
+		},
+		AddedAt: addedAt,
+	}
+}
+
+func TestNewImportCmd(t *testing.T) {
+	cmd := newImportCmd()
+
+	assert.Equal(t, "import", cmd.Use)
+	for _, name := range []string{"profile", "prefix", "dry-run"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), name)
+	}
+}
+
+func TestImportPlaylists(t *testing.T) {
+	september := time.Date(2025, time.September, 12, 20, 0, 0, 0, time.UTC)
+	october := time.Date(2025, time.October, 6, 9, 0, 0, 0, time.UTC)
+
+	svc := &MockImportSpotifyService{
+		MockSpotifyServiceForSync: MockSpotifyServiceForSync{
+			playlists: []types.Playlist{
+				{ID: "oct", Name: "KMHD-2025-10"},
+				{ID: "sep", Name: "KMHD-2025-09"},
+				{ID: "other", Name: "Road Trip"},
+				{ID: "top", Name: "KMHD Top 50"},
+				{ID: "year", Name: "KMHD-2025"},
+				{ID: "chart", Name: "KMHD-Chart-2025-10"},
+				{ID: "rolling", Name: "KMHD-Last-30-Days"},
+			},
+		},
+		items: map[string][]types.PlaylistItem{
+			"sep": {
+				importItem("track1", "Miles Davis", "So What", september),
+				importItem("track2", "John Coltrane", "Giant Steps", september.Add(time.Hour)),
+				{Track: types.Track{ID: "track3", Name: "No Date", Artists: []types.Artist{{Name: "Bill Evans"}}}},
+			},
+			"oct": {
+				importItem("track1", "Miles Davis", "So What", october),
+				importItem("track4", "Esperanza Spalding", "Formwela 1", october.Add(time.Hour)),
+			},
+			"other":   {importItem("track5", "Kenny G", "Songbird", october)},
+			"top":     {importItem("track6", "Kenny G", "Songbird", october)},
+			"year":    {importItem("track6", "Kenny G", "Songbird", october)},
+			"chart":   {importItem("track6", "Kenny G", "Songbird", october)},
+			"rolling": {importItem("track6", "Kenny G", "Songbird", october)},
+		},
+	}
+
+	store, err := history.Open(filepath.Join(t.TempDir(), history.FileName))
+	require.NoError(t, err)
+
+	// The October So What was already recorded during a sync
+	synced := types.Song{Artist: "Miles Davis", Title: "So What", PlayedAt: october.Add(-20 * time.Minute), KMHDID: "a"}
+	require.NoError(t, store.SetMatch(synced, types.Track{ID: "track1"}, 0.9))
+
+	results, err := importPlaylists(svc, "KMHD", store, true)
+	require.NoError(t, err)
+	assert.Equal(t, []importResult{
+		{Playlist: "KMHD-2025-09", Tracks: 3, Imported: 2},
+		{Playlist: "KMHD-2025-10", Tracks: 2, Imported: 1},
+	}, results)
+	assert.Equal(t, 1, store.Len(), "a dry run doesn't change the history")
+
+	results, err = importPlaylists(svc, "KMHD", store, false)
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, 4, store.Len())
+
+	plays := store.Plays()
+	first := plays[0]
+	assert.True(t, first.Imported)
+	assert.Equal(t, "Miles Davis", first.Song.Artist)
+	assert.Equal(t, september, first.Song.PlayedAt)
+	require.NotNil(t, first.Match)
+	assert.Equal(t, []string{"Miles Davis", "Sideman"}, first.Match.Artists)
+	assert.Equal(t, "spotify:track:track1", first.Match.TrackURI)
+
+	results, err = importPlaylists(svc, "KMHD", store, false)
+	require.NoError(t, err)
+	assert.Zero(t, results[0].Imported+results[1].Imported, "importing again adds nothing")
+}
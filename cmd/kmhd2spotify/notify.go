@@ -0,0 +1,34 @@
+// Package cmd provides notification helpers for kmhd2spotify commands.
+package cmd
+
+import (
+	"time"
+
+	"github.com/toozej/kmhd2spotify/internal/notify"
+	"github.com/toozej/kmhd2spotify/internal/nowplaying"
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+// notifier delivers song notifications to the configured sinks.
+// It is created from configuration before every command runs; a nil notifier discards events.
+var notifier *notify.Notifier
+
+// notifySongAdded notifies the configured sinks that a KMHD song was added to a playlist.
+func notifySongAdded(song types.Song, track *types.Track, playlist *types.Playlist) {
+	notifier.Notify(notify.Event{
+		Type:     notify.EventSongAdded,
+		Song:     song,
+		Track:    track,
+		Playlist: playlist,
+		Time:     time.Now(),
+	})
+}
+
+// notifyNowPlaying notifies the configured sinks that the song playing on KMHD changed.
+func notifyNowPlaying(event nowplaying.Event) {
+	notifier.Notify(notify.Event{
+		Type: notify.EventNowPlaying,
+		Song: event.Song,
+		Time: event.DetectedAt,
+	})
+}
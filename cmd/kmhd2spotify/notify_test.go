@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/notify"
+	"github.com/toozej/kmhd2spotify/internal/nowplaying"
+	"github.com/toozej/kmhd2spotify/internal/queue"
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+// recordingNotifySink records the notifications it receives
+type recordingNotifySink struct {
+	events []notify.Event
+}
+
+func (s *recordingNotifySink) Name() string { return "recording" }
+
+func (s *recordingNotifySink) Send(ctx context.Context, event notify.Event) error {
+	s.events = append(s.events, event)
+	return nil
+}
+
+// useRecordingNotifier replaces the package notifier for the duration of a test
+func useRecordingNotifier(t *testing.T, events ...notify.EventType) *recordingNotifySink {
+	t.Helper()
+
+	sink := &recordingNotifySink{}
+	n := notify.NewNotifier(time.Second)
+	n.AddSink(sink, notify.NewFilter(events, nil))
+
+	original := notifier
+	notifier = n
+	t.Cleanup(func() { notifier = original })
+
+	return sink
+}
+
+func TestNotifySongAdded(t *testing.T) {
+	sink := useRecordingNotifier(t, notify.EventSongAdded)
+
+	song := types.Song{Artist: "Miles Davis", Title: "So What"}
+	notifySongAdded(song, &types.Track{ID: "track1"}, &types.Playlist{Name: "KMHD-2025-10"})
+	notifyNowPlaying(nowplaying.Event{Song: song, DetectedAt: time.Now()})
+
+	require.Len(t, sink.events, 1, "the sink only subscribes to song_added")
+	assert.Equal(t, notify.EventSongAdded, sink.events[0].Type)
+	assert.Equal(t, "track1", sink.events[0].Track.ID)
+	assert.Equal(t, "KMHD-2025-10", sink.events[0].Playlist.Name)
+}
+
+func TestNotifyNowPlaying(t *testing.T) {
+	sink := useRecordingNotifier(t, notify.EventNowPlaying)
+
+	notifyNowPlaying(nowplaying.Event{Song: types.Song{Artist: "Miles Davis", Title: "So What"}, DetectedAt: time.Now()})
+
+	require.Len(t, sink.events, 1)
+	assert.Equal(t, notify.EventNowPlaying, sink.events[0].Type)
+	assert.Nil(t, sink.events[0].Track)
+}
+
+func TestRetriedAddIsNotified(t *testing.T) {
+	sink := useRecordingNotifier(t, notify.EventSongAdded)
+
+	q, err := queue.New(filepath.Join(t.TempDir(), retryQueueFileName), queue.Policy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Minute,
+		MaxDelay:    time.Hour,
+	})
+	require.NoError(t, err)
+
+	originalQueue := retryQueue
+	retryQueue = q
+	defer func() { retryQueue = originalQueue }()
+
+	song := types.Song{Artist: "Miles Davis", Title: "So What"}
+	enqueueFailedAdd(song, &types.Track{ID: "track1", Name: "So What"}, types.Playlist{ID: "p", Name: "KMHD-2025-10"}, "", errors.New("boom"))
+	require.NoError(t, q.RequeueAll())
+
+	processRetryQueue(syncTarget{Service: &MockFailingAddSpotifyService{}})
+
+	require.Len(t, sink.events, 1)
+	assert.Equal(t, "Miles Davis", sink.events[0].Song.Artist)
+	assert.Equal(t, "track1", sink.events[0].Track.ID)
+	assert.Equal(t, "KMHD-2025-10", sink.events[0].Playlist.Name)
+}
+
+func TestNotifyWithoutNotifier(t *testing.T) {
+	original := notifier
+	notifier = nil
+	defer func() { notifier = original }()
+
+	assert.NotPanics(t, func() {
+		notifySongAdded(types.Song{}, nil, nil)
+	})
+}
@@ -0,0 +1,129 @@
+// Package cmd provides the now command implementation for kmhd2spotify.
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/kmhd2spotify/internal/nowplaying"
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+// newNowCmd creates the now command for showing what is currently playing on KMHD.
+func newNowCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "now",
+		Short: "Show the song currently playing on KMHD",
+		Long: `Show the song currently playing on KMHD.
+With --follow, keep watching the station and print a line each time the song changes.
+Polls are scheduled around each track's expected end, so following is cheap.
+Use --format json to emit one JSON object per song for status bars and scripts.
+Song changes are also delivered to notification sinks subscribed to now_playing events,
+and finished songs are scrobbled when a scrobbling service is configured.`,
+		Args: cobra.NoArgs,
+		Run:  runNow,
+	}
+
+	cmd.Flags().BoolP("follow", "f", false, "Keep watching and print each song change")
+	cmd.Flags().String("format", "text", "Output format: text or json")
+
+	return cmd
+}
+
+// runNow executes the now command.
+func runNow(cmd *cobra.Command, args []string) {
+	follow, _ := cmd.Flags().GetBool("follow")
+	format, _ := cmd.Flags().GetString("format")
+	if format != "text" && format != "json" {
+		log.WithField("format", format).Fatal("Unsupported output format, expected text or json")
+		return
+	}
+
+	kmhdAPIClient, err := initializeKMHDAPIClient()
+	if err != nil {
+		log.WithError(err).Fatal("Failed to initialize KMHD API client")
+		return
+	}
+
+	watcher := nowplaying.NewWatcher(kmhdAPIClient)
+	out := cmd.OutOrStdout()
+
+	if !follow {
+		song, err := watcher.Current()
+		if err != nil {
+			log.WithError(err).Fatal("Failed to fetch now playing")
+			return
+		}
+		if song == nil {
+			fmt.Fprintln(out, "📻 Nothing is playing on KMHD right now")
+			return
+		}
+		writeNowPlaying(out, format, nowplaying.Event{
+			Song:        *song,
+			ExpectedEnd: song.ExpectedEnd(),
+			DetectedAt:  time.Now(),
+		})
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	scrobbler, err = openScrobbler()
+	if err != nil {
+		log.WithError(err).Warn("Failed to open scrobble queue, plays will not be scrobbled")
+	}
+
+	log.Info("Following KMHD now playing, press Ctrl+C to stop")
+	err = watcher.Watch(ctx, func(event nowplaying.Event) {
+		writeNowPlaying(out, format, event)
+		notifyNowPlaying(event)
+		scrobblePlays(nowPlayingSongs(event))
+	})
+	if err != nil && ctx.Err() == nil {
+		log.WithError(err).Fatal("Now playing watcher stopped")
+	}
+}
+
+// nowPlayingSongs returns the plays of a now-playing event, so the previous song is
+// scrobbled once the next one has started.
+func nowPlayingSongs(event nowplaying.Event) []types.Song {
+	if event.Previous == nil {
+		return []types.Song{event.Song}
+	}
+	return []types.Song{*event.Previous, event.Song}
+}
+
+// writeNowPlaying prints a now-playing event in the requested format.
+func writeNowPlaying(out io.Writer, format string, event nowplaying.Event) {
+	if format == "json" {
+		data, err := json.Marshal(event)
+		if err != nil {
+			log.WithError(err).Error("Failed to encode now playing event")
+			return
+		}
+		fmt.Fprintln(out, string(data))
+		return
+	}
+
+	fmt.Fprintf(out, "🎵 %s\n", event.Song.String())
+	if !event.Song.PlayedAt.IsZero() {
+		fmt.Fprintf(out, "   🕒 Started: %s", event.Song.PlayedAt.Local().Format("15:04:05"))
+		if !event.ExpectedEnd.IsZero() {
+			remaining := event.ExpectedEnd.Sub(event.DetectedAt).Round(time.Second)
+			if remaining > 0 {
+				fmt.Fprintf(out, " (%s remaining)", remaining)
+			}
+		}
+		fmt.Fprintln(out)
+	}
+}
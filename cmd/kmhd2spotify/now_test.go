@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/nowplaying"
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+func TestWriteNowPlaying(t *testing.T) {
+	start := time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)
+	event := nowplaying.Event{
+		Song: types.Song{
+			Artist:   "John Coltrane",
+			Title:    "Giant Steps",
+			Album:    "Giant Steps",
+			PlayedAt: start,
+			Duration: 5 * time.Minute,
+		},
+		ExpectedEnd: start.Add(5 * time.Minute),
+		DetectedAt:  start.Add(time.Minute),
+	}
+
+	t.Run("text", func(t *testing.T) {
+		var out bytes.Buffer
+		writeNowPlaying(&out, "text", event)
+		assert.Contains(t, out.String(), "John Coltrane - Giant Steps")
+		assert.Contains(t, out.String(), "(4m0s remaining)")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var out bytes.Buffer
+		writeNowPlaying(&out, "json", event)
+
+		var decoded nowplaying.Event
+		require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+		assert.Equal(t, "Giant Steps", decoded.Song.Title)
+		assert.True(t, decoded.ExpectedEnd.Equal(event.ExpectedEnd))
+	})
+}
+
+func TestNewNowCmd(t *testing.T) {
+	cmd := newNowCmd()
+	assert.Equal(t, "now", cmd.Use)
+	assert.NotNil(t, cmd.Flags().Lookup("follow"))
+	assert.NotNil(t, cmd.Flags().Lookup("format"))
+}
@@ -0,0 +1,684 @@
+// Package cmd provides the playlists command implementation for kmhd2spotify.
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/kmhd2spotify/internal/cover"
+	"github.com/toozej/kmhd2spotify/internal/history"
+	"github.com/toozej/kmhd2spotify/internal/playlist"
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+// newPlaylistsCmd creates the playlists command for browsing and managing the user's playlists.
+func newPlaylistsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "playlists",
+		Short: "Browse and manage your Spotify playlists",
+		Long: `Browse and manage the Spotify playlists owned by your account, such as the
+monthly playlists, without going to the Spotify app.
+
+Playlists are given by name or ID. Incoming playlists are the inboxes you add artists
+to. A playlist is incoming if its name starts with SPOTIFY_INCOMING_PREFIX (e.g.
+"Incoming: Jazz") or its description contains SPOTIFY_INCOMING_TAG (e.g. "#incoming").`,
+	}
+	cmd.PersistentFlags().String("profile", "", "Profile whose playlists to use (default account if empty)")
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List your playlists, optionally only incoming ones or those matching a search",
+		Example: `  kmhd2spotify playlists list
+  kmhd2spotify playlists list --page 2 --per-page 20
+  kmhd2spotify playlists list --incoming --search jazz`,
+		Args: cobra.NoArgs,
+		Run:  runPlaylistsList,
+	}
+	listCmd.Flags().Bool("incoming", false, "Only list incoming playlists")
+	listCmd.Flags().String("search", "", "Only list playlists whose name contains this (case-insensitive)")
+	listCmd.Flags().Int("page", 1, "Page of playlists to list")
+	listCmd.Flags().Int("per-page", 50, "Playlists per page (0 lists all)")
+
+	archiveCmd := &cobra.Command{
+		Use:   "archive <year>",
+		Short: "Merge a year's monthly playlists into a yearly playlist",
+		Long: `Merge the monthly playlists of a year, such as KMHD-2025-01 to KMHD-2025-12, into
+the yearly playlist KMHD-2025, creating it if needed. Tracks are added month by month,
+skipping tracks already in the yearly playlist, so archive can be run again as more
+months end. The current month is never archived.
+
+With --delete, the merged monthly playlists are removed from your library afterwards.
+Tracks without a Spotify ID, such as local files, can't be merged, so monthly
+playlists with any are kept.`,
+		Example: `  kmhd2spotify playlists archive 2025 --dry-run
+  kmhd2spotify playlists archive 2025 --delete`,
+		Args: cobra.ExactArgs(1),
+		Run:  runPlaylistsArchive,
+	}
+	archiveCmd.Flags().Bool("delete", false, "Remove the monthly playlists from your library once merged")
+	archiveCmd.Flags().Bool("dry-run", false, "Show what would be merged without changing any playlist")
+
+	coverCmd := &cobra.Command{
+		Use:   "cover <playlist> [image-file]",
+		Short: "Upload a JPEG image or a generated cover as a playlist's cover",
+		Long: `Upload a JPEG image as a playlist's cover. Spotify accepts JPEG images up to
+256 KB once base64 encoded, about 190 KB on disk.
+
+With --generate, a cover is generated instead: a collage of the album artwork of the
+playlist's tracks as played on KMHD, labelled with the month and year of a monthly
+playlist or the playlist's name. Without artwork, for example when offline, the cover
+is typographic.
+
+Uploading needs the ugc-image-upload permission; tokens from before it was requested
+need 'kmhd2spotify auth' again.`,
+		Example: `  kmhd2spotify playlists cover KMHD-2025-10 october.jpg
+  kmhd2spotify playlists cover KMHD-2025-10 --generate`,
+		Args: cobra.RangeArgs(1, 2),
+		Run:  runPlaylistsCover,
+	}
+	coverCmd.Flags().Bool("generate", false, "Generate the cover from the artwork of the playlist's KMHD plays")
+
+	cmd.AddCommand(
+		listCmd,
+		&cobra.Command{
+			Use:     "show <playlist>",
+			Short:   "Show a playlist's tracks with their KMHD plays",
+			Example: `  kmhd2spotify playlists show KMHD-2025-10`,
+			Args:    cobra.ExactArgs(1),
+			Run:     runPlaylistsShow,
+		},
+		&cobra.Command{
+			Use:     "rename <playlist> <name>",
+			Short:   "Rename a playlist",
+			Example: `  kmhd2spotify playlists rename "Incoming: Jazz" "Incoming: Piano Trios"`,
+			Args:    cobra.ExactArgs(2),
+			Run:     runPlaylistsRename,
+		},
+		&cobra.Command{
+			Use:     "describe <playlist> <description>",
+			Short:   "Set a playlist's description",
+			Example: `  kmhd2spotify playlists describe KMHD-2025-10 "October on KMHD, with the Jazz Showcase"`,
+			Args:    cobra.ExactArgs(2),
+			Run:     runPlaylistsDescribe,
+		},
+		&cobra.Command{
+			Use:     "set-public <playlist> <true|false>",
+			Short:   "Make a playlist public or private",
+			Example: `  kmhd2spotify playlists set-public KMHD-2025-10 true`,
+			Args:    cobra.ExactArgs(2),
+			Run:     runPlaylistsSetPublic,
+		},
+		coverCmd,
+		archiveCmd,
+	)
+
+	return cmd
+}
+
+// openPlaylistsService creates the authenticated Spotify service of the command's profile.
+func openPlaylistsService(cmd *cobra.Command) (types.SpotifyService, error) {
+	profile, _ := cmd.Flags().GetString("profile")
+
+	spotifyService, err := newProfileService(profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Spotify service: %w", err)
+	}
+	if err := ensureAuthenticated(spotifyService, profile, false); err != nil {
+		return nil, fmt.Errorf("spotify authentication failed: %w", err)
+	}
+	return spotifyService, nil
+}
+
+// runPlaylistsList executes the playlists list command.
+func runPlaylistsList(cmd *cobra.Command, args []string) {
+	incoming, _ := cmd.Flags().GetBool("incoming")
+	search, _ := cmd.Flags().GetString("search")
+	page, _ := cmd.Flags().GetInt("page")
+	perPage, _ := cmd.Flags().GetInt("per-page")
+	if page < 1 || perPage < 0 {
+		log.WithFields(log.Fields{"page": page, "per_page": perPage}).Fatal("--page must be at least 1 and --per-page must not be negative")
+		return
+	}
+
+	spotifyService, err := openPlaylistsService(cmd)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to connect to Spotify")
+		return
+	}
+
+	playlists, err := listPlaylists(spotifyService, incoming, search)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to list playlists")
+		return
+	}
+	writePlaylists(cmd.OutOrStdout(), playlists, incoming, search, page, perPage)
+}
+
+// listPlaylists returns the user's playlists, only the incoming ones with incoming,
+// filtered by the search term.
+func listPlaylists(spotifyService types.SpotifyService, incoming bool, search string) ([]types.Playlist, error) {
+	var manager types.PlaylistManager = playlist.NewService(spotifyService, log.StandardLogger())
+
+	var playlists []types.Playlist
+	var err error
+	if incoming {
+		playlists, err = manager.GetIncomingPlaylists()
+	} else {
+		playlists, err = spotifyService.GetUserPlaylists("")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return manager.FilterPlaylistsBySearch(playlists, search), nil
+}
+
+// pagePlaylists returns the playlists on a 1-based page and the number of pages. A
+// perPage of 0 puts every playlist on one page.
+func pagePlaylists(playlists []types.Playlist, page, perPage int) ([]types.Playlist, int) {
+	if perPage == 0 || len(playlists) == 0 {
+		if page > 1 {
+			return nil, 1
+		}
+		return playlists, 1
+	}
+
+	pages := (len(playlists) + perPage - 1) / perPage
+	start := (page - 1) * perPage
+	if start >= len(playlists) {
+		return nil, pages
+	}
+	return playlists[start:min(start+perPage, len(playlists))], pages
+}
+
+// writePlaylists prints a page of playlists with their track counts and IDs.
+func writePlaylists(out io.Writer, playlists []types.Playlist, incoming bool, search string, page, perPage int) {
+	noun := "playlist"
+	if incoming {
+		noun = "incoming playlist"
+	}
+	matching := ""
+	if search != "" {
+		matching = fmt.Sprintf(" matching '%s'", search)
+	}
+
+	if len(playlists) == 0 {
+		fmt.Fprintf(out, "📭 No %ss%s\n", noun, matching)
+		return
+	}
+
+	shown, pages := pagePlaylists(playlists, page, perPage)
+	paging := ""
+	if pages > 1 || page > 1 {
+		paging = fmt.Sprintf(", page %d of %d", page, pages)
+	}
+	fmt.Fprintf(out, "📂 %s%s%s\n", pluralize(len(playlists), noun), matching, paging)
+	for _, p := range shown {
+		icon := "🎵"
+		if p.IsIncoming {
+			icon = "📥"
+		}
+		fmt.Fprintf(out, "   %s %s (%s) %s\n", icon, p.Name, playlistSummary(p), p.ID)
+	}
+	if page < pages {
+		fmt.Fprintf(out, "Run again with --page %d for more.\n", page+1)
+	}
+}
+
+// playlistSummary formats a playlist's track count and visibility.
+func playlistSummary(p types.Playlist) string {
+	summary := pluralize(p.TrackCount, "track")
+	if p.Public {
+		return summary + ", public"
+	}
+	return summary + ", private"
+}
+
+// findPlaylist returns the user's playlist with the ID or name.
+func findPlaylist(spotifyService types.SpotifyService, nameOrID string) (types.Playlist, error) {
+	playlists, err := spotifyService.GetUserPlaylists("")
+	if err != nil {
+		return types.Playlist{}, fmt.Errorf("failed to get user playlists: %w", err)
+	}
+	for _, p := range playlists {
+		if p.ID == nameOrID {
+			return p, nil
+		}
+	}
+	for _, p := range playlists {
+		if p.Name == nameOrID {
+			return p, nil
+		}
+	}
+	return types.Playlist{}, fmt.Errorf("no playlist named '%s' found", nameOrID)
+}
+
+// trackPlays is how often and when last KMHD played a track.
+type trackPlays struct {
+	Count      int
+	LastPlayed time.Time
+}
+
+// kmhdPlays returns the KMHD plays of each matched Spotify track, by track ID.
+func kmhdPlays(plays []history.Play) map[string]trackPlays {
+	byTrack := make(map[string]trackPlays)
+	for _, play := range plays {
+		if play.Match == nil {
+			continue
+		}
+		info := byTrack[play.Match.TrackID]
+		info.Count++
+		if play.Song.PlayedAt.After(info.LastPlayed) {
+			info.LastPlayed = play.Song.PlayedAt
+		}
+		byTrack[play.Match.TrackID] = info
+	}
+	return byTrack
+}
+
+// runPlaylistsShow executes the playlists show command.
+func runPlaylistsShow(cmd *cobra.Command, args []string) {
+	spotifyService, err := openPlaylistsService(cmd)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to connect to Spotify")
+		return
+	}
+
+	target, err := findPlaylist(spotifyService, args[0])
+	if err != nil {
+		log.WithError(err).Fatal("Failed to find playlist")
+		return
+	}
+	items, err := spotifyService.GetPlaylistItems(target.ID)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to get playlist tracks")
+		return
+	}
+
+	var plays map[string]trackPlays
+	store, err := openPlayHistoryReadOnly()
+	if err != nil {
+		log.WithError(err).Warn("Failed to open play history, showing tracks without KMHD plays")
+	} else {
+		plays = kmhdPlays(store.Plays())
+	}
+	writePlaylistTracks(cmd.OutOrStdout(), target, items, plays)
+}
+
+// writePlaylistTracks prints a playlist's tracks with when they were added and their
+// KMHD plays.
+func writePlaylistTracks(out io.Writer, target types.Playlist, items []types.PlaylistItem, plays map[string]trackPlays) {
+	target.TrackCount = len(items)
+	fmt.Fprintf(out, "📋 %s (%s) %s\n", target.Name, playlistSummary(target), target.ID)
+	if target.Description != "" {
+		fmt.Fprintf(out, "   %s\n", target.Description)
+	}
+
+	for i, item := range items {
+		added := "unknown"
+		if !item.AddedAt.IsZero() {
+			added = item.AddedAt.Local().Format("2006-01-02")
+		}
+		line := fmt.Sprintf("%3d. %s, added %s", i+1, dedupeTrackName(item.Track), added)
+		if plays != nil {
+			if info, ok := plays[item.Track.ID]; ok {
+				line += fmt.Sprintf(", 📻 %s, last %s", pluralize(info.Count, "play"), info.LastPlayed.Local().Format("2006-01-02"))
+			} else {
+				line += ", not in play history"
+			}
+		}
+		fmt.Fprintln(out, line)
+	}
+}
+
+// runPlaylistsRename executes the playlists rename command.
+func runPlaylistsRename(cmd *cobra.Command, args []string) {
+	if strings.TrimSpace(args[1]) == "" {
+		log.Fatal("Playlist names must not be empty")
+		return
+	}
+	updatePlaylist(cmd, args[0], types.PlaylistUpdate{Name: args[1]}, func(p types.Playlist) string {
+		return fmt.Sprintf("✏️  Renamed %s to %s", p.Name, args[1])
+	})
+}
+
+// runPlaylistsDescribe executes the playlists describe command.
+func runPlaylistsDescribe(cmd *cobra.Command, args []string) {
+	if args[1] == "" {
+		log.Fatal("Playlist descriptions can't be cleared through the Spotify API, give a new description")
+		return
+	}
+	updatePlaylist(cmd, args[0], types.PlaylistUpdate{Description: args[1]}, func(p types.Playlist) string {
+		return fmt.Sprintf("📝 Updated the description of %s", p.Name)
+	})
+}
+
+// runPlaylistsSetPublic executes the playlists set-public command.
+func runPlaylistsSetPublic(cmd *cobra.Command, args []string) {
+	public, err := strconv.ParseBool(args[1])
+	if err != nil {
+		log.WithField("value", args[1]).Fatal("Expected true or false")
+		return
+	}
+	updatePlaylist(cmd, args[0], types.PlaylistUpdate{Public: &public}, func(p types.Playlist) string {
+		if public {
+			return fmt.Sprintf("🌍 %s is now public", p.Name)
+		}
+		return fmt.Sprintf("🔒 %s is now private", p.Name)
+	})
+}
+
+// updatePlaylist applies an update to the named playlist and prints the message for it.
+func updatePlaylist(cmd *cobra.Command, nameOrID string, update types.PlaylistUpdate, message func(types.Playlist) string) {
+	spotifyService, err := openPlaylistsService(cmd)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to connect to Spotify")
+		return
+	}
+
+	target, err := findPlaylist(spotifyService, nameOrID)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to find playlist")
+		return
+	}
+	if err := spotifyService.UpdatePlaylist(target.ID, update); err != nil {
+		log.WithError(err).Fatal("Failed to update playlist")
+		return
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), message(target))
+}
+
+// runPlaylistsCover executes the playlists cover command.
+func runPlaylistsCover(cmd *cobra.Command, args []string) {
+	generate, _ := cmd.Flags().GetBool("generate")
+	if generate == (len(args) == 2) {
+		log.Fatal("Give either an image file or --generate")
+		return
+	}
+
+	var image []byte
+	if !generate {
+		var err error
+		image, err = readCoverImage(args[1])
+		if err != nil {
+			log.WithError(err).Fatal("Invalid cover image")
+			return
+		}
+	}
+
+	spotifyService, err := openPlaylistsService(cmd)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to connect to Spotify")
+		return
+	}
+
+	target, err := findPlaylist(spotifyService, args[0])
+	if err != nil {
+		log.WithError(err).Fatal("Failed to find playlist")
+		return
+	}
+	if generate {
+		err = generatePlaylistCover(spotifyService, target)
+	} else {
+		err = spotifyService.SetPlaylistCover(target.ID, image)
+	}
+	if err != nil {
+		profile, _ := cmd.Flags().GetString("profile")
+		log.WithError(err).Fatalf("Failed to upload cover, if Spotify denied access run 'kmhd2spotify auth%s' again", profileFlagHint(profile))
+		return
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "🖼️  Uploaded the cover of %s\n", target.Name)
+}
+
+// generatePlaylistCover uploads a cover generated from the artwork of the KMHD plays
+// of the playlist's tracks.
+func generatePlaylistCover(spotifyService types.SpotifyService, target types.Playlist) error {
+	var plays []history.Play
+	store, err := openPlayHistoryReadOnly()
+	if err != nil {
+		log.WithError(err).Warn("Failed to open play history, generating a cover without artwork")
+	} else {
+		plays = store.Plays()
+	}
+
+	tracks, err := spotifyService.GetPlaylistTracks(target.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get playlist tracks: %w", err)
+	}
+	trackIDs := make([]string, 0, len(tracks))
+	for _, track := range tracks {
+		trackIDs = append(trackIDs, track.ID)
+	}
+	return setGeneratedCover(spotifyService, target, plays, trackIDs)
+}
+
+// readCoverImage reads a cover image file and checks that Spotify will accept it.
+func readCoverImage(path string) ([]byte, error) {
+	image, err := os.ReadFile(path) // #nosec G304 -- user-specified image file
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cover image: %w", err)
+	}
+	if !bytes.HasPrefix(image, []byte{0xff, 0xd8, 0xff}) {
+		return nil, fmt.Errorf("%s is not a JPEG image", path)
+	}
+	if size := base64.StdEncoding.EncodedLen(len(image)); size > cover.MaxImageSize {
+		return nil, fmt.Errorf("%s is %d KB once encoded, Spotify accepts up to %d KB", path, size/1024, cover.MaxImageSize/1024)
+	}
+	return image, nil
+}
+
+// archivedMonth is a monthly playlist merged into a yearly playlist.
+type archivedMonth struct {
+	Playlist types.Playlist
+	Tracks   int
+	Added    int
+
+	// Skipped counts tracks without a Spotify ID, such as local files, which can't be
+	// added to the yearly playlist. Kept marks a month that wasn't deleted because of them.
+	Skipped int
+	Kept    bool
+}
+
+// archiveResult is the outcome of archiving a year's monthly playlists.
+type archiveResult struct {
+	Yearly  types.Playlist
+	Created bool
+	Months  []archivedMonth
+	Added   int
+	Skipped int
+	Deleted int
+}
+
+// runPlaylistsArchive executes the playlists archive command.
+func runPlaylistsArchive(cmd *cobra.Command, args []string) {
+	profile, _ := cmd.Flags().GetString("profile")
+	deleteMonths, _ := cmd.Flags().GetBool("delete")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	year, err := strconv.Atoi(args[0])
+	if err != nil || year < 1000 || year > 9999 {
+		log.WithField("year", args[0]).Fatal("Expected a year such as 2025")
+		return
+	}
+	spotifyConfig, err := conf.SpotifyConfigForProfile(profile)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to load profile")
+		return
+	}
+	if spotifyConfig.PlaylistNamePrefix == "" {
+		log.Fatal("No playlist prefix configured, set SPOTIFY_PLAYLIST_NAME_PREFIX")
+		return
+	}
+
+	spotifyService, err := openPlaylistsService(cmd)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to connect to Spotify")
+		return
+	}
+
+	result, err := archivePlaylists(spotifyService, spotifyConfig.PlaylistNamePrefix, year, time.Now(), deleteMonths, dryRun)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to archive monthly playlists")
+		return
+	}
+	writeArchiveResult(cmd.OutOrStdout(), result, dryRun)
+}
+
+// archivePlaylists merges the prefix's monthly playlists of the year, before the
+// current month, into the yearly playlist, and with deleteMonths removes them, except
+// those with tracks that couldn't be merged. With dryRun, nothing is changed and the
+// result shows what would be merged.
+func archivePlaylists(spotifyService types.SpotifyService, prefix string, year int, now time.Time, deleteMonths, dryRun bool) (archiveResult, error) {
+	playlists, err := spotifyService.GetUserPlaylists("")
+	if err != nil {
+		return archiveResult{}, fmt.Errorf("failed to get user playlists: %w", err)
+	}
+
+	yearlyName := fmt.Sprintf("%s-%04d", prefix, year)
+	currentMonth := fmt.Sprintf("%s-%04d-%02d", prefix, now.Year(), now.Month())
+	monthly := regexp.MustCompile(`^` + regexp.QuoteMeta(yearlyName) + `-(0[1-9]|1[0-2])$`)
+
+	var result archiveResult
+	var months []types.Playlist
+	for _, p := range playlists {
+		switch {
+		case p.Name == yearlyName && result.Yearly.ID == "":
+			result.Yearly = p
+		case monthly.MatchString(p.Name) && p.Name != currentMonth:
+			months = append(months, p)
+		}
+	}
+	if len(months) == 0 {
+		return archiveResult{}, fmt.Errorf("no finished monthly playlists of %d found", year)
+	}
+	sort.Slice(months, func(i, j int) bool { return months[i].Name < months[j].Name })
+
+	// Tracks already in the yearly playlist are not added again
+	archived := make(map[string]bool)
+	if result.Yearly.ID != "" {
+		tracks, err := spotifyService.GetPlaylistTracks(result.Yearly.ID)
+		if err != nil {
+			return archiveResult{}, fmt.Errorf("failed to get tracks of playlist %s: %w", yearlyName, err)
+		}
+		for _, track := range tracks {
+			archived[track.ID] = true
+		}
+	}
+
+	var add []string
+	for _, month := range months {
+		tracks, err := spotifyService.GetPlaylistTracks(month.ID)
+		if err != nil {
+			return archiveResult{}, fmt.Errorf("failed to get tracks of playlist %s: %w", month.Name, err)
+		}
+		archivedMonth := archivedMonth{Playlist: month, Tracks: len(tracks)}
+		for _, track := range tracks {
+			if track.ID == "" {
+				archivedMonth.Skipped++
+				continue
+			}
+			if archived[track.ID] {
+				continue
+			}
+			archived[track.ID] = true
+			add = append(add, track.ID)
+			archivedMonth.Added++
+		}
+		archivedMonth.Kept = deleteMonths && archivedMonth.Skipped > 0
+		result.Skipped += archivedMonth.Skipped
+		result.Months = append(result.Months, archivedMonth)
+	}
+	result.Added = len(add)
+	if dryRun {
+		if result.Yearly.ID == "" {
+			result.Yearly = types.Playlist{Name: yearlyName}
+			result.Created = true
+		}
+		return result, nil
+	}
+
+	if result.Yearly.ID == "" {
+		description := fmt.Sprintf("Songs played on KMHD jazz radio in %d, merged from the monthly playlists.", year)
+		yearly, err := spotifyService.CreatePlaylist(yearlyName, description, false)
+		if err != nil {
+			return archiveResult{}, fmt.Errorf("failed to create playlist '%s': %w", yearlyName, err)
+		}
+		result.Yearly = *yearly
+		result.Created = true
+	}
+	if len(add) > 0 {
+		if err := spotifyService.AddTracksToPlaylist(result.Yearly.ID, add); err != nil {
+			return result, fmt.Errorf("failed to add tracks to playlist %s: %w", yearlyName, err)
+		}
+	}
+	if result.Created {
+		setNewPlaylistCover(spotifyService, result.Yearly, add)
+	}
+
+	if deleteMonths {
+		for _, month := range result.Months {
+			if month.Kept {
+				log.WithFields(log.Fields{
+					"playlist": month.Playlist.Name,
+					"skipped":  month.Skipped,
+				}).Warn("Keeping monthly playlist with tracks that can't be merged")
+				continue
+			}
+			if err := spotifyService.DeletePlaylist(month.Playlist.ID); err != nil {
+				return result, fmt.Errorf("failed to delete playlist %s: %w", month.Playlist.Name, err)
+			}
+			result.Deleted++
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"playlist": yearlyName,
+		"months":   len(result.Months),
+		"added":    result.Added,
+		"skipped":  result.Skipped,
+		"deleted":  result.Deleted,
+	}).Info("Archived monthly playlists")
+	return result, nil
+}
+
+// writeArchiveResult prints the monthly playlists merged into a yearly playlist.
+func writeArchiveResult(out io.Writer, result archiveResult, dryRun bool) {
+	for _, month := range result.Months {
+		line := fmt.Sprintf("🗓️  %s: %s, %d new", month.Playlist.Name, pluralize(month.Tracks, "track"), month.Added)
+		if month.Skipped > 0 {
+			line += fmt.Sprintf(", %s skipped", pluralize(month.Skipped, "local file"))
+		}
+		fmt.Fprintln(out, line)
+	}
+
+	summary := fmt.Sprintf("%s from %s into %s", pluralize(result.Added, "track"), pluralize(len(result.Months), "monthly playlist"), result.Yearly.Name)
+	if result.Created {
+		summary += " (new playlist)"
+	}
+	if dryRun {
+		fmt.Fprintf(out, "🔎 Dry run: would merge %s\n", summary)
+	} else {
+		fmt.Fprintf(out, "✅ Merged %s\n", summary)
+	}
+	if result.Skipped > 0 {
+		fmt.Fprintf(out, "⚠️  Skipped %s, which can't be added to a playlist by the Spotify API\n", pluralize(result.Skipped, "local file"))
+	}
+	for _, month := range result.Months {
+		if month.Kept {
+			fmt.Fprintf(out, "📌 Kept %s in your library because of its local files\n", month.Playlist.Name)
+		}
+	}
+	if result.Deleted > 0 {
+		fmt.Fprintf(out, "🗑️  Removed %s from your library\n", pluralize(result.Deleted, "monthly playlist"))
+	}
+}
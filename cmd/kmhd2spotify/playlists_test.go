@@ -0,0 +1,287 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/history"
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+// MockPlaylistsSpotifyService serves tracks per playlist and records the playlists
+// changed
+type MockPlaylistsSpotifyService struct {
+	MockSpotifyServiceForSync
+	playlistTracks map[string][]types.Track
+	added          map[string][]string
+	created        []string
+	deleted        []string
+}
+
+func (m *MockPlaylistsSpotifyService) GetPlaylistTracks(playlistID string) ([]types.Track, error) {
+	return m.playlistTracks[playlistID], nil
+}
+
+func (m *MockPlaylistsSpotifyService) CreatePlaylist(name, description string, public bool) (*types.Playlist, error) {
+	m.created = append(m.created, name)
+	return m.MockSpotifyServiceForSync.CreatePlaylist(name, description, public)
+}
+
+func (m *MockPlaylistsSpotifyService) AddTracksToPlaylist(playlistID string, trackIDs []string) error {
+	if m.added == nil {
+		m.added = make(map[string][]string)
+	}
+	m.added[playlistID] = append(m.added[playlistID], trackIDs...)
+	return nil
+}
+
+func (m *MockPlaylistsSpotifyService) DeletePlaylist(playlistID string) error {
+	m.deleted = append(m.deleted, playlistID)
+	return nil
+}
+
+func TestNewPlaylistsCmd(t *testing.T) {
+	cmd := newPlaylistsCmd()
+	assert.Equal(t, "playlists", cmd.Use)
+	assert.NotNil(t, cmd.PersistentFlags().Lookup("profile"))
+
+	listCmd, _, err := cmd.Find([]string{"list"})
+	require.NoError(t, err)
+	assert.Equal(t, "list", listCmd.Use)
+	assert.NotNil(t, listCmd.Flags().Lookup("incoming"))
+	assert.NotNil(t, listCmd.Flags().Lookup("search"))
+	assert.Equal(t, "50", listCmd.Flags().Lookup("per-page").DefValue)
+	assert.Error(t, listCmd.Args(listCmd, []string{"extra"}))
+
+	for _, use := range []string{"show", "rename", "describe", "set-public", "cover", "archive"} {
+		sub, _, err := cmd.Find([]string{use})
+		require.NoError(t, err, use)
+		assert.Contains(t, sub.Use, use)
+	}
+
+	coverCmd, _, err := cmd.Find([]string{"cover"})
+	require.NoError(t, err)
+	assert.NotNil(t, coverCmd.Flags().Lookup("generate"))
+	assert.NoError(t, coverCmd.Args(coverCmd, []string{"KMHD-2025-10"}))
+
+	archiveCmd, _, err := cmd.Find([]string{"archive"})
+	require.NoError(t, err)
+	assert.NotNil(t, archiveCmd.Flags().Lookup("delete"))
+	assert.NotNil(t, archiveCmd.Flags().Lookup("dry-run"))
+}
+
+func TestListPlaylists(t *testing.T) {
+	mock := &MockSpotifyServiceForSync{playlists: []types.Playlist{
+		{ID: "inbox-jazz", Name: "Incoming: Jazz", IsIncoming: true},
+		{ID: "oct", Name: "KMHD-2025-10"},
+		{ID: "inbox-soul", Name: "Saturday Soul", Description: "#incoming", IsIncoming: true},
+		{ID: "standards", Name: "Jazz Standards"},
+	}}
+
+	tests := []struct {
+		name     string
+		incoming bool
+		search   string
+		expected []string
+	}{
+		{name: "all", expected: []string{"inbox-jazz", "oct", "inbox-soul", "standards"}},
+		{name: "search", search: "JAZZ", expected: []string{"inbox-jazz", "standards"}},
+		{name: "incoming", incoming: true, expected: []string{"inbox-jazz", "inbox-soul"}},
+		{name: "incoming search", incoming: true, search: "jazz", expected: []string{"inbox-jazz"}},
+		{name: "no match", incoming: true, search: "blues", expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			playlists, err := listPlaylists(mock, tt.incoming, tt.search)
+			require.NoError(t, err)
+
+			ids := make([]string, 0, len(playlists))
+			for _, playlist := range playlists {
+				ids = append(ids, playlist.ID)
+			}
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
+}
+
+func TestWritePlaylists(t *testing.T) {
+	var out bytes.Buffer
+	writePlaylists(&out, []types.Playlist{
+		{ID: "inbox-jazz", Name: "Incoming: Jazz", TrackCount: 1, IsIncoming: true},
+		{ID: "standards", Name: "Jazz Standards", TrackCount: 42, Public: true},
+	}, false, "jazz", 1, 50)
+
+	assert.Contains(t, out.String(), "2 playlists matching 'jazz'\n")
+	assert.Contains(t, out.String(), "📥 Incoming: Jazz (1 track, private) inbox-jazz")
+	assert.Contains(t, out.String(), "🎵 Jazz Standards (42 tracks, public) standards")
+	assert.NotContains(t, out.String(), "--page")
+
+	out.Reset()
+	writePlaylists(&out, nil, true, "", 1, 50)
+	assert.Equal(t, "📭 No incoming playlists\n", out.String())
+}
+
+func TestPagePlaylists(t *testing.T) {
+	playlists := []types.Playlist{{ID: "1"}, {ID: "2"}, {ID: "3"}, {ID: "4"}, {ID: "5"}}
+
+	page, pages := pagePlaylists(playlists, 1, 2)
+	assert.Equal(t, playlists[:2], page)
+	assert.Equal(t, 3, pages)
+
+	page, _ = pagePlaylists(playlists, 3, 2)
+	assert.Equal(t, playlists[4:], page)
+
+	page, _ = pagePlaylists(playlists, 4, 2)
+	assert.Empty(t, page)
+
+	page, pages = pagePlaylists(playlists, 1, 0)
+	assert.Equal(t, playlists, page)
+	assert.Equal(t, 1, pages)
+
+	var out bytes.Buffer
+	writePlaylists(&out, playlists, false, "", 2, 2)
+	assert.Contains(t, out.String(), "5 playlists, page 2 of 3\n")
+	assert.Contains(t, out.String(), "Run again with --page 3 for more.")
+}
+
+func TestWritePlaylistTracks(t *testing.T) {
+	playedAt := time.Date(2025, 10, 3, 21, 15, 0, 0, time.Local)
+	addedAt := time.Date(2025, 10, 4, 9, 0, 0, 0, time.Local)
+	waltz := types.Track{ID: "waltz", Name: "Waltz for Debby", Artists: []types.Artist{{Name: "Bill Evans"}}}
+	nardis := types.Track{ID: "nardis", Name: "Nardis", Artists: []types.Artist{{Name: "Bill Evans"}}}
+
+	plays := kmhdPlays([]history.Play{
+		{Song: types.Song{PlayedAt: playedAt.Add(-48 * time.Hour)}, Match: &history.Match{TrackID: "waltz"}},
+		{Song: types.Song{PlayedAt: playedAt}, Match: &history.Match{TrackID: "waltz"}},
+		{Song: types.Song{PlayedAt: playedAt}},
+	})
+	assert.Equal(t, trackPlays{Count: 2, LastPlayed: playedAt}, plays["waltz"])
+
+	var out bytes.Buffer
+	writePlaylistTracks(&out, types.Playlist{ID: "oct", Name: "KMHD-2025-10", Description: "October on KMHD"},
+		[]types.PlaylistItem{{Track: waltz, AddedAt: addedAt}, {Track: nardis}}, plays)
+
+	assert.Contains(t, out.String(), "📋 KMHD-2025-10 (2 tracks, private) oct\n   October on KMHD\n")
+	assert.Contains(t, out.String(), "  1. Bill Evans - Waltz for Debby, added 2025-10-04, 📻 2 plays, last 2025-10-03\n")
+	assert.Contains(t, out.String(), "  2. Bill Evans - Nardis, added unknown, not in play history\n")
+
+	out.Reset()
+	writePlaylistTracks(&out, types.Playlist{ID: "oct", Name: "KMHD-2025-10"}, []types.PlaylistItem{{Track: nardis}}, nil)
+	assert.NotContains(t, out.String(), "play history")
+}
+
+func TestReadCoverImage(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+		return path
+	}
+
+	jpeg := append([]byte{0xff, 0xd8, 0xff, 0xe0}, make([]byte, 1024)...)
+	image, err := readCoverImage(write("cover.jpg", jpeg))
+	require.NoError(t, err)
+	assert.Equal(t, jpeg, image)
+
+	_, err = readCoverImage(write("cover.png", []byte("\x89PNG\r\n\x1a\n")))
+	assert.ErrorContains(t, err, "is not a JPEG image")
+
+	_, err = readCoverImage(write("large.jpg", append([]byte{0xff, 0xd8, 0xff, 0xe0}, make([]byte, 200*1024)...)))
+	assert.ErrorContains(t, err, "Spotify accepts up to 256 KB")
+
+	_, err = readCoverImage(filepath.Join(dir, "missing.jpg"))
+	assert.ErrorContains(t, err, "failed to read cover image")
+}
+
+func TestArchivePlaylists(t *testing.T) {
+	now := time.Date(2025, 12, 10, 12, 0, 0, 0, time.UTC)
+	newMock := func() *MockPlaylistsSpotifyService {
+		return &MockPlaylistsSpotifyService{
+			MockSpotifyServiceForSync: MockSpotifyServiceForSync{playlists: []types.Playlist{
+				{ID: "feb", Name: "KMHD-2025-02"},
+				{ID: "jan", Name: "KMHD-2025-01"},
+				{ID: "dec", Name: "KMHD-2025-12"},
+				{ID: "last-dec", Name: "KMHD-2024-12"},
+				{ID: "inbox", Name: "Incoming: Jazz"},
+				{ID: "rolling", Name: "KMHD-2025-rolling"},
+			}},
+			playlistTracks: map[string][]types.Track{
+				"jan": {{ID: "waltz"}, {ID: "nardis"}},
+				"feb": {{ID: "nardis"}, {ID: "peace"}, {Name: "Home Recording"}},
+				"dec": {{ID: "blue-in-green"}},
+			},
+		}
+	}
+
+	t.Run("creates yearly playlist", func(t *testing.T) {
+		mock := newMock()
+
+		result, err := archivePlaylists(mock, "KMHD", 2025, now, false, false)
+		require.NoError(t, err)
+
+		assert.True(t, result.Created)
+		assert.Equal(t, []string{"KMHD-2025"}, mock.created)
+		assert.Equal(t, []string{"waltz", "nardis", "peace"}, mock.added["test-playlist-id"])
+		require.Len(t, result.Months, 2)
+		assert.Equal(t, "KMHD-2025-01", result.Months[0].Playlist.Name)
+		assert.Equal(t, 2, result.Months[0].Added)
+		assert.Equal(t, 3, result.Months[1].Tracks)
+		assert.Equal(t, 1, result.Months[1].Added)
+		assert.Equal(t, 1, result.Months[1].Skipped)
+		assert.False(t, result.Months[1].Kept)
+		assert.Empty(t, mock.deleted)
+	})
+
+	t.Run("existing yearly playlist and delete", func(t *testing.T) {
+		mock := newMock()
+		mock.playlists = append(mock.playlists, types.Playlist{ID: "year", Name: "KMHD-2025"})
+		mock.playlistTracks["year"] = []types.Track{{ID: "waltz"}}
+
+		result, err := archivePlaylists(mock, "KMHD", 2025, now, true, false)
+		require.NoError(t, err)
+
+		assert.False(t, result.Created)
+		assert.Empty(t, mock.created)
+		assert.Equal(t, []string{"nardis", "peace"}, mock.added["year"])
+		assert.Equal(t, []string{"jan"}, mock.deleted, "months with local files are kept")
+		assert.Equal(t, 1, result.Deleted)
+		assert.True(t, result.Months[1].Kept)
+
+		var out bytes.Buffer
+		writeArchiveResult(&out, result, false)
+		assert.Contains(t, out.String(), "🗓️  KMHD-2025-02: 3 tracks, 1 new, 1 local file skipped\n")
+		assert.Contains(t, out.String(), "✅ Merged 2 tracks from 2 monthly playlists into KMHD-2025\n")
+		assert.Contains(t, out.String(), "⚠️  Skipped 1 local file")
+		assert.Contains(t, out.String(), "📌 Kept KMHD-2025-02 in your library")
+		assert.Contains(t, out.String(), "Removed 1 monthly playlist from")
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		mock := newMock()
+
+		result, err := archivePlaylists(mock, "KMHD", 2025, now, true, true)
+		require.NoError(t, err)
+
+		assert.Empty(t, mock.created)
+		assert.Empty(t, mock.added)
+		assert.Empty(t, mock.deleted)
+		assert.Equal(t, 3, result.Added)
+
+		var out bytes.Buffer
+		writeArchiveResult(&out, result, true)
+		assert.Contains(t, out.String(), "🗓️  KMHD-2025-01: 2 tracks, 2 new\n")
+		assert.Contains(t, out.String(), "🔎 Dry run: would merge 3 tracks from 2 monthly playlists into KMHD-2025 (new playlist)\n")
+	})
+
+	t.Run("no monthly playlists", func(t *testing.T) {
+		_, err := archivePlaylists(newMock(), "KMHD", 2023, now, false, false)
+		assert.ErrorContains(t, err, "no finished monthly playlists of 2023 found")
+	})
+}
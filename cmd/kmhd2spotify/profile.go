@@ -0,0 +1,197 @@
+// Package cmd provides Spotify account profile support for kmhd2spotify.
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/kmhd2spotify/internal/duplicate"
+	"github.com/toozej/kmhd2spotify/internal/routing"
+	"github.com/toozej/kmhd2spotify/internal/spotify"
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+// syncTarget is a Spotify account and playlist that synced songs are added to.
+// The default account has an empty profile name. With routing rules, Router picks the
+// playlists for each song instead of Playlist.
+type syncTarget struct {
+	Profile        string
+	Service        types.SpotifyService
+	Playlist       types.Playlist
+	Artists        []string
+	ExcludeArtists []string
+	Prefix         string
+	Router         *routing.Router
+
+	// Duplicates decides whether a track is already in one of the target's playlists.
+	// If nil, only the playlist a track is added to is checked.
+	Duplicates *duplicate.DuplicateService
+
+	// playlists caches routed, rolling and chart playlists by name
+	playlists map[string]types.Playlist
+}
+
+// Accepts reports whether the song passes the target's artist filters.
+func (t syncTarget) Accepts(song types.Song) bool {
+	for _, artist := range t.ExcludeArtists {
+		if strings.EqualFold(strings.TrimSpace(artist), song.Artist) {
+			return false
+		}
+	}
+	if len(t.Artists) == 0 {
+		return true
+	}
+	for _, artist := range t.Artists {
+		if strings.EqualFold(strings.TrimSpace(artist), song.Artist) {
+			return true
+		}
+	}
+	return false
+}
+
+// duplicates returns the target's duplicate service, or one that only checks the
+// playlist a track is added to.
+func (t syncTarget) duplicates() *duplicate.DuplicateService {
+	if t.Duplicates != nil {
+		return t.Duplicates
+	}
+	return duplicate.NewDuplicateService(t.Service, log.StandardLogger())
+}
+
+// profileSuffix returns a suffix naming the profile for console output, or an empty
+// string for the default account so single-account output is unchanged.
+func profileSuffix(profile string) string {
+	if profile == "" {
+		return ""
+	}
+	return fmt.Sprintf(" [%s]", profile)
+}
+
+// profileFlagHint returns the --profile flag to include in suggested commands.
+func profileFlagHint(profile string) string {
+	if profile == "" {
+		return ""
+	}
+	return " --profile " + profile
+}
+
+// newProfileService creates the Spotify service for the named profile, or the default
+// account if the name is empty.
+func newProfileService(profile string) (*spotify.Service, error) {
+	spotifyConfig, err := conf.SpotifyConfigForProfile(profile)
+	if err != nil {
+		return nil, err
+	}
+	return spotify.NewService(spotifyConfig, log.StandardLogger()), nil
+}
+
+// newAccountTargets creates a target for every configured profile (or the default
+// account) and makes sure each is authenticated, without resolving a sync playlist.
+// It is used by commands that manage their own playlists.
+func newAccountTargets(waitForAuth bool) ([]syncTarget, error) {
+	var targets []syncTarget
+
+	for _, name := range conf.ProfileNames() {
+		profile, err := conf.Profile(name)
+		if err != nil {
+			return nil, err
+		}
+		spotifyConfig, err := conf.SpotifyConfigForProfile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		spotifyService := spotify.NewService(spotifyConfig, log.StandardLogger())
+		if err := ensureAuthenticated(spotifyService, name, waitForAuth); err != nil {
+			return nil, err
+		}
+
+		targets = append(targets, syncTarget{
+			Profile:        name,
+			Service:        spotifyService,
+			Artists:        profile.Artists,
+			ExcludeArtists: profile.ExcludeArtists,
+			Prefix:         spotifyConfig.PlaylistNamePrefix,
+			playlists:      make(map[string]types.Playlist),
+		})
+	}
+
+	return targets, nil
+}
+
+// newSyncTargets creates a sync target for every configured profile (or the default
+// account), making sure each is authenticated and resolving its monthly playlist.
+// Profiles whose playlist can't be resolved are skipped. With routing rules, playlists
+// are resolved per song instead.
+func newSyncTargets(waitForAuth bool) ([]syncTarget, error) {
+	router, err := newRouter()
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := newAccountTargets(waitForAuth)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []syncTarget
+
+	for _, target := range accounts {
+		if router != nil {
+			log.WithFields(log.Fields{
+				"profile": target.Profile,
+				"rules":   conf.Routing.RulesFile,
+			}).Info("Routing songs to playlists with routing rules")
+
+			target.Router = router
+			targets = append(targets, target)
+			continue
+		}
+
+		// Get or create target playlist for current month
+		targetPlaylist, err := getOrCreateMonthlyPlaylist(target.Service, target.Prefix)
+		if err != nil {
+			log.WithError(err).WithField("profile", target.Profile).Error("Failed to get or create monthly playlist")
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"profile":  target.Profile,
+			"playlist": targetPlaylist.Name,
+		}).Info("Using playlist as sync target")
+
+		target.Playlist = targetPlaylist
+		targets = append(targets, target)
+	}
+
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no Spotify playlist available to sync to")
+	}
+	applyDuplicatePolicy(targets)
+	return targets, nil
+}
+
+// ensureAuthenticated makes sure the profile's Spotify service is authenticated. If it
+// isn't, the callback flow is started when someone can complete it; otherwise an
+// error explains how to authenticate.
+func ensureAuthenticated(spotifyService types.SpotifyService, profile string, waitForAuth bool) error {
+	if spotifyService.IsAuthenticated() {
+		return nil
+	}
+
+	if !canWaitForAuth(waitForAuth, runningInContainer(), stdinIsTerminal()) {
+		hint := profileFlagHint(profile)
+		return fmt.Errorf("spotify is not authenticated%s. Run 'kmhd2spotify auth%s' (or 'kmhd2spotify auth import%s' with a blob from 'auth export') first, or pass --wait-for-auth to wait for browser authentication",
+			profileSuffix(profile), hint, hint)
+	}
+
+	log.WithField("profile", profile).Info("Spotify authentication required. Starting authentication flow...")
+	if err := authenticateSpotify(spotifyService); err != nil {
+		return fmt.Errorf("failed to authenticate with Spotify%s: %w", profileSuffix(profile), err)
+	}
+
+	log.WithField("profile", profile).Info("Spotify authentication completed successfully")
+	return nil
+}
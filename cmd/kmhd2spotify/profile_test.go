@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/toozej/kmhd2spotify/internal/search"
+	"github.com/toozej/kmhd2spotify/internal/types"
+	"github.com/toozej/kmhd2spotify/pkg/config"
+)
+
+func TestSyncTargetAccepts(t *testing.T) {
+	miles := types.Song{Artist: "Miles Davis", Title: "So What"}
+	kenny := types.Song{Artist: "Kenny G", Title: "Songbird"}
+
+	tests := []struct {
+		name       string
+		target     syncTarget
+		wantMiles  bool
+		wantKennyG bool
+	}{
+		{name: "no filters", target: syncTarget{}, wantMiles: true, wantKennyG: true},
+		{name: "artists", target: syncTarget{Artists: []string{" miles davis "}}, wantMiles: true},
+		{name: "exclude artists", target: syncTarget{ExcludeArtists: []string{"Kenny G"}}, wantMiles: true},
+		{name: "exclude wins", target: syncTarget{Artists: []string{"Kenny G"}, ExcludeArtists: []string{"kenny g"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantMiles, tt.target.Accepts(miles))
+			assert.Equal(t, tt.wantKennyG, tt.target.Accepts(kenny))
+		})
+	}
+}
+
+func TestProfileSuffixAndHint(t *testing.T) {
+	assert.Empty(t, profileSuffix(""))
+	assert.Equal(t, " [alice]", profileSuffix("alice"))
+	assert.Empty(t, profileFlagHint(""))
+	assert.Equal(t, " --profile alice", profileFlagHint("alice"))
+}
+
+func TestNewProfileService(t *testing.T) {
+	origConf := conf
+	defer func() { conf = origConf }()
+
+	conf = config.Config{
+		Spotify:  config.SpotifyConfig{ClientID: "test-id", TokenFilePath: t.TempDir() + "/token.json"},
+		Profiles: []config.ProfileConfig{{Name: "alice"}},
+	}
+
+	_, err := newProfileService("alice")
+	assert.NoError(t, err)
+
+	_, err = newProfileService("bob")
+	assert.Error(t, err)
+}
+
+func TestSyncSongsToSpotify_FansOutToProfiles(t *testing.T) {
+	tracks := []types.Track{{ID: "track1", Name: "So What", Album: types.Album{Name: "Kind of Blue"}}}
+	alice := &MockFailingAddSpotifyService{MockSpotifyServiceForSync: MockSpotifyServiceForSync{tracks: tracks}}
+	bob := &MockFailingAddSpotifyService{MockSpotifyServiceForSync: MockSpotifyServiceForSync{tracks: tracks}}
+	carol := &MockFailingAddSpotifyService{MockSpotifyServiceForSync: MockSpotifyServiceForSync{tracks: tracks}}
+
+	targets := []syncTarget{
+		{Profile: "alice", Service: alice, Playlist: types.Playlist{ID: "alice-playlist"}},
+		{Profile: "bob", Service: bob, Playlist: types.Playlist{ID: "bob-playlist"}, Artists: []string{"Miles Davis"}},
+		{Profile: "carol", Service: carol, Playlist: types.Playlist{ID: "carol-playlist"}, ExcludeArtists: []string{"Miles Davis"}},
+	}
+
+	logger := log.New()
+	logger.SetLevel(log.ErrorLevel)
+	searcher := search.NewFuzzySongSearcher(alice, logger)
+
+	songs := []types.Song{{Artist: "Miles Davis", Title: "So What", Album: "Kind of Blue"}}
+	syncSongsToSpotify(songs, searcher, targets, make(map[string]bool))
+
+	assert.Equal(t, []string{"track1"}, alice.addedIDs)
+	assert.Equal(t, []string{"track1"}, bob.addedIDs)
+	assert.Empty(t, carol.addedIDs, "excluded artist should not be added")
+}
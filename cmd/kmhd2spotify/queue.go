@@ -0,0 +1,302 @@
+// Package cmd provides the queue command implementation for kmhd2spotify.
+package cmd
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/kmhd2spotify/internal/queue"
+	"github.com/toozej/kmhd2spotify/internal/spotify"
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+// retryQueueFileName is the name of the retry queue file within the state directory.
+const retryQueueFileName = "retry_queue.json"
+
+// retryQueue holds failed Spotify operations for later retry.
+// It is nil when the queue could not be opened, in which case failures are only logged.
+var retryQueue *queue.Queue
+
+// newQueueCmd creates the queue command for inspecting and driving the retry queue.
+func newQueueCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "queue",
+		Short: "Inspect and manage the retry queue",
+		Long: `Inspect and manage the queue of failed Spotify operations.
+Songs that could not be added to Spotify during a sync are retried automatically
+with exponential backoff. Operations that exhaust their attempts are moved to a
+dead-letter list, where they can be retried manually or dropped.`,
+	}
+
+	retryCmd := &cobra.Command{
+		Use:   "retry [id...]",
+		Short: "Retry queued operations now",
+		Long: `Retry queued operations immediately. With IDs, the given pending or dead-letter
+items are retried. With --all, every pending and dead-letter item is retried.
+Without arguments, only pending items that are already due are retried.`,
+		Run: runQueueRetry,
+	}
+	retryCmd.Flags().Bool("all", false, "Retry every pending and dead-letter item")
+
+	dropCmd := &cobra.Command{
+		Use:   "drop [id...]",
+		Short: "Drop queued operations",
+		Long:  `Remove the given pending or dead-letter items from the queue. With --dead-letter, the whole dead-letter list is cleared.`,
+		Run:   runQueueDrop,
+	}
+	dropCmd.Flags().Bool("dead-letter", false, "Drop every item in the dead-letter list")
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "list",
+			Short: "List pending and dead-letter operations",
+			Args:  cobra.NoArgs,
+			Run:   runQueueList,
+		},
+		retryCmd,
+		dropCmd,
+	)
+
+	return cmd
+}
+
+// runQueueList executes the queue list command.
+func runQueueList(cmd *cobra.Command, args []string) {
+	q, err := openRetryQueue()
+	if err != nil {
+		log.WithError(err).Fatal("Failed to open retry queue")
+		return
+	}
+
+	displayQueueItems("⏳ Pending", q.Pending())
+	displayQueueItems("💀 Dead-letter", q.DeadLetter())
+}
+
+// runQueueRetry executes the queue retry command.
+func runQueueRetry(cmd *cobra.Command, args []string) {
+	all, _ := cmd.Flags().GetBool("all")
+
+	q, err := openRetryQueue()
+	if err != nil {
+		log.WithError(err).Fatal("Failed to open retry queue")
+		return
+	}
+
+	if all {
+		if err := q.RequeueAll(); err != nil {
+			log.WithError(err).Fatal("Failed to requeue items")
+			return
+		}
+	}
+	for _, id := range args {
+		if err := q.Requeue(id); err != nil {
+			log.WithError(err).WithField("item_id", id).Error("Failed to requeue item")
+		}
+	}
+
+	due := q.Due()
+	if len(due) == 0 {
+		fmt.Println("Nothing to retry.")
+		return
+	}
+
+	// Retry each profile's items with that profile's account
+	var profiles []string
+	seen := make(map[string]bool)
+	for _, item := range due {
+		if !seen[item.Profile] {
+			seen[item.Profile] = true
+			profiles = append(profiles, item.Profile)
+		}
+	}
+
+	retryQueue = q
+	var targets []syncTarget
+	for _, profile := range profiles {
+		target, err := newRetryTarget(profile)
+		if err != nil {
+			log.WithError(err).WithField("profile", profile).Error("Failed to retry queued items")
+			continue
+		}
+		targets = append(targets, target)
+	}
+
+	// Retries follow the same duplicate policy as a sync
+	applyDuplicatePolicy(targets)
+	for _, target := range targets {
+		processRetryQueue(target)
+	}
+}
+
+// newRetryTarget creates the sync target of a profile whose queued items are retried
+// outside a sync. The profile must already be authenticated.
+func newRetryTarget(profile string) (syncTarget, error) {
+	spotifyConfig, err := conf.SpotifyConfigForProfile(profile)
+	if err != nil {
+		return syncTarget{}, err
+	}
+	spotifyService := spotify.NewService(spotifyConfig, log.StandardLogger())
+	if !spotifyService.IsAuthenticated() {
+		return syncTarget{}, fmt.Errorf("spotify is not authenticated%s. Run 'kmhd2spotify auth%s' first", profileSuffix(profile), profileFlagHint(profile))
+	}
+	return syncTarget{
+		Profile:   profile,
+		Service:   spotifyService,
+		Prefix:    spotifyConfig.PlaylistNamePrefix,
+		playlists: make(map[string]types.Playlist),
+	}, nil
+}
+
+// runQueueDrop executes the queue drop command.
+func runQueueDrop(cmd *cobra.Command, args []string) {
+	deadLetter, _ := cmd.Flags().GetBool("dead-letter")
+
+	if !deadLetter && len(args) == 0 {
+		log.Error("Provide one or more item IDs or --dead-letter")
+		return
+	}
+
+	q, err := openRetryQueue()
+	if err != nil {
+		log.WithError(err).Fatal("Failed to open retry queue")
+		return
+	}
+
+	if deadLetter {
+		count, err := q.DropDeadLetter()
+		if err != nil {
+			log.WithError(err).Error("Failed to clear dead-letter list")
+			return
+		}
+		fmt.Printf("🗑️  Dropped %d dead-letter item(s)\n", count)
+	}
+
+	for _, id := range args {
+		if err := q.Drop(id); err != nil {
+			log.WithError(err).WithField("item_id", id).Error("Failed to drop item")
+			continue
+		}
+		fmt.Printf("🗑️  Dropped %s\n", id)
+	}
+}
+
+// openRetryQueue opens the retry queue file in the configured state directory.
+func openRetryQueue() (*queue.Queue, error) {
+	path, err := conf.State.GetFilePath(retryQueueFileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve retry queue path: %w", err)
+	}
+	return queue.New(path, queue.PolicyFromConfig(conf.Queue))
+}
+
+// enqueueFailedAdd records a track that could not be added to the target playlist.
+func enqueueFailedAdd(song types.Song, track *types.Track, targetPlaylist types.Playlist, profile string, cause error) {
+	if retryQueue == nil || track == nil {
+		return
+	}
+
+	item, err := retryQueue.Enqueue(queue.Item{
+		Operation:    queue.OperationAddTrack,
+		Song:         song,
+		TrackID:      track.ID,
+		TrackName:    track.Name,
+		Track:        track,
+		PlaylistID:   targetPlaylist.ID,
+		PlaylistName: targetPlaylist.Name,
+		Profile:      profile,
+	}, cause)
+	if err != nil {
+		log.WithError(err).WithField("kmhd_song", song.String()).Warn("Failed to queue song for retry")
+		return
+	}
+
+	fmt.Printf("   🔁 Queued for retry (%s) at %s\n", item.ID, item.NextAttempt.Format("2006-01-02 15:04:05"))
+}
+
+// processRetryQueue retries every due item of the target's profile in the retry queue.
+func processRetryQueue(target syncTarget) {
+	if retryQueue == nil {
+		return
+	}
+
+	var due []queue.Item
+	for _, item := range retryQueue.Due() {
+		if item.Profile == target.Profile {
+			due = append(due, item)
+		}
+	}
+	if len(due) == 0 {
+		return
+	}
+
+	log.WithFields(log.Fields{"due_count": len(due), "profile": target.Profile}).Info("Retrying queued operations")
+
+	for _, item := range due {
+		fmt.Printf("🔁 Retrying: %s → %s%s\n", item.Song.String(), item.PlaylistName, profileSuffix(item.Profile))
+
+		added, err := retryAddTrack(target, item)
+		if err != nil {
+			updated, failErr := retryQueue.Fail(item.ID, err)
+			if failErr != nil {
+				log.WithError(failErr).WithField("item_id", item.ID).Warn("Failed to update retry queue")
+				continue
+			}
+			if updated.NextAttempt.IsZero() {
+				fmt.Printf("   💀 Giving up after %d attempts: %s\n", updated.Attempts, err.Error())
+			} else {
+				fmt.Printf("   ❌ Retry failed, next attempt at %s: %s\n", updated.NextAttempt.Format("2006-01-02 15:04:05"), err.Error())
+			}
+			continue
+		}
+
+		if err := retryQueue.Succeed(item.ID); err != nil {
+			log.WithError(err).WithField("item_id", item.ID).Warn("Failed to update retry queue")
+		}
+		if added {
+			fmt.Printf("   ✅ Added to playlist: %s\n", item.TrackName)
+		} else {
+			fmt.Printf("   ⏭️  Track already added: %s\n", item.TrackName)
+		}
+	}
+}
+
+// retryAddTrack adds a queued track to its playlist through the same duplicate checks
+// as a sync. It reports whether the track was added, rather than found already added.
+func retryAddTrack(target syncTarget, item queue.Item) (bool, error) {
+	track := types.Track{ID: item.TrackID, Name: item.TrackName}
+	if item.Track != nil {
+		track = *item.Track
+	}
+	playlist := types.Playlist{ID: item.PlaylistID, Name: item.PlaylistName}
+
+	existing, err := addTrack(item.Song, &track, target, playlist)
+	if err != nil {
+		return false, err
+	}
+	if existing != nil {
+		log.WithFields(log.Fields{
+			"item_id": item.ID,
+			"reason":  existing.Message,
+		}).Debug("Queued track already added")
+		return false, nil
+	}
+	return true, nil
+}
+
+// displayQueueItems displays queue items in a formatted way
+func displayQueueItems(title string, items []queue.Item) {
+	fmt.Printf("\n%s (%d):\n", title, len(items))
+	for _, item := range items {
+		fmt.Printf("  [%s] %s → %s%s\n", item.ID, item.Song.String(), item.PlaylistName, profileSuffix(item.Profile))
+		fmt.Printf("        attempts: %d", item.Attempts)
+		if !item.NextAttempt.IsZero() {
+			fmt.Printf(", next attempt: %s", item.NextAttempt.Format("2006-01-02 15:04:05"))
+		}
+		fmt.Println()
+		if item.LastError != "" {
+			fmt.Printf("        last error: %s\n", item.LastError)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/queue"
+	"github.com/toozej/kmhd2spotify/internal/types"
+	"github.com/toozej/kmhd2spotify/pkg/config"
+)
+
+func TestNewQueueCmd(t *testing.T) {
+	cmd := newQueueCmd()
+
+	assert.Equal(t, "queue", cmd.Use)
+
+	names := make([]string, 0)
+	for _, sub := range cmd.Commands() {
+		names = append(names, sub.Name())
+	}
+	assert.ElementsMatch(t, []string{"list", "retry", "drop"}, names)
+}
+
+// MockFailingAddSpotifyService fails to add tracks until told otherwise
+type MockFailingAddSpotifyService struct {
+	MockSpotifyServiceForSync
+	addErr     error
+	addedIDs   []string
+	inPlaylist bool
+}
+
+func (m *MockFailingAddSpotifyService) AddTracksToPlaylist(playlistID string, trackIDs []string) error {
+	if m.addErr != nil {
+		return m.addErr
+	}
+	m.addedIDs = append(m.addedIDs, trackIDs...)
+	return nil
+}
+
+func (m *MockFailingAddSpotifyService) CheckTracksInPlaylist(playlistID string, trackIDs []string) ([]bool, error) {
+	return []bool{m.inPlaylist}, nil
+}
+
+func TestFailedAddIsQueuedAndRetried(t *testing.T) {
+	q, err := queue.New(filepath.Join(t.TempDir(), retryQueueFileName), queue.Policy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Minute,
+		MaxDelay:    time.Hour,
+	})
+	require.NoError(t, err)
+
+	originalQueue := retryQueue
+	retryQueue = q
+	defer func() { retryQueue = originalQueue }()
+
+	song := types.Song{Artist: "Miles Davis", Title: "So What"}
+	track := &types.Track{ID: "track1", Name: "So What"}
+	playlist := types.Playlist{ID: "playlist1", Name: "KMHD-2025-10"}
+
+	enqueueFailedAdd(song, track, playlist, "", errors.New("503 service unavailable"))
+	require.Len(t, q.Pending(), 1)
+
+	// Make the item due and retry with a service that still fails
+	require.NoError(t, q.RequeueAll())
+	mockSpotify := &MockFailingAddSpotifyService{addErr: errors.New("still down")}
+	processRetryQueue(syncTarget{Service: mockSpotify})
+
+	pending := q.Pending()
+	require.Len(t, pending, 1)
+	assert.Equal(t, 2, pending[0].Attempts)
+	assert.Equal(t, "still down", pending[0].LastError)
+
+	// Retry again once Spotify recovers
+	require.NoError(t, q.RequeueAll())
+	mockSpotify.addErr = nil
+	processRetryQueue(syncTarget{Service: mockSpotify})
+
+	assert.Empty(t, q.Pending())
+	assert.Equal(t, []string{"track1"}, mockSpotify.addedIDs)
+}
+
+func TestProcessRetryQueueSkipsTracksAlreadyInPlaylist(t *testing.T) {
+	q, err := queue.New(filepath.Join(t.TempDir(), retryQueueFileName), queue.Policy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Minute,
+		MaxDelay:    time.Hour,
+	})
+	require.NoError(t, err)
+
+	originalQueue := retryQueue
+	retryQueue = q
+	defer func() { retryQueue = originalQueue }()
+
+	enqueueFailedAdd(types.Song{Artist: "A", Title: "B"}, &types.Track{ID: "track1"}, types.Playlist{ID: "p"}, "", errors.New("boom"))
+	require.NoError(t, q.RequeueAll())
+
+	mockSpotify := &MockFailingAddSpotifyService{inPlaylist: true}
+	processRetryQueue(syncTarget{Service: mockSpotify})
+
+	assert.Empty(t, q.Pending())
+	assert.Empty(t, mockSpotify.addedIDs)
+}
+
+func TestProcessRetryQueueWithoutQueue(t *testing.T) {
+	originalQueue := retryQueue
+	retryQueue = nil
+	defer func() { retryQueue = originalQueue }()
+
+	assert.NotPanics(t, func() {
+		processRetryQueue(syncTarget{Service: &MockSpotifyServiceForSync{}})
+		enqueueFailedAdd(types.Song{}, &types.Track{}, types.Playlist{}, "", errors.New("boom"))
+	})
+}
+
+func TestProcessRetryQueueOnlyRetriesProfileItems(t *testing.T) {
+	q, err := queue.New(filepath.Join(t.TempDir(), retryQueueFileName), queue.Policy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Minute,
+		MaxDelay:    time.Hour,
+	})
+	require.NoError(t, err)
+
+	originalQueue := retryQueue
+	retryQueue = q
+	defer func() { retryQueue = originalQueue }()
+
+	song := types.Song{Artist: "Miles Davis", Title: "So What"}
+	enqueueFailedAdd(song, &types.Track{ID: "track1"}, types.Playlist{ID: "alice-playlist"}, "alice", errors.New("boom"))
+	enqueueFailedAdd(song, &types.Track{ID: "track2"}, types.Playlist{ID: "bob-playlist"}, "bob", errors.New("boom"))
+	require.NoError(t, q.RequeueAll())
+
+	aliceSpotify := &MockFailingAddSpotifyService{}
+	processRetryQueue(syncTarget{Profile: "alice", Service: aliceSpotify})
+
+	assert.Equal(t, []string{"track1"}, aliceSpotify.addedIDs)
+	pending := q.Pending()
+	require.Len(t, pending, 1)
+	assert.Equal(t, "bob", pending[0].Profile)
+}
+
+func TestProcessRetryQueueFollowsDuplicatePolicy(t *testing.T) {
+	originalConf, originalQueue := conf, retryQueue
+	defer func() { conf, retryQueue = originalConf, originalQueue }()
+
+	conf = config.Config{
+		State:     config.StateConfig{Dir: t.TempDir()},
+		Duplicate: config.DuplicateConfig{Policy: config.DuplicatePolicyPrefix},
+	}
+	q, err := queue.New(filepath.Join(t.TempDir(), retryQueueFileName), queue.Policy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Minute,
+		MaxDelay:    time.Hour,
+	})
+	require.NoError(t, err)
+	retryQueue = q
+
+	september := types.Playlist{ID: "sep", Name: "KMHD-2025-09"}
+	october := types.Playlist{ID: "oct", Name: "KMHD-2025-10"}
+	november := types.Playlist{ID: "nov", Name: "KMHD-2025-11"}
+	naima := &types.Track{ID: "naima", Name: "Naima"}
+	soWhat := &types.Track{ID: "so-what", Name: "So What"}
+	mock := &MockMonthlySpotifyService{
+		MockSpotifyServiceForSync: MockSpotifyServiceForSync{playlists: []types.Playlist{september, october}},
+		items: map[string][]types.PlaylistItem{
+			"sep": {{Track: *naima, AddedAt: time.Now().AddDate(0, -1, 0)}},
+		},
+	}
+	targets := []syncTarget{{Service: mock, Prefix: "KMHD"}}
+	applyDuplicatePolicy(targets)
+
+	enqueueFailedAdd(types.Song{Artist: "John Coltrane", Title: "Naima"}, naima, october, "", errors.New("boom"))
+	enqueueFailedAdd(types.Song{Artist: "Miles Davis", Title: "So What"}, soWhat, october, "", errors.New("boom"))
+	require.NoError(t, q.RequeueAll())
+
+	processRetryQueue(targets[0])
+
+	assert.Empty(t, q.Pending())
+	assert.Equal(t, []string{"so-what"}, mock.addedIDs, "tracks added in another month are not retried")
+	existing, err := targets[0].Duplicates.CheckTrack(november, *soWhat)
+	require.NoError(t, err)
+	assert.True(t, existing.HasDuplicates, "retried adds are recorded for later duplicate checks")
+}
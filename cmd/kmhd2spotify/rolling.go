@@ -0,0 +1,77 @@
+// Package cmd provides the rolling playlist of recent KMHD plays for kmhd2spotify.
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/kmhd2spotify/internal/history"
+)
+
+// updateRollingPlaylists brings every target's rolling playlist in line with the plays
+// of the last ROLLING_DAYS days recorded in the play history.
+func updateRollingPlaylists(targets []syncTarget, now time.Time) {
+	if conf.Rolling.PlaylistName == "" || playHistory == nil {
+		return
+	}
+
+	plays := playHistory.Between(now.Add(-conf.Rolling.Window()), time.Time{})
+	description := fmt.Sprintf("Songs played on KMHD jazz radio in the last %d days, most recent last.", conf.Rolling.Days)
+
+	for _, target := range targets {
+		playlist, err := getOrCreateTargetPlaylist(target, conf.Rolling.PlaylistName, description)
+		if err != nil {
+			log.WithError(err).WithField("profile", target.Profile).Warn("Failed to get or create rolling playlist")
+			continue
+		}
+
+		added, removed, err := syncPlaylistTracks(target.Service, playlist.ID, rollingTrackIDs(plays, target))
+		if err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"profile":  target.Profile,
+				"playlist": playlist.Name,
+			}).Warn("Failed to update rolling playlist")
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"profile":  target.Profile,
+			"playlist": playlist.Name,
+			"added":    added,
+			"removed":  removed,
+		}).Debug("Updated rolling playlist")
+		if added > 0 || removed > 0 {
+			fmt.Printf("🔁 Rolling playlist %s%s: %d added, %d removed\n", playlist.Name, profileSuffix(target.Profile), added, removed)
+		}
+	}
+}
+
+// rollingTrackIDs returns the matched tracks of the plays accepted by the target,
+// ordered by when each was last played.
+func rollingTrackIDs(plays []history.Play, target syncTarget) []string {
+	lastPlayed := make(map[string]time.Time)
+	for _, play := range plays {
+		if play.Match == nil || play.Match.TrackID == "" || !target.Accepts(play.Song) {
+			continue
+		}
+		if playedAt, ok := lastPlayed[play.Match.TrackID]; !ok || play.Song.PlayedAt.After(playedAt) {
+			lastPlayed[play.Match.TrackID] = play.Song.PlayedAt
+		}
+	}
+
+	trackIDs := make([]string, 0, len(lastPlayed))
+	for trackID := range lastPlayed {
+		trackIDs = append(trackIDs, trackID)
+	}
+	sort.Slice(trackIDs, func(i, j int) bool {
+		a, b := lastPlayed[trackIDs[i]], lastPlayed[trackIDs[j]]
+		if a.Equal(b) {
+			return trackIDs[i] < trackIDs[j]
+		}
+		return a.Before(b)
+	})
+	return trackIDs
+}
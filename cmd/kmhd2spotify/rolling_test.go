@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/history"
+	"github.com/toozej/kmhd2spotify/internal/types"
+	"github.com/toozej/kmhd2spotify/pkg/config"
+)
+
+// MockRollingSpotifyService keeps the contents of a single playlist and applies
+// additions, removals and reorders to it like Spotify does
+type MockRollingSpotifyService struct {
+	MockSpotifyServiceForSync
+	contents []string
+	reorders int
+}
+
+func (m *MockRollingSpotifyService) GetPlaylistTracks(playlistID string) ([]types.Track, error) {
+	tracks := make([]types.Track, 0, len(m.contents))
+	for _, trackID := range m.contents {
+		tracks = append(tracks, types.Track{ID: trackID})
+	}
+	return tracks, nil
+}
+
+func (m *MockRollingSpotifyService) GetPlaylistItems(playlistID string) ([]types.PlaylistItem, error) {
+	items := make([]types.PlaylistItem, 0, len(m.contents))
+	for _, trackID := range m.contents {
+		items = append(items, types.PlaylistItem{Track: types.Track{ID: trackID}})
+	}
+	return items, nil
+}
+
+func (m *MockRollingSpotifyService) AddTracksToPlaylist(playlistID string, trackIDs []string) error {
+	m.contents = append(m.contents, trackIDs...)
+	return nil
+}
+
+func (m *MockRollingSpotifyService) RemoveTracksFromPlaylist(playlistID string, trackIDs []string) error {
+	m.contents = slices.DeleteFunc(m.contents, func(trackID string) bool {
+		return slices.Contains(trackIDs, trackID)
+	})
+	return nil
+}
+
+func (m *MockRollingSpotifyService) RemoveTrackPositions(playlistID string, positions map[string][]int) error {
+	removed := make(map[int]bool)
+	for _, trackPositions := range positions {
+		for _, position := range trackPositions {
+			removed[position] = true
+		}
+	}
+	kept := m.contents[:0]
+	for i, trackID := range m.contents {
+		if !removed[i] {
+			kept = append(kept, trackID)
+		}
+	}
+	m.contents = kept
+	return nil
+}
+
+func (m *MockRollingSpotifyService) ReorderPlaylist(playlistID string, rangeStart, rangeLength, insertBefore int) error {
+	m.reorders++
+	moved := slices.Clone(m.contents[rangeStart : rangeStart+rangeLength])
+	rest := slices.Delete(slices.Clone(m.contents), rangeStart, rangeStart+rangeLength)
+	if insertBefore > rangeStart {
+		insertBefore -= rangeLength
+	}
+	m.contents = slices.Insert(rest, insertBefore, moved...)
+	return nil
+}
+
+func matchedPlay(artist, trackID string, playedAt time.Time) history.Play {
+	return history.Play{
+		Song:  types.Song{Artist: artist, Title: trackID, PlayedAt: playedAt},
+		Match: &history.Match{TrackID: trackID},
+	}
+}
+
+func TestRollingTrackIDsOrdersByLastPlay(t *testing.T) {
+	now := time.Date(2025, time.October, 17, 12, 0, 0, 0, time.UTC)
+	plays := []history.Play{
+		matchedPlay("Miles Davis", "a", now.Add(-3*time.Hour)),
+		matchedPlay("John Coltrane", "b", now.Add(-2*time.Hour)),
+		matchedPlay("Miles Davis", "a", now.Add(-time.Hour)),
+		{Song: types.Song{Artist: "Unmatched", Title: "x", PlayedAt: now}},
+		matchedPlay("Kenny G", "c", now),
+	}
+	target := syncTarget{ExcludeArtists: []string{"Kenny G"}}
+
+	assert.Equal(t, []string{"b", "a"}, rollingTrackIDs(plays, target))
+}
+
+func TestSyncPlaylistTracks(t *testing.T) {
+	svc := &MockRollingSpotifyService{contents: []string{"old", "a", "b", "a", "c"}}
+
+	added, removed, err := syncPlaylistTracks(svc, "rolling", []string{"b", "c", "a", "d"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "c", "a", "d"}, svc.contents)
+	assert.Equal(t, 2, added, "the duplicated track is re-added along with the new one")
+	assert.Equal(t, 2, removed)
+
+	// Nothing changes once the playlist is in the wanted order
+	reorders := svc.reorders
+	added, removed, err = syncPlaylistTracks(svc, "rolling", []string{"b", "c", "a", "d"})
+	require.NoError(t, err)
+	assert.Zero(t, added)
+	assert.Zero(t, removed)
+	assert.Equal(t, reorders, svc.reorders)
+
+	// A replayed track moves to the end
+	_, _, err = syncPlaylistTracks(svc, "rolling", []string{"c", "a", "d", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "a", "d", "b"}, svc.contents)
+}
+
+func TestUpdateRollingPlaylistsPrunesOldPlays(t *testing.T) {
+	now := time.Now()
+	store, err := history.Open(filepath.Join(t.TempDir(), history.FileName))
+	require.NoError(t, err)
+
+	old := types.Song{Artist: "Miles Davis", Title: "So What", PlayedAt: now.Add(-10 * 24 * time.Hour)}
+	recent := types.Song{Artist: "John Coltrane", Title: "Giant Steps", PlayedAt: now.Add(-time.Hour)}
+	_, err = store.Record([]types.Song{old, recent})
+	require.NoError(t, err)
+	require.NoError(t, store.SetMatch(old, types.Track{ID: "old"}, 0.9))
+	require.NoError(t, store.SetMatch(recent, types.Track{ID: "recent"}, 0.9))
+
+	originalConf, originalHistory := conf, playHistory
+	conf = config.Config{Rolling: config.RollingConfig{PlaylistName: "KMHD Last 7 Days", Days: 7}}
+	playHistory = store
+	defer func() { conf, playHistory = originalConf, originalHistory }()
+
+	svc := &MockRollingSpotifyService{
+		MockSpotifyServiceForSync: MockSpotifyServiceForSync{
+			playlists: []types.Playlist{{ID: "rolling", Name: "KMHD Last 7 Days"}},
+		},
+		contents: []string{"old"},
+	}
+	updateRollingPlaylists([]syncTarget{{Service: svc, playlists: make(map[string]types.Playlist)}}, now)
+
+	assert.Equal(t, []string{"recent"}, svc.contents)
+}
+
+func TestUpdateRollingPlaylistsDisabled(t *testing.T) {
+	originalConf, originalHistory := conf, playHistory
+	conf = config.Config{}
+	playHistory = nil
+	defer func() { conf, playHistory = originalConf, originalHistory }()
+
+	svc := &MockRollingSpotifyService{contents: []string{"a"}}
+	updateRollingPlaylists([]syncTarget{{Service: svc}}, time.Now())
+	assert.Equal(t, []string{"a"}, svc.contents)
+}
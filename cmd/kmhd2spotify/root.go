@@ -26,6 +26,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
+	"github.com/toozej/kmhd2spotify/internal/notify"
 	"github.com/toozej/kmhd2spotify/pkg/config"
 	"github.com/toozej/kmhd2spotify/pkg/man"
 	"github.com/toozej/kmhd2spotify/pkg/version"
@@ -71,7 +72,8 @@ func rootCmdRun(cmd *cobra.Command, args []string) {
 // This function is called before both the root command and any subcommands.
 //
 // It configures the logging level based on the debug flag. When debug mode
-// is enabled, logrus is set to DebugLevel for detailed logging output.
+// is enabled, logrus is set to DebugLevel for detailed logging output. It also
+// creates the notifier for the sinks enabled in the configuration.
 //
 // Parameters:
 //   - cmd: The cobra command being executed
@@ -82,6 +84,11 @@ func rootCmdPreRun(cmd *cobra.Command, args []string) {
 	if debug {
 		log.SetLevel(log.DebugLevel)
 	}
+
+	notifier = notify.New(conf.Notify)
+	if sinks := notifier.Sinks(); len(sinks) > 0 {
+		log.WithField("sinks", sinks).Debug("Notification sinks enabled")
+	}
 }
 
 // Execute starts the command-line interface execution.
@@ -109,7 +116,7 @@ func Execute() {
 //   - Loads configuration from environment variables using config.GetEnvVars()
 //   - Defines persistent flags that are available to all commands
 //   - Sets up command-specific flags for the root command
-//   - Registers subcommands (sync, search, man pages, and version information)
+//   - Registers subcommands (sync, auth, search, queue, now, healthcheck, man pages, and version information)
 //
 // The debug flag (-d, --debug) enables debug-level logging and is persistent,
 // meaning it's inherited by all subcommands. The username flag (-u, --username)
@@ -121,7 +128,19 @@ func init() {
 	// add sub-commands
 	rootCmd.AddCommand(
 		newSyncCmd(),
+		newAuthCmd(),
 		newSearchCmd(),
+		newPlaylistsCmd(),
+		newAddArtistCmd(),
+		newQueueCmd(),
+		newNowCmd(),
+		newChartCmd(),
+		newStatsCmd(),
+		newExportCmd(),
+		newImportCmd(),
+		newDedupeCmd(),
+		newScrobbleCmd(),
+		newHealthcheckCmd(),
 		man.NewManCmd(),
 		version.Command(),
 	)
@@ -0,0 +1,164 @@
+// Package cmd provides playlist routing support for kmhd2spotify.
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/kmhd2spotify/internal/routing"
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+// newRouter creates the playlist router from the routing rules in ROUTING_RULES_FILE,
+// or returns nil if none are configured and songs go to the monthly playlist.
+func newRouter() (*routing.Router, error) {
+	if conf.Routing.Rules == nil {
+		return nil, nil
+	}
+	router, err := routing.New(conf.Routing.Rules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load routing rules from %s: %w", conf.Routing.RulesFile, err)
+	}
+	return router, nil
+}
+
+// Routes returns the playlists the song is routed to. Without routing rules every
+// song goes to the target's monthly playlist.
+func (t syncTarget) Routes(song types.Song) []routing.Route {
+	if t.Router == nil {
+		return []routing.Route{{Name: "monthly", Playlist: t.Playlist.Name}}
+	}
+	return t.Router.Route(song, t.Profile, t.Prefix)
+}
+
+// resolveRoutePlaylist returns the target's playlist for a route, finding or creating
+// it by name.
+func resolveRoutePlaylist(target syncTarget, route routing.Route) (types.Playlist, error) {
+	if target.Router == nil {
+		return target.Playlist, nil
+	}
+	return getOrCreateTargetPlaylist(target, route.Playlist, fmt.Sprintf("KMHD jazz radio songs routed by '%s'.", route.Name))
+}
+
+// getOrCreateTargetPlaylist finds or creates the named playlist in the target's account,
+// caching it on the target so each name is only looked up once.
+func getOrCreateTargetPlaylist(target syncTarget, name, description string) (types.Playlist, error) {
+	if playlist, ok := target.playlists[name]; ok {
+		return playlist, nil
+	}
+
+	playlist, err := getOrCreatePlaylist(target.Service, name, description)
+	if err != nil {
+		return types.Playlist{}, err
+	}
+	if target.playlists != nil {
+		target.playlists[name] = playlist
+	}
+	return playlist, nil
+}
+
+// getOrCreatePlaylist finds the user's playlist with the given name, creating it if it
+// doesn't exist.
+func getOrCreatePlaylist(spotifyService types.SpotifyService, name, description string) (types.Playlist, error) {
+	playlists, err := spotifyService.GetUserPlaylists("")
+	if err != nil {
+		return types.Playlist{}, fmt.Errorf("failed to get user playlists: %w", err)
+	}
+
+	for _, playlist := range playlists {
+		if playlist.Name == name {
+			log.WithFields(log.Fields{
+				"playlist_id":   playlist.ID,
+				"playlist_name": playlist.Name,
+			}).Debug("Found existing playlist")
+			return playlist, nil
+		}
+	}
+
+	newPlaylist, err := spotifyService.CreatePlaylist(name, description, false)
+	if err != nil {
+		return types.Playlist{}, fmt.Errorf("failed to create playlist '%s': %w", name, err)
+	}
+
+	log.WithFields(log.Fields{
+		"playlist_id":   newPlaylist.ID,
+		"playlist_name": newPlaylist.Name,
+	}).Info("Created playlist")
+	fmt.Printf("📁 Created playlist: %s\n", newPlaylist.Name)
+	setNewPlaylistCover(spotifyService, *newPlaylist, nil)
+
+	return *newPlaylist, nil
+}
+
+// syncPlaylistTracks makes the playlist hold exactly the wanted tracks in the wanted
+// order: tracks that are no longer wanted (or appear more than once) are removed, new
+// tracks are appended, and tracks that changed position are moved into place. It
+// returns how many tracks were added and removed.
+func syncPlaylistTracks(spotifyService types.SpotifyService, playlistID string, wanted []string) (int, int, error) {
+	current, err := spotifyService.GetPlaylistTracks(playlistID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get playlist tracks: %w", err)
+	}
+
+	wantedSet := make(map[string]bool, len(wanted))
+	for _, trackID := range wanted {
+		wantedSet[trackID] = true
+	}
+	occurrences := make(map[string]int, len(current))
+	for _, track := range current {
+		occurrences[track.ID]++
+	}
+
+	// Removing a track removes every occurrence, so duplicates are removed and re-added
+	var remove []string
+	for trackID, count := range occurrences {
+		if !wantedSet[trackID] || count > 1 {
+			remove = append(remove, trackID)
+		}
+	}
+	sort.Strings(remove)
+	if len(remove) > 0 {
+		if err := spotifyService.RemoveTracksFromPlaylist(playlistID, remove); err != nil {
+			return 0, 0, fmt.Errorf("failed to remove tracks from playlist: %w", err)
+		}
+	}
+
+	order := make([]string, 0, len(wanted))
+	for _, track := range current {
+		if !slices.Contains(remove, track.ID) {
+			order = append(order, track.ID)
+		}
+	}
+
+	var add []string
+	for _, trackID := range wanted {
+		if !slices.Contains(order, trackID) {
+			add = append(add, trackID)
+		}
+	}
+	for start := 0; start < len(add); start += 100 {
+		batch := add[start:min(start+100, len(add))]
+		if err := spotifyService.AddTracksToPlaylist(playlistID, batch); err != nil {
+			return 0, len(remove), fmt.Errorf("failed to add tracks to playlist: %w", err)
+		}
+		order = append(order, batch...)
+	}
+
+	// Every track before position i is already in place, so the wanted track is always
+	// found at or after i and moved up
+	for i, trackID := range wanted {
+		j := slices.Index(order, trackID)
+		if j <= i {
+			continue
+		}
+		if err := spotifyService.ReorderPlaylist(playlistID, j, 1, i); err != nil {
+			return len(add), len(remove), fmt.Errorf("failed to reorder playlist: %w", err)
+		}
+		order = slices.Insert(slices.Delete(order, j, j+1), i, trackID)
+	}
+
+	return len(add), len(remove), nil
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/routing"
+	"github.com/toozej/kmhd2spotify/internal/search"
+	"github.com/toozej/kmhd2spotify/internal/types"
+	"github.com/toozej/kmhd2spotify/pkg/config"
+)
+
+// MockRoutingSpotifyService records created playlists and the playlists tracks are added to
+type MockRoutingSpotifyService struct {
+	MockSpotifyServiceForSync
+	created []string
+	added   map[string][]string
+}
+
+func (m *MockRoutingSpotifyService) CreatePlaylist(name, description string, public bool) (*types.Playlist, error) {
+	m.created = append(m.created, name)
+	playlist := types.Playlist{ID: "id-" + name, Name: name}
+	m.playlists = append(m.playlists, playlist)
+	return &playlist, nil
+}
+
+func (m *MockRoutingSpotifyService) AddTracksToPlaylist(playlistID string, trackIDs []string) error {
+	if m.added == nil {
+		m.added = make(map[string][]string)
+	}
+	m.added[playlistID] = append(m.added[playlistID], trackIDs...)
+	return nil
+}
+
+func newTestRoutingTarget(t *testing.T, svc types.SpotifyService, rules config.RoutingRules) syncTarget {
+	t.Helper()
+	rules.Timezone = "UTC"
+	router, err := routing.New(&rules)
+	require.NoError(t, err)
+	return syncTarget{Service: svc, Prefix: "KMHD", Router: router, playlists: make(map[string]types.Playlist)}
+}
+
+func TestNewRouterWithoutRules(t *testing.T) {
+	originalConf := conf
+	conf = config.Config{}
+	defer func() { conf = originalConf }()
+
+	router, err := newRouter()
+	assert.NoError(t, err)
+	assert.Nil(t, router)
+}
+
+func TestRoutesWithoutRouterUseMonthlyPlaylist(t *testing.T) {
+	target := syncTarget{Playlist: types.Playlist{ID: "p1", Name: "KMHD-2025-10"}}
+
+	routes := target.Routes(types.Song{Artist: "Miles Davis"})
+	require.Len(t, routes, 1)
+	assert.Equal(t, "KMHD-2025-10", routes[0].Playlist)
+
+	playlist, err := resolveRoutePlaylist(target, routes[0])
+	require.NoError(t, err)
+	assert.Equal(t, "p1", playlist.ID)
+}
+
+func TestResolveRoutePlaylistFindsCreatesAndCaches(t *testing.T) {
+	mockSpotify := &MockRoutingSpotifyService{MockSpotifyServiceForSync: MockSpotifyServiceForSync{
+		playlists: []types.Playlist{{ID: "existing", Name: "Favourites"}},
+	}}
+	target := newTestRoutingTarget(t, mockSpotify, config.RoutingRules{Routes: []config.RouteRule{{Playlist: "x"}}})
+
+	playlist, err := resolveRoutePlaylist(target, routing.Route{Name: "favourites", Playlist: "Favourites"})
+	require.NoError(t, err)
+	assert.Equal(t, "existing", playlist.ID)
+
+	playlist, err = resolveRoutePlaylist(target, routing.Route{Name: "quarterly", Playlist: "KMHD 2025 Q4"})
+	require.NoError(t, err)
+	assert.Equal(t, "id-KMHD 2025 Q4", playlist.ID)
+
+	// Cached playlists aren't looked up or created again
+	mockSpotify.playlists = nil
+	_, err = resolveRoutePlaylist(target, routing.Route{Name: "quarterly", Playlist: "KMHD 2025 Q4"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"KMHD 2025 Q4"}, mockSpotify.created)
+}
+
+func TestSyncSongsToSpotify_RoutesToMultiplePlaylists(t *testing.T) {
+	tracks := []types.Track{{ID: "track1", Name: "So What", Album: types.Album{Name: "Kind of Blue"}}}
+	mockSpotify := &MockRoutingSpotifyService{MockSpotifyServiceForSync: MockSpotifyServiceForSync{tracks: tracks}}
+	target := newTestRoutingTarget(t, mockSpotify, config.RoutingRules{
+		Routes: []config.RouteRule{
+			{Playlist: "{prefix} {year} Q{quarter}"},
+			{Playlist: "Favourites", When: config.RouteCondition{Artists: []string{"Miles Davis"}}},
+			{Playlist: "Latin Jazz", When: config.RouteCondition{GenreContains: "Latin"}},
+		},
+	})
+
+	logger := log.New()
+	logger.SetLevel(log.ErrorLevel)
+	searcher := search.NewFuzzySongSearcher(mockSpotify, logger)
+
+	playedAt := time.Date(2025, time.October, 17, 12, 0, 0, 0, time.UTC)
+	songs := []types.Song{{Artist: "Miles Davis", Title: "So What", Album: "Kind of Blue", PlayedAt: playedAt}}
+	syncSongsToSpotify(songs, searcher, []syncTarget{target}, make(map[string]bool))
+
+	assert.Equal(t, []string{"KMHD 2025 Q4", "Favourites"}, mockSpotify.created)
+	assert.Equal(t, map[string][]string{
+		"id-KMHD 2025 Q4": {"track1"},
+		"id-Favourites":   {"track1"},
+	}, mockSpotify.added)
+}
+
+func TestSyncSongsToSpotify_SkipsUnroutedSongs(t *testing.T) {
+	mockSpotify := &MockRoutingSpotifyService{}
+	target := newTestRoutingTarget(t, mockSpotify, config.RoutingRules{
+		Routes: []config.RouteRule{{Playlist: "Favourites", When: config.RouteCondition{Artists: []string{"Miles Davis"}}}},
+	})
+
+	logger := log.New()
+	logger.SetLevel(log.ErrorLevel)
+	searcher := search.NewFuzzySongSearcher(mockSpotify, logger)
+
+	syncSongsToSpotify([]types.Song{{Artist: "Kenny G", Title: "Songbird"}}, searcher, []syncTarget{target}, make(map[string]bool))
+
+	assert.Empty(t, mockSpotify.created)
+	assert.Empty(t, mockSpotify.added)
+}
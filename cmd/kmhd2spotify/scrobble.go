@@ -0,0 +1,159 @@
+// Package cmd provides the scrobble command and scrobbling of KMHD plays for kmhd2spotify.
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/kmhd2spotify/internal/scrobble"
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+// scrobbler submits KMHD plays to the configured scrobbling services.
+// It is nil when no service is configured or the queue could not be opened, in which
+// case plays are not scrobbled.
+var scrobbler *scrobble.Scrobbler
+
+// newScrobbleCmd creates the scrobble command for inspecting and flushing the scrobble queue.
+func newScrobbleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "scrobble",
+		Short: "Inspect and submit queued scrobbles",
+		Long: `Inspect and submit the queue of KMHD plays waiting to be scrobbled.
+The sync command and 'now --follow' scrobble every KMHD play that started within
+SCROBBLE_SCHEDULE once it has finished, to ListenBrainz and/or a Last.fm-compatible
+server. Scrobbles that cannot be submitted stay queued and are resubmitted in batches
+by the next sync, 'now --follow' song change, or 'scrobble flush'.`,
+	}
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "status",
+			Short: "Show the scrobbling services and queued scrobbles",
+			Args:  cobra.NoArgs,
+			Run:   runScrobbleStatus,
+		},
+		&cobra.Command{
+			Use:   "flush",
+			Short: "Submit queued scrobbles now",
+			Args:  cobra.NoArgs,
+			Run:   runScrobbleFlush,
+		},
+	)
+
+	return cmd
+}
+
+// runScrobbleStatus executes the scrobble status command.
+func runScrobbleStatus(cmd *cobra.Command, args []string) {
+	s, err := openScrobbler()
+	if err != nil {
+		log.WithError(err).Fatal("Failed to open scrobble queue")
+		return
+	}
+	writeScrobbleStatus(cmd.OutOrStdout(), s)
+}
+
+// runScrobbleFlush executes the scrobble flush command.
+func runScrobbleFlush(cmd *cobra.Command, args []string) {
+	s, err := openScrobbler()
+	if err != nil {
+		log.WithError(err).Fatal("Failed to open scrobble queue")
+		return
+	}
+	if s == nil {
+		fmt.Println("📭 No scrobbling service configured")
+		return
+	}
+
+	submitted, err := s.Flush()
+	if submitted > 0 {
+		fmt.Printf("📻 Submitted %s\n", pluralize(submitted, "scrobble"))
+	}
+	if err != nil {
+		log.WithError(err).Fatal("Failed to submit every queued scrobble")
+		return
+	}
+	if submitted == 0 {
+		fmt.Println("Nothing to submit.")
+	}
+}
+
+// writeScrobbleStatus prints the services and queue of a scrobbler.
+func writeScrobbleStatus(out io.Writer, s *scrobble.Scrobbler) {
+	if s == nil {
+		fmt.Fprintln(out, "📭 No scrobbling service configured, set SCROBBLE_LISTENBRAINZ_TOKEN or SCROBBLE_LASTFM_API_KEY")
+		return
+	}
+
+	fmt.Fprintf(out, "📻 Scrobbling to %s\n", strings.Join(s.Services(), ", "))
+	if len(conf.Scrobble.Schedule) > 0 || len(conf.Scrobble.Days) > 0 {
+		schedule := "any time"
+		if len(conf.Scrobble.Schedule) > 0 {
+			schedule = strings.Join(conf.Scrobble.Schedule, ", ")
+		}
+		if len(conf.Scrobble.Days) > 0 {
+			schedule += " on " + strings.Join(conf.Scrobble.Days, ", ")
+		}
+		fmt.Fprintf(out, "   🕒 Schedule: %s (%s)\n", schedule, conf.Scrobble.Timezone)
+	}
+	fmt.Fprintf(out, "   Plays considered until %s\n", s.Until().Local().Format("2006-01-02 15:04:05"))
+
+	pending := s.Pending()
+	names := make([]string, 0, len(pending))
+	for name := range pending {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(out, "   ⏳ %s: %s queued\n", name, pluralize(pending[name], "scrobble"))
+	}
+}
+
+// openScrobbler creates the scrobbler for the configured services with its queue in the
+// state directory. It returns nil if no service is configured.
+func openScrobbler() (*scrobble.Scrobbler, error) {
+	path, err := conf.State.GetFilePath(scrobble.FileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve scrobble queue path: %w", err)
+	}
+	return scrobble.New(conf.Scrobble, path)
+}
+
+// scrobblePlays queues the finished KMHD plays for scrobbling and submits the queue.
+func scrobblePlays(songs []types.Song) {
+	if scrobbler == nil {
+		return
+	}
+
+	queued, err := scrobbler.Add(songs)
+	if err != nil {
+		log.WithError(err).Warn("Failed to queue plays for scrobbling")
+	}
+
+	pending := 0
+	for _, count := range scrobbler.Pending() {
+		pending += count
+	}
+	if pending == 0 {
+		return
+	}
+
+	submitted, err := scrobbler.Flush()
+	if err != nil {
+		// Failed scrobbles stay queued for the next attempt
+		log.WithError(err).Warn("Failed to submit scrobbles")
+	}
+	log.WithFields(log.Fields{
+		"queued":    queued,
+		"submitted": submitted,
+	}).Debug("Scrobbled KMHD plays")
+	if submitted > 0 {
+		fmt.Printf("📻 Scrobbled %s\n", pluralize(submitted, "play"))
+	}
+}
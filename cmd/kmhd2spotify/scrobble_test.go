@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/nowplaying"
+	"github.com/toozej/kmhd2spotify/internal/scrobble"
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+// MockScrobbleService records the scrobbles it is asked to submit
+type MockScrobbleService struct {
+	submitted []types.Song
+	err       error
+}
+
+func (m *MockScrobbleService) Name() string { return "mock" }
+
+func (m *MockScrobbleService) MaxBatch() int { return 10 }
+
+func (m *MockScrobbleService) Submit(ctx context.Context, songs []types.Song) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.submitted = append(m.submitted, songs...)
+	return nil
+}
+
+// withScrobbler replaces the package scrobbler for the duration of a test
+func withScrobbler(t *testing.T, service *MockScrobbleService, now time.Time) *scrobble.Scrobbler {
+	t.Helper()
+
+	s, err := scrobble.NewScrobbler(filepath.Join(t.TempDir(), scrobble.FileName), []scrobble.Service{service}, scrobble.Schedule{}, 0, time.Second)
+	require.NoError(t, err)
+	s.SetClock(func() time.Time { return now })
+
+	original := scrobbler
+	scrobbler = s
+	t.Cleanup(func() { scrobbler = original })
+	return s
+}
+
+func TestNewScrobbleCmd(t *testing.T) {
+	cmd := newScrobbleCmd()
+
+	assert.Equal(t, "scrobble", cmd.Use)
+
+	names := make([]string, 0)
+	for _, sub := range cmd.Commands() {
+		names = append(names, sub.Name())
+	}
+	assert.ElementsMatch(t, []string{"status", "flush"}, names)
+}
+
+func TestScrobblePlays(t *testing.T) {
+	t.Run("without scrobbler", func(t *testing.T) {
+		original := scrobbler
+		scrobbler = nil
+		defer func() { scrobbler = original }()
+
+		assert.NotPanics(t, func() {
+			scrobblePlays([]types.Song{{Artist: "Miles Davis", Title: "So What"}})
+		})
+	})
+
+	t.Run("scrobbles finished plays", func(t *testing.T) {
+		start := time.Now()
+		service := &MockScrobbleService{}
+		withScrobbler(t, service, start.Add(10*time.Minute))
+
+		scrobblePlays([]types.Song{
+			{Artist: "Miles Davis", Title: "So What", PlayedAt: start.Add(time.Minute), Duration: 9 * time.Minute},
+			{Artist: "John Coltrane", Title: "Naima", PlayedAt: start.Add(11 * time.Minute)},
+		})
+
+		require.Len(t, service.submitted, 1)
+		assert.Equal(t, "So What", service.submitted[0].Title)
+	})
+
+	t.Run("keeps failed scrobbles queued", func(t *testing.T) {
+		start := time.Now()
+		service := &MockScrobbleService{err: errors.New("service unavailable")}
+		s := withScrobbler(t, service, start.Add(10*time.Minute))
+
+		scrobblePlays([]types.Song{
+			{Artist: "Miles Davis", Title: "So What", PlayedAt: start.Add(time.Minute), Duration: 5 * time.Minute},
+		})
+
+		assert.Empty(t, service.submitted)
+		assert.Equal(t, map[string]int{"mock": 1}, s.Pending())
+	})
+}
+
+func TestNowPlayingSongs(t *testing.T) {
+	current := types.Song{Artist: "John Coltrane", Title: "Naima"}
+	assert.Equal(t, []types.Song{current}, nowPlayingSongs(nowplaying.Event{Song: current}))
+
+	previous := types.Song{Artist: "Miles Davis", Title: "So What"}
+	assert.Equal(t, []types.Song{previous, current}, nowPlayingSongs(nowplaying.Event{Song: current, Previous: &previous}))
+}
+
+func TestWriteScrobbleStatus(t *testing.T) {
+	t.Run("not configured", func(t *testing.T) {
+		var out bytes.Buffer
+		writeScrobbleStatus(&out, nil)
+		assert.Contains(t, out.String(), "No scrobbling service configured")
+	})
+
+	t.Run("pending scrobbles", func(t *testing.T) {
+		start := time.Now()
+		service := &MockScrobbleService{err: errors.New("service unavailable")}
+		s := withScrobbler(t, service, start.Add(10*time.Minute))
+		_, err := s.Add([]types.Song{
+			{Artist: "Miles Davis", Title: "So What", PlayedAt: start.Add(time.Minute), Duration: 5 * time.Minute},
+		})
+		require.NoError(t, err)
+
+		var out bytes.Buffer
+		writeScrobbleStatus(&out, s)
+		assert.Contains(t, out.String(), "Scrobbling to mock")
+		assert.Contains(t, out.String(), "mock: 1 scrobble queued")
+	})
+}
@@ -73,20 +73,46 @@ func runSearch(cmd *cobra.Command, args []string) {
 	displaySearchResults(matches, query)
 }
 
+// kmhdCacheDirName is the name of the KMHD response cache directory within the state directory.
+const kmhdCacheDirName = "kmhd_cache"
+
 // initializeKMHDAPIClient creates and initializes the KMHD API client using configuration
 func initializeKMHDAPIClient() (*api.KMHDAPIClient, error) {
 	// Initialize KMHD API client
 	kmhdAPIClient := api.NewKMHDAPIClient(conf.KMHD)
+
+	// Attach the response cache; the client works without it if it cannot be created
+	if conf.KMHD.CacheEnabled {
+		cache, err := newKMHDResponseCache()
+		if err != nil {
+			log.WithError(err).Warn("Failed to set up KMHD response cache, continuing without it")
+		} else {
+			kmhdAPIClient.SetCache(cache)
+		}
+	}
+
 	return kmhdAPIClient, nil
 }
 
+// newKMHDResponseCache opens the KMHD response cache in the configured state directory.
+func newKMHDResponseCache() (*api.ResponseCache, error) {
+	cacheDir, err := conf.State.GetFilePath(kmhdCacheDirName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve KMHD cache directory: %w", err)
+	}
+	return api.NewResponseCache(cacheDir, conf.KMHD.CacheMaxAge)
+}
+
 // initializeAllServices creates and initializes all required services using configuration
 func initializeAllServices() (types.KMHDScraper, types.SpotifyService, *search.FuzzySongSearcher, error) {
 	// Create logger
 	logger := log.StandardLogger()
 
 	// Initialize KMHD API client (replaces scraper)
-	kmhdAPIClient := api.NewKMHDAPIClient(conf.KMHD)
+	kmhdAPIClient, err := initializeKMHDAPIClient()
+	if err != nil {
+		return nil, nil, nil, err
+	}
 
 	// Initialize Spotify service
 	spotifyService := spotify.NewService(conf.Spotify, logger)
@@ -0,0 +1,110 @@
+// Package cmd provides the status server for the continuous sync daemon.
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/kmhd2spotify/internal/health"
+	"github.com/toozej/kmhd2spotify/internal/metrics"
+)
+
+// healthTracker records the sync loop's health for the /healthz and /readyz endpoints.
+// It is nil outside continuous mode, in which case nothing is recorded.
+var healthTracker *health.Tracker
+
+// tokenValidator is implemented by Spotify services that can verify their token is still valid.
+type tokenValidator interface {
+	ValidateToken() error
+}
+
+// newHealthTracker creates a health tracker for a continuous sync running at the given
+// interval. The Spotify token check fails if any target's token is invalid.
+func newHealthTracker(targets []syncTarget, interval time.Duration) *health.Tracker {
+	var validators []syncTarget
+	for _, target := range targets {
+		if _, ok := target.Service.(tokenValidator); ok {
+			validators = append(validators, target)
+		}
+	}
+
+	var validate health.TokenValidator
+	if len(validators) > 0 {
+		validate = func() error {
+			for _, target := range validators {
+				err := target.Service.(tokenValidator).ValidateToken()
+				if err != nil && target.Profile != "" {
+					return fmt.Errorf("profile %s: %w", target.Profile, err)
+				}
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	return health.NewTracker(validate, healthMaxStaleness(interval))
+}
+
+// healthMaxStaleness returns how long the daemon may go without a successful sync
+// before it is reported unhealthy. This tolerates one missed cycle: two intervals,
+// each with the random offset of up to an hour.
+func healthMaxStaleness(interval time.Duration) time.Duration {
+	return 2*interval + 2*time.Hour
+}
+
+// newStatusMux creates the handler for the status server's endpoints.
+func newStatusMux(tracker *health.Tracker, metricsEnabled bool) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", tracker.HandleHealthz)
+	mux.HandleFunc("/readyz", tracker.HandleReadyz)
+	if metricsEnabled {
+		mux.Handle("/metrics", metrics.Handler())
+	}
+	return mux
+}
+
+// startStatusServer serves the health endpoints, and metrics if enabled, on the configured
+// server address in the background. Continuous mode only starts it after authentication,
+// so it does not conflict with the OAuth callback server on the same address.
+func startStatusServer(tracker *health.Tracker) {
+	handler := newStatusMux(tracker, conf.Server.MetricsEnabled)
+
+	go func() {
+		if err := serveStatus(context.Background(), conf.Server.Address(), handler); err != nil {
+			log.WithError(err).Error("Status server stopped")
+		}
+	}()
+}
+
+// serveStatus serves the handler on the given address until the context is cancelled.
+func serveStatus(ctx context.Context, addr string, handler http.Handler) error {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second, // Prevent Slowloris attacks
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.WithField("address", addr).Info("Serving health and metrics endpoints")
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return fmt.Errorf("status server error: %w", err)
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	}
+}
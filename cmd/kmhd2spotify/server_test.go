@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/health"
+	"github.com/toozej/kmhd2spotify/internal/search"
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+// MockValidatingSpotifyService reports a configurable token validation result
+type MockValidatingSpotifyService struct {
+	MockSpotifyServiceForSync
+	tokenErr error
+}
+
+func (m *MockValidatingSpotifyService) ValidateToken() error {
+	return m.tokenErr
+}
+
+func TestNewHealthTracker_UsesTokenValidation(t *testing.T) {
+	tracker := newHealthTracker([]syncTarget{{Service: &MockValidatingSpotifyService{tokenErr: errors.New("revoked")}}}, time.Hour)
+	report := tracker.Report()
+	assert.Equal(t, "unhealthy", report.Status)
+	assert.Equal(t, "revoked", report.Checks["spotify_token"].Error)
+
+	tracker = newHealthTracker([]syncTarget{{Service: &MockSpotifyServiceForSync{}}}, time.Hour)
+	report = tracker.Report()
+	assert.Equal(t, "ok", report.Status)
+	assert.NotContains(t, report.Checks, "spotify_token")
+}
+
+func TestHealthMaxStaleness(t *testing.T) {
+	assert.Equal(t, 4*time.Hour, healthMaxStaleness(time.Hour))
+}
+
+func TestStatusMux(t *testing.T) {
+	tracker := health.NewTracker(nil, time.Hour)
+
+	mux := newStatusMux(tracker, true)
+	for path, status := range map[string]int{
+		"/healthz": http.StatusOK,
+		"/readyz":  http.StatusServiceUnavailable,
+		"/metrics": http.StatusOK,
+	} {
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, status, recorder.Code, path)
+	}
+
+	recorder := httptest.NewRecorder()
+	newStatusMux(tracker, false).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestRunSingleSyncRecordsHealth(t *testing.T) {
+	originalTracker := healthTracker
+	healthTracker = health.NewTracker(nil, time.Hour)
+	defer func() { healthTracker = originalTracker }()
+
+	logger := log.New()
+	logger.SetLevel(log.ErrorLevel)
+	mockSpotify := &MockSpotifyServiceForSync{}
+	searcher := search.NewFuzzySongSearcher(mockSpotify, logger)
+
+	runSingleSync(&MockKMHDScraperWithError{err: errors.New("kmhd down")}, searcher, []syncTarget{{Service: mockSpotify, Playlist: types.Playlist{ID: "playlist1", Name: "KMHD-2025-10"}}}, make(map[string]bool))
+	report := healthTracker.Report()
+	assert.False(t, report.Checks["kmhd_fetch"].OK)
+	assert.False(t, report.Checks["sync"].OK)
+	assert.False(t, report.Ready)
+
+	runSingleSync(&MockKMHDScraper{}, searcher, []syncTarget{{Service: mockSpotify, Playlist: types.Playlist{ID: "playlist1", Name: "KMHD-2025-10"}}}, make(map[string]bool))
+	report = healthTracker.Report()
+	assert.True(t, report.Checks["kmhd_fetch"].OK)
+	assert.True(t, report.Checks["sync"].OK)
+	assert.True(t, report.Ready)
+}
+
+func TestServeStatus(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- serveStatus(ctx, addr, newStatusMux(health.NewTracker(nil, time.Hour), true)) }()
+
+	var resp *http.Response
+	require.Eventually(t, func() bool {
+		resp, err = http.Get("http://" + addr + "/healthz") // #nosec G107 -- local test server
+		return err == nil
+	}, 2*time.Second, 10*time.Millisecond)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), `"status":"ok"`)
+
+	cancel()
+	require.NoError(t, <-done)
+}
@@ -0,0 +1,171 @@
+// Package cmd provides the stats command implementation for kmhd2spotify.
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/kmhd2spotify/internal/stats"
+	"github.com/toozej/kmhd2spotify/pkg/config"
+)
+
+// newStatsCmd creates the stats command for reporting listening statistics.
+func newStatsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show listening statistics from the play history",
+		Long: `Show statistics about what KMHD plays, computed from the play history recorded
+during syncs: plays per hour of day and day of week, unique artists and match
+quality per month, the repeat rate, the longest-running albums and the artists
+that are new to KMHD this month. Times are in KMHD's Pacific timezone.
+With --html, also write a self-contained HTML report with charts that works offline.`,
+		Args: cobra.NoArgs,
+		Run:  runStats,
+	}
+
+	cmd.Flags().String("format", "text", "Output format: text or json")
+	cmd.Flags().String("html", "", "Also write an HTML report to this file")
+	cmd.Flags().Int("days", 0, "Only count plays from the last N days; new artists and repeats still look at all history (default all history)")
+	cmd.Flags().IntP("limit", "n", 10, "Number of albums and new artists to show")
+
+	return cmd
+}
+
+// runStats executes the stats command.
+func runStats(cmd *cobra.Command, args []string) {
+	format, _ := cmd.Flags().GetString("format")
+	htmlPath, _ := cmd.Flags().GetString("html")
+	days, _ := cmd.Flags().GetInt("days")
+	limit, _ := cmd.Flags().GetInt("limit")
+	if format != "text" && format != "json" {
+		log.WithField("format", format).Fatal("Unsupported output format, expected text or json")
+		return
+	}
+
+	store, err := openPlayHistoryReadOnly()
+	if err != nil {
+		log.WithError(err).Fatal("Failed to open play history")
+		return
+	}
+
+	now := time.Now()
+	var since time.Time
+	if days > 0 {
+		since = now.AddDate(0, 0, -days)
+	}
+	report := stats.Compute(store.Plays(), since, now, kmhdLocation())
+
+	if htmlPath != "" {
+		err := writeOutputFile(htmlPath, func(w io.Writer) error {
+			return stats.WriteHTML(w, report)
+		})
+		if err != nil {
+			log.WithError(err).Fatal("Failed to write HTML report")
+			return
+		}
+		log.WithField("path", htmlPath).Info("Wrote HTML report")
+	}
+
+	if err := writeStats(cmd.OutOrStdout(), format, report.Top(limit)); err != nil {
+		log.WithError(err).Fatal("Failed to write statistics")
+	}
+}
+
+// kmhdLocation returns KMHD's timezone, falling back to local time if it cannot be loaded.
+func kmhdLocation() *time.Location {
+	location, err := time.LoadLocation(config.DefaultRoutingTimezone)
+	if err != nil {
+		log.WithError(err).Warn("Failed to load Pacific timezone, using local time")
+		return time.Local
+	}
+	return location
+}
+
+// writeOutputFile writes a generated file to path atomically, so readers never see a
+// partial file.
+func writeOutputFile(path string, write func(io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	// #nosec G302 -- generated files are meant to be opened by other programs and shared
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to set permissions of %s: %w", path, err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// writeStats prints a statistics report in the requested format.
+func writeStats(out io.Writer, format string, report stats.Report) error {
+	if format == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	if report.Plays == 0 {
+		fmt.Fprintln(out, "📭 No plays recorded yet, run sync to record plays")
+		return nil
+	}
+
+	location := kmhdLocation()
+	fmt.Fprintf(out, "📊 KMHD listening statistics (%s to %s, %s)\n",
+		report.From.In(location).Format("2006-01-02"), report.Until.In(location).Format("2006-01-02"), report.Timezone)
+	fmt.Fprintf(out, "   Plays: %d, unique artists: %d, unique recordings: %d\n", report.Plays, report.UniqueArtists, report.UniqueRecordings)
+	fmt.Fprintf(out, "   Repeat rate: %.1f%%, match failure rate: %.1f%%, average match confidence: %.2f\n",
+		report.RepeatRate*100, report.MatchFailureRate*100, report.AverageConfidence)
+
+	// Headings contain no tabs, so each table below is aligned on its own
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\n🕒 Plays by hour of day")
+	for _, bucket := range report.PlaysByHour {
+		fmt.Fprintf(tw, "   %s\t%d\n", bucket.Label, bucket.Plays)
+	}
+
+	fmt.Fprintln(tw, "\n📅 Plays by day of week")
+	for _, bucket := range report.PlaysByWeekday {
+		fmt.Fprintf(tw, "   %s\t%d\n", bucket.Label, bucket.Plays)
+	}
+
+	fmt.Fprintln(tw, "\n🗓️  Months")
+	fmt.Fprintln(tw, "   Month\tPlays\tArtists\tMatched\tFailure rate\tConfidence")
+	for _, month := range report.Months {
+		fmt.Fprintf(tw, "   %s\t%d\t%d\t%d\t%.1f%%\t%.2f\n", month.Month, month.Plays, month.UniqueArtists,
+			month.MatchedPlays, month.MatchFailureRate*100, month.AverageConfidence)
+	}
+
+	fmt.Fprintln(tw, "\n💿 Longest-running albums")
+	if len(report.LongestRunning) == 0 {
+		fmt.Fprintln(tw, "   No album has been played more than once yet")
+	}
+	for _, album := range report.LongestRunning {
+		fmt.Fprintf(tw, "   %s\t%s\t%s\t%s\n", album.Name, album.Artist, pluralize(album.Plays, "play"), pluralize(album.Days, "day"))
+	}
+
+	fmt.Fprintln(tw, "\n🆕 New to KMHD this month")
+	if len(report.NewArtists) == 0 {
+		fmt.Fprintln(tw, "   No new artists this month")
+	}
+	for _, artist := range report.NewArtists {
+		fmt.Fprintf(tw, "   %s\t%s\tfirst played %s\n", artist.Name, pluralize(artist.Plays, "play"),
+			artist.FirstPlayed.In(location).Format("2006-01-02 15:04"))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/stats"
+)
+
+func TestWriteStats(t *testing.T) {
+	now := time.Now()
+	report := stats.Compute(newTestChartHistory(t, now).Plays(), time.Time{}, now, kmhdLocation())
+
+	var out bytes.Buffer
+	require.NoError(t, writeStats(&out, "text", report.Top(5)))
+	assert.Contains(t, out.String(), "📊 KMHD listening statistics")
+	assert.Contains(t, out.String(), "Plays: 7, unique artists: 4, unique recordings: 4")
+	assert.Contains(t, out.String(), "Plays by hour of day")
+	assert.Contains(t, out.String(), "Longest-running albums")
+	assert.Contains(t, out.String(), "Giant Steps")
+
+	out.Reset()
+	require.NoError(t, writeStats(&out, "json", report))
+	var decoded stats.Report
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, 7, decoded.Plays)
+	assert.Len(t, decoded.PlaysByHour, 24)
+
+	out.Reset()
+	require.NoError(t, writeStats(&out, "text", stats.Compute(nil, time.Time{}, now, time.UTC)))
+	assert.Contains(t, out.String(), "No plays recorded yet")
+}
+
+func TestWriteOutputFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.html")
+	now := time.Now()
+	report := stats.Compute(newTestChartHistory(t, now).Plays(), time.Time{}, now, kmhdLocation())
+
+	require.NoError(t, writeOutputFile(path, func(w io.Writer) error {
+		return stats.WriteHTML(w, report)
+	}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "<!DOCTYPE html>")
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary files are left behind")
+}
+
+func TestStatsCmdFlags(t *testing.T) {
+	cmd := newStatsCmd()
+	for _, name := range []string{"format", "html", "days", "limit"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), "missing --%s flag", name)
+	}
+}
@@ -4,6 +4,8 @@ package cmd
 import (
 	"context"
 	"crypto/rand"
+	"crypto/subtle"
+	"errors"
 	"fmt"
 	"math/big"
 	"net/http"
@@ -12,6 +14,8 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
+	"github.com/toozej/kmhd2spotify/internal/api"
+	"github.com/toozej/kmhd2spotify/internal/metrics"
 	"github.com/toozej/kmhd2spotify/internal/search"
 	"github.com/toozej/kmhd2spotify/internal/types"
 )
@@ -29,6 +33,7 @@ to your specified Spotify playlist using fuzzy matching.`,
 
 	cmd.Flags().BoolP("continuous", "c", false, "Run continuously, checking for new songs every hour with randomized timing")
 	cmd.Flags().DurationP("interval", "i", time.Hour, "Base interval between checks in continuous mode (randomization will be added)")
+	cmd.Flags().Bool("wait-for-auth", false, "Wait for browser authentication via the callback server instead of failing when not authenticated")
 
 	return cmd
 }
@@ -37,6 +42,7 @@ to your specified Spotify playlist using fuzzy matching.`,
 func runSync(cmd *cobra.Command, args []string) {
 	continuous, _ := cmd.Flags().GetBool("continuous")
 	interval, _ := cmd.Flags().GetDuration("interval")
+	waitForAuth, _ := cmd.Flags().GetBool("wait-for-auth")
 
 	if continuous {
 		log.WithField("interval", interval).Info("Starting continuous KMHD to Spotify sync operation")
@@ -44,34 +50,40 @@ func runSync(cmd *cobra.Command, args []string) {
 		log.Info("Starting single KMHD to Spotify sync operation")
 	}
 
-	// Initialize services using configuration
-	kmhdScraper, spotifyService, fuzzySongSearcher, err := initializeAllServices()
+	// Initialize the KMHD client
+	kmhdScraper, err := initializeKMHDAPIClient()
 	if err != nil {
 		log.WithError(err).Fatal("Failed to initialize services")
 		return
 	}
 
-	// Check if Spotify is authenticated, if not, start auth flow
-	if !spotifyService.IsAuthenticated() {
-		log.Info("Spotify authentication required. Starting authentication flow...")
-
-		err := authenticateSpotify(spotifyService)
-		if err != nil {
-			log.WithError(err).Fatal("Failed to authenticate with Spotify")
-			return
-		}
-
-		log.Info("Spotify authentication completed successfully")
+	// Open the play history so every play and its match are kept for the rolling playlist,
+	// and new playlists' covers can show the artwork of recent plays
+	playHistory, err = openPlayHistory()
+	if err != nil {
+		log.WithError(err).Warn("Failed to open play history, plays will not be recorded")
 	}
 
-	// Get or create target playlist for current month
-	targetPlaylist, err := getOrCreateMonthlyPlaylist(spotifyService, conf.Spotify.PlaylistNamePrefix)
+	// Authenticate every profile and resolve its playlist. Songs are resolved once and
+	// fanned out to every target, so any authenticated account can be used for searching.
+	targets, err := newSyncTargets(waitForAuth)
 	if err != nil {
-		log.WithError(err).Error("Failed to get or create monthly playlist")
+		log.WithError(err).Fatal("Failed to set up Spotify sync targets")
 		return
 	}
+	fuzzySongSearcher := search.NewFuzzySongSearcher(targets[0].Service, log.StandardLogger())
 
-	log.WithField("playlist", targetPlaylist.Name).Info("Using playlist as sync target")
+	// Open the retry queue so failed Spotify operations are retried in later cycles
+	retryQueue, err = openRetryQueue()
+	if err != nil {
+		log.WithError(err).Warn("Failed to open retry queue, failed songs will not be retried")
+	}
+
+	// Open the scrobble queue so finished plays are scrobbled when a service is configured
+	scrobbler, err = openScrobbler()
+	if err != nil {
+		log.WithError(err).Warn("Failed to open scrobble queue, plays will not be scrobbled")
+	}
 
 	// For radio monitoring, we don't need to track "seen songs" across cycles
 	// since the same song can legitimately play multiple times and users might want it added each time
@@ -79,11 +91,13 @@ func runSync(cmd *cobra.Command, args []string) {
 
 	// Run sync operation
 	if continuous {
-		runContinuousSync(kmhdScraper, spotifyService, fuzzySongSearcher, targetPlaylist, interval)
+		healthTracker = newHealthTracker(targets, interval)
+		startStatusServer(healthTracker)
+		runContinuousSync(kmhdScraper, fuzzySongSearcher, targets, interval)
 	} else {
 		// For single sync, use a seenSongs map to avoid processing the same song multiple times within one batch
 		seenSongs := make(map[string]bool)
-		runSingleSync(kmhdScraper, spotifyService, fuzzySongSearcher, targetPlaylist, seenSongs)
+		runSingleSync(kmhdScraper, fuzzySongSearcher, targets, seenSongs)
 	}
 }
 
@@ -91,19 +105,25 @@ func runSync(cmd *cobra.Command, args []string) {
 // This function is shared between search and sync commands
 
 // runContinuousSync runs the sync operation continuously at the specified interval with randomization
-func runContinuousSync(kmhdScraper types.KMHDScraper, spotifyService types.SpotifyService, fuzzySongSearcher *search.FuzzySongSearcher, targetPlaylist types.Playlist, interval time.Duration) {
+func runContinuousSync(kmhdScraper types.KMHDScraper, fuzzySongSearcher *search.FuzzySongSearcher, targets []syncTarget, interval time.Duration) {
 	log.Info("🎵 Starting continuous sync mode - monitoring KMHD for new songs...")
 	fmt.Printf("🎵 Monitoring KMHD every %v (with randomization) for new songs...\n", interval)
 	fmt.Printf("Press Ctrl+C to stop\n\n")
 
 	// Run initial sync
 	cycleSeen := make(map[string]bool)
-	runSingleSync(kmhdScraper, spotifyService, fuzzySongSearcher, targetPlaylist, cycleSeen)
+	runSingleSync(kmhdScraper, fuzzySongSearcher, targets, cycleSeen)
 
 	// Continue monitoring with randomized intervals
 	for {
 		// Calculate next sync time with randomization
 		nextSyncDuration := calculateNextSyncTime(interval)
+
+		// Back off further while the KMHD circuit breaker is open
+		if breakerWait := kmhdBreakerWait(kmhdScraper); breakerWait > nextSyncDuration {
+			log.WithField("breaker_wait", breakerWait).Warn("KMHD API appears to be down, backing off until the circuit breaker allows a new attempt")
+			nextSyncDuration = breakerWait
+		}
 		nextSyncTime := time.Now().Add(nextSyncDuration)
 
 		log.WithFields(log.Fields{
@@ -121,7 +141,7 @@ func runContinuousSync(kmhdScraper types.KMHDScraper, spotifyService types.Spoti
 		// Create a fresh cycle-specific seenSongs map for each cycle
 		// Global tracking prevents cross-day duplicates in long-running sessions
 		cycleSeen := make(map[string]bool)
-		runSingleSync(kmhdScraper, spotifyService, fuzzySongSearcher, targetPlaylist, cycleSeen)
+		runSingleSync(kmhdScraper, fuzzySongSearcher, targets, cycleSeen)
 	}
 }
 
@@ -133,6 +153,26 @@ func calculateNextSyncTime(baseInterval time.Duration) time.Duration {
 	return baseInterval + randomOffset
 }
 
+// circuitBreakerAware is implemented by KMHD clients that suspend fetches after repeated failures.
+type circuitBreakerAware interface {
+	CircuitOpenUntil() time.Time
+}
+
+// kmhdBreakerWait returns how long fetches are suspended by the KMHD client's circuit breaker,
+// or zero if the client has no breaker or it is closed.
+func kmhdBreakerWait(kmhdScraper types.KMHDScraper) time.Duration {
+	aware, ok := kmhdScraper.(circuitBreakerAware)
+	if !ok {
+		return 0
+	}
+
+	openUntil := aware.CircuitOpenUntil()
+	if openUntil.IsZero() {
+		return 0
+	}
+	return time.Until(openUntil)
+}
+
 // globalSeenSongs tracks songs across all sync cycles to prevent cross-day duplicates
 // in long-running sessions. Key format: "artist - title"
 var globalSeenSongs = make(map[string]time.Time)
@@ -148,15 +188,39 @@ func generateSecureRandomInt(max int64) int64 {
 }
 
 // runSingleSync runs a single sync operation
-func runSingleSync(kmhdScraper types.KMHDScraper, spotifyService types.SpotifyService, fuzzySongSearcher *search.FuzzySongSearcher, targetPlaylist types.Playlist, seenSongs map[string]bool) {
+func runSingleSync(kmhdScraper types.KMHDScraper, fuzzySongSearcher *search.FuzzySongSearcher, targets []syncTarget, seenSongs map[string]bool) {
+	// Retry previously failed operations before processing new songs
+	for _, target := range targets {
+		processRetryQueue(target)
+	}
+
 	// Fetch KMHD playlist from JSON API
 	log.Debug("Fetching KMHD playlist from JSON API...")
 	songCollection, err := kmhdScraper.ScrapePlaylist()
+	healthTracker.RecordFetch(err)
 	if err != nil {
+		healthTracker.RecordSync(fmt.Errorf("KMHD fetch failed: %w", err))
+		if errors.Is(err, api.ErrCircuitOpen) {
+			log.WithError(err).Warn("Skipping KMHD fetch while the API is unavailable")
+			return
+		}
 		log.WithError(err).Error("Failed to fetch KMHD playlist from API")
 		return
 	}
 
+	// The cycle counts as successful once KMHD was fetched, even if there is nothing new
+	defer func() {
+		metrics.SyncSucceeded(time.Now())
+		healthTracker.RecordSync(nil)
+	}()
+
+	// Record every fetched play, including repeats, then refresh the rolling and chart
+	// playlists once this cycle's matches are recorded
+	recordPlays(songCollection.Songs)
+	scrobblePlays(songCollection.Songs)
+	defer updateChartPlaylists(targets, conf.Chart.Period, time.Now())
+	defer updateRollingPlaylists(targets, time.Now())
+
 	if len(songCollection.Songs) == 0 {
 		log.Debug("No songs found in KMHD playlist")
 		return
@@ -204,7 +268,7 @@ func runSingleSync(kmhdScraper types.KMHDScraper, spotifyService types.SpotifySe
 	log.WithField("new_song_count", len(newSongs)).Info("Found new songs to sync")
 
 	// Sync new songs to Spotify
-	syncSongsToSpotify(newSongs, spotifyService, fuzzySongSearcher, targetPlaylist, seenSongs)
+	syncSongsToSpotify(newSongs, fuzzySongSearcher, targets, seenSongs)
 }
 
 // filterNewSongs returns only songs that haven't been seen in this cycle or globally
@@ -283,14 +347,24 @@ func filterNewSongs(songs []types.Song, seenSongs map[string]bool) []types.Song
 	return newSongs
 }
 
-// syncSongsToSpotify syncs the API-fetched songs to the specified Spotify playlist
-func syncSongsToSpotify(songs []types.Song, spotifyService types.SpotifyService, fuzzySongSearcher *search.FuzzySongSearcher, targetPlaylist types.Playlist, seenSongs map[string]bool) {
-	log.WithField("playlist", targetPlaylist.Name).Debug("Starting sync to Spotify playlist")
+// syncSongsToSpotify resolves each API-fetched song on Spotify once and adds it to
+// every target whose filters accept it
+func syncSongsToSpotify(songs []types.Song, fuzzySongSearcher *search.FuzzySongSearcher, targets []syncTarget, seenSongs map[string]bool) {
+	log.WithField("targets", len(targets)).Debug("Starting sync to Spotify playlists")
 
 	syncedCount := 0
 	skippedCount := 0
 
+	// Duplicate checks fetch each playlist's tracks once per cycle
+	for _, target := range targets {
+		if target.Duplicates != nil {
+			target.Duplicates.ResetRecordings()
+		}
+	}
+
 	for i, song := range songs {
+		metrics.SongSeen()
+
 		// Log the song found on KMHD before processing
 		fmt.Printf("🎵 Found on KMHD: %s\n", song.String())
 		log.WithFields(log.Fields{
@@ -299,6 +373,21 @@ func syncSongsToSpotify(songs []types.Song, spotifyService types.SpotifyService,
 			"kmhd_song":   song.String(),
 		}).Info("Processing song from KMHD")
 
+		// Only search Spotify if at least one target routes the song to a playlist
+		var accepting []syncTarget
+		for _, target := range targets {
+			if target.Accepts(song) && len(target.Routes(song)) > 0 {
+				accepting = append(accepting, target)
+			}
+		}
+		if len(accepting) == 0 {
+			log.WithField("kmhd_song", song.String()).Debug("No profile or route accepts the song, skipping")
+			fmt.Printf("   ⏭️  Filtered out for every profile\n")
+			metrics.SongSkipped(metrics.SkipFiltered)
+			skippedCount++
+			continue
+		}
+
 		// Search for artist, song, and album on Spotify using the enhanced fuzzy song searcher
 		songMatch, err := fuzzySongSearcher.FindBestSongMatchWithAlbum(song.Artist, song.Title, song.Album)
 		if err != nil {
@@ -307,11 +396,14 @@ func syncSongsToSpotify(songs []types.Song, spotifyService types.SpotifyService,
 				"error":     err.Error(),
 			}).Warn("Failed to find song match, skipping song")
 			fmt.Printf("   ❌ Could not find song on Spotify: %s\n", err.Error())
+			recordNoMatch(song)
+			metrics.SongSkipped(metrics.SkipNoMatch)
 			skippedCount++
 			continue
 		}
 
 		// Skip low confidence matches
+		metrics.SongMatched(songMatch.OverallConfidence, songMatch.OverallConfidence >= 0.5)
 		if songMatch.OverallConfidence < 0.5 {
 			log.WithFields(log.Fields{
 				"kmhd_song":          song.String(),
@@ -320,6 +412,8 @@ func syncSongsToSpotify(songs []types.Song, spotifyService types.SpotifyService,
 				"song_confidence":    songMatch.SongConfidence,
 			}).Debug("Low confidence match, skipping song")
 			fmt.Printf("   ❌ Low confidence match (%.2f), skipping\n", songMatch.OverallConfidence)
+			recordNoMatch(song)
+			metrics.SongSkipped(metrics.SkipLowConfidence)
 			skippedCount++
 			continue
 		}
@@ -327,48 +421,34 @@ func syncSongsToSpotify(songs []types.Song, spotifyService types.SpotifyService,
 		fmt.Printf("   🎯 Found match: %s - %s (artist: %.2f, song: %.2f, overall: %.2f)\n",
 			songMatch.Artist.Name, songMatch.Track.Name,
 			songMatch.ArtistConfidence, songMatch.SongConfidence, songMatch.OverallConfidence)
-
-		// Use the matched track
-		trackIDs := []string{songMatch.Track.ID}
-
-		// Check if tracks are already in playlist
-		existing, err := spotifyService.CheckTracksInPlaylist(targetPlaylist.ID, trackIDs)
-		if err != nil {
-			log.WithFields(log.Fields{
-				"playlist": targetPlaylist.Name,
-				"error":    err.Error(),
-			}).Warn("Failed to check existing tracks, attempting to add anyway")
-		} else if len(existing) > 0 && existing[0] {
-			log.WithFields(log.Fields{
-				"kmhd_song": song.String(),
-				"playlist":  targetPlaylist.Name,
-			}).Debug("Track already exists in playlist, skipping")
-			fmt.Printf("   ⏭️  Track already in playlist: %s\n", songMatch.Track.Name)
-			skippedCount++
-			continue
+		recordMatch(song, songMatch.Track, songMatch.OverallConfidence)
+
+		// Fan the matched track out to every playlist the song is routed to
+		added := false
+		for _, target := range accepting {
+			for _, route := range target.Routes(song) {
+				playlist, err := resolveRoutePlaylist(target, route)
+				if err != nil {
+					log.WithFields(log.Fields{
+						"profile":  target.Profile,
+						"route":    route.Name,
+						"playlist": route.Playlist,
+						"error":    err.Error(),
+					}).Warn("Failed to resolve routed playlist")
+					fmt.Printf("   ❌ Failed to resolve playlist%s: %s\n", profileSuffix(target.Profile), err.Error())
+					metrics.SongSkipped(metrics.SkipAddFailed)
+					continue
+				}
+				if addTrackToTarget(song, songMatch.Track, target, playlist) {
+					added = true
+				}
+			}
 		}
-
-		// Add track to playlist
-		err = spotifyService.AddTracksToPlaylist(targetPlaylist.ID, trackIDs)
-		if err != nil {
-			log.WithFields(log.Fields{
-				"kmhd_song": song.String(),
-				"playlist":  targetPlaylist.Name,
-				"error":     err.Error(),
-			}).Warn("Failed to add track to playlist")
-			fmt.Printf("   ❌ Failed to add to playlist: %s\n", err.Error())
+		if added {
+			syncedCount++
+		} else {
 			skippedCount++
-			continue
 		}
-
-		log.WithFields(log.Fields{
-			"kmhd_song": song.String(),
-			"playlist":  targetPlaylist.Name,
-			"track":     songMatch.Track.Name,
-		}).Info("Successfully synced song to Spotify")
-
-		fmt.Printf("   ✅ Added to playlist: %s\n", songMatch.Track.Name)
-		syncedCount++
 	}
 
 	// Display sync summary
@@ -377,11 +457,92 @@ func syncSongsToSpotify(songs []types.Song, spotifyService types.SpotifyService,
 		fmt.Printf("   • Songs processed: %d\n", len(songs))
 		fmt.Printf("   • Songs synced: %d\n", syncedCount)
 		fmt.Printf("   • Songs skipped: %d\n", skippedCount)
-		fmt.Printf("   • Target playlist: %s\n", targetPlaylist.Name)
+		for _, target := range targets {
+			if target.Router != nil {
+				fmt.Printf("   • Target playlists: routed by %s%s\n", conf.Routing.RulesFile, profileSuffix(target.Profile))
+				continue
+			}
+			fmt.Printf("   • Target playlist: %s%s\n", target.Playlist.Name, profileSuffix(target.Profile))
+		}
 		fmt.Println()
 	}
 }
 
+// addTrackToTarget adds a matched track to one of the target's playlists unless it is
+// already there, queueing it for retry if adding fails. It reports whether the track was added.
+func addTrackToTarget(song types.Song, track *types.Track, target syncTarget, targetPlaylist types.Playlist) bool {
+	suffix := profileSuffix(target.Profile)
+
+	existing, err := addTrack(song, track, target, targetPlaylist)
+	if err != nil {
+		fmt.Printf("   ❌ Failed to add to playlist%s: %s\n", suffix, err.Error())
+		enqueueFailedAdd(song, track, targetPlaylist, target.Profile, err)
+		metrics.SongSkipped(metrics.SkipAddFailed)
+		return false
+	}
+	if existing != nil {
+		if existing.PlaylistName != "" {
+			fmt.Printf("   ⏭️  Track already added to %s%s: %s\n", existing.PlaylistName, suffix, track.Name)
+		} else {
+			fmt.Printf("   ⏭️  Track already in playlist%s: %s\n", suffix, track.Name)
+		}
+		metrics.SongSkipped(metrics.SkipAlreadyInPlaylist)
+		return false
+	}
+
+	fmt.Printf("   ✅ Added to playlist%s: %s\n", suffix, track.Name)
+	return true
+}
+
+// addTrack adds a matched track to one of the target's playlists unless the duplicate
+// policy finds it already added, and records the addition. It returns the duplicate
+// check result if the track was skipped. Sync and the retry queue both add tracks here.
+func addTrack(song types.Song, track *types.Track, target syncTarget, targetPlaylist types.Playlist) (*types.DuplicateResult, error) {
+	// Check if the track is already in the playlist, or in another playlist under the duplicate policy
+	duplicates := target.duplicates()
+	existing, err := duplicates.CheckTrack(targetPlaylist, *track)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"profile":  target.Profile,
+			"playlist": targetPlaylist.Name,
+			"error":    err.Error(),
+		}).Warn("Failed to check existing tracks, attempting to add anyway")
+	} else if existing.HasDuplicates {
+		log.WithFields(log.Fields{
+			"kmhd_song": song.String(),
+			"profile":   target.Profile,
+			"playlist":  targetPlaylist.Name,
+			"reason":    existing.Message,
+		}).Debug("Track already exists in playlist, skipping")
+		return existing, nil
+	}
+
+	// Add track to playlist
+	if err := target.Service.AddTracksToPlaylist(targetPlaylist.ID, []string{track.ID}); err != nil {
+		log.WithFields(log.Fields{
+			"kmhd_song": song.String(),
+			"profile":   target.Profile,
+			"playlist":  targetPlaylist.Name,
+			"error":     err.Error(),
+		}).Warn("Failed to add track to playlist")
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{
+		"kmhd_song": song.String(),
+		"profile":   target.Profile,
+		"playlist":  targetPlaylist.Name,
+		"track":     track.Name,
+	}).Info("Successfully synced song to Spotify")
+	if err := duplicates.RecordAdded(targetPlaylist, *track); err != nil {
+		log.WithError(err).WithField("playlist", targetPlaylist.Name).Warn("Failed to record added track in duplicate index")
+	}
+
+	notifySongAdded(song, track, &targetPlaylist)
+	metrics.SongAdded()
+	return nil, nil
+}
+
 // getOrCreateMonthlyPlaylist finds or creates a monthly playlist based on the configured prefix.
 // Creates playlists with format: "{prefix}-YYYY-MM" (e.g., "KMHD-2025-10")
 // If no prefix is configured, it returns the first existing playlist.
@@ -441,6 +602,7 @@ func getOrCreateMonthlyPlaylist(spotifyService types.SpotifyService, playlistNam
 		"description":   description,
 		"folder_hint":   playlistNamePrefix,
 	}).Info("Successfully created new monthly playlist")
+	setNewPlaylistCover(spotifyService, *newPlaylist, nil)
 
 	// Log instructions for manual folder organization
 	log.WithFields(log.Fields{
@@ -458,6 +620,12 @@ func getOrCreateMonthlyPlaylist(spotifyService types.SpotifyService, playlistNam
 func authenticateSpotify(spotifyService types.SpotifyService) error {
 	authURL := spotifyService.GetAuthURL()
 
+	// The state of this attempt is kept server-side and every callback is checked against it
+	expectedState := authStateFromURL(authURL)
+	if expectedState == "" {
+		return fmt.Errorf("spotify auth URL has no state, check SPOTIFY_CLIENT_ID and SPOTIFY_REDIRECT_URI")
+	}
+
 	log.WithField("auth_url", authURL).Info("Please visit this URL to authenticate with Spotify")
 	fmt.Printf("\n🔐 Spotify Authentication Required\n")
 	fmt.Printf("Please visit this URL to authenticate:\n%s\n\n", authURL)
@@ -469,7 +637,7 @@ func authenticateSpotify(spotifyService types.SpotifyService) error {
 	// Set up HTTP server to handle the callback
 	mux := http.NewServeMux()
 	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
-		handleSpotifyCallback(w, r, spotifyService, authComplete)
+		handleSpotifyCallback(w, r, spotifyService, expectedState, authComplete)
 	})
 
 	// Use the server configuration from config instead of parsing redirect URI
@@ -517,12 +685,20 @@ func authenticateSpotify(spotifyService types.SpotifyService) error {
 	}
 }
 
-// handleSpotifyCallback handles the OAuth callback from Spotify
-func handleSpotifyCallback(w http.ResponseWriter, r *http.Request, spotifyService types.SpotifyService, authComplete chan<- error) {
+// handleSpotifyCallback handles the OAuth callback from Spotify. Callbacks whose state
+// doesn't match expectedState are rejected without ending the authentication attempt,
+// so a forged request can neither inject a code nor abort the flow.
+func handleSpotifyCallback(w http.ResponseWriter, r *http.Request, spotifyService types.SpotifyService, expectedState string, authComplete chan<- error) {
 	code := r.URL.Query().Get("code")
 	state := r.URL.Query().Get("state")
 	errorParam := r.URL.Query().Get("error")
 
+	if expectedState == "" || subtle.ConstantTimeCompare([]byte(state), []byte(expectedState)) != 1 {
+		log.WithField("remote_addr", r.RemoteAddr).Warn("Rejected Spotify callback with invalid state")
+		http.Error(w, "Invalid state parameter", http.StatusBadRequest)
+		return
+	}
+
 	if errorParam != "" {
 		log.WithField("error", errorParam).Error("Spotify authentication error")
 		http.Error(w, "Authentication failed: "+errorParam, http.StatusBadRequest)
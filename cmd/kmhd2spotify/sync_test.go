@@ -1,12 +1,16 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/toozej/kmhd2spotify/internal/api"
 	"github.com/toozej/kmhd2spotify/internal/search"
 	"github.com/toozej/kmhd2spotify/internal/types"
@@ -51,7 +55,7 @@ func TestSyncSongsToSpotify(t *testing.T) {
 
 	// Test that syncSongsToSpotify doesn't panic
 	assert.NotPanics(t, func() {
-		syncSongsToSpotify(songs, mockSpotify, fuzzySongSearcher, targetPlaylist, seenSongs)
+		syncSongsToSpotify(songs, fuzzySongSearcher, []syncTarget{{Service: mockSpotify, Playlist: targetPlaylist}}, seenSongs)
 	})
 }
 
@@ -65,6 +69,10 @@ func (m *MockSpotifyServiceForSync) SearchArtist(query string) (*types.Artist, e
 	return &types.Artist{ID: "artist1", Name: query}, nil
 }
 
+func (m *MockSpotifyServiceForSync) SearchArtists(query string, limit int) ([]types.Artist, error) {
+	return []types.Artist{{ID: "artist1", Name: query}}, nil
+}
+
 func (m *MockSpotifyServiceForSync) GetArtistTopTracks(artistID string) ([]types.Track, error) {
 	return m.tracks, nil
 }
@@ -104,6 +112,38 @@ func (m *MockSpotifyServiceForSync) CreatePlaylist(name, description string, pub
 	}, nil
 }
 
+func (m *MockSpotifyServiceForSync) GetPlaylistTracks(playlistID string) ([]types.Track, error) {
+	return nil, nil
+}
+
+func (m *MockSpotifyServiceForSync) GetPlaylistItems(playlistID string) ([]types.PlaylistItem, error) {
+	return nil, nil
+}
+
+func (m *MockSpotifyServiceForSync) RemoveTracksFromPlaylist(playlistID string, trackIDs []string) error {
+	return nil
+}
+
+func (m *MockSpotifyServiceForSync) RemoveTrackPositions(playlistID string, positions map[string][]int) error {
+	return nil
+}
+
+func (m *MockSpotifyServiceForSync) ReorderPlaylist(playlistID string, rangeStart, rangeLength, insertBefore int) error {
+	return nil
+}
+
+func (m *MockSpotifyServiceForSync) UpdatePlaylist(playlistID string, update types.PlaylistUpdate) error {
+	return nil
+}
+
+func (m *MockSpotifyServiceForSync) SetPlaylistCover(playlistID string, jpeg []byte) error {
+	return nil
+}
+
+func (m *MockSpotifyServiceForSync) DeletePlaylist(playlistID string) error {
+	return nil
+}
+
 func TestAuthenticateSpotify(t *testing.T) {
 	// Test that authentication flow is triggered when service is not authenticated
 	mockSpotify := &MockUnauthenticatedSpotifyService{}
@@ -126,6 +166,10 @@ func (m *MockUnauthenticatedSpotifyService) SearchArtist(query string) (*types.A
 	return nil, fmt.Errorf("not authenticated")
 }
 
+func (m *MockUnauthenticatedSpotifyService) SearchArtists(query string, limit int) ([]types.Artist, error) {
+	return nil, fmt.Errorf("not authenticated")
+}
+
 func (m *MockUnauthenticatedSpotifyService) GetArtistTopTracks(artistID string) ([]types.Track, error) {
 	return nil, fmt.Errorf("not authenticated")
 }
@@ -158,6 +202,38 @@ func (m *MockUnauthenticatedSpotifyService) CreatePlaylist(name, description str
 	return nil, fmt.Errorf("not authenticated")
 }
 
+func (m *MockUnauthenticatedSpotifyService) GetPlaylistTracks(playlistID string) ([]types.Track, error) {
+	return nil, fmt.Errorf("not authenticated")
+}
+
+func (m *MockUnauthenticatedSpotifyService) GetPlaylistItems(playlistID string) ([]types.PlaylistItem, error) {
+	return nil, fmt.Errorf("not authenticated")
+}
+
+func (m *MockUnauthenticatedSpotifyService) RemoveTracksFromPlaylist(playlistID string, trackIDs []string) error {
+	return fmt.Errorf("not authenticated")
+}
+
+func (m *MockUnauthenticatedSpotifyService) RemoveTrackPositions(playlistID string, positions map[string][]int) error {
+	return fmt.Errorf("not authenticated")
+}
+
+func (m *MockUnauthenticatedSpotifyService) ReorderPlaylist(playlistID string, rangeStart, rangeLength, insertBefore int) error {
+	return fmt.Errorf("not authenticated")
+}
+
+func (m *MockUnauthenticatedSpotifyService) UpdatePlaylist(playlistID string, update types.PlaylistUpdate) error {
+	return fmt.Errorf("not authenticated")
+}
+
+func (m *MockUnauthenticatedSpotifyService) SetPlaylistCover(playlistID string, jpeg []byte) error {
+	return fmt.Errorf("not authenticated")
+}
+
+func (m *MockUnauthenticatedSpotifyService) DeletePlaylist(playlistID string) error {
+	return fmt.Errorf("not authenticated")
+}
+
 func TestGetOrCreateMonthlyPlaylist(t *testing.T) {
 	mockSpotify := &MockSpotifyServiceForSync{
 		playlists: []types.Playlist{
@@ -221,7 +297,7 @@ func TestRunSingleSyncIntegration(t *testing.T) {
 
 	// Test single sync operation
 	assert.NotPanics(t, func() {
-		runSingleSync(mockKMHD, mockSpotify, fuzzySongSearcher, targetPlaylist, seenSongs)
+		runSingleSync(mockKMHD, fuzzySongSearcher, []syncTarget{{Service: mockSpotify, Playlist: targetPlaylist}}, seenSongs)
 	})
 
 	// Verify songs were marked as seen
@@ -290,7 +366,7 @@ func TestSyncWithAPIUnavailable(t *testing.T) {
 
 	// Should not panic when API is unavailable
 	assert.NotPanics(t, func() {
-		runSingleSync(mockKMHD, mockSpotify, fuzzySongSearcher, targetPlaylist, seenSongs)
+		runSingleSync(mockKMHD, fuzzySongSearcher, []syncTarget{{Service: mockSpotify, Playlist: targetPlaylist}}, seenSongs)
 	})
 
 	// No songs should be processed
@@ -389,7 +465,7 @@ func TestAPIToSpotifyEndToEnd(t *testing.T) {
 	// Test the complete sync flow
 	// Note: This test may fail if the API is unavailable, which is expected
 	assert.NotPanics(t, func() {
-		runSingleSync(apiClient, mockSpotify, fuzzySongSearcher, targetPlaylist, seenSongs)
+		runSingleSync(apiClient, fuzzySongSearcher, []syncTarget{{Service: mockSpotify, Playlist: targetPlaylist}}, seenSongs)
 	})
 
 	// If the API was available and returned data, verify the integration worked
@@ -472,11 +548,11 @@ func TestAPIErrorHandling(t *testing.T) {
 
 			if tt.expectPanic {
 				assert.Panics(t, func() {
-					runSingleSync(mockKMHD, mockSpotify, fuzzySongSearcher, targetPlaylist, seenSongs)
+					runSingleSync(mockKMHD, fuzzySongSearcher, []syncTarget{{Service: mockSpotify, Playlist: targetPlaylist}}, seenSongs)
 				})
 			} else {
 				assert.NotPanics(t, func() {
-					runSingleSync(mockKMHD, mockSpotify, fuzzySongSearcher, targetPlaylist, seenSongs)
+					runSingleSync(mockKMHD, fuzzySongSearcher, []syncTarget{{Service: mockSpotify, Playlist: targetPlaylist}}, seenSongs)
 				})
 
 				// Should not process any songs when API fails
@@ -529,3 +605,113 @@ func TestSongExtractionEquivalence(t *testing.T) {
 		assert.NotEmpty(t, song.Title, "Song %d should have title", i)
 	}
 }
+
+// MockBreakerKMHDScraper reports an open circuit breaker
+type MockBreakerKMHDScraper struct {
+	MockKMHDScraperWithError
+	openUntil time.Time
+}
+
+func (m *MockBreakerKMHDScraper) CircuitOpenUntil() time.Time {
+	return m.openUntil
+}
+
+func TestKMHDBreakerWait(t *testing.T) {
+	// Scrapers without a circuit breaker never delay the loop
+	assert.Equal(t, time.Duration(0), kmhdBreakerWait(&MockKMHDScraper{}))
+
+	// A closed breaker does not delay the loop
+	assert.Equal(t, time.Duration(0), kmhdBreakerWait(&MockBreakerKMHDScraper{}))
+
+	// An open breaker delays the loop until it allows a new attempt
+	wait := kmhdBreakerWait(&MockBreakerKMHDScraper{openUntil: time.Now().Add(2 * time.Hour)})
+	assert.Greater(t, wait, time.Hour+59*time.Minute)
+	assert.LessOrEqual(t, wait, 2*time.Hour)
+}
+
+func TestHandleSpotifyCallback(t *testing.T) {
+	const expectedState = "expected-state"
+
+	tests := []struct {
+		name         string
+		query        string
+		completeErr  error
+		wantStatus   int
+		wantCode     string
+		wantSignal   bool
+		wantSignalOK bool
+	}{
+		{
+			name:         "valid callback",
+			query:        "code=real-code&state=expected-state",
+			wantStatus:   http.StatusOK,
+			wantCode:     "real-code",
+			wantSignal:   true,
+			wantSignalOK: true,
+		},
+		{
+			name:       "forged state",
+			query:      "code=attacker-code&state=forged",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "missing state",
+			query:      "code=attacker-code",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "forged error does not abort authentication",
+			query:      "error=access_denied&state=forged",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "denied access",
+			query:      "error=access_denied&state=expected-state",
+			wantStatus: http.StatusBadRequest,
+			wantSignal: true,
+		},
+		{
+			name:       "missing code",
+			query:      "state=expected-state",
+			wantStatus: http.StatusBadRequest,
+			wantSignal: true,
+		},
+		{
+			name:        "exchange failure",
+			query:       "code=real-code&state=expected-state",
+			completeErr: errors.New("exchange failed"),
+			wantStatus:  http.StatusInternalServerError,
+			wantCode:    "real-code",
+			wantSignal:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &MockAuthSpotifyService{completeErr: tt.completeErr}
+			authComplete := make(chan error, 1)
+			req := httptest.NewRequest(http.MethodGet, "/callback?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			handleSpotifyCallback(rec, req, mock, expectedState, authComplete)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantCode, mock.gotCode)
+			if tt.wantCode != "" {
+				assert.Equal(t, expectedState, mock.gotState)
+			}
+
+			select {
+			case err := <-authComplete:
+				require.True(t, tt.wantSignal, "unexpected authentication result: %v", err)
+				if tt.wantSignalOK {
+					assert.NoError(t, err)
+				} else {
+					assert.Error(t, err)
+				}
+			default:
+				assert.False(t, tt.wantSignal, "expected an authentication result")
+			}
+		})
+	}
+}
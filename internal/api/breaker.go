@@ -0,0 +1,107 @@
+package api
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/toozej/kmhd2spotify/pkg/config"
+)
+
+// ErrCircuitOpen is returned when a fetch is skipped because the circuit breaker is open.
+var ErrCircuitOpen = errors.New("KMHD API circuit breaker is open")
+
+// CircuitBreaker stops requests to the KMHD API after repeated failures so that a
+// station outage does not get hammered on every sync cycle.
+//
+// The breaker opens after Threshold consecutive failures and stays open for the
+// cooldown period. Once the cooldown has elapsed a single trial request is allowed;
+// if it fails the breaker re-opens with a doubled cooldown (up to MaxCooldown),
+// and if it succeeds the breaker closes and the cooldown is reset.
+type CircuitBreaker struct {
+	mu                  sync.Mutex
+	clock               Clock
+	threshold           int
+	baseCooldown        time.Duration
+	maxCooldown         time.Duration
+	cooldown            time.Duration
+	consecutiveFailures int
+	openUntil           time.Time
+}
+
+// NewCircuitBreaker creates a circuit breaker from the KMHD configuration.
+func NewCircuitBreaker(cfg config.KMHDConfig, clock Clock) *CircuitBreaker {
+	threshold := cfg.BreakerThreshold
+	if threshold <= 0 {
+		threshold = 3
+	}
+
+	cooldown := cfg.BreakerCooldown
+	if cooldown <= 0 {
+		cooldown = 30 * time.Minute
+	}
+
+	maxCooldown := cfg.BreakerMaxCooldown
+	if maxCooldown < cooldown {
+		maxCooldown = cooldown
+	}
+
+	return &CircuitBreaker{
+		clock:        clock,
+		threshold:    threshold,
+		baseCooldown: cooldown,
+		maxCooldown:  maxCooldown,
+		cooldown:     cooldown,
+	}
+}
+
+// Allow reports whether a request may be made now.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.clock.Now().Before(b.openUntil)
+}
+
+// RecordSuccess closes the breaker and resets its failure count and cooldown.
+func (b *CircuitBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.consecutiveFailures = 0
+	b.cooldown = b.baseCooldown
+	b.openUntil = time.Time{}
+}
+
+// RecordFailure counts a failed fetch and opens the breaker once the threshold is reached.
+// It returns true if this failure opened the breaker.
+func (b *CircuitBreaker) RecordFailure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.consecutiveFailures++
+	if b.consecutiveFailures < b.threshold {
+		return false
+	}
+
+	// A failed trial request after a previous trip backs off further
+	if b.consecutiveFailures > b.threshold {
+		b.cooldown *= 2
+		if b.cooldown > b.maxCooldown {
+			b.cooldown = b.maxCooldown
+		}
+	}
+
+	b.openUntil = b.clock.Now().Add(b.cooldown)
+	return true
+}
+
+// OpenUntil returns the time until which the breaker is open, or the zero time if it is closed.
+func (b *CircuitBreaker) OpenUntil() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.clock.Now().Before(b.openUntil) {
+		return b.openUntil
+	}
+	return time.Time{}
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/pkg/config"
+)
+
+func TestCircuitBreaker_OpensAfterThreshold(t *testing.T) {
+	clock := newFakeClock()
+	breaker := NewCircuitBreaker(config.KMHDConfig{
+		BreakerThreshold:   3,
+		BreakerCooldown:    10 * time.Minute,
+		BreakerMaxCooldown: time.Hour,
+	}, clock)
+
+	assert.True(t, breaker.Allow())
+	assert.False(t, breaker.RecordFailure())
+	assert.False(t, breaker.RecordFailure())
+	assert.True(t, breaker.Allow())
+
+	assert.True(t, breaker.RecordFailure())
+	assert.False(t, breaker.Allow())
+	assert.Equal(t, clock.Now().Add(10*time.Minute), breaker.OpenUntil())
+
+	clock.Advance(10 * time.Minute)
+	assert.True(t, breaker.Allow(), "a trial request is allowed after the cooldown")
+	assert.True(t, breaker.OpenUntil().IsZero())
+}
+
+func TestCircuitBreaker_BacksOffOnFailedTrials(t *testing.T) {
+	clock := newFakeClock()
+	breaker := NewCircuitBreaker(config.KMHDConfig{
+		BreakerThreshold:   1,
+		BreakerCooldown:    10 * time.Minute,
+		BreakerMaxCooldown: 25 * time.Minute,
+	}, clock)
+
+	breaker.RecordFailure()
+	assert.Equal(t, clock.Now().Add(10*time.Minute), breaker.OpenUntil())
+
+	clock.Advance(10 * time.Minute)
+	breaker.RecordFailure()
+	assert.Equal(t, clock.Now().Add(20*time.Minute), breaker.OpenUntil())
+
+	clock.Advance(20 * time.Minute)
+	breaker.RecordFailure()
+	assert.Equal(t, clock.Now().Add(25*time.Minute), breaker.OpenUntil(), "cooldown is capped")
+
+	clock.Advance(25 * time.Minute)
+	breaker.RecordSuccess()
+	assert.True(t, breaker.Allow())
+
+	breaker.RecordFailure()
+	assert.Equal(t, clock.Now().Add(10*time.Minute), breaker.OpenUntil(), "cooldown resets after success")
+}
+
+func TestNewCircuitBreaker_Defaults(t *testing.T) {
+	clock := newFakeClock()
+	breaker := NewCircuitBreaker(config.KMHDConfig{}, clock)
+
+	assert.Equal(t, 3, breaker.threshold)
+	assert.Equal(t, 30*time.Minute, breaker.baseCooldown)
+	assert.Equal(t, 30*time.Minute, breaker.maxCooldown)
+}
+
+func TestFetchPlaylist_CircuitBreakerSkipsRequests(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	clock := newFakeClock()
+	client := createTestClientWithClock(config.KMHDConfig{
+		APIEndpoint:        server.URL,
+		RetryAttempts:      1,
+		BreakerThreshold:   2,
+		BreakerCooldown:    time.Hour,
+		BreakerMaxCooldown: time.Hour,
+	}, clock)
+
+	for i := 0; i < 2; i++ {
+		_, err := client.FetchPlaylist(clock.Now())
+		require.Error(t, err)
+		assert.False(t, errors.Is(err, ErrCircuitOpen))
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	assert.Equal(t, clock.Now().Add(time.Hour), client.CircuitOpenUntil())
+
+	// The breaker is open, so no request reaches the server
+	_, err := client.FetchPlaylist(clock.Now())
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	// After the cooldown a trial request is made again
+	clock.Advance(time.Hour)
+	_, err = client.FetchPlaylist(clock.Now())
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, ErrCircuitOpen))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
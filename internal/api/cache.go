@@ -0,0 +1,124 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CacheEntry is a cached KMHD playlist response for a single Pacific day.
+type CacheEntry struct {
+	// Day is the Pacific calendar day of the playlist in YYYY-MM-DD format.
+	Day string `json:"day"`
+	// ETag is the entity tag returned by the server, if any.
+	ETag string `json:"etag,omitempty"`
+	// LastModified is the Last-Modified header returned by the server, if any.
+	LastModified string `json:"last_modified,omitempty"`
+	// FetchedAt is when the response was last fetched or revalidated.
+	FetchedAt time.Time `json:"fetched_at"`
+	// Complete is true once the playlist was fetched after the day ended, so it can no longer change.
+	Complete bool `json:"complete"`
+	// Body is the raw JSON response body.
+	Body json.RawMessage `json:"body"`
+}
+
+// ResponseCache stores KMHD playlist responses on disk, one file per Pacific day.
+type ResponseCache struct {
+	dir    string
+	maxAge time.Duration
+}
+
+// NewResponseCache creates a response cache in the given directory, creating it if needed.
+// Entries not written for longer than maxAge are removed by Prune; zero keeps them forever.
+func NewResponseCache(dir string, maxAge time.Duration) (*ResponseCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory %s: %w", dir, err)
+	}
+	return &ResponseCache{dir: dir, maxAge: maxAge}, nil
+}
+
+// Get returns the cached entry for the given day, or nil if there is none.
+func (rc *ResponseCache) Get(day string) (*CacheEntry, error) {
+	data, err := os.ReadFile(rc.path(day))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read cache entry for %s: %w", day, err)
+	}
+
+	var entry CacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("failed to parse cache entry for %s: %w", day, err)
+	}
+	return &entry, nil
+}
+
+// Put stores the entry for its day, replacing any previous entry.
+func (rc *ResponseCache) Put(entry *CacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+
+	// Write to temporary file first, then rename for atomic operation
+	path := rc.path(entry.Day)
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	if err := os.Rename(tempFile, path); err != nil {
+		_ = os.Remove(tempFile) // Clean up temp file
+		return fmt.Errorf("failed to rename cache entry: %w", err)
+	}
+
+	return nil
+}
+
+// Prune removes the entries that were last written more than the maximum age before now,
+// so the cache doesn't grow without bound. It returns how many entries were removed.
+func (rc *ResponseCache) Prune(now time.Time) (int, error) {
+	if rc.maxAge <= 0 {
+		return 0, nil
+	}
+
+	files, err := os.ReadDir(rc.dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read cache directory %s: %w", rc.dir, err)
+	}
+
+	cutoff := now.Add(-rc.maxAge)
+	removed := 0
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(rc.dir, file.Name())); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("failed to remove cache entry %s: %w", file.Name(), err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// path returns the file path for a day's cache entry.
+func (rc *ResponseCache) path(day string) string {
+	return filepath.Join(rc.dir, day+".json")
+}
+
+// isDayComplete reports whether the Pacific day has ended at the given time.
+func isDayComplete(day string, now time.Time) bool {
+	start, err := time.ParseInLocation("2006-01-02", day, pacificLocation())
+	if err != nil {
+		return false
+	}
+	return !now.Before(start.AddDate(0, 0, 1))
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/pkg/config"
+)
+
+const cacheTestResponse = `[{"artistName":"Miles Davis","trackName":"So What","_start_time":"2025-10-18T12:00:00-07:00"}]`
+
+func newCachedTestClient(t *testing.T, serverURL string, clock *fakeClock) *KMHDAPIClient {
+	t.Helper()
+
+	cache, err := NewResponseCache(t.TempDir(), 0)
+	require.NoError(t, err)
+
+	client := createTestClientWithClock(config.KMHDConfig{APIEndpoint: serverURL, RetryAttempts: 1}, clock)
+	client.SetCache(cache)
+	return client
+}
+
+func TestResponseCache_GetPut(t *testing.T) {
+	cache, err := NewResponseCache(t.TempDir(), 0)
+	require.NoError(t, err)
+
+	entry, err := cache.Get("2025-10-18")
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+
+	require.NoError(t, cache.Put(&CacheEntry{
+		Day:  "2025-10-18",
+		ETag: `"abc"`,
+		Body: []byte(cacheTestResponse),
+	}))
+
+	entry, err = cache.Get("2025-10-18")
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Equal(t, `"abc"`, entry.ETag)
+	assert.JSONEq(t, cacheTestResponse, string(entry.Body))
+}
+
+func TestResponseCache_Prune(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewResponseCache(dir, 24*time.Hour)
+	require.NoError(t, err)
+
+	now := time.Now()
+	for _, day := range []string{"2025-10-16", "2025-10-17", "2025-10-18"} {
+		require.NoError(t, cache.Put(&CacheEntry{Day: day, Body: []byte(cacheTestResponse)}))
+	}
+	old := now.Add(-48 * time.Hour)
+	require.NoError(t, os.Chtimes(cache.path("2025-10-16"), old, old))
+	require.NoError(t, os.Chtimes(cache.path("2025-10-17"), old, old))
+
+	removed, err := cache.Prune(now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+
+	entry, err := cache.Get("2025-10-16")
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+	entry, err = cache.Get("2025-10-18")
+	require.NoError(t, err)
+	assert.NotNil(t, entry)
+
+	// Without a maximum age, entries are kept forever
+	unbounded, err := NewResponseCache(dir, 0)
+	require.NoError(t, err)
+	require.NoError(t, os.Chtimes(cache.path("2025-10-18"), old, old))
+	removed, err = unbounded.Prune(now)
+	require.NoError(t, err)
+	assert.Zero(t, removed)
+}
+
+func TestIsDayComplete(t *testing.T) {
+	pacific := pacificLocation()
+
+	assert.False(t, isDayComplete("2025-10-18", time.Date(2025, 10, 18, 23, 59, 0, 0, pacific)))
+	assert.True(t, isDayComplete("2025-10-18", time.Date(2025, 10, 19, 0, 0, 0, 0, pacific)))
+	// 06:30 UTC on the 19th is still the 18th in Pacific time
+	assert.False(t, isDayComplete("2025-10-18", time.Date(2025, 10, 19, 6, 30, 0, 0, time.UTC)))
+	assert.False(t, isDayComplete("not-a-day", time.Now()))
+}
+
+func TestFetchPlaylist_ConditionalRequests(t *testing.T) {
+	var requests, notModified int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Sat, 18 Oct 2025 19:00:00 GMT")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(cacheTestResponse))
+	}))
+	defer server.Close()
+
+	clock := newFakeClock()
+	clock.now = time.Date(2025, 10, 18, 12, 0, 0, 0, pacificLocation())
+	client := newCachedTestClient(t, server.URL, clock)
+
+	first, err := client.FetchPlaylist(clock.Now())
+	require.NoError(t, err)
+	assert.Len(t, first.Songs, 1)
+
+	clock.Advance(time.Hour)
+	second, err := client.FetchPlaylist(clock.Now())
+	require.NoError(t, err)
+	assert.Len(t, second.Songs, 1)
+	assert.Equal(t, "Miles Davis", second.Songs[0].Artist)
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&notModified))
+}
+
+func TestFetchPlaylist_CompletedDaysAreNotRefetched(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(cacheTestResponse))
+	}))
+	defer server.Close()
+
+	clock := newFakeClock()
+	clock.now = time.Date(2025, 10, 18, 22, 0, 0, 0, pacificLocation())
+	client := newCachedTestClient(t, server.URL, clock)
+	day := time.Date(2025, 10, 18, 12, 0, 0, 0, pacificLocation())
+
+	// Fetched before midnight: the day is still in progress and has no validators, so it is re-downloaded
+	_, err := client.FetchPlaylist(day)
+	require.NoError(t, err)
+	_, err = client.FetchPlaylist(day)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	// Fetched after midnight: the day is complete and cached permanently
+	clock.Advance(3 * time.Hour)
+	_, err = client.FetchPlaylist(day)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+
+	collection, err := client.FetchPlaylist(day)
+	require.NoError(t, err)
+	assert.Equal(t, "kmhd_cache", collection.Source)
+	assert.Len(t, collection.Songs, 1)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
+
+func TestFetchPlaylist_InvalidResponsesAreNotCached(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"invalid": json}`))
+	}))
+	defer server.Close()
+
+	clock := newFakeClock()
+	client := newCachedTestClient(t, server.URL, clock)
+
+	_, err := client.FetchPlaylist(clock.Now())
+	require.Error(t, err)
+
+	entry, err := client.cache.Get(pacificDay(clock.Now()))
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+}
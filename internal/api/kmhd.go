@@ -7,6 +7,7 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"runtime"
@@ -15,6 +16,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/toozej/kmhd2spotify/internal/metrics"
 	"github.com/toozej/kmhd2spotify/internal/types"
 	"github.com/toozej/kmhd2spotify/pkg/config"
 	"github.com/toozej/kmhd2spotify/pkg/useragent"
@@ -22,9 +24,13 @@ import (
 
 // KMHDAPIClient handles fetching and parsing of KMHD JSON API data.
 type KMHDAPIClient struct {
-	baseURL    string
-	httpClient *http.Client
-	logger     *log.Entry
+	baseURL     string
+	httpClient  *http.Client
+	logger      *log.Entry
+	clock       Clock
+	retryPolicy RetryPolicy
+	breaker     *CircuitBreaker
+	cache       *ResponseCache
 }
 
 // CompleteTrack represents a track object with full iTunes metadata from the JSON API.
@@ -64,6 +70,12 @@ type APIResponse []json.RawMessage
 
 // NewKMHDAPIClient creates a new KMHD API client instance.
 func NewKMHDAPIClient(cfg config.KMHDConfig) *KMHDAPIClient {
+	return NewKMHDAPIClientWithClock(cfg, realClock{})
+}
+
+// NewKMHDAPIClientWithClock creates a new KMHD API client instance that uses the given
+// clock for retry delays and circuit breaker timing.
+func NewKMHDAPIClientWithClock(cfg config.KMHDConfig, clock Clock) *KMHDAPIClient {
 	// Use the API endpoint from config
 	apiEndpoint := cfg.APIEndpoint
 	if apiEndpoint == "" {
@@ -81,14 +93,37 @@ func NewKMHDAPIClient(cfg config.KMHDConfig) *KMHDAPIClient {
 		httpClient: &http.Client{
 			Timeout: timeout,
 		},
-		logger: log.WithField("component", "kmhd_api_client"),
+		logger:      log.WithField("component", "kmhd_api_client"),
+		clock:       clock,
+		retryPolicy: NewRetryPolicy(cfg),
+		breaker:     NewCircuitBreaker(cfg, clock),
 	}
 }
 
 // FetchPlaylist fetches playlist data from the KMHD JSON API for the specified date.
+//
+// When a response cache is configured, playlists of completed Pacific days are served
+// from the cache without a request, and the current day's playlist is revalidated with
+// If-None-Match/If-Modified-Since when the server provided validators.
 func (c *KMHDAPIClient) FetchPlaylist(date time.Time) (*types.SongCollection, error) {
 	c.logger.Info("Fetching playlist from KMHD JSON API")
 
+	day := pacificDay(date)
+	cached := c.getCachedEntry(day)
+	if cached != nil && cached.Complete {
+		c.logger.WithField("day", day).Info("Using cached playlist for completed day")
+		metrics.ObserveKMHDFetch("cache", 0)
+		return c.parseBody(cached.Body, "kmhd_cache", 0)
+	}
+
+	// Skip the request entirely while the circuit breaker is open
+	if !c.breaker.Allow() {
+		openUntil := c.breaker.OpenUntil()
+		c.logger.WithField("open_until", openUntil.Format(time.RFC3339)).Warn("KMHD API circuit breaker is open, skipping fetch")
+		metrics.ObserveKMHDFetch("circuit_open", 0)
+		return nil, fmt.Errorf("%w until %s", ErrCircuitOpen, openUntil.Format(time.RFC3339))
+	}
+
 	// Build the API URL with date parameter
 	apiURL := c.buildAPIURL(date)
 	c.logger.Debugf("API URL: %s", apiURL)
@@ -107,6 +142,16 @@ func (c *KMHDAPIClient) FetchPlaylist(date time.Time) (*types.SongCollection, er
 	req.Header.Set("User-Agent", userAgentString)
 	req.Header.Set("Referer", c.baseURL)
 
+	// Revalidate a cached response instead of downloading it again
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
 	c.logger.WithFields(log.Fields{
 		"user_agent": userAgentString,
 		"os":         runtime.GOOS,
@@ -114,66 +159,77 @@ func (c *KMHDAPIClient) FetchPlaylist(date time.Time) (*types.SongCollection, er
 	}).Debug("Making API request with platform-appropriate user agent")
 
 	// Make the HTTP request with retry logic for better Docker container reliability
-	var resp *http.Response
-	var requestDuration time.Duration
-	maxRetries := 3
-
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		attemptStart := time.Now()
-		resp, err = c.httpClient.Do(req) // #nosec G704 -- URL is hardcoded KMHD API endpoint
-		requestDuration = time.Since(attemptStart)
-
-		if err == nil && resp.StatusCode != http.StatusBadGateway && resp.StatusCode != http.StatusGatewayTimeout {
-			// Success or non-retryable error
-			break
-		}
-
-		if resp != nil {
-			_ = resp.Body.Close()
-		}
-
-		if attempt < maxRetries {
-			waitTime := time.Duration(attempt) * 2 * time.Second // Exponential backoff: 2s, 4s
-			c.logger.WithFields(log.Fields{
-				"attempt":     attempt,
-				"max_retries": maxRetries,
-				"wait_time":   waitTime,
-				"error":       err,
-				"status_code": func() int {
-					if resp != nil {
-						return resp.StatusCode
-					}
-					return 0
-				}(),
-			}).Warn("API request failed, retrying...")
-			time.Sleep(waitTime)
-		}
-	}
-
+	resp, requestDuration, attempts, err := c.doWithRetry(req)
 	if err != nil {
+		metrics.ObserveKMHDFetch("error", requestDuration)
+		c.breaker.RecordFailure()
 		c.logger.WithFields(log.Fields{
 			"duration_ms": requestDuration.Milliseconds(),
-			"attempts":    maxRetries,
+			"attempts":    attempts,
 			"error":       err.Error(),
 		}).Error("API request failed after all retries")
-		return nil, fmt.Errorf("failed to make HTTP request after %d attempts: %w", maxRetries, err)
+		return nil, fmt.Errorf("failed to make HTTP request after %d attempts: %w", attempts, err)
 	}
 	defer resp.Body.Close()
+	metrics.ObserveKMHDFetch(metrics.StatusLabel(resp.StatusCode), requestDuration)
+
+	// The cached response is still current
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		c.breaker.RecordSuccess()
+		c.logger.WithFields(log.Fields{
+			"day":         day,
+			"duration_ms": requestDuration.Milliseconds(),
+		}).Info("KMHD playlist not modified, using cached response")
+
+		cached.FetchedAt = c.clock.Now()
+		cached.Complete = isDayComplete(day, cached.FetchedAt)
+		c.putCachedEntry(cached)
+		return c.parseBody(cached.Body, "kmhd_api", requestDuration)
+	}
 
 	// Check response status
 	if resp.StatusCode != http.StatusOK {
+		c.breaker.RecordFailure()
 		c.logger.WithFields(log.Fields{
 			"status_code": resp.StatusCode,
 			"status":      resp.Status,
+			"attempts":    attempts,
 			"duration_ms": requestDuration.Milliseconds(),
 		}).Error("API returned non-200 status code")
 		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, resp.Status)
 	}
+	c.breaker.RecordSuccess()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.logger.WithError(err).Error("Failed to read response body from API")
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	collection, err := c.parseBody(body, "kmhd_api", requestDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only cache responses that parsed successfully
+	fetchedAt := c.clock.Now()
+	c.putCachedEntry(&CacheEntry{
+		Day:          day,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FetchedAt:    fetchedAt,
+		Complete:     isDayComplete(day, fetchedAt),
+		Body:         body,
+	})
 
+	return collection, nil
+}
+
+// parseBody decodes a raw KMHD API response body into a song collection.
+func (c *KMHDAPIClient) parseBody(body []byte, source string, requestDuration time.Duration) (*types.SongCollection, error) {
 	// Parse the JSON response
 	var apiResponse APIResponse
-	decoder := json.NewDecoder(resp.Body)
-	if err := decoder.Decode(&apiResponse); err != nil {
+	if err := json.Unmarshal(body, &apiResponse); err != nil {
 		c.logger.WithFields(log.Fields{
 			"duration_ms": requestDuration.Milliseconds(),
 			"error":       err.Error(),
@@ -184,6 +240,7 @@ func (c *KMHDAPIClient) FetchPlaylist(date time.Time) (*types.SongCollection, er
 	c.logger.WithFields(log.Fields{
 		"track_count": len(apiResponse),
 		"duration_ms": requestDuration.Milliseconds(),
+		"source":      source,
 	}).Info("Successfully received API response")
 
 	// Parse the response into a song collection
@@ -192,23 +249,114 @@ func (c *KMHDAPIClient) FetchPlaylist(date time.Time) (*types.SongCollection, er
 		c.logger.WithError(err).Error("Failed to parse API response into song collection")
 		return nil, fmt.Errorf("failed to parse API response: %w", err)
 	}
+	collection.Source = source
 
 	c.logger.Infof("Successfully parsed %d songs from KMHD API", len(collection.Songs))
 	return collection, nil
 }
 
+// SetCache configures a response cache used for conditional requests and for
+// serving completed days without a request. A nil cache disables caching.
+func (c *KMHDAPIClient) SetCache(cache *ResponseCache) {
+	c.cache = cache
+}
+
+// getCachedEntry returns the cached response for a day, or nil if caching is disabled or there is none.
+func (c *KMHDAPIClient) getCachedEntry(day string) *CacheEntry {
+	if c.cache == nil {
+		return nil
+	}
+
+	entry, err := c.cache.Get(day)
+	if err != nil {
+		c.logger.WithError(err).WithField("day", day).Warn("Failed to read cached KMHD response, ignoring cache")
+		return nil
+	}
+	return entry
+}
+
+// putCachedEntry stores a response in the cache, if caching is enabled.
+func (c *KMHDAPIClient) putCachedEntry(entry *CacheEntry) {
+	if c.cache == nil {
+		return
+	}
+
+	if err := c.cache.Put(entry); err != nil {
+		c.logger.WithError(err).WithField("day", entry.Day).Warn("Failed to cache KMHD response")
+		return
+	}
+
+	c.logger.WithFields(log.Fields{
+		"day":      entry.Day,
+		"complete": entry.Complete,
+		"etag":     entry.ETag,
+	}).Debug("Cached KMHD response")
+
+	removed, err := c.cache.Prune(c.clock.Now())
+	if err != nil {
+		c.logger.WithError(err).Warn("Failed to prune KMHD response cache")
+		return
+	}
+	if removed > 0 {
+		c.logger.WithField("removed", removed).Debug("Pruned expired KMHD responses from the cache")
+	}
+}
+
+// doWithRetry performs the request, retrying network errors and retryable status codes
+// according to the client's retry policy. It returns the final response, the duration of
+// the last attempt, and the number of attempts made.
+func (c *KMHDAPIClient) doWithRetry(req *http.Request) (*http.Response, time.Duration, int, error) {
+	var resp *http.Response
+	var err error
+	var requestDuration time.Duration
+
+	for attempt := 1; attempt <= c.retryPolicy.Attempts; attempt++ {
+		attemptStart := c.clock.Now()
+		resp, err = c.httpClient.Do(req) // #nosec G704 -- URL is hardcoded KMHD API endpoint
+		requestDuration = c.clock.Now().Sub(attemptStart)
+
+		if !c.retryPolicy.IsRetryable(resp, err) {
+			// Success or non-retryable error
+			return resp, requestDuration, attempt, nil
+		}
+
+		if attempt == c.retryPolicy.Attempts {
+			break
+		}
+
+		waitTime := c.retryPolicy.Delay(attempt, resp, c.clock.Now())
+		statusCode := 0
+		if resp != nil {
+			statusCode = resp.StatusCode
+			_ = resp.Body.Close()
+		}
+
+		c.logger.WithFields(log.Fields{
+			"attempt":     attempt,
+			"max_retries": c.retryPolicy.Attempts,
+			"wait_time":   waitTime,
+			"error":       err,
+			"status_code": statusCode,
+		}).Warn("API request failed, retrying...")
+		c.clock.Sleep(waitTime)
+	}
+
+	// The final attempt still failed: hand back a retryable status response so the
+	// caller can report it, or the network error
+	return resp, requestDuration, c.retryPolicy.Attempts, err
+}
+
+// CircuitOpenUntil returns the time until which fetches are suspended by the circuit
+// breaker, or the zero time if fetches are allowed.
+func (c *KMHDAPIClient) CircuitOpenUntil() time.Time {
+	return c.breaker.OpenUntil()
+}
+
 // buildAPIURL constructs the API URL with the date parameter.
 func (c *KMHDAPIClient) buildAPIURL(date time.Time) string {
 	// Convert to Pacific Time (KMHD's timezone) for consistent API queries
 	// This ensures the correct date is used regardless of container timezone
-	pacificTZ, err := time.LoadLocation("America/Los_Angeles")
-	if err != nil {
-		c.logger.WithError(err).Warn("Failed to load Pacific timezone, using local time")
-		pacificTZ = time.Local
-	}
-
-	// Convert the date to Pacific Time
-	pacificDate := date.In(pacificTZ)
+	pacificDate := date.In(pacificLocation())
 
 	// Format the date as ISO 8601 with timezone offset
 	dateStr := pacificDate.Format("2006-01-02T15:04:05.000-07:00")
@@ -229,6 +377,21 @@ func (c *KMHDAPIClient) buildAPIURL(date time.Time) string {
 	return fullURL
 }
 
+// pacificLocation returns KMHD's timezone, falling back to local time if it cannot be loaded.
+func pacificLocation() *time.Location {
+	pacificTZ, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		log.WithError(err).Warn("Failed to load Pacific timezone, using local time")
+		return time.Local
+	}
+	return pacificTZ
+}
+
+// pacificDay returns the Pacific calendar day of the given time in YYYY-MM-DD format.
+func pacificDay(date time.Time) string {
+	return date.In(pacificLocation()).Format("2006-01-02")
+}
+
 // parseResponse parses the JSON API response into a song collection.
 func (c *KMHDAPIClient) parseResponse(apiResponse APIResponse) (*types.SongCollection, error) {
 	collection := &types.SongCollection{
@@ -263,8 +426,9 @@ func (c *KMHDAPIClient) parseTrackObject(rawTrack json.RawMessage) (*types.Song,
 	if err := json.Unmarshal(rawTrack, &completeTrack); err == nil {
 		// Validate required fields
 		if completeTrack.ArtistName != "" && completeTrack.TrackName != "" {
-			return c.mapTrackToSong(completeTrack.ArtistName, completeTrack.TrackName,
+			song, err := c.mapTrackToSong(completeTrack.ArtistName, completeTrack.TrackName,
 				completeTrack.CollectionName, completeTrack.StartTime, string(rawTrack))
+			return withPlayMetadata(song, err, completeTrack.ID, completeTrack.Duration, completeTrack.PrimaryGenre, completeTrack.ArtworkURL100)
 		}
 	}
 
@@ -273,8 +437,9 @@ func (c *KMHDAPIClient) parseTrackObject(rawTrack json.RawMessage) (*types.Song,
 	if err := json.Unmarshal(rawTrack, &minimalTrack); err == nil {
 		// Validate required fields
 		if minimalTrack.ArtistName != "" && minimalTrack.TrackName != "" {
-			return c.mapTrackToSong(minimalTrack.ArtistName, minimalTrack.TrackName,
+			song, err := c.mapTrackToSong(minimalTrack.ArtistName, minimalTrack.TrackName,
 				minimalTrack.CollectionName, minimalTrack.StartTime, string(rawTrack))
+			return withPlayMetadata(song, err, minimalTrack.ID, minimalTrack.Duration, "", "")
 		}
 	}
 
@@ -289,13 +454,34 @@ func (c *KMHDAPIClient) parseTrackObject(rawTrack json.RawMessage) (*types.Song,
 	trackName, _ := rawMap["trackName"].(string)
 	collectionName, _ := rawMap["collectionName"].(string)
 	startTime, _ := rawMap["_start_time"].(string)
+	id, _ := rawMap["_id"].(string)
+	duration, _ := rawMap["_duration"].(float64)
+	genre, _ := rawMap["primaryGenreName"].(string)
+	artworkURL, _ := rawMap["artworkUrl100"].(string)
 
 	// Validate required fields
 	if artistName == "" || trackName == "" {
 		return nil, fmt.Errorf("missing required fields: artistName=%q, trackName=%q", artistName, trackName)
 	}
 
-	return c.mapTrackToSong(artistName, trackName, collectionName, startTime, string(rawTrack))
+	song, err := c.mapTrackToSong(artistName, trackName, collectionName, startTime, string(rawTrack))
+	return withPlayMetadata(song, err, id, int(duration), genre, artworkURL)
+}
+
+// withPlayMetadata sets the KMHD play ID, duration (in milliseconds), genre and artwork
+// URL on a mapped song.
+func withPlayMetadata(song *types.Song, err error, id string, durationMillis int, genre, artworkURL string) (*types.Song, error) {
+	if err != nil || song == nil {
+		return song, err
+	}
+
+	song.KMHDID = id
+	song.Genre = genre
+	song.ArtworkURL = artworkURL
+	if durationMillis > 0 {
+		song.Duration = time.Duration(durationMillis) * time.Millisecond
+	}
+	return song, nil
 }
 
 // mapTrackToSong converts JSON track data to the existing types.Song structure.
@@ -371,21 +557,7 @@ func (c *KMHDAPIClient) GetCurrentlyPlaying() (*types.Song, error) {
 	}
 
 	// Find the most recent song (closest to current time)
-	var currentSong *types.Song
-	now := time.Now()
-	minTimeDiff := time.Duration(24 * time.Hour) // Start with a large duration
-
-	for i := range collection.Songs {
-		song := &collection.Songs[i]
-		timeDiff := now.Sub(song.PlayedAt)
-
-		// Only consider songs that were played in the past (not future)
-		if timeDiff >= 0 && timeDiff < minTimeDiff {
-			minTimeDiff = timeDiff
-			currentSong = song
-		}
-	}
-
+	currentSong := collection.MostRecentAt(time.Now())
 	if currentSong == nil {
 		// If no past songs found, return the first song as fallback
 		currentSong = &collection.Songs[0]
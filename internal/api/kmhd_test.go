@@ -278,7 +278,7 @@ func TestKMHDAPIClient_parseTrackObject(t *testing.T) {
 	}{
 		{
 			name:        "complete track object",
-			rawJSON:     `{"_id":"123","artistName":"Miles Davis","trackName":"So What","collectionName":"Kind of Blue","_start_time":"2025-10-18T19:53:11Z"}`,
+			rawJSON:     `{"_id":"123","artistName":"Miles Davis","trackName":"So What","collectionName":"Kind of Blue","_start_time":"2025-10-18T19:53:11Z","primaryGenreName":"Jazz","artworkUrl100":"https://is1-ssl.mzstatic.com/image/thumb/kind-of-blue/100x100bb.jpg"}`,
 			expectError: false,
 			validate: func(t *testing.T, client *KMHDAPIClient, rawJSON string) {
 				song, err := client.parseTrackObject(json.RawMessage(rawJSON))
@@ -287,6 +287,8 @@ func TestKMHDAPIClient_parseTrackObject(t *testing.T) {
 				assert.Equal(t, "Miles Davis", song.Artist)
 				assert.Equal(t, "So What", song.Title)
 				assert.Equal(t, "Kind of Blue", song.Album)
+				assert.Equal(t, "Jazz", song.Genre)
+				assert.Equal(t, "https://is1-ssl.mzstatic.com/image/thumb/kind-of-blue/100x100bb.jpg", song.ArtworkURL)
 				assert.True(t, song.IsValid())
 			},
 		},
@@ -598,6 +600,8 @@ func TestKMHDAPIClient_ScrapePlaylist(t *testing.T) {
 	require.NotNil(t, collection)
 	assert.Equal(t, 1, len(collection.Songs))
 	assert.Equal(t, "John Coltrane", collection.Songs[0].Artist)
+	assert.Equal(t, "track1", collection.Songs[0].KMHDID)
+	assert.Equal(t, 3*time.Minute, collection.Songs[0].Duration)
 	assert.Equal(t, "Giant Steps", collection.Songs[0].Title)
 	assert.Equal(t, "Giant Steps", collection.Songs[0].Album)
 	assert.Equal(t, "kmhd_api", collection.Source)
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"crypto/rand"
+	"math/big"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/toozej/kmhd2spotify/pkg/config"
+)
+
+// defaultRetryMaxDelay caps retry delays when no maximum is configured.
+const defaultRetryMaxDelay = 5 * time.Minute
+
+// defaultRetryStatuses are the HTTP status codes retried when none are configured.
+var defaultRetryStatuses = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// Clock abstracts time so retry and circuit breaker behaviour can be tested without real delays.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+// realClock is the Clock backed by the time package.
+type realClock struct{}
+
+// Now returns the current time.
+func (realClock) Now() time.Time { return time.Now() }
+
+// Sleep pauses the current goroutine for the given duration.
+func (realClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// RetryPolicy controls how failed KMHD API requests are retried.
+type RetryPolicy struct {
+	// Attempts is the total number of attempts, including the first request.
+	Attempts int
+	// BaseDelay is the delay before the first retry, doubled for each further retry.
+	BaseDelay time.Duration
+	// Jitter is the maximum random delay added to each retry.
+	Jitter time.Duration
+	// MaxDelay caps every delay, including one requested with Retry-After. Zero means
+	// no cap.
+	MaxDelay time.Duration
+	// RetryableStatuses are the HTTP status codes that are retried.
+	RetryableStatuses map[int]bool
+}
+
+// NewRetryPolicy builds a retry policy from the KMHD configuration, falling back to
+// defaults for unset values.
+func NewRetryPolicy(cfg config.KMHDConfig) RetryPolicy {
+	attempts := cfg.RetryAttempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+
+	statuses := cfg.RetryStatuses
+	if len(statuses) == 0 {
+		statuses = defaultRetryStatuses
+	}
+
+	maxDelay := cfg.RetryMaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultRetryMaxDelay
+	}
+
+	retryable := make(map[int]bool, len(statuses))
+	for _, status := range statuses {
+		retryable[status] = true
+	}
+
+	return RetryPolicy{
+		Attempts:          attempts,
+		BaseDelay:         cfg.RetryBaseDelay,
+		Jitter:            cfg.RetryJitter,
+		MaxDelay:          maxDelay,
+		RetryableStatuses: retryable,
+	}
+}
+
+// IsRetryable reports whether a request that produced the given response and error
+// should be retried. Network errors are always retried.
+func (p RetryPolicy) IsRetryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp != nil && p.RetryableStatuses[resp.StatusCode]
+}
+
+// Delay returns how long to wait after the given failed attempt (1-based) before retrying.
+// A Retry-After header on the response takes precedence over the exponential backoff.
+// Either is capped at MaxDelay, so a bad Retry-After header can't stall the sync.
+func (p RetryPolicy) Delay(attempt int, resp *http.Response, now time.Time) time.Duration {
+	delay := p.delay(attempt, resp, now)
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// delay returns the uncapped delay after the given failed attempt.
+func (p RetryPolicy) delay(attempt int, resp *http.Response, now time.Time) time.Duration {
+	if retryAfter, ok := parseRetryAfter(resp, now); ok {
+		return retryAfter
+	}
+
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	delay := p.BaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+	}
+
+	return delay + randomJitter(p.Jitter)
+}
+
+// parseRetryAfter parses a Retry-After header given in either seconds or as an HTTP date.
+func parseRetryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := date.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+
+	return 0, false
+}
+
+// randomJitter returns a cryptographically random duration in the range [0, max).
+func randomJitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(max)))
+	if err != nil {
+		return 0
+	}
+	return time.Duration(n.Int64())
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/pkg/config"
+)
+
+// fakeClock records sleeps instead of blocking so retry timing can be asserted
+type fakeClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.sleeps = append(c.sleeps, d)
+	c.now = c.now.Add(d)
+}
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func createTestClientWithClock(cfg config.KMHDConfig, clock Clock) *KMHDAPIClient {
+	client := NewKMHDAPIClientWithClock(cfg, clock)
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	client.logger = logger.WithField("component", "kmhd_api_client")
+
+	return client
+}
+
+func TestNewRetryPolicy(t *testing.T) {
+	t.Run("defaults for unset values", func(t *testing.T) {
+		policy := NewRetryPolicy(config.KMHDConfig{})
+		assert.Equal(t, 3, policy.Attempts)
+		assert.Equal(t, 5*time.Minute, policy.MaxDelay)
+		for _, status := range []int{429, 502, 503, 504} {
+			assert.True(t, policy.RetryableStatuses[status], "status %d should be retryable", status)
+		}
+		assert.False(t, policy.RetryableStatuses[500])
+	})
+
+	t.Run("configured values", func(t *testing.T) {
+		policy := NewRetryPolicy(config.KMHDConfig{
+			RetryAttempts:  5,
+			RetryBaseDelay: time.Second,
+			RetryJitter:    time.Millisecond,
+			RetryMaxDelay:  time.Minute,
+			RetryStatuses:  []int{500},
+		})
+		assert.Equal(t, 5, policy.Attempts)
+		assert.Equal(t, time.Second, policy.BaseDelay)
+		assert.Equal(t, time.Millisecond, policy.Jitter)
+		assert.Equal(t, time.Minute, policy.MaxDelay)
+		assert.True(t, policy.RetryableStatuses[500])
+		assert.False(t, policy.RetryableStatuses[502])
+	})
+}
+
+func TestRetryPolicy_IsRetryable(t *testing.T) {
+	policy := NewRetryPolicy(config.KMHDConfig{})
+
+	assert.True(t, policy.IsRetryable(nil, errors.New("connection reset")))
+	assert.True(t, policy.IsRetryable(&http.Response{StatusCode: http.StatusTooManyRequests}, nil))
+	assert.True(t, policy.IsRetryable(&http.Response{StatusCode: http.StatusServiceUnavailable}, nil))
+	assert.False(t, policy.IsRetryable(&http.Response{StatusCode: http.StatusOK}, nil))
+	assert.False(t, policy.IsRetryable(&http.Response{StatusCode: http.StatusNotFound}, nil))
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	now := time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	t.Run("exponential backoff without jitter", func(t *testing.T) {
+		policy := RetryPolicy{BaseDelay: 2 * time.Second}
+		assert.Equal(t, 2*time.Second, policy.Delay(1, nil, now))
+		assert.Equal(t, 4*time.Second, policy.Delay(2, nil, now))
+		assert.Equal(t, 8*time.Second, policy.Delay(3, nil, now))
+	})
+
+	t.Run("jitter stays within bounds", func(t *testing.T) {
+		policy := RetryPolicy{BaseDelay: time.Second, Jitter: 500 * time.Millisecond}
+		for i := 0; i < 20; i++ {
+			delay := policy.Delay(1, nil, now)
+			assert.GreaterOrEqual(t, delay, time.Second)
+			assert.Less(t, delay, 1500*time.Millisecond)
+		}
+	})
+
+	t.Run("retry-after seconds", func(t *testing.T) {
+		policy := RetryPolicy{BaseDelay: time.Second}
+		resp := &http.Response{Header: http.Header{"Retry-After": []string{"30"}}}
+		assert.Equal(t, 30*time.Second, policy.Delay(1, resp, now))
+	})
+
+	t.Run("retry-after date", func(t *testing.T) {
+		policy := RetryPolicy{BaseDelay: time.Second}
+		resp := &http.Response{Header: http.Header{"Retry-After": []string{now.Add(time.Minute).Format(http.TimeFormat)}}}
+		assert.Equal(t, time.Minute, policy.Delay(1, resp, now))
+	})
+
+	t.Run("capped at max delay", func(t *testing.T) {
+		policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Minute}
+		resp := &http.Response{Header: http.Header{"Retry-After": []string{"86400"}}}
+		assert.Equal(t, 5*time.Minute, policy.Delay(1, resp, now))
+
+		resp = &http.Response{Header: http.Header{"Retry-After": []string{now.Add(30 * 24 * time.Hour).Format(http.TimeFormat)}}}
+		assert.Equal(t, 5*time.Minute, policy.Delay(1, resp, now))
+		assert.Equal(t, 5*time.Minute, policy.Delay(20, nil, now))
+	})
+}
+
+func TestFetchPlaylist_RetriesTransientStatuses(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusBadGateway} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&requests, 1) == 1 {
+					w.WriteHeader(status)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(`[{"artistName":"Miles Davis","trackName":"So What"}]`))
+			}))
+			defer server.Close()
+
+			clock := newFakeClock()
+			client := createTestClientWithClock(config.KMHDConfig{
+				APIEndpoint:    server.URL,
+				RetryAttempts:  3,
+				RetryBaseDelay: 2 * time.Second,
+			}, clock)
+
+			collection, err := client.FetchPlaylist(clock.Now())
+			require.NoError(t, err)
+			assert.Len(t, collection.Songs, 1)
+			assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+			assert.Equal(t, []time.Duration{2 * time.Second}, clock.sleeps)
+		})
+	}
+}
+
+func TestFetchPlaylist_GivesUpAfterConfiguredAttempts(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	clock := newFakeClock()
+	client := createTestClientWithClock(config.KMHDConfig{
+		APIEndpoint:    server.URL,
+		RetryAttempts:  4,
+		RetryBaseDelay: time.Second,
+	}, clock)
+
+	_, err := client.FetchPlaylist(clock.Now())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "API returned status 503")
+	assert.Equal(t, int32(4), atomic.LoadInt32(&requests))
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}, clock.sleeps)
+}
+
+func TestFetchPlaylist_RetriesNetworkErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serverURL := server.URL
+	server.Close() // Nothing is listening any more, so every request fails at the transport level
+
+	clock := newFakeClock()
+	client := createTestClientWithClock(config.KMHDConfig{
+		APIEndpoint:    serverURL,
+		RetryAttempts:  2,
+		RetryBaseDelay: time.Second,
+	}, clock)
+
+	_, err := client.FetchPlaylist(clock.Now())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "after 2 attempts")
+	assert.Len(t, clock.sleeps, 1)
+}
+
+func TestFetchPlaylist_DoesNotRetryNonRetryableStatus(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	clock := newFakeClock()
+	client := createTestClientWithClock(config.KMHDConfig{APIEndpoint: server.URL, RetryAttempts: 3}, clock)
+
+	_, err := client.FetchPlaylist(clock.Now())
+	require.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	assert.Empty(t, clock.sleeps)
+}
@@ -0,0 +1,175 @@
+// Package chart ranks the tracks, artists and albums KMHD plays most.
+//
+// Charts are computed from the play history, so every play counts, including repeat
+// plays that are not synced to Spotify again.
+package chart
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/toozej/kmhd2spotify/internal/history"
+)
+
+// Track is a Spotify track and how often KMHD played it.
+type Track struct {
+	TrackID    string    `json:"track_id"`
+	TrackURI   string    `json:"track_uri,omitempty"`
+	Name       string    `json:"name"`
+	Artists    []string  `json:"artists,omitempty"`
+	Album      string    `json:"album,omitempty"`
+	Plays      int       `json:"plays"`
+	LastPlayed time.Time `json:"last_played"`
+}
+
+// Entry is an artist or album and how often KMHD played it.
+type Entry struct {
+	Name       string    `json:"name"`
+	Artist     string    `json:"artist,omitempty"`
+	Plays      int       `json:"plays"`
+	LastPlayed time.Time `json:"last_played"`
+}
+
+// Report is the chart of a period.
+type Report struct {
+	Period       string    `json:"period"`
+	From         time.Time `json:"from"`
+	Until        time.Time `json:"until"`
+	Plays        int       `json:"plays"`
+	MatchedPlays int       `json:"matched_plays"`
+	Tracks       []Track   `json:"tracks"`
+	Artists      []Entry   `json:"artists"`
+	Albums       []Entry   `json:"albums"`
+}
+
+// Build ranks the given plays of a period. Tracks are counted by their Spotify match,
+// so only matched plays count towards them; artists and albums are counted from what
+// KMHD reported. Ties are ranked by the most recent play.
+func Build(period string, from, until time.Time, plays []history.Play) Report {
+	report := Report{Period: period, From: from, Until: until, Plays: len(plays)}
+
+	tracks := make(map[string]*Track)
+	artists := make(map[string]*Entry)
+	albums := make(map[string]*Entry)
+
+	for _, play := range plays {
+		song := play.Song
+		played := song.PlayedAt
+
+		if artist := strings.TrimSpace(song.Artist); artist != "" {
+			count(artists, strings.ToLower(artist), Entry{Name: artist}, played)
+		}
+
+		album := strings.TrimSpace(song.Album)
+		if album == "" && play.Match != nil {
+			album = play.Match.Album
+		}
+		if album != "" {
+			artist := strings.TrimSpace(song.Artist)
+			key := strings.ToLower(album) + "|" + strings.ToLower(artist)
+			count(albums, key, Entry{Name: album, Artist: artist}, played)
+		}
+
+		if play.Match == nil || play.Match.TrackID == "" {
+			continue
+		}
+		report.MatchedPlays++
+
+		track, ok := tracks[play.Match.TrackID]
+		if !ok {
+			name := play.Match.TrackName
+			if name == "" {
+				name = song.Title
+			}
+			trackArtists := play.Match.Artists
+			if len(trackArtists) == 0 {
+				trackArtists = []string{song.Artist}
+			}
+			track = &Track{
+				TrackID:  play.Match.TrackID,
+				TrackURI: play.Match.TrackURI,
+				Name:     name,
+				Artists:  trackArtists,
+				Album:    play.Match.Album,
+			}
+			tracks[play.Match.TrackID] = track
+		}
+		track.Plays++
+		if played.After(track.LastPlayed) {
+			track.LastPlayed = played
+		}
+	}
+
+	report.Tracks = make([]Track, 0, len(tracks))
+	for _, track := range tracks {
+		report.Tracks = append(report.Tracks, *track)
+	}
+	sort.Slice(report.Tracks, func(i, j int) bool {
+		a, b := report.Tracks[i], report.Tracks[j]
+		return ranksBefore(a.Plays, b.Plays, a.LastPlayed, b.LastPlayed, a.TrackID, b.TrackID)
+	})
+
+	report.Artists = ranked(artists)
+	report.Albums = ranked(albums)
+	return report
+}
+
+// Top returns the report with at most n tracks, artists and albums. A non-positive n
+// keeps everything.
+func (r Report) Top(n int) Report {
+	if n <= 0 {
+		return r
+	}
+	r.Tracks = r.Tracks[:min(n, len(r.Tracks))]
+	r.Artists = r.Artists[:min(n, len(r.Artists))]
+	r.Albums = r.Albums[:min(n, len(r.Albums))]
+	return r
+}
+
+// TrackIDs returns the IDs of the ranked tracks, most played first.
+func (r Report) TrackIDs() []string {
+	trackIDs := make([]string, 0, len(r.Tracks))
+	for _, track := range r.Tracks {
+		trackIDs = append(trackIDs, track.TrackID)
+	}
+	return trackIDs
+}
+
+// count adds a play to the entry with the given key, creating it from entry if needed.
+func count(entries map[string]*Entry, key string, entry Entry, played time.Time) {
+	existing, ok := entries[key]
+	if !ok {
+		existing = &entry
+		entries[key] = existing
+	}
+	existing.Plays++
+	if played.After(existing.LastPlayed) {
+		existing.LastPlayed = played
+	}
+}
+
+// ranked returns the entries ordered by play count.
+func ranked(entries map[string]*Entry) []Entry {
+	result := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, *entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		return ranksBefore(a.Plays, b.Plays, a.LastPlayed, b.LastPlayed, a.Name+"|"+a.Artist, b.Name+"|"+b.Artist)
+	})
+	return result
+}
+
+// ranksBefore orders by play count, then most recent play, then name so rankings are
+// stable between updates.
+func ranksBefore(playsA, playsB int, lastA, lastB time.Time, nameA, nameB string) bool {
+	if playsA != playsB {
+		return playsA > playsB
+	}
+	if !lastA.Equal(lastB) {
+		return lastA.After(lastB)
+	}
+	return nameA < nameB
+}
@@ -0,0 +1,73 @@
+package chart
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/history"
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+var baseTime = time.Date(2025, time.October, 17, 12, 0, 0, 0, time.UTC)
+
+func play(artist, title, album, trackID string, offset time.Duration) history.Play {
+	p := history.Play{Song: types.Song{Artist: artist, Title: title, Album: album, PlayedAt: baseTime.Add(offset)}}
+	if trackID != "" {
+		p.Match = &history.Match{TrackID: trackID, TrackName: title, Artists: []string{artist}, Album: album}
+	}
+	return p
+}
+
+func TestBuildRanksByPlays(t *testing.T) {
+	plays := []history.Play{
+		play("Miles Davis", "So What", "Kind of Blue", "so-what", 0),
+		play("John Coltrane", "Giant Steps", "Giant Steps", "giant-steps", time.Hour),
+		play("miles davis", "Freddie Freeloader", "Kind of Blue", "freddie", 2*time.Hour),
+		play("Miles Davis", "So What", "Kind of Blue", "so-what", 3*time.Hour),
+		play("Bill Evans", "Peace Piece", "", "", 4*time.Hour),
+	}
+
+	report := Build("week", baseTime.Add(-7*24*time.Hour), baseTime, plays)
+
+	assert.Equal(t, 5, report.Plays)
+	assert.Equal(t, 4, report.MatchedPlays)
+	assert.Equal(t, []string{"so-what", "freddie", "giant-steps"}, report.TrackIDs())
+	assert.Equal(t, 2, report.Tracks[0].Plays)
+	assert.Equal(t, baseTime.Add(3*time.Hour), report.Tracks[0].LastPlayed)
+
+	require.Len(t, report.Artists, 3)
+	assert.Equal(t, Entry{Name: "Miles Davis", Plays: 3, LastPlayed: baseTime.Add(3 * time.Hour)}, report.Artists[0])
+	assert.Equal(t, "Bill Evans", report.Artists[1].Name, "ties go to the most recent play")
+
+	require.Len(t, report.Albums, 2, "plays without an album are not counted as albums")
+	assert.Equal(t, "Kind of Blue", report.Albums[0].Name)
+	assert.Equal(t, 3, report.Albums[0].Plays, "album artists are compared case-insensitively")
+}
+
+func TestBuildUsesMatchedAlbumWhenKMHDHasNone(t *testing.T) {
+	p := play("Miles Davis", "So What", "", "so-what", 0)
+	p.Match.Album = "Kind of Blue (Legacy Edition)"
+	report := Build("month", time.Time{}, baseTime, []history.Play{p})
+
+	require.Len(t, report.Albums, 1)
+	assert.Equal(t, "Kind of Blue (Legacy Edition)", report.Albums[0].Name)
+}
+
+func TestTop(t *testing.T) {
+	plays := []history.Play{
+		play("A", "One", "X", "1", 0),
+		play("B", "Two", "Y", "2", time.Hour),
+		play("C", "Three", "Z", "3", 2*time.Hour),
+	}
+	report := Build("week", time.Time{}, baseTime, plays)
+
+	top := report.Top(2)
+	assert.Len(t, top.Tracks, 2)
+	assert.Len(t, top.Artists, 2)
+	assert.Len(t, top.Albums, 2)
+	assert.Len(t, report.Tracks, 3, "Top does not modify the original report")
+	assert.Len(t, report.Top(0).Tracks, 3)
+}
@@ -0,0 +1,105 @@
+package cover
+
+import (
+	"context"
+	"fmt"
+	"image"
+	_ "image/jpeg" // register JPEG artwork
+	_ "image/png"  // register PNG artwork
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// maxArtworkSize is the largest artwork download decoded, in bytes.
+	maxArtworkSize = 2 << 20
+
+	// smallArtworkSize and largeArtworkSize are the renditions in Apple artwork URLs, as
+	// reported by KMHD. The small one is too blurry for a collage tile.
+	smallArtworkSize = "100x100bb"
+	largeArtworkSize = "600x600bb"
+)
+
+// Fetcher downloads album artwork for cover collages.
+type Fetcher struct {
+	client  *http.Client
+	timeout time.Duration
+	logger  *log.Entry
+}
+
+// NewFetcher creates an artwork fetcher whose Fetch calls give up after the timeout.
+func NewFetcher(timeout time.Duration) *Fetcher {
+	return &Fetcher{
+		client:  &http.Client{},
+		timeout: timeout,
+		logger:  log.WithField("component", "cover"),
+	}
+}
+
+// Fetch downloads and decodes up to limit images from the URLs, in order. Empty and
+// repeated URLs are skipped, as is artwork that fails to download or decode, so the
+// result is empty when offline. All downloads share the fetcher's timeout, so an
+// unreachable artwork server can't hold up the caller for longer.
+func (f *Fetcher) Fetch(urls []string, limit int) []image.Image {
+	ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+	defer cancel()
+
+	var images []image.Image
+	seen := make(map[string]bool)
+	for _, url := range urls {
+		if len(images) >= limit || ctx.Err() != nil {
+			break
+		}
+		if url == "" || seen[url] {
+			continue
+		}
+		seen[url] = true
+
+		img, err := f.fetchLarge(ctx, url)
+		if err != nil {
+			f.logger.WithError(err).WithField("url", url).Debug("Skipping artwork")
+			continue
+		}
+		images = append(images, img)
+	}
+	return images
+}
+
+// fetchLarge downloads the larger rendition of Apple artwork at the URL, falling back
+// to the URL itself when there is none.
+func (f *Fetcher) fetchLarge(ctx context.Context, url string) (image.Image, error) {
+	if large := strings.Replace(url, smallArtworkSize, largeArtworkSize, 1); large != url {
+		img, err := f.fetch(ctx, large)
+		if err == nil {
+			return img, nil
+		}
+		f.logger.WithError(err).WithField("url", large).Debug("Falling back to small artwork")
+	}
+	return f.fetch(ctx, url)
+}
+
+// fetch downloads and decodes the image at the URL.
+func (f *Fetcher) fetch(ctx context.Context, url string) (image.Image, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download artwork: %w", err)
+	}
+	resp, err := f.client.Do(req) // #nosec G107 -- artwork URL from the KMHD API
+	if err != nil {
+		return nil, fmt.Errorf("failed to download artwork: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download artwork: HTTP %d", resp.StatusCode)
+	}
+	img, _, err := image.Decode(io.LimitReader(resp.Body, maxArtworkSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode artwork: %w", err)
+	}
+	return img, nil
+}
@@ -0,0 +1,100 @@
+package cover
+
+import (
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetcher_Fetch(t *testing.T) {
+	requests := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		switch r.URL.Path {
+		case "/missing.png":
+			http.NotFound(w, r)
+		case "/garbage.png":
+			_, _ = w.Write([]byte("not an image"))
+		default:
+			_ = png.Encode(w, solid(color.RGBA{0x80, 0x40, 0x20, 0xff}, 100))
+		}
+	}))
+	defer server.Close()
+
+	fetcher := NewFetcher(time.Second)
+	urls := []string{
+		server.URL + "/missing.png",
+		"",
+		server.URL + "/kind-of-blue.png",
+		server.URL + "/garbage.png",
+		server.URL + "/kind-of-blue.png",
+		server.URL + "/a-love-supreme.png",
+		server.URL + "/mingus-ah-um.png",
+	}
+
+	images := fetcher.Fetch(urls, 2)
+	assert.Len(t, images, 2)
+	assert.Equal(t, 1, requests["/kind-of-blue.png"])
+	assert.Equal(t, 1, requests["/a-love-supreme.png"])
+	assert.Zero(t, requests["/mingus-ah-um.png"])
+
+	server.Close()
+	assert.Empty(t, fetcher.Fetch(urls, 4))
+}
+
+func TestFetcher_FetchLargeArtwork(t *testing.T) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		switch r.URL.Path {
+		case "/kind-of-blue/600x600bb.png":
+			_ = png.Encode(w, solid(color.Black, 600))
+		case "/a-love-supreme/600x600bb.png":
+			http.NotFound(w, r)
+		default:
+			_ = png.Encode(w, solid(color.Black, 100))
+		}
+	}))
+	defer server.Close()
+
+	images := NewFetcher(time.Second).Fetch([]string{
+		server.URL + "/kind-of-blue/100x100bb.png",
+		server.URL + "/a-love-supreme/100x100bb.png",
+	}, 4)
+	if assert.Len(t, images, 2) {
+		assert.Equal(t, 600, images[0].Bounds().Dx())
+		assert.Equal(t, 100, images[1].Bounds().Dx(), "falls back to the small artwork")
+	}
+	assert.Equal(t, []string{
+		"/kind-of-blue/600x600bb.png",
+		"/a-love-supreme/600x600bb.png",
+		"/a-love-supreme/100x100bb.png",
+	}, requested)
+}
+
+func TestFetcher_FetchDeadline(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	urls := make([]string, 8)
+	for i := range urls {
+		urls[i] = server.URL + "/" + strconv.Itoa(i) + ".png"
+	}
+
+	start := time.Now()
+	assert.Empty(t, NewFetcher(200*time.Millisecond).Fetch(urls, 4))
+	assert.Less(t, time.Since(start), time.Second, "all downloads share one timeout")
+}
@@ -0,0 +1,237 @@
+// Package cover generates playlist cover images.
+//
+// A cover is a collage of album artwork with a label, such as the month and year of a
+// monthly playlist, drawn over it. Without artwork, for example when it can't be
+// downloaded, a typographic cover is drawn on a colour gradient instead. Covers are
+// rendered in pure Go with the embedded Go fonts, so no network access or system fonts
+// are needed.
+package cover
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"sync"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// Size is the width and height of generated covers in pixels.
+	Size = 640
+
+	// MaxImageSize is the largest base64 encoded cover image Spotify accepts.
+	MaxImageSize = 256 * 1024
+
+	// margin is the space between the text and the edges of the cover.
+	margin = 48
+)
+
+// Design describes a cover.
+type Design struct {
+	// Label is the small line above the title, e.g. "KMHD".
+	Label string
+
+	// Title is the large text, e.g. "October".
+	Title string
+
+	// Subtitle is the line below the title, e.g. "2025".
+	Subtitle string
+
+	// Artwork are the album covers of the collage. The first four are used; without
+	// artwork the cover is typographic.
+	Artwork []image.Image
+}
+
+// palettes are the gradients of typographic covers, picked by the title.
+var palettes = [][2]color.RGBA{
+	{{0x1b, 0x26, 0x3b, 0xff}, {0x0d, 0x13, 0x1e, 0xff}},
+	{{0x5c, 0x1a, 0x1b, 0xff}, {0x24, 0x0b, 0x0c, 0xff}},
+	{{0x1f, 0x4d, 0x3a, 0xff}, {0x0b, 0x1f, 0x17, 0xff}},
+	{{0x4a, 0x2c, 0x5e, 0xff}, {0x1c, 0x10, 0x26, 0xff}},
+	{{0x7a, 0x4b, 0x12, 0xff}, {0x2e, 0x1b, 0x05, 0xff}},
+	{{0x24, 0x4e, 0x63, 0xff}, {0x0c, 0x1c, 0x24, 0xff}},
+}
+
+// accent is the colour of the rule above the title.
+var accent = color.RGBA{0xe8, 0xb9, 0x4a, 0xff}
+
+// fonts are the parsed Go fonts, loaded on first use.
+var fonts = sync.OnceValues(func() ([2]*opentype.Font, error) {
+	bold, err := opentype.Parse(gobold.TTF)
+	if err != nil {
+		return [2]*opentype.Font{}, fmt.Errorf("failed to parse bold font: %w", err)
+	}
+	regular, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		return [2]*opentype.Font{}, fmt.Errorf("failed to parse regular font: %w", err)
+	}
+	return [2]*opentype.Font{bold, regular}, nil
+})
+
+// Generate renders the design and encodes it as a JPEG image small enough to upload
+// to Spotify.
+func Generate(design Design) ([]byte, error) {
+	img, err := Render(design)
+	if err != nil {
+		return nil, err
+	}
+	return Encode(img)
+}
+
+// Render draws the design as a Size x Size image.
+func Render(design Design) (*image.RGBA, error) {
+	img := image.NewRGBA(image.Rect(0, 0, Size, Size))
+	if len(design.Artwork) > 0 {
+		drawCollage(img, design.Artwork)
+		shadeBottom(img)
+	} else {
+		drawGradient(img, palettes[paletteIndex(design.Title)])
+	}
+
+	if err := drawText(img, design); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// Encode encodes the image as a JPEG, lowering the quality until its base64 encoding
+// fits in MaxImageSize.
+func Encode(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	for quality := 90; quality >= 30; quality -= 10 {
+		buf.Reset()
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, fmt.Errorf("failed to encode cover: %w", err)
+		}
+		if base64.StdEncoding.EncodedLen(buf.Len()) <= MaxImageSize {
+			return buf.Bytes(), nil
+		}
+	}
+	return nil, fmt.Errorf("cover is larger than %d KB at the lowest quality", MaxImageSize/1024)
+}
+
+// drawCollage fills the image with the artwork: one cover for fewer than four, or a
+// two by two grid of the first four.
+func drawCollage(img *image.RGBA, artwork []image.Image) {
+	if len(artwork) < 4 {
+		xdraw.CatmullRom.Scale(img, img.Bounds(), artwork[0], artwork[0].Bounds(), draw.Src, nil)
+		return
+	}
+
+	tile := Size / 2
+	for i, art := range artwork[:4] {
+		x, y := (i%2)*tile, (i/2)*tile
+		xdraw.CatmullRom.Scale(img, image.Rect(x, y, x+tile, y+tile), art, art.Bounds(), draw.Src, nil)
+	}
+}
+
+// shadeBottom darkens the lower half of the image so the text stands out against the
+// artwork.
+func shadeBottom(img *image.RGBA) {
+	start := Size * 2 / 5
+	for y := start; y < Size; y++ {
+		alpha := uint8(220 * (y - start) / (Size - start))
+		shade := image.NewUniform(color.RGBA{0, 0, 0, alpha})
+		draw.Draw(img, image.Rect(0, y, Size, y+1), shade, image.Point{}, draw.Over)
+	}
+}
+
+// drawGradient fills the image with a vertical gradient between the palette's colours.
+func drawGradient(img *image.RGBA, palette [2]color.RGBA) {
+	top, bottom := palette[0], palette[1]
+	for y := 0; y < Size; y++ {
+		mix := func(a, b uint8) uint8 {
+			return uint8((int(a)*(Size-y) + int(b)*y) / Size)
+		}
+		row := color.RGBA{mix(top.R, bottom.R), mix(top.G, bottom.G), mix(top.B, bottom.B), 0xff}
+		draw.Draw(img, image.Rect(0, y, Size, y+1), image.NewUniform(row), image.Point{}, draw.Src)
+	}
+}
+
+// paletteIndex picks a palette for the title, so a playlist keeps its colours.
+func paletteIndex(title string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(title))
+	return int(h.Sum32() % uint32(len(palettes)))
+}
+
+// drawText draws the label, title and subtitle in the bottom left of the image, with
+// the title shrunk to fit the width.
+func drawText(img *image.RGBA, design Design) error {
+	loaded, err := fonts()
+	if err != nil {
+		return err
+	}
+	bold, regular := loaded[0], loaded[1]
+
+	subtitleFace, err := newFace(regular, 44)
+	if err != nil {
+		return err
+	}
+	labelFace, err := newFace(bold, 28)
+	if err != nil {
+		return err
+	}
+	titleFace, err := fitFace(bold, design.Title, Size-2*margin, 120, 36)
+	if err != nil {
+		return err
+	}
+
+	white := image.NewUniform(color.White)
+	baseline := Size - margin
+	if design.Subtitle != "" {
+		drawString(img, subtitleFace, white, design.Subtitle, baseline)
+		baseline -= 64
+	}
+	if design.Title != "" {
+		drawString(img, titleFace, white, design.Title, baseline)
+		baseline -= titleFace.Metrics().Ascent.Ceil() + 24
+	}
+
+	rule := image.Rect(margin, baseline-6, margin+72, baseline)
+	draw.Draw(img, rule, image.NewUniform(accent), image.Point{}, draw.Src)
+	if design.Label != "" {
+		drawString(img, labelFace, image.NewUniform(accent), design.Label, baseline-20)
+	}
+	return nil
+}
+
+// newFace creates a face of the font at the size in points.
+func newFace(f *opentype.Font, size float64) (font.Face, error) {
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create font face: %w", err)
+	}
+	return face, nil
+}
+
+// fitFace returns the largest face of the font, from maxSize down to minSize, in which
+// the text fits the width.
+func fitFace(f *opentype.Font, text string, width int, maxSize, minSize float64) (font.Face, error) {
+	for size := maxSize; ; size -= 4 {
+		face, err := newFace(f, size)
+		if err != nil {
+			return nil, err
+		}
+		if size <= minSize || font.MeasureString(face, text).Ceil() <= width {
+			return face, nil
+		}
+	}
+}
+
+// drawString draws the text with its baseline at y, starting at the left margin.
+func drawString(img *image.RGBA, face font.Face, src image.Image, text string, y int) {
+	drawer := &font.Drawer{Dst: img, Src: src, Face: face, Dot: fixed.P(margin, y)}
+	drawer.DrawString(text)
+}
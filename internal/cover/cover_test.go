@@ -0,0 +1,100 @@
+package cover
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// solid returns a square image of a single colour.
+func solid(c color.Color, size int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// near reports whether the colours are within a small distance of each other.
+func near(t *testing.T, expected color.RGBA, actual color.Color) {
+	t.Helper()
+	r, g, b, _ := actual.RGBA()
+	got := color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), 0xff}
+	for _, diff := range []int{int(expected.R) - int(got.R), int(expected.G) - int(got.G), int(expected.B) - int(got.B)} {
+		if diff < -8 || diff > 8 {
+			t.Errorf("expected colour near %v, got %v", expected, got)
+			return
+		}
+	}
+}
+
+func TestRender_Typographic(t *testing.T) {
+	img, err := Render(Design{Label: "KMHD", Title: "October", Subtitle: "2025"})
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, Size, Size), img.Bounds())
+
+	// The gradient starts at the palette's top colour and the title is drawn in white
+	near(t, palettes[paletteIndex("October")][0], img.At(Size-1, 0))
+	white := 0
+	for y := Size / 2; y < Size; y++ {
+		for x := 0; x < Size; x++ {
+			if r, g, b, _ := img.At(x, y).RGBA(); r == 0xffff && g == 0xffff && b == 0xffff {
+				white++
+			}
+		}
+	}
+	assert.Greater(t, white, 1000)
+
+	again, err := Render(Design{Label: "KMHD", Title: "October", Subtitle: "2025"})
+	require.NoError(t, err)
+	assert.Equal(t, img.Pix, again.Pix)
+}
+
+func TestRender_Collage(t *testing.T) {
+	red := color.RGBA{0xc0, 0x20, 0x20, 0xff}
+	blue := color.RGBA{0x20, 0x20, 0xc0, 0xff}
+	green := color.RGBA{0x20, 0xc0, 0x20, 0xff}
+	yellow := color.RGBA{0xc0, 0xc0, 0x20, 0xff}
+
+	img, err := Render(Design{Title: "October", Artwork: []image.Image{solid(red, 100), solid(blue, 100), solid(green, 100), solid(yellow, 100)}})
+	require.NoError(t, err)
+	near(t, red, img.At(Size/4, 10))
+	near(t, blue, img.At(Size*3/4, 10))
+
+	// Fewer than four covers fill the whole image, and the bottom is shaded
+	img, err = Render(Design{Title: "October", Artwork: []image.Image{solid(red, 100), solid(blue, 100)}})
+	require.NoError(t, err)
+	near(t, red, img.At(Size-1, 0))
+	r, _, _, _ := img.At(Size-1, Size-1).RGBA()
+	assert.Less(t, r>>8, uint32(0x40))
+}
+
+func TestGenerate(t *testing.T) {
+	data, err := Generate(Design{Label: "KMHD", Title: "A Very Long Playlist Name That Has To Shrink", Subtitle: "2025"})
+	require.NoError(t, err)
+	assert.LessOrEqual(t, base64.StdEncoding.EncodedLen(len(data)), MaxImageSize)
+
+	decoded, err := jpeg.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, Size, Size), decoded.Bounds())
+}
+
+func TestEncode_LowersQuality(t *testing.T) {
+	// Noise compresses badly, so it only fits at a lower quality
+	noise := image.NewRGBA(image.Rect(0, 0, Size, Size))
+	random := rand.New(rand.NewSource(1)) // #nosec G404 -- deterministic test noise
+	random.Read(noise.Pix)
+
+	data, err := Encode(noise)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, base64.StdEncoding.EncodedLen(len(data)), MaxImageSize)
+}
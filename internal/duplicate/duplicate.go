@@ -2,25 +2,177 @@ package duplicate
 
 import (
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/toozej/kmhd2spotify/internal/types"
+	"github.com/toozej/kmhd2spotify/pkg/config"
 )
 
 // DuplicateService implements the DuplicateDetector interface
 type DuplicateService struct {
 	spotify types.SpotifyService
 	logger  *log.Logger
+
+	// policy, index, profile and prefix enforce a duplicate policy across playlists.
+	// Without an index, only the target playlist is checked.
+	policy  config.DuplicateConfig
+	index   *Index
+	profile string
+	prefix  string
+	now     func() time.Time
+
+	// tolerance is how much the durations of the same recording may differ.
+	tolerance time.Duration
+
+	// recordings caches the identities of the tracks of each checked playlist until
+	// ResetRecordings, so a sync cycle fetches a playlist's tracks only once.
+	recordings map[string][]Identity
 }
 
 // NewDuplicateService creates a new duplicate detection service
 func NewDuplicateService(spotify types.SpotifyService, logger *log.Logger) *DuplicateService {
 	return &DuplicateService{
-		spotify: spotify,
-		logger:  logger,
+		spotify:    spotify,
+		logger:     logger,
+		policy:     config.DuplicateConfig{Policy: config.DuplicatePolicyPlaylist},
+		now:        time.Now,
+		tolerance:  DefaultDurationTolerance,
+		recordings: make(map[string][]Identity),
+	}
+}
+
+// ResetRecordings forgets the cached tracks of the checked playlists, so the next
+// check fetches them again. Syncs call it once per cycle to see changes made on Spotify.
+func (d *DuplicateService) ResetRecordings() {
+	clear(d.recordings)
+}
+
+// SetPolicy enforces the duplicate policy across the profile's playlists with the name
+// prefix, as recorded in the index. The playlist policy only checks the target playlist
+// and doesn't use the index.
+func (d *DuplicateService) SetPolicy(policy config.DuplicateConfig, index *Index, profile, prefix string) {
+	d.policy = policy
+	d.tolerance = policy.DurationTolerance
+	d.index = index
+	d.profile = profile
+	d.prefix = prefix
+	if policy.Policy == config.DuplicatePolicyPlaylist || prefix == "" {
+		d.index = nil
+	}
+}
+
+// RefreshIndex adds the profile's playlists with the name prefix that are not indexed
+// yet, such as monthly playlists synced before the index existed, and removes indexed
+// playlists that no longer exist. It returns how many playlists were indexed.
+func (d *DuplicateService) RefreshIndex() (int, error) {
+	if d.index == nil {
+		return 0, nil
+	}
+
+	playlists, err := d.spotify.GetUserPlaylists("")
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user playlists: %w", err)
+	}
+
+	indexed := 0
+	keep := make(map[string]bool)
+	for _, playlist := range playlists {
+		if !hasPrefix(playlist.Name, d.prefix) {
+			continue
+		}
+		keep[playlist.ID] = true
+		if d.index.Indexed(playlist.ID) {
+			continue
+		}
+		if err := d.indexPlaylist(playlist); err != nil {
+			return indexed, err
+		}
+		indexed++
+	}
+
+	removed, err := d.index.Prune(d.profile, d.prefix, keep)
+	if err != nil {
+		return indexed, err
 	}
+
+	d.logger.WithFields(log.Fields{
+		"component": "duplicate_service",
+		"operation": "refresh_index",
+		"profile":   d.profile,
+		"prefix":    d.prefix,
+		"indexed":   indexed,
+		"removed":   removed,
+	}).Debug("Refreshed duplicate index")
+	return indexed, nil
+}
+
+// CheckTrack checks whether a track is a duplicate for the playlist under the duplicate
+// policy: whether it is already in the playlist, or was added to another playlist with
+// the name prefix (within the window, for the window policy).
+func (d *DuplicateService) CheckTrack(playlist types.Playlist, track types.Track) (*types.DuplicateResult, error) {
+	result, err := d.CheckDuplicates(playlist.ID, []types.Track{track})
+	if err != nil || result.HasDuplicates || d.index == nil {
+		return result, err
+	}
+
+	since, err := d.policy.WindowStart(d.now())
+	if err != nil {
+		return nil, err
+	}
+	additions := d.index.Find(d.profile, d.prefix, track, since, d.tolerance)
+	if len(additions) == 0 {
+		return result, nil
+	}
+
+	latest := additions[0]
+	d.logger.WithFields(log.Fields{
+		"component":   "duplicate_service",
+		"operation":   "check_track",
+		"policy":      d.policy.Policy,
+		"playlist_id": playlist.ID,
+		"track":       track.Name,
+		"added_to":    latest.PlaylistName,
+		"added_at":    latest.AddedAt,
+	}).Info("Track already added to another playlist")
+
+	return &types.DuplicateResult{
+		HasDuplicates:   true,
+		DuplicateTracks: []types.Track{track},
+		LastAdded:       latest.AddedAt,
+		PlaylistName:    latest.PlaylistName,
+		Message: fmt.Sprintf("Track '%s' was already added to %s on %s",
+			track.Name, latest.PlaylistName, latest.AddedAt.Local().Format("2006-01-02")),
+	}, nil
+}
+
+// RecordAdded records that a track was added to a playlist with the name prefix, so
+// later checks find it. A playlist that isn't indexed yet, such as a new monthly
+// playlist, is indexed from Spotify instead.
+func (d *DuplicateService) RecordAdded(playlist types.Playlist, track types.Track) error {
+	if identities, ok := d.recordings[playlist.ID]; ok {
+		d.recordings[playlist.ID] = append(identities, NewIdentity(track))
+	}
+
+	if d.index == nil || !hasPrefix(playlist.Name, d.prefix) {
+		return nil
+	}
+	if !d.index.Indexed(playlist.ID) {
+		return d.indexPlaylist(playlist)
+	}
+	return d.index.Record(playlist.ID, track, d.now())
+}
+
+// indexPlaylist replaces the indexed tracks of a playlist with its current items.
+func (d *DuplicateService) indexPlaylist(playlist types.Playlist) error {
+	items, err := d.spotify.GetPlaylistItems(playlist.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get tracks of playlist %s: %w", playlist.Name, err)
+	}
+	return d.index.SetPlaylist(d.profile, playlist, items)
 }
 
 // CheckDuplicates checks if any of the provided tracks already exist in the playlist
@@ -64,6 +216,12 @@ func (d *DuplicateService) CheckDuplicates(playlistID string, tracks []types.Tra
 		return nil, fmt.Errorf("failed to check tracks in playlist: %w", err)
 	}
 
+	// Tracks that are not in the playlist by ID may still be there as another release
+	// of the same recording
+	if slices.Contains(existsResults, false) {
+		existsResults = d.checkRecordings(playlistID, tracks, existsResults)
+	}
+
 	// Collect duplicate tracks
 	var duplicateTracks []types.Track
 	var duplicateTrackNames []string
@@ -79,7 +237,6 @@ func (d *DuplicateService) CheckDuplicates(playlistID string, tracks []types.Tra
 	result := &types.DuplicateResult{
 		HasDuplicates:   hasDuplicates,
 		DuplicateTracks: duplicateTracks,
-		LastAdded:       time.Now(), // This would ideally come from playlist metadata
 	}
 
 	if hasDuplicates {
@@ -107,7 +264,60 @@ func (d *DuplicateService) CheckDuplicates(playlistID string, tracks []types.Tra
 	return result, nil
 }
 
-// CheckArtistInPlaylist checks if an artist's tracks already exist in the playlist
+// checkRecordings marks the tracks whose recording is in the playlist under another
+// track ID, such as a compilation or remastered release. If the playlist's tracks can't
+// be fetched, the results are returned unchanged.
+func (d *DuplicateService) checkRecordings(playlistID string, tracks []types.Track, existsResults []bool) []bool {
+	identities, err := d.playlistRecordings(playlistID)
+	if err != nil {
+		d.logger.WithError(err).WithFields(log.Fields{
+			"component":   "duplicate_service",
+			"operation":   "check_recordings",
+			"playlist_id": playlistID,
+		}).Warn("Failed to get playlist tracks, only checking track IDs")
+		return existsResults
+	}
+
+	results := slices.Clone(existsResults)
+	for i, track := range tracks {
+		if i >= len(results) || results[i] {
+			continue
+		}
+		if matchesAny(NewIdentity(track), identities, d.tolerance) {
+			d.logger.WithFields(log.Fields{
+				"component":   "duplicate_service",
+				"operation":   "check_recordings",
+				"playlist_id": playlistID,
+				"track":       track.Name,
+			}).Debug("Recording already in playlist under another track ID")
+			results[i] = true
+		}
+	}
+	return results
+}
+
+// playlistRecordings returns the identities of the playlist's tracks, fetching them
+// only if they aren't cached since the last ResetRecordings.
+func (d *DuplicateService) playlistRecordings(playlistID string) ([]Identity, error) {
+	if identities, ok := d.recordings[playlistID]; ok {
+		return identities, nil
+	}
+
+	playlistTracks, err := d.spotify.GetPlaylistTracks(playlistID)
+	if err != nil {
+		return nil, err
+	}
+	identities := make([]Identity, len(playlistTracks))
+	for i, track := range playlistTracks {
+		identities[i] = NewIdentity(track)
+	}
+	d.recordings[playlistID] = identities
+	return identities, nil
+}
+
+// CheckArtistInPlaylist checks whether the playlist already has tracks by the artist.
+// Every track in the playlist is scanned, and the artist's tracks are returned with
+// when Spotify reports they were added, most recently added first.
 func (d *DuplicateService) CheckArtistInPlaylist(playlistID, artistID string) (*types.DuplicateResult, error) {
 	d.logger.WithFields(log.Fields{
 		"component":   "duplicate_service",
@@ -116,73 +326,81 @@ func (d *DuplicateService) CheckArtistInPlaylist(playlistID, artistID string) (*
 		"artist_id":   artistID,
 	}).Debug("Checking if artist tracks exist in playlist")
 
-	// Get the artist's top tracks
-	tracks, err := d.spotify.GetArtistTopTracks(artistID)
+	items, err := d.spotify.GetPlaylistItems(playlistID)
 	if err != nil {
 		d.logger.WithError(err).WithFields(log.Fields{
 			"component":   "duplicate_service",
 			"operation":   "check_artist_duplicates",
 			"artist_id":   artistID,
 			"playlist_id": playlistID,
-		}).Error("Failed to get artist top tracks for duplicate check")
-		return nil, fmt.Errorf("failed to get artist top tracks: %w", err)
+		}).Error("Failed to get playlist items for duplicate check")
+		return nil, fmt.Errorf("failed to get playlist items: %w", err)
 	}
 
-	if len(tracks) == 0 {
-		d.logger.WithFields(log.Fields{
-			"component":   "duplicate_service",
-			"operation":   "check_artist_duplicates",
-			"artist_id":   artistID,
-			"playlist_id": playlistID,
-		}).Debug("Artist has no tracks to check for duplicates")
-		return &types.DuplicateResult{
-			HasDuplicates: false,
-			Message:       "Artist has no tracks",
-		}, nil
-	}
-
-	// Get artist name from the first track for better messaging
+	// Keep the most recent addition of each of the artist's tracks
 	var artistName string
-	if len(tracks) > 0 && len(tracks[0].Artists) > 0 {
-		artistName = tracks[0].Artists[0].Name
+	latest := make(map[string]types.PlaylistItem)
+	for _, item := range items {
+		for _, artist := range item.Track.Artists {
+			if artist.ID != artistID {
+				continue
+			}
+			artistName = artist.Name
+			if existing, ok := latest[item.Track.ID]; !ok || item.AddedAt.After(existing.AddedAt) {
+				latest[item.Track.ID] = item
+			}
+			break
+		}
 	}
 
-	// Use CheckDuplicates to do the actual checking
-	result, err := d.CheckDuplicates(playlistID, tracks)
-	if err != nil {
-		return nil, err
+	result := &types.DuplicateResult{ArtistName: artistName}
+	for _, item := range latest {
+		result.DuplicateItems = append(result.DuplicateItems, item)
 	}
+	sort.Slice(result.DuplicateItems, func(i, j int) bool {
+		a, b := result.DuplicateItems[i], result.DuplicateItems[j]
+		if !a.AddedAt.Equal(b.AddedAt) {
+			return a.AddedAt.After(b.AddedAt)
+		}
+		return a.Track.Name < b.Track.Name
+	})
+	for _, item := range result.DuplicateItems {
+		result.DuplicateTracks = append(result.DuplicateTracks, item.Track)
+	}
+	if len(result.DuplicateItems) > 0 {
+		result.LastAdded = result.DuplicateItems[0].AddedAt
+	}
+	result.HasDuplicates = len(result.DuplicateTracks) > 0
 
-	// Enhance the result with artist-specific information
-	if result != nil {
-		result.ArtistName = artistName
-
-		if result.HasDuplicates {
-			result.Message = fmt.Sprintf("Artist '%s' already has %d track(s) in this playlist (last added: %s). Use 'Add Anyway' to override.",
-				artistName, len(result.DuplicateTracks), result.LastAdded.Format("2006-01-02 15:04:05"))
+	if !result.HasDuplicates {
+		result.Message = "Artist has no tracks in this playlist, safe to add"
+		d.logger.WithFields(log.Fields{
+			"component":      "duplicate_service",
+			"operation":      "check_artist_duplicates",
+			"artist_id":      artistID,
+			"playlist_id":    playlistID,
+			"has_duplicates": false,
+		}).Debug("Artist tracks not found in playlist")
+		return result, nil
+	}
 
-			d.logger.WithFields(log.Fields{
-				"component":       "duplicate_service",
-				"operation":       "check_artist_duplicates",
-				"artist_name":     artistName,
-				"artist_id":       artistID,
-				"playlist_id":     playlistID,
-				"duplicate_count": len(result.DuplicateTracks),
-				"last_added":      result.LastAdded,
-				"has_duplicates":  true,
-			}).Info("Artist tracks already exist in playlist")
-		} else {
-			result.Message = fmt.Sprintf("Artist '%s' tracks not found in playlist, safe to add", artistName)
-			d.logger.WithFields(log.Fields{
-				"component":      "duplicate_service",
-				"operation":      "check_artist_duplicates",
-				"artist_name":    artistName,
-				"artist_id":      artistID,
-				"playlist_id":    playlistID,
-				"has_duplicates": false,
-			}).Debug("Artist tracks not found in playlist")
-		}
+	lastAdded := "unknown"
+	if !result.LastAdded.IsZero() {
+		lastAdded = result.LastAdded.Local().Format("2006-01-02 15:04:05")
 	}
+	result.Message = fmt.Sprintf("Artist '%s' already has %d track(s) in this playlist (last added: %s). Use 'Add Anyway' to override.",
+		artistName, len(result.DuplicateTracks), lastAdded)
+
+	d.logger.WithFields(log.Fields{
+		"component":       "duplicate_service",
+		"operation":       "check_artist_duplicates",
+		"artist_name":     artistName,
+		"artist_id":       artistID,
+		"playlist_id":     playlistID,
+		"duplicate_count": len(result.DuplicateTracks),
+		"last_added":      result.LastAdded,
+		"has_duplicates":  true,
+	}).Info("Artist tracks already exist in playlist")
 
 	return result, nil
 }
@@ -2,12 +2,15 @@ package duplicate
 
 import (
 	"errors"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/toozej/kmhd2spotify/internal/types"
+	"github.com/toozej/kmhd2spotify/pkg/config"
 )
 
 // MockSpotifyService is a mock implementation of the SpotifyService interface
@@ -26,6 +29,8 @@ type MockSpotifyService struct {
 	getAuthURLFunc          func() string
 	isAuthenticatedFunc     func() bool
 	completeAuthFunc        func(code, state string) error
+	getPlaylistItemsFunc    func(playlistID string) ([]types.PlaylistItem, error)
+	getPlaylistTracksFunc   func(playlistID string) ([]types.Track, error)
 }
 
 func (m *MockSpotifyService) SearchArtist(query string) (*types.Artist, error) {
@@ -35,6 +40,10 @@ func (m *MockSpotifyService) SearchArtist(query string) (*types.Artist, error) {
 	return nil, errors.New("not implemented")
 }
 
+func (m *MockSpotifyService) SearchArtists(query string, limit int) ([]types.Artist, error) {
+	return nil, errors.New("not implemented")
+}
+
 func (m *MockSpotifyService) GetArtistTopTracks(artistID string) ([]types.Track, error) {
 	if m.getArtistTopTracksFunc != nil {
 		return m.getArtistTopTracksFunc(artistID)
@@ -91,6 +100,44 @@ func (m *MockSpotifyService) CreatePlaylist(name, description string, public boo
 	return nil, errors.New("not implemented")
 }
 
+func (m *MockSpotifyService) GetPlaylistTracks(playlistID string) ([]types.Track, error) {
+	if m.getPlaylistTracksFunc != nil {
+		return m.getPlaylistTracksFunc(playlistID)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockSpotifyService) GetPlaylistItems(playlistID string) ([]types.PlaylistItem, error) {
+	if m.getPlaylistItemsFunc != nil {
+		return m.getPlaylistItemsFunc(playlistID)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockSpotifyService) RemoveTracksFromPlaylist(playlistID string, trackIDs []string) error {
+	return errors.New("not implemented")
+}
+
+func (m *MockSpotifyService) RemoveTrackPositions(playlistID string, positions map[string][]int) error {
+	return errors.New("not implemented")
+}
+
+func (m *MockSpotifyService) ReorderPlaylist(playlistID string, rangeStart, rangeLength, insertBefore int) error {
+	return errors.New("not implemented")
+}
+
+func (m *MockSpotifyService) UpdatePlaylist(playlistID string, update types.PlaylistUpdate) error {
+	return errors.New("not implemented")
+}
+
+func (m *MockSpotifyService) SetPlaylistCover(playlistID string, jpeg []byte) error {
+	return errors.New("not implemented")
+}
+
+func (m *MockSpotifyService) DeletePlaylist(playlistID string) error {
+	return errors.New("not implemented")
+}
+
 func TestNewDuplicateService(t *testing.T) {
 	logger := logrus.New()
 	mockSpotify := &MockSpotifyService{}
@@ -144,8 +191,7 @@ func TestDuplicateService_CheckDuplicates(t *testing.T) {
 				assert.False(t, result.HasDuplicates)
 				assert.Equal(t, "No duplicate tracks found", result.Message)
 				assert.Empty(t, result.DuplicateTracks)
-				assert.False(t, result.LastAdded.IsZero())
-				assert.WithinDuration(t, time.Now(), result.LastAdded, 1*time.Second)
+				assert.True(t, result.LastAdded.IsZero(), "playlist track checks don't know when tracks were added")
 			},
 		},
 		{
@@ -167,8 +213,7 @@ func TestDuplicateService_CheckDuplicates(t *testing.T) {
 				assert.Equal(t, "Song 1", result.DuplicateTracks[0].Name)
 				assert.Equal(t, "track3", result.DuplicateTracks[1].ID)
 				assert.Equal(t, "Song 3", result.DuplicateTracks[1].Name)
-				assert.False(t, result.LastAdded.IsZero())
-				assert.WithinDuration(t, time.Now(), result.LastAdded, 1*time.Second)
+				assert.True(t, result.LastAdded.IsZero(), "playlist track checks don't know when tracks were added")
 			},
 		},
 		{
@@ -189,8 +234,7 @@ func TestDuplicateService_CheckDuplicates(t *testing.T) {
 				assert.Equal(t, "Song 1", result.DuplicateTracks[0].Name)
 				assert.Equal(t, "track2", result.DuplicateTracks[1].ID)
 				assert.Equal(t, "Song 2", result.DuplicateTracks[1].Name)
-				assert.False(t, result.LastAdded.IsZero())
-				assert.WithinDuration(t, time.Now(), result.LastAdded, 1*time.Second)
+				assert.True(t, result.LastAdded.IsZero(), "playlist track checks don't know when tracks were added")
 			},
 		},
 		{
@@ -234,99 +278,78 @@ func TestDuplicateService_CheckArtistInPlaylist(t *testing.T) {
 	logger := logrus.New()
 	logger.SetLevel(logrus.ErrorLevel) // Reduce log noise in tests
 
-	artistName := "Test Artist"
+	artist := types.Artist{ID: "artist123", Name: "Test Artist"}
+	other := types.Artist{ID: "other", Name: "Other Artist"}
+	september := time.Date(2025, 9, 3, 20, 0, 0, 0, time.UTC)
+	october := time.Date(2025, 10, 5, 21, 30, 0, 0, time.UTC)
 
 	tests := []struct {
-		name            string
-		playlistID      string
-		artistID        string
-		mockTracks      []types.Track
-		mockTracksError error
-		mockExists      []bool
-		mockExistsError error
-		expectedError   bool
-		checkResult     func(*testing.T, *types.DuplicateResult)
+		name          string
+		mockItems     []types.PlaylistItem
+		mockError     error
+		expectedError bool
+		checkResult   func(*testing.T, *types.DuplicateResult)
 	}{
 		{
-			name:            "artist has no tracks",
-			playlistID:      "playlist123",
-			artistID:        "artist123",
-			mockTracks:      []types.Track{},
-			mockTracksError: nil,
-			expectedError:   false,
+			name:      "empty playlist",
+			mockItems: []types.PlaylistItem{},
 			checkResult: func(t *testing.T, result *types.DuplicateResult) {
 				assert.False(t, result.HasDuplicates)
-				assert.Equal(t, "Artist has no tracks", result.Message)
-				assert.Equal(t, "", result.ArtistName) // No artist name when no tracks
+				assert.Equal(t, "Artist has no tracks in this playlist, safe to add", result.Message)
 				assert.Empty(t, result.DuplicateTracks)
-				// LastAdded should remain zero when no tracks (as per implementation)
 				assert.True(t, result.LastAdded.IsZero())
 			},
 		},
 		{
-			name:       "artist tracks with no duplicates",
-			playlistID: "playlist123",
-			artistID:   "artist123",
-			mockTracks: []types.Track{
-				{ID: "track1", Name: "Song 1", Artists: []types.Artist{{Name: artistName}}, Album: types.Album{ID: "album1", Name: "Album 1", Type: "album"}},
-				{ID: "track2", Name: "Song 2", Artists: []types.Artist{{Name: artistName}}, Album: types.Album{ID: "album2", Name: "Album 2", Type: "album"}},
+			name: "only other artists in playlist",
+			mockItems: []types.PlaylistItem{
+				{Track: types.Track{ID: "track1", Name: "Song 1", Artists: []types.Artist{other}}, AddedAt: october},
 			},
-			mockTracksError: nil,
-			mockExists:      []bool{false, false},
-			mockExistsError: nil,
-			expectedError:   false,
 			checkResult: func(t *testing.T, result *types.DuplicateResult) {
 				assert.False(t, result.HasDuplicates)
-				assert.Contains(t, result.Message, "tracks not found in playlist, safe to add")
-				assert.Equal(t, artistName, result.ArtistName)
-				assert.Empty(t, result.DuplicateTracks)
-				assert.False(t, result.LastAdded.IsZero())
-				assert.WithinDuration(t, time.Now(), result.LastAdded, 1*time.Second)
+				assert.Empty(t, result.DuplicateItems)
+				assert.True(t, result.LastAdded.IsZero())
 			},
 		},
 		{
-			name:       "artist tracks with duplicates",
-			playlistID: "playlist123",
-			artistID:   "artist123",
-			mockTracks: []types.Track{
-				{ID: "track1", Name: "Song 1", Artists: []types.Artist{{Name: artistName}}, Album: types.Album{ID: "album1", Name: "Album 1", Type: "album"}},
-				{ID: "track2", Name: "Song 2", Artists: []types.Artist{{Name: artistName}}, Album: types.Album{ID: "album2", Name: "Album 2", Type: "album"}},
+			name: "artist tracks beyond top tracks with real added_at",
+			mockItems: []types.PlaylistItem{
+				{Track: types.Track{ID: "track1", Name: "Deep Cut", Artists: []types.Artist{artist}}, AddedAt: september},
+				{Track: types.Track{ID: "track2", Name: "Song 2", Artists: []types.Artist{other}}, AddedAt: october},
+				{Track: types.Track{ID: "track3", Name: "Collaboration", Artists: []types.Artist{other, artist}}, AddedAt: october},
+				{Track: types.Track{ID: "track1", Name: "Deep Cut", Artists: []types.Artist{artist}}, AddedAt: september.Add(time.Hour)},
 			},
-			mockTracksError: nil,
-			mockExists:      []bool{true, false},
-			mockExistsError: nil,
-			expectedError:   false,
 			checkResult: func(t *testing.T, result *types.DuplicateResult) {
 				assert.True(t, result.HasDuplicates)
-				assert.Contains(t, result.Message, "already has")
-				assert.Equal(t, artistName, result.ArtistName)
-				assert.Len(t, result.DuplicateTracks, 1)
-				assert.Equal(t, "track1", result.DuplicateTracks[0].ID)
-				assert.False(t, result.LastAdded.IsZero())
-				assert.WithinDuration(t, time.Now(), result.LastAdded, 1*time.Second)
+				assert.Equal(t, "Test Artist", result.ArtistName)
+				assert.Contains(t, result.Message, "already has 2 track(s)")
+				assert.Equal(t, october, result.LastAdded)
+
+				// Most recently added first, each track once with its latest add
+				require.Len(t, result.DuplicateItems, 2)
+				assert.Equal(t, "track3", result.DuplicateItems[0].Track.ID)
+				assert.Equal(t, october, result.DuplicateItems[0].AddedAt)
+				assert.Equal(t, "track1", result.DuplicateItems[1].Track.ID)
+				assert.Equal(t, september.Add(time.Hour), result.DuplicateItems[1].AddedAt)
+				require.Len(t, result.DuplicateTracks, 2)
+				assert.Equal(t, "track3", result.DuplicateTracks[0].ID)
 			},
 		},
 		{
-			name:            "get artist tracks error",
-			playlistID:      "playlist123",
-			artistID:        "artist123",
-			mockTracks:      nil,
-			mockTracksError: errors.New("failed to get artist tracks"),
-			expectedError:   true,
-			checkResult:     nil,
+			name: "unknown added_at",
+			mockItems: []types.PlaylistItem{
+				{Track: types.Track{ID: "track1", Name: "Song 1", Artists: []types.Artist{artist}}},
+			},
+			checkResult: func(t *testing.T, result *types.DuplicateResult) {
+				assert.True(t, result.HasDuplicates)
+				assert.True(t, result.LastAdded.IsZero())
+				assert.Contains(t, result.Message, "last added: unknown")
+			},
 		},
 		{
-			name:       "check tracks in playlist error",
-			playlistID: "playlist123",
-			artistID:   "artist123",
-			mockTracks: []types.Track{
-				{ID: "track1", Name: "Song 1", Artists: []types.Artist{{Name: artistName}}, Album: types.Album{ID: "album1", Name: "Album 1", Type: "album"}},
-			},
-			mockTracksError: nil,
-			mockExists:      nil,
-			mockExistsError: errors.New("failed to check tracks"),
-			expectedError:   true,
-			checkResult:     nil,
+			name:          "get playlist items error",
+			mockError:     errors.New("failed to get playlist items"),
+			expectedError: true,
 		},
 	}
 
@@ -334,16 +357,18 @@ func TestDuplicateService_CheckArtistInPlaylist(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockSpotify := &MockSpotifyService{
 				getArtistTopTracksFunc: func(artistID string) ([]types.Track, error) {
-					return tt.mockTracks, tt.mockTracksError
+					t.Error("artist check should not depend on the artist's top tracks")
+					return nil, nil
 				},
-				checkTracksInPlaylistFunc: func(playlistID string, trackIDs []string) ([]bool, error) {
-					return tt.mockExists, tt.mockExistsError
+				getPlaylistItemsFunc: func(playlistID string) ([]types.PlaylistItem, error) {
+					assert.Equal(t, "playlist123", playlistID)
+					return tt.mockItems, tt.mockError
 				},
 			}
 
 			service := NewDuplicateService(mockSpotify, logger)
 
-			result, err := service.CheckArtistInPlaylist(tt.playlistID, tt.artistID)
+			result, err := service.CheckArtistInPlaylist("playlist123", artist.ID)
 
 			if tt.expectedError {
 				assert.Error(t, err)
@@ -357,29 +382,151 @@ func TestDuplicateService_CheckArtistInPlaylist(t *testing.T) {
 	}
 }
 
-func TestDuplicateService_CheckArtistInPlaylist_NoArtistName(t *testing.T) {
+func TestDuplicateService_CheckTrackPolicy(t *testing.T) {
 	logger := logrus.New()
 	logger.SetLevel(logrus.ErrorLevel)
 
-	mockSpotify := &MockSpotifyService{
-		getArtistTopTracksFunc: func(artistID string) ([]types.Track, error) {
-			return []types.Track{
-				{ID: "track1", Name: "Song 1", Album: types.Album{ID: "album1", Name: "Album 1", Type: "album"}}, // No artist name in track
-			}, nil
+	now := time.Date(2025, 10, 17, 12, 0, 0, 0, time.UTC)
+	playlists := []types.Playlist{
+		{ID: "aug", Name: "KMHD-2025-08"},
+		{ID: "sep", Name: "KMHD-2025-09"},
+		{ID: "oct", Name: "KMHD-2025-10"},
+		{ID: "other", Name: "Road Trip"},
+	}
+	items := map[string][]types.PlaylistItem{
+		"aug":   {{Track: types.Track{ID: "naima"}, AddedAt: now.AddDate(0, -2, 0)}},
+		"sep":   {{Track: types.Track{ID: "so-what"}, AddedAt: now.AddDate(0, -1, 0)}},
+		"other": {{Track: types.Track{ID: "blue-in-green"}, AddedAt: now}},
+	}
+	fetched := 0
+	newMock := func() *MockSpotifyService {
+		return &MockSpotifyService{
+			getUserPlaylistsFunc: func(folderName string) ([]types.Playlist, error) { return playlists, nil },
+			getPlaylistItemsFunc: func(playlistID string) ([]types.PlaylistItem, error) {
+				fetched++
+				return items[playlistID], nil
+			},
+			checkTracksInPlaylistFunc: func(playlistID string, trackIDs []string) ([]bool, error) {
+				return []bool{false}, nil
+			},
+		}
+	}
+
+	index, err := OpenIndex(filepath.Join(t.TempDir(), IndexFileName))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		policy     config.DuplicateConfig
+		track      string
+		duplicate  bool
+		lastAdded  time.Time
+		addedToMsg string
+	}{
+		{name: "playlist policy ignores other months", policy: config.DuplicateConfig{Policy: config.DuplicatePolicyPlaylist}, track: "so-what"},
+		{name: "prefix policy finds earlier month", policy: config.DuplicateConfig{Policy: config.DuplicatePolicyPrefix}, track: "naima", duplicate: true, lastAdded: now.AddDate(0, -2, 0), addedToMsg: "KMHD-2025-08"},
+		{name: "prefix policy ignores other playlists", policy: config.DuplicateConfig{Policy: config.DuplicatePolicyPrefix}, track: "blue-in-green"},
+		{name: "window policy finds track in window", policy: config.DuplicateConfig{Policy: config.DuplicatePolicyWindow, Window: "45d"}, track: "so-what", duplicate: true, lastAdded: now.AddDate(0, -1, 0), addedToMsg: "KMHD-2025-09"},
+		{name: "window policy ignores track before window", policy: config.DuplicateConfig{Policy: config.DuplicatePolicyWindow, Window: "45d"}, track: "naima"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewDuplicateService(newMock(), logger)
+			service.now = func() time.Time { return now }
+			service.SetPolicy(tt.policy, index, "", "KMHD")
+			_, err := service.RefreshIndex()
+			require.NoError(t, err)
+
+			result, err := service.CheckTrack(playlists[2], types.Track{ID: tt.track, Name: tt.track})
+			require.NoError(t, err)
+			assert.Equal(t, tt.duplicate, result.HasDuplicates)
+			if tt.duplicate {
+				assert.Equal(t, tt.lastAdded, result.LastAdded)
+				assert.Contains(t, result.Message, tt.addedToMsg)
+			}
+		})
+	}
+
+	// Playlists are only fetched the first time they are indexed
+	assert.Equal(t, 3, fetched)
+}
+
+func TestDuplicateService_RecordAdded(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	now := time.Date(2025, 11, 1, 8, 0, 0, 0, time.UTC)
+	mock := &MockSpotifyService{
+		getPlaylistItemsFunc: func(playlistID string) ([]types.PlaylistItem, error) {
+			return []types.PlaylistItem{{Track: types.Track{ID: "naima"}, AddedAt: now}}, nil
 		},
 		checkTracksInPlaylistFunc: func(playlistID string, trackIDs []string) ([]bool, error) {
 			return []bool{false}, nil
 		},
 	}
+	index, err := OpenIndex(filepath.Join(t.TempDir(), IndexFileName))
+	require.NoError(t, err)
+
+	service := NewDuplicateService(mock, logger)
+	service.now = func() time.Time { return now }
+	service.SetPolicy(config.DuplicateConfig{Policy: config.DuplicatePolicyPrefix}, index, "", "KMHD")
+
+	// A new monthly playlist is indexed from Spotify, later additions are recorded
+	november := types.Playlist{ID: "nov", Name: "KMHD-2025-11"}
+	require.NoError(t, service.RecordAdded(november, types.Track{ID: "naima", Name: "Naima"}))
+	require.NoError(t, service.RecordAdded(november, types.Track{ID: "so-what", Name: "So What"}))
+	assert.Len(t, index.Find("", "KMHD", types.Track{ID: "naima"}, time.Time{}, 0), 1)
+	assert.Len(t, index.Find("", "KMHD", types.Track{ID: "so-what"}, time.Time{}, 0), 1)
+
+	// Playlists without the prefix aren't indexed
+	require.NoError(t, service.RecordAdded(types.Playlist{ID: "road", Name: "Road Trip"}, types.Track{ID: "naima", Name: "Naima"}))
+	assert.False(t, index.Indexed("road"))
+
+	// Checking a later month finds the earlier addition
+	result, err := service.CheckTrack(types.Playlist{ID: "dec", Name: "KMHD-2025-12"}, types.Track{ID: "so-what", Name: "So What"})
+	require.NoError(t, err)
+	assert.True(t, result.HasDuplicates)
+}
 
-	service := NewDuplicateService(mockSpotify, logger)
-
-	result, err := service.CheckArtistInPlaylist("playlist123", "artist123")
+func TestDuplicateService_CheckDuplicatesByRecording(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
 
-	assert.NoError(t, err)
-	assert.NotNil(t, result)
-	assert.Equal(t, "", result.ArtistName) // Should be empty when no artist name in tracks
-	assert.Contains(t, result.Message, "tracks not found in playlist, safe to add")
-	assert.False(t, result.LastAdded.IsZero())
-	assert.WithinDuration(t, time.Now(), result.LastAdded, 1*time.Second)
+	album := types.Track{ID: "album", Name: "Naima", Artists: []types.Artist{{Name: "John Coltrane"}}, Duration: 261000, ISRC: "USAT29900609"}
+	fetches := 0
+	mock := &MockSpotifyService{
+		checkTracksInPlaylistFunc: func(playlistID string, trackIDs []string) ([]bool, error) {
+			return make([]bool, len(trackIDs)), nil
+		},
+		getPlaylistTracksFunc: func(playlistID string) ([]types.Track, error) {
+			fetches++
+			return []types.Track{album}, nil
+		},
+	}
+	service := NewDuplicateService(mock, logger)
+
+	compilation := album
+	compilation.ID = "compilation"
+	other := types.Track{ID: "other", Name: "Giant Steps", Artists: []types.Artist{{Name: "John Coltrane"}}, Duration: 286000}
+
+	result, err := service.CheckDuplicates("playlist123", []types.Track{compilation, other})
+	require.NoError(t, err)
+	assert.True(t, result.HasDuplicates)
+	require.Len(t, result.DuplicateTracks, 1)
+	assert.Equal(t, "compilation", result.DuplicateTracks[0].ID)
+
+	// The playlist's tracks are fetched once per cycle, and tracks added are remembered
+	require.NoError(t, service.RecordAdded(types.Playlist{ID: "playlist123"}, other))
+	result, err = service.CheckDuplicates("playlist123", []types.Track{compilation, other})
+	require.NoError(t, err)
+	assert.Len(t, result.DuplicateTracks, 2)
+	assert.Equal(t, 1, fetches)
+
+	// Without the playlist's tracks, only track IDs are compared
+	service.ResetRecordings()
+	mock.getPlaylistTracksFunc = nil
+	result, err = service.CheckDuplicates("playlist123", []types.Track{compilation})
+	require.NoError(t, err)
+	assert.False(t, result.HasDuplicates)
 }
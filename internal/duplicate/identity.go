@@ -0,0 +1,155 @@
+package duplicate
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+// DefaultDurationTolerance is how much the durations of two tracks with the same
+// artist and title may differ for them to be the same recording.
+const DefaultDurationTolerance = 3 * time.Second
+
+// remasterSuffix matches title suffixes that mark a remaster of the same recording,
+// such as " - Remastered 2009" or " (2015 Remaster)".
+var remasterSuffix = regexp.MustCompile(`(?i)\s*(-\s+[^-]*remaster[^-]*|[(\[][^)\]]*remaster[^)\]]*[)\]])\s*$`)
+
+// Identity identifies a recording independently of the Spotify track ID, which differs
+// between the album, compilation and remastered releases of a recording and between
+// regions.
+type Identity struct {
+	// TrackID is the Spotify track ID. The same track ID is always the same recording.
+	TrackID string
+	// ISRC is the International Standard Recording Code, if known.
+	ISRC string
+	// Artist and Title are the normalized first artist and title.
+	Artist string
+	Title  string
+	// Duration is the track length, or zero if unknown.
+	Duration time.Duration
+}
+
+// NewIdentity returns the identity of a track.
+func NewIdentity(track types.Track) Identity {
+	identity := Identity{
+		TrackID:  track.ID,
+		ISRC:     strings.ToUpper(strings.TrimSpace(track.ISRC)),
+		Title:    normalizeTitle(track.Name),
+		Duration: time.Duration(track.Duration) * time.Millisecond,
+	}
+	if len(track.Artists) > 0 {
+		identity.Artist = normalizeName(track.Artists[0].Name)
+	}
+	return identity
+}
+
+// Matches reports whether two identities are the same recording: by track ID, by ISRC
+// when both have one, otherwise by artist and title with durations within the tolerance.
+func (i Identity) Matches(other Identity, tolerance time.Duration) bool {
+	if i.TrackID != "" && i.TrackID == other.TrackID {
+		return true
+	}
+	if i.ISRC != "" && other.ISRC != "" {
+		return i.ISRC == other.ISRC
+	}
+	if i.Artist == "" || i.Title == "" || i.Artist != other.Artist || i.Title != other.Title {
+		return false
+	}
+	if i.Duration <= 0 || other.Duration <= 0 {
+		return false
+	}
+	difference := i.Duration - other.Duration
+	if difference < 0 {
+		difference = -difference
+	}
+	return difference <= tolerance
+}
+
+// normalizeTitle normalizes a track title, dropping remaster suffixes.
+func normalizeTitle(title string) string {
+	return normalizeName(remasterSuffix.ReplaceAllString(title, ""))
+}
+
+// normalizeName lowercases a name and collapses punctuation and spacing, so "Naima"
+// and "naima." compare equal.
+func normalizeName(name string) string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+// Entry is a playlist item at its 0-based position in the playlist.
+type Entry struct {
+	types.PlaylistItem
+	Position int
+}
+
+// Group is a recording that appears more than once in a playlist.
+type Group struct {
+	// Kept is the earliest added occurrence.
+	Kept Entry
+	// Duplicates are the later occurrences, in the order they were added.
+	Duplicates []Entry
+}
+
+// FindDuplicates groups the playlist items that are the same recording, keeping the
+// earliest added occurrence of each, and returns the groups with duplicates in
+// playlist order of the kept occurrence.
+func FindDuplicates(items []types.PlaylistItem, tolerance time.Duration) []Group {
+	entries := make([]Entry, 0, len(items))
+	for position, item := range items {
+		if item.Track.ID == "" {
+			continue
+		}
+		entries = append(entries, Entry{PlaylistItem: item, Position: position})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].AddedAt.Equal(entries[j].AddedAt) {
+			return entries[i].AddedAt.Before(entries[j].AddedAt)
+		}
+		return entries[i].Position < entries[j].Position
+	})
+
+	var groups []Group
+	var identities [][]Identity
+	for _, entry := range entries {
+		identity := NewIdentity(entry.Track)
+		found := false
+		for g := range groups {
+			if matchesAny(identity, identities[g], tolerance) {
+				groups[g].Duplicates = append(groups[g].Duplicates, entry)
+				identities[g] = append(identities[g], identity)
+				found = true
+				break
+			}
+		}
+		if !found {
+			groups = append(groups, Group{Kept: entry})
+			identities = append(identities, []Identity{identity})
+		}
+	}
+
+	var duplicates []Group
+	for _, group := range groups {
+		if len(group.Duplicates) > 0 {
+			duplicates = append(duplicates, group)
+		}
+	}
+	sort.Slice(duplicates, func(i, j int) bool { return duplicates[i].Kept.Position < duplicates[j].Kept.Position })
+	return duplicates
+}
+
+// matchesAny reports whether an identity is the same recording as any of a group's.
+func matchesAny(identity Identity, group []Identity, tolerance time.Duration) bool {
+	for _, other := range group {
+		if identity.Matches(other, tolerance) {
+			return true
+		}
+	}
+	return false
+}
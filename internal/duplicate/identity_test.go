@@ -0,0 +1,108 @@
+package duplicate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+func track(id, artist, name string, durationMS int, isrc string) types.Track {
+	return types.Track{ID: id, Name: name, Artists: []types.Artist{{Name: artist}}, Duration: durationMS, ISRC: isrc}
+}
+
+func TestIdentityMatches(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  types.Track
+		match bool
+	}{
+		{
+			name:  "same track ID",
+			a:     types.Track{ID: "track1"},
+			b:     types.Track{ID: "track1"},
+			match: true,
+		},
+		{
+			name:  "same ISRC under different IDs",
+			a:     track("album", "John Coltrane", "Naima", 261000, "USAT29900609"),
+			b:     track("compilation", "John Coltrane", "Naima", 261000, "usat29900609"),
+			match: true,
+		},
+		{
+			name:  "different ISRCs",
+			a:     track("studio", "John Coltrane", "Naima", 261000, "USAT29900609"),
+			b:     track("live", "John Coltrane", "Naima", 261000, "USAT29900610"),
+			match: false,
+		},
+		{
+			name:  "remaster without ISRC within tolerance",
+			a:     track("album", "Miles Davis", "So What", 562000, ""),
+			b:     track("remaster", "Miles Davis", "So What - Remastered 2009", 564000, "USSM15900113"),
+			match: true,
+		},
+		{
+			name:  "parenthesized remaster and punctuation",
+			a:     track("album", "Thelonious Monk", "'Round Midnight", 323000, ""),
+			b:     track("remaster", "thelonious monk", "Round Midnight (2015 Remaster)", 323500, ""),
+			match: true,
+		},
+		{
+			name:  "durations beyond tolerance",
+			a:     track("studio", "Miles Davis", "So What", 562000, ""),
+			b:     track("live", "Miles Davis", "So What", 740000, ""),
+			match: false,
+		},
+		{
+			name:  "unknown duration",
+			a:     track("a", "Miles Davis", "So What", 0, ""),
+			b:     track("b", "Miles Davis", "So What", 562000, ""),
+			match: false,
+		},
+		{
+			name:  "different artist",
+			a:     track("a", "Miles Davis", "Autumn Leaves", 400000, ""),
+			b:     track("b", "Cannonball Adderley", "Autumn Leaves", 400000, ""),
+			match: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.match, NewIdentity(tt.a).Matches(NewIdentity(tt.b), DefaultDurationTolerance))
+			assert.Equal(t, tt.match, NewIdentity(tt.b).Matches(NewIdentity(tt.a), DefaultDurationTolerance))
+		})
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	day := time.Date(2025, 10, 1, 20, 0, 0, 0, time.UTC)
+	items := []types.PlaylistItem{
+		{Track: track("remaster", "Miles Davis", "So What - Remastered", 563000, ""), AddedAt: day.Add(48 * time.Hour)},
+		{Track: track("naima", "John Coltrane", "Naima", 261000, "USAT29900609"), AddedAt: day},
+		{Track: track("so-what", "Miles Davis", "So What", 562000, ""), AddedAt: day.Add(time.Hour)},
+		{Track: track("blue", "Miles Davis", "Blue in Green", 337000, ""), AddedAt: day.Add(2 * time.Hour)},
+		{Track: track("naima", "John Coltrane", "Naima", 261000, "USAT29900609"), AddedAt: day.Add(72 * time.Hour)},
+		{Track: track("naima-comp", "John Coltrane", "Naima", 261000, "USAT29900609"), AddedAt: day.Add(24 * time.Hour)},
+		{Track: types.Track{}, AddedAt: day},
+	}
+
+	groups := FindDuplicates(items, DefaultDurationTolerance)
+	require.Len(t, groups, 2)
+
+	// Groups are in playlist order of the kept, earliest added occurrence
+	assert.Equal(t, 1, groups[0].Kept.Position)
+	assert.Equal(t, "naima", groups[0].Kept.Track.ID)
+	require.Len(t, groups[0].Duplicates, 2)
+	assert.Equal(t, 5, groups[0].Duplicates[0].Position)
+	assert.Equal(t, 4, groups[0].Duplicates[1].Position)
+
+	assert.Equal(t, 2, groups[1].Kept.Position)
+	require.Len(t, groups[1].Duplicates, 1)
+	assert.Equal(t, "remaster", groups[1].Duplicates[0].Track.ID)
+
+	assert.Empty(t, FindDuplicates(items[2:4], DefaultDurationTolerance))
+}
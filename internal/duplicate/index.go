@@ -0,0 +1,221 @@
+package duplicate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+// IndexFileName is the name of the added tracks index file within the state directory.
+const IndexFileName = "added.json"
+
+// IndexedPlaylist is a playlist in the index and its tracks, by track ID.
+type IndexedPlaylist struct {
+	Profile string                  `json:"profile,omitempty"`
+	Name    string                  `json:"name"`
+	Tracks  map[string]IndexedTrack `json:"tracks"`
+}
+
+// IndexedTrack is a track in an indexed playlist, with what identifies its recording
+// and when it was added.
+type IndexedTrack struct {
+	Name     string    `json:"name"`
+	Artist   string    `json:"artist,omitempty"`
+	ISRC     string    `json:"isrc,omitempty"`
+	Duration int       `json:"duration_ms,omitempty"`
+	AddedAt  time.Time `json:"added_at"`
+}
+
+// newIndexedTrack returns the indexed form of a track added at addedAt.
+func newIndexedTrack(track types.Track, addedAt time.Time) IndexedTrack {
+	indexed := IndexedTrack{Name: track.Name, ISRC: track.ISRC, Duration: track.Duration, AddedAt: addedAt}
+	if len(track.Artists) > 0 {
+		indexed.Artist = track.Artists[0].Name
+	}
+	return indexed
+}
+
+// identity returns the recording identity of the indexed track with the ID.
+func (t IndexedTrack) identity(trackID string) Identity {
+	track := types.Track{ID: trackID, Name: t.Name, ISRC: t.ISRC, Duration: t.Duration}
+	if t.Artist != "" {
+		track.Artists = []types.Artist{{Name: t.Artist}}
+	}
+	return NewIdentity(track)
+}
+
+// Addition is a track found in an indexed playlist.
+type Addition struct {
+	PlaylistID   string
+	PlaylistName string
+	AddedAt      time.Time
+}
+
+// Index is a local index of which tracks were added to which playlists, persisted to
+// a JSON file so duplicates across playlists can be found without fetching them all.
+type Index struct {
+	mu        sync.Mutex
+	path      string
+	playlists map[string]*IndexedPlaylist
+	logger    *log.Entry
+}
+
+// OpenIndex opens the index stored at path, creating an empty one if the file doesn't
+// exist yet.
+func OpenIndex(path string) (*Index, error) {
+	i := &Index{
+		path:      path,
+		playlists: make(map[string]*IndexedPlaylist),
+		logger:    log.WithField("component", "duplicate_index"),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return i, nil
+		}
+		return nil, fmt.Errorf("failed to read duplicate index file: %w", err)
+	}
+	if err := json.Unmarshal(data, &i.playlists); err != nil {
+		return nil, fmt.Errorf("failed to parse duplicate index file: %w", err)
+	}
+	if i.playlists == nil {
+		i.playlists = make(map[string]*IndexedPlaylist)
+	}
+
+	i.logger.WithField("playlists", len(i.playlists)).Debug("Loaded duplicate index from file")
+	return i, nil
+}
+
+// Indexed reports whether the playlist is in the index.
+func (i *Index) Indexed(playlistID string) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	_, ok := i.playlists[playlistID]
+	return ok
+}
+
+// SetPlaylist replaces the indexed tracks of a profile's playlist with its items.
+func (i *Index) SetPlaylist(profile string, playlist types.Playlist, items []types.PlaylistItem) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	indexed := &IndexedPlaylist{Profile: profile, Name: playlist.Name, Tracks: make(map[string]IndexedTrack)}
+	for _, item := range items {
+		if item.Track.ID == "" {
+			continue
+		}
+		if added, ok := indexed.Tracks[item.Track.ID]; !ok || item.AddedAt.After(added.AddedAt) {
+			indexed.Tracks[item.Track.ID] = newIndexedTrack(item.Track, item.AddedAt)
+		}
+	}
+	i.playlists[playlist.ID] = indexed
+	return i.saveUnsafe()
+}
+
+// Record records that a track was added to an indexed playlist.
+func (i *Index) Record(playlistID string, track types.Track, addedAt time.Time) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	indexed, ok := i.playlists[playlistID]
+	if !ok {
+		return fmt.Errorf("playlist %s is not indexed", playlistID)
+	}
+	indexed.Tracks[track.ID] = newIndexedTrack(track, addedAt)
+	return i.saveUnsafe()
+}
+
+// Prune removes the profile's playlists with the name prefix that are not in keep,
+// such as playlists that were deleted or renamed, and returns how many were removed.
+func (i *Index) Prune(profile, prefix string, keep map[string]bool) (int, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	removed := 0
+	for id, indexed := range i.playlists {
+		if indexed.Profile == profile && hasPrefix(indexed.Name, prefix) && !keep[id] {
+			delete(i.playlists, id)
+			removed++
+		}
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, i.saveUnsafe()
+}
+
+// Find returns where the track's recording was added among the profile's playlists
+// with the name prefix, at or after since, most recent first. A zero since finds every
+// addition. Recordings are compared by identity, with durations within the tolerance.
+func (i *Index) Find(profile, prefix string, track types.Track, since time.Time, tolerance time.Duration) []Addition {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	identity := NewIdentity(track)
+	var additions []Addition
+	for id, indexed := range i.playlists {
+		if indexed.Profile != profile || !hasPrefix(indexed.Name, prefix) {
+			continue
+		}
+
+		// Only the most recent addition of the recording to each playlist counts
+		var latest *IndexedTrack
+		for trackID, added := range indexed.Tracks {
+			if added.AddedAt.Before(since) || !identity.Matches(added.identity(trackID), tolerance) {
+				continue
+			}
+			if latest == nil || added.AddedAt.After(latest.AddedAt) {
+				latest = &added
+			}
+		}
+		if latest != nil {
+			additions = append(additions, Addition{PlaylistID: id, PlaylistName: indexed.Name, AddedAt: latest.AddedAt})
+		}
+	}
+
+	// Most recent first, by playlist name for equal times so results are stable
+	sort.Slice(additions, func(a, b int) bool {
+		if !additions[a].AddedAt.Equal(additions[b].AddedAt) {
+			return additions[a].AddedAt.After(additions[b].AddedAt)
+		}
+		return additions[a].PlaylistName < additions[b].PlaylistName
+	})
+	return additions
+}
+
+// hasPrefix reports whether a playlist name belongs to the playlists with the prefix,
+// such as "KMHD-2025-10" for the prefix "KMHD".
+func hasPrefix(name, prefix string) bool {
+	return strings.HasPrefix(name, prefix+"-")
+}
+
+// saveUnsafe writes the index to disk without acquiring locks.
+// This should only be called when the caller already holds the lock.
+func (i *Index) saveUnsafe() error {
+	data, err := json.MarshalIndent(i.playlists, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal duplicate index: %w", err)
+	}
+
+	// Write to temporary file first, then rename for atomic operation
+	tempFile := i.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("failed to write duplicate index file: %w", err)
+	}
+
+	if err := os.Rename(tempFile, i.path); err != nil {
+		_ = os.Remove(tempFile) // Clean up temp file
+		return fmt.Errorf("failed to rename duplicate index file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package duplicate
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+func TestIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), IndexFileName)
+	index, err := OpenIndex(path)
+	require.NoError(t, err)
+
+	september := time.Date(2025, 9, 3, 20, 0, 0, 0, time.UTC)
+	october := time.Date(2025, 10, 5, 20, 0, 0, 0, time.UTC)
+
+	require.NoError(t, index.SetPlaylist("", types.Playlist{ID: "sep", Name: "KMHD-2025-09"}, []types.PlaylistItem{
+		{Track: types.Track{ID: "naima"}, AddedAt: september},
+		{Track: types.Track{ID: "naima"}, AddedAt: september.Add(time.Hour)},
+		{Track: types.Track{}, AddedAt: september},
+	}))
+	require.NoError(t, index.SetPlaylist("", types.Playlist{ID: "oct", Name: "KMHD-2025-10"}, nil))
+	require.NoError(t, index.SetPlaylist("work", types.Playlist{ID: "work-oct", Name: "KMHD-2025-10"}, []types.PlaylistItem{
+		{Track: types.Track{ID: "naima"}, AddedAt: october},
+	}))
+	require.NoError(t, index.Record("oct", types.Track{ID: "naima"}, october))
+	assert.Error(t, index.Record("missing", types.Track{ID: "naima"}, october))
+
+	// Reopening keeps the index
+	index, err = OpenIndex(path)
+	require.NoError(t, err)
+	assert.True(t, index.Indexed("sep"))
+
+	additions := index.Find("", "KMHD", types.Track{ID: "naima"}, time.Time{}, 0)
+	require.Len(t, additions, 2)
+	assert.Equal(t, "KMHD-2025-10", additions[0].PlaylistName)
+	assert.True(t, october.Equal(additions[0].AddedAt))
+	assert.Equal(t, "KMHD-2025-09", additions[1].PlaylistName)
+	assert.True(t, september.Add(time.Hour).Equal(additions[1].AddedAt))
+
+	assert.Len(t, index.Find("", "KMHD", types.Track{ID: "naima"}, october, 0), 1)
+	assert.Len(t, index.Find("work", "KMHD", types.Track{ID: "naima"}, time.Time{}, 0), 1)
+	assert.Empty(t, index.Find("", "Jazz", types.Track{ID: "naima"}, time.Time{}, 0))
+
+	// Other releases of the recording are found by identity
+	require.NoError(t, index.Record("oct", types.Track{ID: "giant-steps", Name: "Giant Steps", ISRC: "USAT20001234"}, october))
+	compilation := types.Track{ID: "giant-steps-comp", Name: "Giant Steps", ISRC: "USAT20001234"}
+	assert.Len(t, index.Find("", "KMHD", compilation, time.Time{}, 0), 1)
+
+	removed, err := index.Prune("", "KMHD", map[string]bool{"oct": true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.False(t, index.Indexed("sep"))
+	assert.True(t, index.Indexed("work-oct"))
+}
@@ -0,0 +1,178 @@
+// Package export writes KMHD plays and Spotify playlists to standard playlist formats.
+//
+// Every format carries the KMHD metadata of a play (when it was played, the album and
+// the KMHD ID) alongside the Spotify URI of its match, so exports can be imported into
+// other players and services or archived independently of Spotify.
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/toozej/kmhd2spotify/internal/history"
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+// Format is a playlist file format.
+type Format string
+
+// Supported export formats.
+const (
+	FormatM3U8 Format = "m3u8"
+	FormatXSPF Format = "xspf"
+	FormatJSPF Format = "jspf"
+	FormatCSV  Format = "csv"
+	FormatJSON Format = "json"
+)
+
+// Formats lists the supported export formats.
+var Formats = []Format{FormatM3U8, FormatXSPF, FormatJSPF, FormatCSV, FormatJSON}
+
+// ParseFormat returns the format with the given name.
+func ParseFormat(name string) (Format, error) {
+	for _, format := range Formats {
+		if strings.EqualFold(name, string(format)) {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported export format %q, expected one of m3u8, xspf, jspf, csv or json", name)
+}
+
+// FormatForPath returns the format matching the file extension of path, such as m3u8
+// for "october.m3u8".
+func FormatForPath(path string) (Format, error) {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	if ext == "" {
+		return "", fmt.Errorf("cannot tell the export format of %q without a file extension", path)
+	}
+	if strings.EqualFold(ext, "m3u") {
+		return FormatM3U8, nil
+	}
+	return ParseFormat(ext)
+}
+
+// Entry is a single exported play or playlist track.
+type Entry struct {
+	Artist   string        `json:"artist"`
+	Title    string        `json:"title"`
+	Album    string        `json:"album,omitempty"`
+	Duration time.Duration `json:"-"`
+
+	// PlayedAt and KMHDID describe the KMHD play, if the entry is known to KMHD
+	PlayedAt time.Time `json:"played_at,omitzero"`
+	KMHDID   string    `json:"kmhd_id,omitempty"`
+
+	// SpotifyURI is the Spotify track the play was matched to, if any
+	SpotifyURI string `json:"spotify_uri,omitempty"`
+}
+
+// entryJSON is the JSON form of an entry, with the duration in milliseconds.
+type entryJSON struct {
+	jsonEntry
+	DurationMS int64 `json:"duration_ms,omitempty"`
+}
+
+// jsonEntry has the fields of an entry without its methods, so marshalling it doesn't
+// recurse.
+type jsonEntry Entry
+
+// MarshalJSON encodes the entry with its duration in milliseconds as duration_ms.
+func (e Entry) MarshalJSON() ([]byte, error) {
+	return json.Marshal(entryJSON{jsonEntry: jsonEntry(e), DurationMS: e.Duration.Milliseconds()})
+}
+
+// UnmarshalJSON decodes an entry encoded by MarshalJSON.
+func (e *Entry) UnmarshalJSON(data []byte) error {
+	var decoded entryJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*e = Entry(decoded.jsonEntry)
+	e.Duration = time.Duration(decoded.DurationMS) * time.Millisecond
+	return nil
+}
+
+// SpotifyURL returns the open.spotify.com link of the entry's Spotify track, or an
+// empty string if it has none.
+func (e Entry) SpotifyURL() string {
+	trackID, ok := strings.CutPrefix(e.SpotifyURI, "spotify:track:")
+	if !ok || trackID == "" {
+		return ""
+	}
+	return "https://open.spotify.com/track/" + trackID
+}
+
+// Playlist is a titled list of entries to export.
+type Playlist struct {
+	Title   string    `json:"title"`
+	Created time.Time `json:"created"`
+	Entries []Entry   `json:"entries"`
+}
+
+// FromSong creates an entry from a KMHD play without a Spotify match.
+func FromSong(song types.Song) Entry {
+	return Entry{
+		Artist:   song.Artist,
+		Title:    song.Title,
+		Album:    song.Album,
+		Duration: song.Duration,
+		PlayedAt: song.PlayedAt,
+		KMHDID:   song.KMHDID,
+	}
+}
+
+// FromPlay creates an entry from a recorded play and its Spotify match.
+func FromPlay(play history.Play) Entry {
+	entry := FromSong(play.Song)
+	if play.Match != nil {
+		entry.SpotifyURI = play.Match.TrackURI
+		if entry.SpotifyURI == "" && play.Match.TrackID != "" {
+			entry.SpotifyURI = "spotify:track:" + play.Match.TrackID
+		}
+		if entry.Album == "" {
+			entry.Album = play.Match.Album
+		}
+	}
+	return entry
+}
+
+// FromTrack creates an entry from a Spotify track.
+func FromTrack(track types.Track) Entry {
+	artists := make([]string, 0, len(track.Artists))
+	for _, artist := range track.Artists {
+		artists = append(artists, artist.Name)
+	}
+	uri := track.URI
+	if uri == "" && track.ID != "" {
+		uri = "spotify:track:" + track.ID
+	}
+	return Entry{
+		Artist:     strings.Join(artists, ", "),
+		Title:      track.Name,
+		Album:      track.Album.Name,
+		Duration:   time.Duration(track.Duration) * time.Millisecond,
+		SpotifyURI: uri,
+	}
+}
+
+// Write writes the playlist to w in the given format.
+func Write(w io.Writer, format Format, playlist Playlist) error {
+	switch format {
+	case FormatM3U8:
+		return writeM3U8(w, playlist)
+	case FormatXSPF:
+		return writeXSPF(w, playlist)
+	case FormatJSPF:
+		return writeJSPF(w, playlist)
+	case FormatCSV:
+		return writeCSV(w, playlist)
+	case FormatJSON:
+		return writeJSON(w, playlist)
+	default:
+		return fmt.Errorf("unsupported export format %q", format)
+	}
+}
@@ -0,0 +1,73 @@
+package export
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/history"
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+var playedAt = time.Date(2025, time.October, 17, 12, 0, 0, 0, time.UTC)
+
+func TestParseFormat(t *testing.T) {
+	for _, format := range Formats {
+		parsed, err := ParseFormat(string(format))
+		require.NoError(t, err)
+		assert.Equal(t, format, parsed)
+	}
+
+	format, err := ParseFormat("XSPF")
+	require.NoError(t, err)
+	assert.Equal(t, FormatXSPF, format)
+
+	_, err = ParseFormat("pls")
+	assert.Error(t, err)
+}
+
+func TestFormatForPath(t *testing.T) {
+	format, err := FormatForPath("exports/october.jspf")
+	require.NoError(t, err)
+	assert.Equal(t, FormatJSPF, format)
+
+	format, err = FormatForPath("october.m3u")
+	require.NoError(t, err)
+	assert.Equal(t, FormatM3U8, format)
+
+	_, err = FormatForPath("october")
+	assert.Error(t, err)
+	_, err = FormatForPath("october.txt")
+	assert.Error(t, err)
+}
+
+func TestFromPlay(t *testing.T) {
+	song := types.Song{Artist: "Miles Davis", Title: "So What", PlayedAt: playedAt, KMHDID: "abc", Duration: 9 * time.Minute}
+
+	entry := FromPlay(history.Play{Song: song, Match: &history.Match{TrackID: "track1", Album: "Kind of Blue"}})
+	assert.Equal(t, "spotify:track:track1", entry.SpotifyURI)
+	assert.Equal(t, "Kind of Blue", entry.Album, "the matched album fills in a missing KMHD album")
+	assert.Equal(t, "abc", entry.KMHDID)
+	assert.Equal(t, "https://open.spotify.com/track/track1", entry.SpotifyURL())
+
+	entry = FromPlay(history.Play{Song: song})
+	assert.Empty(t, entry.SpotifyURI)
+	assert.Empty(t, entry.SpotifyURL())
+}
+
+func TestFromTrack(t *testing.T) {
+	entry := FromTrack(types.Track{
+		ID:       "track1",
+		Name:     "So What",
+		Artists:  []types.Artist{{Name: "Miles Davis"}, {Name: "John Coltrane"}},
+		Album:    types.Album{Name: "Kind of Blue"},
+		Duration: 562000,
+	})
+
+	assert.Equal(t, "Miles Davis, John Coltrane", entry.Artist)
+	assert.Equal(t, "spotify:track:track1", entry.SpotifyURI)
+	assert.Equal(t, 562*time.Second, entry.Duration)
+	assert.True(t, entry.PlayedAt.IsZero())
+}
@@ -0,0 +1,260 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// metaNamespace prefixes the XSPF meta and JSPF extension keys of KMHD metadata.
+const metaNamespace = "https://github.com/toozej/kmhd2spotify#"
+
+// jspfTrackExtension is the ListenBrainz JSPF track extension key.
+const jspfTrackExtension = "https://musicbrainz.org/doc/jspf#track"
+
+// writeM3U8 writes an extended M3U playlist. Matched entries point at their Spotify
+// URI; unmatched plays are kept as comments so the file still records them.
+func writeM3U8(w io.Writer, playlist Playlist) error {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	if playlist.Title != "" {
+		fmt.Fprintf(&b, "#PLAYLIST:%s\n", oneLine(playlist.Title))
+	}
+
+	for _, entry := range playlist.Entries {
+		b.WriteString("\n")
+		if !entry.PlayedAt.IsZero() {
+			fmt.Fprintf(&b, "# played_at: %s\n", entry.PlayedAt.Format(time.RFC3339))
+		}
+		if entry.KMHDID != "" {
+			fmt.Fprintf(&b, "# kmhd_id: %s\n", oneLine(entry.KMHDID))
+		}
+		if entry.SpotifyURI == "" {
+			fmt.Fprintf(&b, "# not matched on Spotify: %s - %s\n", oneLine(entry.Artist), oneLine(entry.Title))
+			continue
+		}
+
+		seconds := -1
+		if entry.Duration > 0 {
+			seconds = int(entry.Duration.Round(time.Second).Seconds())
+		}
+		fmt.Fprintf(&b, "#EXTINF:%d,%s - %s\n", seconds, oneLine(entry.Artist), oneLine(entry.Title))
+		if entry.Album != "" {
+			fmt.Fprintf(&b, "#EXTALB:%s\n", oneLine(entry.Album))
+		}
+		fmt.Fprintf(&b, "%s\n", entry.SpotifyURI)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// xspfPlaylist is the XML structure of an XSPF playlist.
+type xspfPlaylist struct {
+	XMLName   xml.Name    `xml:"playlist"`
+	Version   string      `xml:"version,attr"`
+	Namespace string      `xml:"xmlns,attr"`
+	Title     string      `xml:"title,omitempty"`
+	Date      string      `xml:"date,omitempty"`
+	Tracks    []xspfTrack `xml:"trackList>track"`
+}
+
+// xspfTrack is the XML structure of an XSPF track.
+type xspfTrack struct {
+	Location   []string   `xml:"location,omitempty"`
+	Identifier []string   `xml:"identifier,omitempty"`
+	Title      string     `xml:"title,omitempty"`
+	Creator    string     `xml:"creator,omitempty"`
+	Album      string     `xml:"album,omitempty"`
+	Duration   int64      `xml:"duration,omitempty"`
+	Meta       []xspfMeta `xml:"meta,omitempty"`
+}
+
+// xspfMeta is an XSPF meta element.
+type xspfMeta struct {
+	Rel   string `xml:"rel,attr"`
+	Value string `xml:",chardata"`
+}
+
+// writeXSPF writes an XSPF playlist, with KMHD metadata in meta elements.
+func writeXSPF(w io.Writer, playlist Playlist) error {
+	doc := xspfPlaylist{
+		Version:   "1",
+		Namespace: "http://xspf.org/ns/0/",
+		Title:     playlist.Title,
+		Date:      formatTime(playlist.Created),
+		Tracks:    make([]xspfTrack, 0, len(playlist.Entries)),
+	}
+
+	for _, entry := range playlist.Entries {
+		track := xspfTrack{
+			Title:    entry.Title,
+			Creator:  entry.Artist,
+			Album:    entry.Album,
+			Duration: entry.Duration.Milliseconds(),
+		}
+		if entry.SpotifyURI != "" {
+			track.Location = []string{entry.SpotifyURI}
+			track.Identifier = []string{entry.SpotifyURL()}
+		}
+		for _, meta := range entryMetadata(entry) {
+			track.Meta = append(track.Meta, xspfMeta{Rel: metaNamespace + meta[0], Value: meta[1]})
+		}
+		doc.Tracks = append(doc.Tracks, track)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode XSPF playlist: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// jspfDocument is the JSON structure of a JSPF playlist.
+type jspfDocument struct {
+	Playlist jspfPlaylist `json:"playlist"`
+}
+
+// jspfPlaylist is a JSPF playlist.
+type jspfPlaylist struct {
+	Title   string      `json:"title"`
+	Creator string      `json:"creator"`
+	Date    string      `json:"date,omitempty"`
+	Track   []jspfTrack `json:"track"`
+}
+
+// jspfTrack is a JSPF track.
+type jspfTrack struct {
+	Title      string         `json:"title"`
+	Creator    string         `json:"creator"`
+	Album      string         `json:"album,omitempty"`
+	Duration   int64          `json:"duration,omitempty"`
+	Identifier []string       `json:"identifier,omitempty"`
+	Location   []string       `json:"location,omitempty"`
+	Extension  map[string]any `json:"extension,omitempty"`
+}
+
+// writeJSPF writes a JSPF playlist as used by ListenBrainz, with KMHD metadata in the
+// additional_metadata of the ListenBrainz track extension.
+func writeJSPF(w io.Writer, playlist Playlist) error {
+	doc := jspfDocument{Playlist: jspfPlaylist{
+		Title:   playlist.Title,
+		Creator: "kmhd2spotify",
+		Date:    formatTime(playlist.Created),
+		Track:   make([]jspfTrack, 0, len(playlist.Entries)),
+	}}
+
+	for _, entry := range playlist.Entries {
+		track := jspfTrack{
+			Title:    entry.Title,
+			Creator:  entry.Artist,
+			Album:    entry.Album,
+			Duration: entry.Duration.Milliseconds(),
+		}
+		if entry.SpotifyURI != "" {
+			track.Identifier = []string{entry.SpotifyURL()}
+			track.Location = []string{entry.SpotifyURI}
+		}
+
+		metadata := make(map[string]string)
+		for _, meta := range entryMetadata(entry) {
+			metadata[meta[0]] = meta[1]
+		}
+		extension := map[string]any{"additional_metadata": metadata}
+		if !entry.PlayedAt.IsZero() {
+			extension["added_at"] = formatTime(entry.PlayedAt)
+			extension["added_by"] = "KMHD"
+		}
+		track.Extension = map[string]any{jspfTrackExtension: extension}
+
+		doc.Playlist.Track = append(doc.Playlist.Track, track)
+	}
+
+	return writeIndentedJSON(w, doc)
+}
+
+// csvHeader is the header row of CSV exports.
+var csvHeader = []string{"played_at", "artist", "title", "album", "duration_seconds", "kmhd_id", "spotify_uri"}
+
+// writeCSV writes one row per entry.
+func writeCSV(w io.Writer, playlist Playlist) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, entry := range playlist.Entries {
+		duration := ""
+		if entry.Duration > 0 {
+			duration = strconv.Itoa(int(entry.Duration.Round(time.Second).Seconds()))
+		}
+		row := []string{
+			formatTime(entry.PlayedAt),
+			entry.Artist,
+			entry.Title,
+			entry.Album,
+			duration,
+			entry.KMHDID,
+			entry.SpotifyURI,
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeJSON writes the playlist as JSON.
+func writeJSON(w io.Writer, playlist Playlist) error {
+	if playlist.Entries == nil {
+		playlist.Entries = []Entry{}
+	}
+	return writeIndentedJSON(w, playlist)
+}
+
+// writeIndentedJSON encodes v as indented JSON.
+func writeIndentedJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// entryMetadata returns the KMHD metadata of an entry as name and value pairs.
+func entryMetadata(entry Entry) [][2]string {
+	var metadata [][2]string
+	if !entry.PlayedAt.IsZero() {
+		metadata = append(metadata, [2]string{"played_at", formatTime(entry.PlayedAt)})
+	}
+	if entry.KMHDID != "" {
+		metadata = append(metadata, [2]string{"kmhd_id", entry.KMHDID})
+	}
+	if entry.SpotifyURI != "" {
+		metadata = append(metadata, [2]string{"spotify_uri", entry.SpotifyURI})
+	}
+	return metadata
+}
+
+// formatTime formats a time as RFC 3339, or an empty string for the zero time.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// oneLine replaces line breaks so a value can't start a new M3U line.
+func oneLine(value string) string {
+	return strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(value)
+}
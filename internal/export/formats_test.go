@@ -0,0 +1,114 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPlaylist() Playlist {
+	return Playlist{
+		Title:   "KMHD 2025-10-17",
+		Created: playedAt,
+		Entries: []Entry{
+			{Artist: "Miles Davis", Title: "So What", Album: "Kind of Blue", Duration: 562 * time.Second, PlayedAt: playedAt, KMHDID: "abc", SpotifyURI: "spotify:track:track1"},
+			{Artist: "Unknown\nTrio", Title: "Live Set", PlayedAt: playedAt.Add(10 * time.Minute), KMHDID: "def"},
+		},
+	}
+}
+
+func write(t *testing.T, format Format, playlist Playlist) string {
+	t.Helper()
+	var out bytes.Buffer
+	require.NoError(t, Write(&out, format, playlist))
+	return out.String()
+}
+
+func TestWriteM3U8(t *testing.T) {
+	out := write(t, FormatM3U8, testPlaylist())
+
+	assert.True(t, strings.HasPrefix(out, "#EXTM3U\n#PLAYLIST:KMHD 2025-10-17\n"))
+	assert.Contains(t, out, "# played_at: 2025-10-17T12:00:00Z\n# kmhd_id: abc\n#EXTINF:562,Miles Davis - So What\n#EXTALB:Kind of Blue\nspotify:track:track1\n")
+	assert.Contains(t, out, "# not matched on Spotify: Unknown Trio - Live Set\n")
+
+	// Every non-comment line is a playable location
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line != "" && !strings.HasPrefix(line, "#") {
+			assert.True(t, strings.HasPrefix(line, "spotify:track:"), "unexpected location %q", line)
+		}
+	}
+}
+
+func TestWriteXSPF(t *testing.T) {
+	out := write(t, FormatXSPF, testPlaylist())
+
+	var doc xspfPlaylist
+	require.NoError(t, xml.Unmarshal([]byte(out), &doc))
+	assert.Equal(t, "http://xspf.org/ns/0/", doc.Namespace)
+	require.Len(t, doc.Tracks, 2)
+	assert.Equal(t, []string{"spotify:track:track1"}, doc.Tracks[0].Location)
+	assert.Equal(t, int64(562000), doc.Tracks[0].Duration)
+	assert.Contains(t, doc.Tracks[0].Meta, xspfMeta{Rel: metaNamespace + "played_at", Value: "2025-10-17T12:00:00Z"})
+	assert.Contains(t, doc.Tracks[1].Meta, xspfMeta{Rel: metaNamespace + "kmhd_id", Value: "def"})
+	assert.Empty(t, doc.Tracks[1].Location)
+}
+
+func TestWriteJSPF(t *testing.T) {
+	out := write(t, FormatJSPF, testPlaylist())
+
+	var doc struct {
+		Playlist struct {
+			Title string `json:"title"`
+			Track []struct {
+				Title      string   `json:"title"`
+				Creator    string   `json:"creator"`
+				Identifier []string `json:"identifier"`
+				Extension  map[string]struct {
+					AddedAt            string            `json:"added_at"`
+					AdditionalMetadata map[string]string `json:"additional_metadata"`
+				} `json:"extension"`
+			} `json:"track"`
+		} `json:"playlist"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &doc))
+	assert.Equal(t, "KMHD 2025-10-17", doc.Playlist.Title)
+	require.Len(t, doc.Playlist.Track, 2)
+
+	track := doc.Playlist.Track[0]
+	assert.Equal(t, []string{"https://open.spotify.com/track/track1"}, track.Identifier)
+	extension := track.Extension[jspfTrackExtension]
+	assert.Equal(t, "2025-10-17T12:00:00Z", extension.AddedAt)
+	assert.Equal(t, "abc", extension.AdditionalMetadata["kmhd_id"])
+	assert.Equal(t, "spotify:track:track1", extension.AdditionalMetadata["spotify_uri"])
+}
+
+func TestWriteCSV(t *testing.T) {
+	out := write(t, FormatCSV, testPlaylist())
+
+	rows, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, csvHeader, rows[0])
+	assert.Equal(t, []string{"2025-10-17T12:00:00Z", "Miles Davis", "So What", "Kind of Blue", "562", "abc", "spotify:track:track1"}, rows[1])
+	assert.Equal(t, "Unknown\nTrio", rows[2][1], "CSV quoting keeps values intact")
+}
+
+func TestWriteJSON(t *testing.T) {
+	var decoded Playlist
+	require.NoError(t, json.Unmarshal([]byte(write(t, FormatJSON, testPlaylist())), &decoded))
+	assert.Equal(t, testPlaylist(), decoded)
+	assert.Contains(t, write(t, FormatJSON, testPlaylist()), `"duration_ms": 562000`)
+
+	assert.Contains(t, write(t, FormatJSON, Playlist{Title: "empty"}), `"entries": []`)
+}
+
+func TestWriteUnsupportedFormat(t *testing.T) {
+	assert.Error(t, Write(&bytes.Buffer{}, Format("pls"), testPlaylist()))
+}
@@ -0,0 +1,6 @@
+// Package filelock provides exclusive file locks shared between processes.
+//
+// State files such as the scrobble queue, the retry queue and the play history are
+// used by the sync daemon and by one-off commands at the same time. Each process takes
+// the lock on a companion lock file before reading and rewriting its state file.
+package filelock
@@ -0,0 +1,196 @@
+// Package health tracks the state of the sync loop for liveness and readiness probes.
+//
+// A Tracker records the outcome of each KMHD fetch and sync cycle and periodically
+// checks that the Spotify token is still valid. Its HTTP handlers serve /healthz,
+// which fails when the token is invalid or no sync has succeeded for too long, and
+// /readyz, which additionally requires at least one successful sync.
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTokenCheckInterval is how long a Spotify token check result is reused,
+	// so frequent probes don't turn into frequent Spotify API calls.
+	DefaultTokenCheckInterval = 5 * time.Minute
+)
+
+// TokenValidator checks that the Spotify token still grants API access.
+type TokenValidator func() error
+
+// Check is the result of a single health check.
+type Check struct {
+	OK    bool      `json:"ok"`
+	Error string    `json:"error,omitempty"`
+	At    time.Time `json:"at,omitempty"`
+}
+
+// Report is the JSON document served by the health endpoints.
+type Report struct {
+	Status           string           `json:"status"`
+	Ready            bool             `json:"ready"`
+	Checks           map[string]Check `json:"checks"`
+	LastSuccess      time.Time        `json:"last_success,omitempty"`
+	SinceLastSuccess string           `json:"since_last_success,omitempty"`
+	Uptime           string           `json:"uptime"`
+}
+
+// Tracker records sync loop state and evaluates health.
+// A nil Tracker is valid and ignores all records.
+type Tracker struct {
+	mu sync.Mutex
+
+	validateToken      TokenValidator
+	tokenCheckInterval time.Duration
+	maxStaleness       time.Duration
+	now                func() time.Time
+
+	startedAt   time.Time
+	token       Check
+	fetch       Check
+	sync        Check
+	lastSuccess time.Time
+}
+
+// NewTracker creates a tracker. The tracker reports unhealthy when no sync has
+// succeeded within maxStaleness (measured from startup until the first success).
+// validateToken may be nil if token validity cannot be checked.
+func NewTracker(validateToken TokenValidator, maxStaleness time.Duration) *Tracker {
+	return &Tracker{
+		validateToken:      validateToken,
+		tokenCheckInterval: DefaultTokenCheckInterval,
+		maxStaleness:       maxStaleness,
+		now:                time.Now,
+		startedAt:          time.Now(),
+	}
+}
+
+// RecordFetch records the result of a KMHD playlist fetch.
+func (t *Tracker) RecordFetch(err error) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.fetch = t.newCheck(err)
+}
+
+// RecordSync records the outcome of a sync cycle.
+func (t *Tracker) RecordSync(err error) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sync = t.newCheck(err)
+	if err == nil {
+		t.lastSuccess = t.sync.At
+	}
+}
+
+// newCheck builds a check result stamped with the current time. Callers hold t.mu.
+func (t *Tracker) newCheck(err error) Check {
+	check := Check{OK: err == nil, At: t.now()}
+	if err != nil {
+		check.Error = err.Error()
+	}
+	return check
+}
+
+// Report evaluates the current health, checking the Spotify token if the last
+// check is older than the token check interval.
+func (t *Tracker) Report() Report {
+	t.refreshTokenCheck()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+
+	checks := map[string]Check{}
+	healthy := true
+	if t.validateToken != nil {
+		checks["spotify_token"] = t.token
+		healthy = t.token.OK
+	}
+	if !t.fetch.At.IsZero() {
+		checks["kmhd_fetch"] = t.fetch
+	}
+	if !t.sync.At.IsZero() {
+		checks["sync"] = t.sync
+	}
+
+	// Until the first success, staleness is measured from startup
+	reference := t.lastSuccess
+	if reference.IsZero() {
+		reference = t.startedAt
+	}
+	if t.maxStaleness > 0 && now.Sub(reference) > t.maxStaleness {
+		healthy = false
+	}
+
+	report := Report{
+		Status:      "ok",
+		Ready:       healthy && !t.lastSuccess.IsZero(),
+		Checks:      checks,
+		LastSuccess: t.lastSuccess,
+		Uptime:      now.Sub(t.startedAt).Round(time.Second).String(),
+	}
+	if !healthy {
+		report.Status = "unhealthy"
+	}
+	if !t.lastSuccess.IsZero() {
+		report.SinceLastSuccess = now.Sub(t.lastSuccess).Round(time.Second).String()
+	}
+	return report
+}
+
+// refreshTokenCheck re-validates the Spotify token if the last check is stale.
+// The validation call is made without holding the lock, so a slow Spotify API
+// doesn't block the sync loop from recording results.
+func (t *Tracker) refreshTokenCheck() {
+	if t.validateToken == nil {
+		return
+	}
+
+	t.mu.Lock()
+	stale := t.token.At.IsZero() || t.now().Sub(t.token.At) >= t.tokenCheckInterval
+	t.mu.Unlock()
+	if !stale {
+		return
+	}
+
+	err := t.validateToken()
+
+	t.mu.Lock()
+	t.token = t.newCheck(err)
+	t.mu.Unlock()
+}
+
+// HandleHealthz serves the liveness report, responding 503 when unhealthy.
+func (t *Tracker) HandleHealthz(w http.ResponseWriter, r *http.Request) {
+	report := t.Report()
+	writeReport(w, report, report.Status == "ok")
+}
+
+// HandleReadyz serves the readiness report, responding 503 until ready.
+func (t *Tracker) HandleReadyz(w http.ResponseWriter, r *http.Request) {
+	report := t.Report()
+	writeReport(w, report, report.Ready)
+}
+
+// writeReport writes the report as JSON with a status code reflecting ok.
+func writeReport(w http.ResponseWriter, report Report, ok bool) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if ok {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(report)
+}
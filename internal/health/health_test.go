@@ -0,0 +1,122 @@
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testStart = time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)
+
+// newTestTracker creates a tracker with a controllable clock
+func newTestTracker(validate TokenValidator, maxStaleness time.Duration) (*Tracker, *time.Time) {
+	now := testStart
+	tracker := NewTracker(validate, maxStaleness)
+	tracker.now = func() time.Time { return now }
+	tracker.startedAt = now
+	return tracker, &now
+}
+
+func TestTracker_ReadyAfterFirstSuccessfulSync(t *testing.T) {
+	tracker, now := newTestTracker(func() error { return nil }, time.Hour)
+
+	report := tracker.Report()
+	assert.Equal(t, "ok", report.Status, "healthy during the startup grace period")
+	assert.False(t, report.Ready, "not ready before the first sync")
+
+	*now = now.Add(time.Minute)
+	tracker.RecordFetch(nil)
+	tracker.RecordSync(nil)
+
+	report = tracker.Report()
+	assert.Equal(t, "ok", report.Status)
+	assert.True(t, report.Ready)
+	assert.Equal(t, testStart.Add(time.Minute), report.LastSuccess)
+	assert.True(t, report.Checks["kmhd_fetch"].OK)
+	assert.True(t, report.Checks["sync"].OK)
+}
+
+func TestTracker_UnhealthyWhenStale(t *testing.T) {
+	tracker, now := newTestTracker(nil, time.Hour)
+	tracker.RecordSync(nil)
+
+	*now = now.Add(30 * time.Minute)
+	tracker.RecordFetch(errors.New("503"))
+	tracker.RecordSync(errors.New("KMHD fetch failed: 503"))
+
+	report := tracker.Report()
+	assert.Equal(t, "ok", report.Status, "a single failure within the staleness window is tolerated")
+	assert.False(t, report.Checks["sync"].OK)
+	assert.Equal(t, "KMHD fetch failed: 503", report.Checks["sync"].Error)
+	assert.Equal(t, "30m0s", report.SinceLastSuccess)
+
+	*now = now.Add(time.Hour)
+	report = tracker.Report()
+	assert.Equal(t, "unhealthy", report.Status)
+	assert.False(t, report.Ready)
+}
+
+func TestTracker_UnhealthyWithoutAnySuccess(t *testing.T) {
+	tracker, now := newTestTracker(nil, time.Hour)
+
+	*now = now.Add(2 * time.Hour)
+	assert.Equal(t, "unhealthy", tracker.Report().Status)
+}
+
+func TestTracker_InvalidTokenIsUnhealthy(t *testing.T) {
+	calls := 0
+	tokenErr := errors.New("refresh token revoked")
+	tracker, now := newTestTracker(func() error {
+		calls++
+		return tokenErr
+	}, time.Hour)
+	tracker.RecordSync(nil)
+
+	report := tracker.Report()
+	assert.Equal(t, "unhealthy", report.Status)
+	assert.False(t, report.Ready)
+	assert.Equal(t, "refresh token revoked", report.Checks["spotify_token"].Error)
+
+	// The token check result is reused until it is stale
+	tracker.Report()
+	assert.Equal(t, 1, calls)
+
+	tokenErr = nil
+	*now = now.Add(DefaultTokenCheckInterval)
+	tracker.RecordSync(nil)
+	report = tracker.Report()
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, "ok", report.Status)
+}
+
+func TestTracker_NilIgnoresRecords(t *testing.T) {
+	var tracker *Tracker
+	assert.NotPanics(t, func() {
+		tracker.RecordFetch(nil)
+		tracker.RecordSync(errors.New("boom"))
+	})
+}
+
+func TestTracker_Handlers(t *testing.T) {
+	tracker, _ := newTestTracker(func() error { return nil }, time.Hour)
+
+	recorder := httptest.NewRecorder()
+	tracker.HandleHealthz(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	recorder = httptest.NewRecorder()
+	tracker.HandleReadyz(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	var report Report
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &report))
+	assert.False(t, report.Ready)
+	assert.True(t, report.Checks["spotify_token"].OK)
+}
@@ -0,0 +1,538 @@
+// Package history persists every song played on KMHD and the Spotify track it was
+// matched to.
+//
+// Plays are appended to a JSON Lines file in the state directory. A later line for the
+// same play replaces an earlier one, so recording a match is a single append; the file
+// is compacted when it is opened for writing. Features such as the rolling playlist are
+// built on the play times recorded here, including repeat plays that are not synced again.
+//
+// The file is shared by a running sync and one-off commands such as 'import' and
+// 'stats'. Every read and write takes a file lock, and plays appended by another
+// process are read before the history is used.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/kmhd2spotify/internal/filelock"
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+// FileName is the name of the play history file within the state directory.
+const FileName = "history.jsonl"
+
+// ErrReadOnly is returned when recording plays in a history opened with OpenReadOnly.
+var ErrReadOnly = errors.New("play history is opened read-only")
+
+// Match is the Spotify track a play was matched to.
+type Match struct {
+	TrackID    string   `json:"track_id"`
+	TrackURI   string   `json:"track_uri,omitempty"`
+	TrackName  string   `json:"track_name,omitempty"`
+	Artists    []string `json:"artists,omitempty"`
+	Album      string   `json:"album,omitempty"`
+	Confidence float64  `json:"confidence,omitempty"`
+}
+
+// Play is a single KMHD play and its Spotify match, if it has been matched.
+type Play struct {
+	Song  types.Song `json:"song"`
+	Match *Match     `json:"match,omitempty"`
+
+	// Imported marks plays recovered from a synced Spotify playlist rather than the KMHD
+	// API. Their play time is when the track was added to the playlist.
+	Imported bool `json:"imported,omitempty"`
+
+	// Unmatched marks plays that were searched for on Spotify without a good enough
+	// match. Plays without a match that were never searched for, such as those of
+	// excluded artists, have neither a match nor this mark.
+	Unmatched bool `json:"unmatched,omitempty"`
+}
+
+// Key returns the identity of a play: its KMHD ID if known, otherwise its start time,
+// artist and title.
+func Key(song types.Song) string {
+	if song.KMHDID != "" {
+		return "kmhd:" + song.KMHDID
+	}
+	return fmt.Sprintf("%s|%s", song.PlayedAt.UTC().Format(time.RFC3339), recordingKey(song))
+}
+
+// recordingKey identifies the recording played, so a repeat play can reuse the match
+// of an earlier play of the same song.
+func recordingKey(song types.Song) string {
+	return strings.ToLower(strings.TrimSpace(song.Artist)) + "|" + strings.ToLower(strings.TrimSpace(song.Title))
+}
+
+// Store is a file-backed history of KMHD plays.
+type Store struct {
+	mu       sync.Mutex
+	path     string
+	readOnly bool
+	plays    []Play
+	index    map[string]int
+	matches  map[string]Match
+	logger   *log.Entry
+
+	// file, offset and lines describe how much of the history file has been read:
+	// the file read, the bytes read so far and the play lines among them.
+	file   os.FileInfo
+	offset int64
+	lines  int
+}
+
+// Open opens the play history stored at path for recording plays, creating an empty
+// history if the file does not exist. The file is compacted if it has replaced lines.
+func Open(path string) (*Store, error) {
+	s := newStore(path, false)
+
+	unlock, err := s.lockUnsafe()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if s.lines > len(s.plays) {
+		if err := s.compactUnsafe(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// OpenReadOnly opens the play history stored at path for commands that only read it.
+// The file is never rewritten, so it can be opened while a sync is recording plays.
+func OpenReadOnly(path string) (*Store, error) {
+	s := newStore(path, true)
+
+	unlock, err := s.lockUnsafe()
+	if err != nil {
+		return nil, err
+	}
+	unlock()
+	return s, nil
+}
+
+// newStore creates an empty store for the history file at path.
+func newStore(path string, readOnly bool) *Store {
+	return &Store{
+		path:     path,
+		readOnly: readOnly,
+		index:    make(map[string]int),
+		matches:  make(map[string]Match),
+		logger:   log.WithField("component", "play_history"),
+	}
+}
+
+// Record adds the plays that are not in the history yet and returns how many were
+// added. A new play of a song that was matched before inherits the earlier match.
+// Invalid songs are ignored.
+func (s *Store) Record(songs []types.Song) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := s.lockForWriteUnsafe()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	var added []Play
+	for _, song := range songs {
+		if !song.IsValid() {
+			continue
+		}
+		song.RawText = ""
+		key := Key(song)
+		if _, ok := s.index[key]; ok {
+			continue
+		}
+
+		play := Play{Song: song}
+		if match, ok := s.matches[recordingKey(song)]; ok {
+			play.Match = &match
+		}
+		s.putUnsafe(play)
+		added = append(added, play)
+	}
+
+	if err := s.appendUnsafe(added...); err != nil {
+		return 0, err
+	}
+	return len(added), nil
+}
+
+// SetMatch records the Spotify track a play was matched to, adding the play if it is
+// not in the history yet.
+func (s *Store) SetMatch(song types.Song, track types.Track, confidence float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := s.lockForWriteUnsafe()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	artists := make([]string, len(track.Artists))
+	for i, artist := range track.Artists {
+		artists[i] = artist.Name
+	}
+
+	song.RawText = ""
+	play := Play{
+		Song: song,
+		Match: &Match{
+			TrackID:    track.ID,
+			TrackURI:   track.URI,
+			TrackName:  track.Name,
+			Artists:    artists,
+			Album:      track.Album.Name,
+			Confidence: confidence,
+		},
+	}
+	s.putUnsafe(play)
+	return s.appendUnsafe(play)
+}
+
+// SetUnmatched records that a play was searched for on Spotify without a good enough
+// match, adding the play if it is not in the history yet. A play that is already
+// matched keeps its match.
+func (s *Store) SetUnmatched(song types.Song) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := s.lockForWriteUnsafe()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	song.RawText = ""
+	if i, ok := s.index[Key(song)]; ok && (s.plays[i].Match != nil || s.plays[i].Unmatched) {
+		return nil
+	}
+
+	play := Play{Song: song, Unmatched: true}
+	s.putUnsafe(play)
+	return s.appendUnsafe(play)
+}
+
+// Import adds plays recovered from elsewhere, such as tracks of synced Spotify
+// playlists, and returns how many were added. A play is skipped if it is already in
+// the history, or if the history has a play matched to the same track within window
+// of it, so importing is repeatable and doesn't duplicate plays recorded during syncs.
+func (s *Store) Import(plays []Play, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := s.lockForWriteUnsafe()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	playedAt := make(map[string][]time.Time)
+	for _, play := range s.plays {
+		if play.Match != nil {
+			playedAt[play.Match.TrackID] = append(playedAt[play.Match.TrackID], play.Song.PlayedAt)
+		}
+	}
+
+	var added []Play
+	for _, play := range plays {
+		if !play.Song.IsValid() || play.Match == nil {
+			continue
+		}
+		play.Song.RawText = ""
+		if _, ok := s.index[Key(play.Song)]; ok {
+			continue
+		}
+
+		nearby := false
+		for _, t := range playedAt[play.Match.TrackID] {
+			if d := t.Sub(play.Song.PlayedAt); d < window && d > -window {
+				nearby = true
+				break
+			}
+		}
+		if nearby {
+			continue
+		}
+
+		s.putUnsafe(play)
+		playedAt[play.Match.TrackID] = append(playedAt[play.Match.TrackID], play.Song.PlayedAt)
+		added = append(added, play)
+	}
+
+	if err := s.appendUnsafe(added...); err != nil {
+		return 0, err
+	}
+	return len(added), nil
+}
+
+// Plays returns all plays ordered by play time.
+func (s *Store) Plays() []Play {
+	return s.Between(time.Time{}, time.Time{})
+}
+
+// Between returns the plays from (inclusive) to until (exclusive), ordered by play
+// time. A zero from or until leaves that end of the range open.
+func (s *Store) Between(from, until time.Time) []Play {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshUnsafe()
+
+	var plays []Play
+	for _, play := range s.plays {
+		playedAt := play.Song.PlayedAt
+		if (!from.IsZero() && playedAt.Before(from)) || (!until.IsZero() && !playedAt.Before(until)) {
+			continue
+		}
+		plays = append(plays, play)
+	}
+
+	sort.SliceStable(plays, func(i, j int) bool {
+		return plays[i].Song.PlayedAt.Before(plays[j].Song.PlayedAt)
+	})
+	return plays
+}
+
+// Copy returns an in-memory copy of the history. Changes to the copy are not saved,
+// so it can be used to preview changes.
+func (s *Store) Copy() *Store {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := &Store{
+		plays:   make([]Play, len(s.plays)),
+		index:   make(map[string]int, len(s.index)),
+		matches: make(map[string]Match, len(s.matches)),
+		logger:  s.logger,
+	}
+	copy(c.plays, s.plays)
+	for key, i := range s.index {
+		c.index[key] = i
+	}
+	for key, match := range s.matches {
+		c.matches[key] = match
+	}
+	return c
+}
+
+// Len returns the number of recorded plays.
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshUnsafe()
+	return len(s.plays)
+}
+
+// putUnsafe adds or replaces a play in memory.
+func (s *Store) putUnsafe(play Play) {
+	key := Key(play.Song)
+	if i, ok := s.index[key]; ok {
+		s.plays[i] = play
+	} else {
+		s.index[key] = len(s.plays)
+		s.plays = append(s.plays, play)
+	}
+	if play.Match != nil {
+		s.matches[recordingKey(play.Song)] = *play.Match
+	}
+}
+
+// lockUnsafe takes the history file lock and reads the plays appended since the file
+// was last read, so plays recorded by another process are seen. An in-memory copy has
+// no file and nothing to lock. The returned function releases the lock.
+func (s *Store) lockUnsafe() (func(), error) {
+	if s.path == "" {
+		return func() {}, nil
+	}
+
+	unlock, err := filelock.Lock(s.path + ".lock")
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock play history: %w", err)
+	}
+	if err := s.readUnsafe(); err != nil {
+		unlock()
+		return nil, err
+	}
+	return unlock, nil
+}
+
+// lockForWriteUnsafe takes the history file lock before recording plays, unless the
+// store was opened read-only.
+func (s *Store) lockForWriteUnsafe() (func(), error) {
+	if s.readOnly {
+		return nil, ErrReadOnly
+	}
+	return s.lockUnsafe()
+}
+
+// refreshUnsafe reads plays recorded by another process for a query, keeping the
+// history in memory if that fails.
+func (s *Store) refreshUnsafe() {
+	unlock, err := s.lockUnsafe()
+	if err != nil {
+		s.logger.WithError(err).Warn("Failed to re-read play history")
+		return
+	}
+	unlock()
+}
+
+// readUnsafe reads the lines appended to the history file since it was last read. If
+// the file was replaced, for example by another process compacting it, it is read again
+// from the start.
+func (s *Store) readUnsafe() error {
+	file, err := os.Open(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to open play history: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to read play history: %w", err)
+	}
+	if s.file == nil || !os.SameFile(s.file, info) || info.Size() < s.offset {
+		s.plays = nil
+		s.index = make(map[string]int)
+		s.matches = make(map[string]Match)
+		s.offset, s.lines = 0, 0
+	}
+	s.file = info
+	if info.Size() == s.offset {
+		return nil
+	}
+	if _, err := file.Seek(s.offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read play history: %w", err)
+	}
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		s.offset += int64(len(line))
+		if len(bytes.TrimSpace(line)) > 0 {
+			s.lines++
+
+			var play Play
+			if err := json.Unmarshal(line, &play); err != nil {
+				// A crash while appending can leave a partial line
+				s.logger.WithError(err).WithField("line", s.lines).Warn("Skipping unreadable play history entry")
+			} else {
+				s.putUnsafe(play)
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read play history: %w", err)
+		}
+	}
+}
+
+// appendUnsafe appends plays to the history file, unless the store is an in-memory
+// copy. The caller must hold the history file lock.
+func (s *Store) appendUnsafe(plays ...Play) error {
+	if len(plays) == 0 || s.path == "" {
+		return nil
+	}
+
+	data, err := encodePlays(plays)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) // #nosec G304 -- path comes from configuration
+	if err != nil {
+		return fmt.Errorf("failed to open play history: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write play history: %w", err)
+	}
+	info, err := file.Stat()
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write play history: %w", err)
+	}
+
+	// The file was read up to its end before appending, so the new lines are read
+	s.file = info
+	s.offset = info.Size()
+	s.lines += len(plays)
+	return nil
+}
+
+// compactUnsafe rewrites the history file with one line per play. The caller must
+// hold the history file lock.
+func (s *Store) compactUnsafe() error {
+	data, err := encodePlays(s.plays)
+	if err != nil {
+		return err
+	}
+
+	// Write to temporary file first, then rename for atomic operation
+	temp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write play history: %w", err)
+	}
+	tempFile := temp.Name()
+	_, err = temp.Write(data)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tempFile) // Clean up temp file
+		return fmt.Errorf("failed to write play history: %w", err)
+	}
+	if err := os.Rename(tempFile, s.path); err != nil {
+		_ = os.Remove(tempFile) // Clean up temp file
+		return fmt.Errorf("failed to rename play history file: %w", err)
+	}
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to read play history: %w", err)
+	}
+	s.file = info
+	s.offset = info.Size()
+	s.lines = len(s.plays)
+
+	s.logger.WithField("plays", len(s.plays)).Debug("Compacted play history")
+	return nil
+}
+
+// encodePlays encodes plays as JSON Lines.
+func encodePlays(plays []Play) ([]byte, error) {
+	var data []byte
+	for _, play := range plays {
+		line, err := json.Marshal(play)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal play history entry: %w", err)
+		}
+		data = append(data, line...)
+		data = append(data, '\n')
+	}
+	return data, nil
+}
@@ -0,0 +1,260 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+var baseTime = time.Date(2025, time.October, 17, 12, 0, 0, 0, time.UTC)
+
+func TestRecordAddsNewPlaysOnce(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), FileName))
+	require.NoError(t, err)
+
+	songs := []types.Song{
+		{Artist: "Miles Davis", Title: "So What", PlayedAt: baseTime, KMHDID: "a", RawText: `{"big":"json"}`},
+		{Artist: "John Coltrane", Title: "Giant Steps", PlayedAt: baseTime.Add(5 * time.Minute)},
+		{Artist: "", Title: "Invalid"},
+	}
+
+	added, err := store.Record(songs)
+	require.NoError(t, err)
+	assert.Equal(t, 2, added)
+
+	added, err = store.Record(songs)
+	require.NoError(t, err)
+	assert.Equal(t, 0, added)
+
+	plays := store.Plays()
+	require.Len(t, plays, 2)
+	assert.Equal(t, "So What", plays[0].Song.Title)
+	assert.Empty(t, plays[0].Song.RawText, "raw API data should not be stored")
+}
+
+func TestSetMatchAndRepeatPlaysInheritMatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	store, err := Open(path)
+	require.NoError(t, err)
+
+	first := types.Song{Artist: "Miles Davis", Title: "So What", PlayedAt: baseTime, KMHDID: "a"}
+	_, err = store.Record([]types.Song{first})
+	require.NoError(t, err)
+
+	track := types.Track{ID: "track1", URI: "spotify:track:track1", Name: "So What", Artists: []types.Artist{{Name: "Miles Davis"}}, Album: types.Album{Name: "Kind of Blue"}}
+	require.NoError(t, store.SetMatch(first, track, 0.9))
+
+	repeat := types.Song{Artist: "miles davis", Title: "So What", PlayedAt: baseTime.Add(24 * time.Hour), KMHDID: "b"}
+	_, err = store.Record([]types.Song{repeat})
+	require.NoError(t, err)
+
+	plays := store.Plays()
+	require.Len(t, plays, 2)
+	for _, play := range plays {
+		require.NotNil(t, play.Match)
+		assert.Equal(t, "track1", play.Match.TrackID)
+		assert.Equal(t, []string{"Miles Davis"}, play.Match.Artists)
+		assert.Equal(t, "Kind of Blue", play.Match.Album)
+	}
+
+	// Reopening replays the appended lines and compacts the replaced one
+	reopened, err := Open(path)
+	require.NoError(t, err)
+	assert.Equal(t, plays, reopened.Plays())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(data), "\n"))
+}
+
+func TestSetUnmatched(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	store, err := Open(path)
+	require.NoError(t, err)
+
+	missing := types.Song{Artist: "Kenny G", Title: "Songbird", PlayedAt: baseTime, KMHDID: "a"}
+	matched := types.Song{Artist: "Miles Davis", Title: "So What", PlayedAt: baseTime.Add(time.Hour), KMHDID: "b"}
+	_, err = store.Record([]types.Song{missing, matched})
+	require.NoError(t, err)
+	require.NoError(t, store.SetMatch(matched, types.Track{ID: "track1", Artists: []types.Artist{{Name: "Miles Davis"}}}, 0.9))
+
+	require.NoError(t, store.SetUnmatched(missing))
+	require.NoError(t, store.SetUnmatched(missing))
+	require.NoError(t, store.SetUnmatched(matched))
+
+	plays := store.Plays()
+	require.Len(t, plays, 2)
+	assert.True(t, plays[0].Unmatched)
+	assert.Nil(t, plays[0].Match)
+	assert.False(t, plays[1].Unmatched, "a matched play keeps its match")
+	require.NotNil(t, plays[1].Match)
+
+	reopened, err := Open(path)
+	require.NoError(t, err)
+	assert.Equal(t, plays, reopened.Plays())
+}
+
+func TestImport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	store, err := Open(path)
+	require.NoError(t, err)
+
+	synced := types.Song{Artist: "Miles Davis", Title: "So What", PlayedAt: baseTime, KMHDID: "a"}
+	require.NoError(t, store.SetMatch(synced, types.Track{ID: "track1"}, 0.9))
+
+	imported := []Play{
+		// Added to the playlist shortly after the synced play
+		{Song: types.Song{Artist: "Miles Davis", Title: "So What", PlayedAt: baseTime.Add(10 * time.Minute)}, Match: &Match{TrackID: "track1"}, Imported: true},
+		// The same track a month earlier is another play
+		{Song: types.Song{Artist: "Miles Davis", Title: "So What", PlayedAt: baseTime.AddDate(0, -1, 0)}, Match: &Match{TrackID: "track1"}, Imported: true},
+		// Added to a second playlist at the same time
+		{Song: types.Song{Artist: "Miles Davis", Title: "So What", PlayedAt: baseTime.AddDate(0, -1, 0).Add(time.Minute)}, Match: &Match{TrackID: "track1"}, Imported: true},
+		{Song: types.Song{Artist: "John Coltrane", Title: "Giant Steps", PlayedAt: baseTime.AddDate(0, -2, 0)}, Match: &Match{TrackID: "track2"}, Imported: true},
+		{Song: types.Song{Artist: "Kenny G", Title: "Unmatched", PlayedAt: baseTime}},
+	}
+
+	added, err := store.Import(imported, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 2, added)
+
+	added, err = store.Import(imported, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 0, added, "importing again adds nothing")
+
+	reopened, err := Open(path)
+	require.NoError(t, err)
+	plays := reopened.Plays()
+	require.Len(t, plays, 3)
+	assert.Equal(t, "Giant Steps", plays[0].Song.Title)
+	assert.True(t, plays[0].Imported)
+	assert.False(t, plays[2].Imported)
+
+	// Changes to a copy are not saved
+	preview := reopened.Copy()
+	added, err = preview.Import([]Play{{Song: types.Song{Artist: "Bill Evans", Title: "Peace Piece", PlayedAt: baseTime}, Match: &Match{TrackID: "track3"}}}, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, added)
+	assert.Equal(t, 4, preview.Len())
+	assert.Equal(t, 3, reopened.Len())
+
+	// Later plays of an imported recording reuse its match
+	_, err = reopened.Record([]types.Song{{Artist: "John Coltrane", Title: "Giant Steps", PlayedAt: baseTime, KMHDID: "b"}})
+	require.NoError(t, err)
+	plays = reopened.Between(baseTime, time.Time{})
+	require.Len(t, plays, 2)
+	require.NotNil(t, plays[1].Match)
+	assert.Equal(t, "track2", plays[1].Match.TrackID)
+}
+
+func TestBetween(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), FileName))
+	require.NoError(t, err)
+
+	_, err = store.Record([]types.Song{
+		{Artist: "C", Title: "Third", PlayedAt: baseTime.Add(2 * time.Hour)},
+		{Artist: "A", Title: "First", PlayedAt: baseTime},
+		{Artist: "B", Title: "Second", PlayedAt: baseTime.Add(time.Hour)},
+	})
+	require.NoError(t, err)
+
+	plays := store.Between(baseTime.Add(time.Hour), baseTime.Add(2*time.Hour))
+	require.Len(t, plays, 1)
+	assert.Equal(t, "Second", plays[0].Song.Title)
+
+	plays = store.Between(baseTime.Add(time.Hour), time.Time{})
+	require.Len(t, plays, 2)
+	assert.Equal(t, "Second", plays[0].Song.Title)
+	assert.Equal(t, "Third", plays[1].Song.Title)
+	assert.Equal(t, 3, store.Len())
+}
+
+func TestOpenSkipsPartialLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	content := `{"song":{"artist":"Miles Davis","title":"So What","album":"","played_at":"2025-10-17T12:00:00Z","raw_text":""}}` + "\n" + `{"song":{"artist":"Jo`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+	store, err := Open(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.Len())
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	store, err := Open(path)
+	require.NoError(t, err)
+
+	song := types.Song{Artist: "Miles Davis", Title: "So What", PlayedAt: baseTime, KMHDID: "a"}
+	_, err = store.Record([]types.Song{song})
+	require.NoError(t, err)
+	require.NoError(t, store.SetMatch(song, types.Track{ID: "track1"}, 0.9))
+	before, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	readOnly, err := OpenReadOnly(path)
+	require.NoError(t, err)
+	require.Len(t, readOnly.Plays(), 1)
+	assert.Equal(t, "track1", readOnly.Plays()[0].Match.TrackID)
+
+	after, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, before, after, "the replaced line is left for a writer to compact")
+
+	_, err = readOnly.Record([]types.Song{{Artist: "John Coltrane", Title: "Naima", PlayedAt: baseTime}})
+	assert.ErrorIs(t, err, ErrReadOnly)
+
+	// A writer compacting the file replaces it, and readers read it again
+	_, err = Open(path)
+	require.NoError(t, err)
+	assert.Equal(t, store.Plays(), readOnly.Plays())
+}
+
+func TestStoresSharingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	sync, err := Open(path)
+	require.NoError(t, err)
+	imports, err := Open(path)
+	require.NoError(t, err)
+
+	song := types.Song{Artist: "Miles Davis", Title: "So What", PlayedAt: baseTime, KMHDID: "a"}
+	_, err = sync.Record([]types.Song{song})
+	require.NoError(t, err)
+
+	imported := Play{
+		Song:     types.Song{Artist: "John Coltrane", Title: "Naima", PlayedAt: baseTime.Add(-24 * time.Hour)},
+		Match:    &Match{TrackID: "naima"},
+		Imported: true,
+	}
+	added, err := imports.Import([]Play{imported}, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, added)
+
+	require.NoError(t, sync.SetMatch(song, types.Track{ID: "so-what", Artists: []types.Artist{{Name: "Miles Davis"}}}, 0.9))
+
+	// Each store sees the plays recorded by the other without reopening
+	plays := sync.Plays()
+	require.Len(t, plays, 2)
+	assert.Equal(t, "Naima", plays[0].Song.Title)
+	assert.Equal(t, "so-what", plays[1].Match.TrackID)
+	assert.Equal(t, plays, imports.Plays())
+
+	added, err = sync.Record([]types.Song{imported.Song})
+	require.NoError(t, err)
+	assert.Zero(t, added, "plays imported by another process are not recorded again")
+}
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, "kmhd:abc", Key(types.Song{KMHDID: "abc", Artist: "A", Title: "B"}))
+	assert.Equal(t,
+		Key(types.Song{Artist: "Miles Davis", Title: "So What", PlayedAt: baseTime}),
+		Key(types.Song{Artist: " miles davis", Title: "SO WHAT", PlayedAt: baseTime.In(time.FixedZone("PDT", -7*3600))}))
+	assert.NotEqual(t,
+		Key(types.Song{Artist: "Miles Davis", Title: "So What", PlayedAt: baseTime}),
+		Key(types.Song{Artist: "Miles Davis", Title: "So What", PlayedAt: baseTime.Add(time.Hour)}))
+}
@@ -0,0 +1,159 @@
+// Package metrics exposes Prometheus metrics for kmhd2spotify.
+//
+// Metrics are registered on a dedicated registry rather than the global default
+// one, so only kmhd2spotify metrics (plus Go runtime and process metrics) are
+// exported. Recording helpers are safe to call whether or not Handler is being
+// served.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "kmhd2spotify"
+
+// Reasons a KMHD song was not added to Spotify.
+const (
+	SkipNoMatch           = "no_match"
+	SkipLowConfidence     = "low_confidence"
+	SkipAlreadyInPlaylist = "already_in_playlist"
+	SkipAddFailed         = "add_failed"
+	SkipFiltered          = "filtered"
+)
+
+// Registry holds every kmhd2spotify metric.
+var Registry = prometheus.NewRegistry()
+
+var (
+	kmhdFetches = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kmhd_fetches_total",
+		Help:      "KMHD playlist fetches by result status (HTTP status code, cache, circuit_open or error).",
+	}, []string{"status"})
+
+	songsSeen = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "songs_seen_total",
+		Help:      "New KMHD songs considered for syncing.",
+	})
+
+	songsMatched = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "songs_matched_total",
+		Help:      "KMHD songs matched to a Spotify track with sufficient confidence.",
+	})
+
+	songsAdded = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "songs_added_total",
+		Help:      "KMHD songs added to a Spotify playlist.",
+	})
+
+	songsSkipped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "songs_skipped_total",
+		Help:      "KMHD songs not added to Spotify, by reason.",
+	}, []string{"reason"})
+
+	spotifyCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "spotify_api_calls_total",
+		Help:      "Spotify API calls by endpoint and HTTP status code (or error).",
+	}, []string{"endpoint", "status"})
+
+	matchConfidence = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "match_confidence",
+		Help:      "Overall confidence of the best Spotify match for each KMHD song.",
+		Buckets:   []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 1.0},
+	})
+
+	apiLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "api_request_duration_seconds",
+		Help:      "Latency of outbound API requests by service (kmhd or spotify).",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"service"})
+
+	lastSuccessfulSync = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_successful_sync_timestamp_seconds",
+		Help:      "Unix time of the last sync cycle that fetched KMHD and processed its songs.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		kmhdFetches,
+		songsSeen,
+		songsMatched,
+		songsAdded,
+		songsSkipped,
+		spotifyCalls,
+		matchConfidence,
+		apiLatency,
+		lastSuccessfulSync,
+	)
+}
+
+// ObserveKMHDFetch records a KMHD playlist fetch with its result status and latency.
+// A zero duration (e.g. a cache hit) is not recorded as latency.
+func ObserveKMHDFetch(status string, duration time.Duration) {
+	kmhdFetches.WithLabelValues(status).Inc()
+	if duration > 0 {
+		apiLatency.WithLabelValues("kmhd").Observe(duration.Seconds())
+	}
+}
+
+// SongSeen records a KMHD song considered for syncing.
+func SongSeen() {
+	songsSeen.Inc()
+}
+
+// SongMatched records the confidence of a song's best Spotify match and, if it
+// was accepted, counts the song as matched.
+func SongMatched(confidence float64, accepted bool) {
+	matchConfidence.Observe(confidence)
+	if accepted {
+		songsMatched.Inc()
+	}
+}
+
+// SongAdded records a song added to a Spotify playlist.
+func SongAdded() {
+	songsAdded.Inc()
+}
+
+// SongSkipped records a song that was not added, with one of the Skip* reasons.
+func SongSkipped(reason string) {
+	songsSkipped.WithLabelValues(reason).Inc()
+}
+
+// ObserveSpotifyCall records a Spotify API call with its status and latency.
+func ObserveSpotifyCall(endpoint, status string, duration time.Duration) {
+	spotifyCalls.WithLabelValues(endpoint, status).Inc()
+	apiLatency.WithLabelValues("spotify").Observe(duration.Seconds())
+}
+
+// SyncSucceeded records the time of a successful sync cycle.
+func SyncSucceeded(at time.Time) {
+	lastSuccessfulSync.Set(float64(at.Unix()))
+}
+
+// StatusLabel converts an HTTP status code to a metric label.
+func StatusLabel(code int) string {
+	return strconv.Itoa(code)
+}
+
+// Handler returns the HTTP handler serving the metrics registry.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordingHelpers(t *testing.T) {
+	fetches200 := testutil.ToFloat64(kmhdFetches.WithLabelValues("200"))
+	fetchesCache := testutil.ToFloat64(kmhdFetches.WithLabelValues("cache"))
+	seen := testutil.ToFloat64(songsSeen)
+	matched := testutil.ToFloat64(songsMatched)
+	added := testutil.ToFloat64(songsAdded)
+	lowConfidence := testutil.ToFloat64(songsSkipped.WithLabelValues(SkipLowConfidence))
+
+	ObserveKMHDFetch("200", 250*time.Millisecond)
+	ObserveKMHDFetch("cache", 0)
+	SongSeen()
+	SongSeen()
+	SongMatched(0.9, true)
+	SongMatched(0.3, false)
+	SongSkipped(SkipLowConfidence)
+	SongAdded()
+
+	assert.Equal(t, fetches200+1, testutil.ToFloat64(kmhdFetches.WithLabelValues("200")))
+	assert.Equal(t, fetchesCache+1, testutil.ToFloat64(kmhdFetches.WithLabelValues("cache")))
+	assert.Equal(t, seen+2, testutil.ToFloat64(songsSeen))
+	assert.Equal(t, matched+1, testutil.ToFloat64(songsMatched))
+	assert.Equal(t, added+1, testutil.ToFloat64(songsAdded))
+	assert.Equal(t, lowConfidence+1, testutil.ToFloat64(songsSkipped.WithLabelValues(SkipLowConfidence)))
+
+	at := time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)
+	SyncSucceeded(at)
+	assert.Equal(t, float64(at.Unix()), testutil.ToFloat64(lastSuccessfulSync))
+}
+
+func TestHandlerExposesMetrics(t *testing.T) {
+	SongSeen()
+	ObserveSpotifyCall("GET /v1/search", "200", 100*time.Millisecond)
+
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	body := recorder.Body.String()
+	assert.Contains(t, body, "kmhd2spotify_songs_seen_total")
+	assert.Contains(t, body, `kmhd2spotify_spotify_api_calls_total{endpoint="GET /v1/search",status="200"}`)
+	assert.Contains(t, body, `kmhd2spotify_api_request_duration_seconds_bucket{service="spotify"`)
+	assert.Contains(t, body, "kmhd2spotify_match_confidence_bucket")
+	assert.Contains(t, body, "go_goroutines")
+}
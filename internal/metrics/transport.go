@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// idCollections are Spotify API path segments that are followed by an ID.
+var idCollections = map[string]bool{
+	"albums":    true,
+	"artists":   true,
+	"playlists": true,
+	"shows":     true,
+	"tracks":    true,
+	"users":     true,
+}
+
+// spotifyTransport records every request made through it as a Spotify API call.
+type spotifyTransport struct {
+	next http.RoundTripper
+}
+
+// NewSpotifyTransport wraps a round tripper so each request is counted by endpoint
+// and status, and its latency observed. If next is nil, http.DefaultTransport is used.
+func NewSpotifyTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &spotifyTransport{next: next}
+}
+
+// RoundTrip executes the request and records it.
+func (t *spotifyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+
+	status := "error"
+	if err == nil {
+		status = StatusLabel(resp.StatusCode)
+	}
+	ObserveSpotifyCall(SpotifyEndpoint(req.Method, req.URL.Path), status, time.Since(start))
+
+	return resp, err
+}
+
+// SpotifyEndpoint returns a low-cardinality endpoint label for a Spotify API request,
+// replacing IDs in the path with {id} (e.g. "POST /v1/playlists/{id}/tracks").
+func SpotifyEndpoint(method, path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 1; i < len(segments); i++ {
+		if idCollections[segments[i-1]] && segments[i] != "" {
+			segments[i] = "{id}"
+		}
+	}
+	return method + " /" + strings.Join(segments, "/")
+}
@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpotifyEndpoint(t *testing.T) {
+	tests := []struct {
+		method   string
+		path     string
+		expected string
+	}{
+		{"GET", "/v1/search", "GET /v1/search"},
+		{"GET", "/v1/me", "GET /v1/me"},
+		{"GET", "/v1/me/playlists", "GET /v1/me/playlists"},
+		{"POST", "/v1/playlists/37i9dQZF1DXcBWIGoYBM5M/tracks", "POST /v1/playlists/{id}/tracks"},
+		{"GET", "/v1/artists/0kbYTNQb4Pb1rPbbaF0pT4/top-tracks", "GET /v1/artists/{id}/top-tracks"},
+		{"POST", "/v1/users/someone/playlists", "POST /v1/users/{id}/playlists"},
+		{"POST", "/api/token", "POST /api/token"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, SpotifyEndpoint(tt.method, tt.path))
+	}
+}
+
+func TestSpotifyTransport_RecordsCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	counter := spotifyCalls.WithLabelValues("GET /v1/playlists/{id}", "429")
+	before := testutil.ToFloat64(counter)
+
+	client := &http.Client{Transport: NewSpotifyTransport(nil)}
+	resp, err := client.Get(server.URL + "/v1/playlists/abc123")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.Equal(t, before+1, testutil.ToFloat64(counter))
+}
+
+func TestSpotifyTransport_RecordsErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serverURL := server.URL
+	server.Close()
+
+	counter := spotifyCalls.WithLabelValues("GET /v1/me", "error")
+	before := testutil.ToFloat64(counter)
+
+	client := &http.Client{Transport: NewSpotifyTransport(nil)}
+	_, err := client.Get(serverURL + "/v1/me")
+	require.Error(t, err)
+
+	assert.Equal(t, before+1, testutil.ToFloat64(counter))
+}
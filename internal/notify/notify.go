@@ -0,0 +1,234 @@
+// Package notify delivers notifications about KMHD songs to pluggable sinks.
+//
+// A Notifier fans each Event out to its sinks. Every sink has its own Filter, so
+// for example a webhook can receive every added song while a push notification
+// only fires for favourite artists. Delivery failures are logged and never
+// interrupt syncing or watching.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/kmhd2spotify/internal/types"
+	"github.com/toozej/kmhd2spotify/pkg/config"
+)
+
+// EventType identifies what happened to a song.
+type EventType string
+
+const (
+	// EventSongAdded is emitted when a KMHD song is added to a Spotify playlist.
+	EventSongAdded EventType = "song_added"
+	// EventNowPlaying is emitted when the song playing on KMHD changes.
+	EventNowPlaying EventType = "now_playing"
+)
+
+// Event describes a song notification.
+type Event struct {
+	Type EventType  `json:"type"`
+	Song types.Song `json:"song"`
+	// Track is the matched Spotify track, if any.
+	Track *types.Track `json:"track,omitempty"`
+	// Playlist is the Spotify playlist the track was added to, if any.
+	Playlist *types.Playlist `json:"playlist,omitempty"`
+	Time     time.Time       `json:"time"`
+}
+
+// Title returns a short human-readable title for the event.
+func (e Event) Title() string {
+	switch e.Type {
+	case EventSongAdded:
+		if e.Playlist != nil {
+			return "Added to " + e.Playlist.Name
+		}
+		return "Added to Spotify"
+	case EventNowPlaying:
+		return "Now playing on KMHD"
+	default:
+		return string(e.Type)
+	}
+}
+
+// Message returns a human-readable description of the song.
+func (e Event) Message() string {
+	return e.Song.String()
+}
+
+// TrackURL returns the Spotify web URL of the matched track, or an empty string.
+func (e Event) TrackURL() string {
+	if e.Track == nil || e.Track.ID == "" {
+		return ""
+	}
+	return "https://open.spotify.com/track/" + e.Track.ID
+}
+
+// Sink delivers events to a single destination.
+type Sink interface {
+	// Name identifies the sink in logs.
+	Name() string
+	// Send delivers the event.
+	Send(ctx context.Context, event Event) error
+}
+
+// Filter selects which events a sink receives.
+type Filter struct {
+	events  map[EventType]bool
+	artists map[string]bool
+}
+
+// NewFilter creates a filter for the given event types and artists. An empty
+// artist list matches songs by any artist.
+func NewFilter(events []EventType, artists []string) Filter {
+	filter := Filter{
+		events:  make(map[EventType]bool),
+		artists: make(map[string]bool),
+	}
+	for _, event := range events {
+		filter.events[event] = true
+	}
+	for _, artist := range artists {
+		if artist = normalizeArtist(artist); artist != "" {
+			filter.artists[artist] = true
+		}
+	}
+	return filter
+}
+
+// FilterFromConfig builds a filter from a sink's filter configuration.
+func FilterFromConfig(cfg config.NotifyFilterConfig) Filter {
+	events := make([]EventType, 0, len(cfg.Events))
+	for _, event := range cfg.Events {
+		events = append(events, EventType(strings.TrimSpace(event)))
+	}
+	return NewFilter(events, cfg.Artists)
+}
+
+// Match reports whether the event passes the filter.
+func (f Filter) Match(event Event) bool {
+	if !f.events[event.Type] {
+		return false
+	}
+	if len(f.artists) == 0 {
+		return true
+	}
+	return f.artists[normalizeArtist(event.Song.Artist)]
+}
+
+// normalizeArtist normalizes an artist name for case-insensitive comparison.
+func normalizeArtist(artist string) string {
+	return strings.ToLower(strings.TrimSpace(artist))
+}
+
+type route struct {
+	sink   Sink
+	filter Filter
+}
+
+// Notifier delivers events to every sink whose filter matches.
+// A nil Notifier is valid and discards all events.
+type Notifier struct {
+	routes  []route
+	timeout time.Duration
+	logger  *log.Entry
+}
+
+// NewNotifier creates a notifier without any sinks.
+func NewNotifier(timeout time.Duration) *Notifier {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &Notifier{
+		timeout: timeout,
+		logger:  log.WithField("component", "notifier"),
+	}
+}
+
+// New creates a notifier with the sinks enabled in the configuration.
+func New(cfg config.NotifyConfig) *Notifier {
+	n := NewNotifier(cfg.Timeout)
+
+	if cfg.Webhook.URL != "" {
+		n.AddSink(NewWebhookSink(cfg.Webhook.URL), FilterFromConfig(cfg.Webhook.Filter))
+	}
+	if cfg.Shell.Command != "" {
+		n.AddSink(NewShellSink(cfg.Shell.Command), FilterFromConfig(cfg.Shell.Filter))
+	}
+	if cfg.Ntfy.URL != "" {
+		n.AddSink(NewNtfySink(cfg.Ntfy.URL, cfg.Ntfy.Token, cfg.Ntfy.Priority), FilterFromConfig(cfg.Ntfy.Filter))
+	}
+	if cfg.Gotify.URL != "" {
+		n.AddSink(NewGotifySink(cfg.Gotify.URL, cfg.Gotify.Token, cfg.Gotify.Priority), FilterFromConfig(cfg.Gotify.Filter))
+	}
+
+	return n
+}
+
+// AddSink registers a sink with its filter.
+func (n *Notifier) AddSink(sink Sink, filter Filter) {
+	n.routes = append(n.routes, route{sink: sink, filter: filter})
+}
+
+// Sinks returns the names of the registered sinks.
+func (n *Notifier) Sinks() []string {
+	if n == nil {
+		return nil
+	}
+	names := make([]string, 0, len(n.routes))
+	for _, r := range n.routes {
+		names = append(names, r.sink.Name())
+	}
+	return names
+}
+
+// Notify delivers the event to every matching sink and returns the number of
+// sinks it was delivered to. Failures are logged and do not stop delivery to
+// the remaining sinks.
+func (n *Notifier) Notify(event Event) int {
+	if n == nil {
+		return 0
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	delivered := 0
+	for _, r := range n.routes {
+		if !r.filter.Match(event) {
+			continue
+		}
+
+		if err := n.send(r.sink, event); err != nil {
+			n.logger.WithFields(log.Fields{
+				"sink":  r.sink.Name(),
+				"event": event.Type,
+				"song":  event.Song.String(),
+				"error": err.Error(),
+			}).Warn("Failed to deliver notification")
+			continue
+		}
+
+		n.logger.WithFields(log.Fields{
+			"sink":  r.sink.Name(),
+			"event": event.Type,
+			"song":  event.Song.String(),
+		}).Debug("Delivered notification")
+		delivered++
+	}
+	return delivered
+}
+
+// send delivers the event to a single sink within the notifier's timeout.
+func (n *Notifier) send(sink Sink, event Event) error {
+	ctx, cancel := context.WithTimeout(context.Background(), n.timeout)
+	defer cancel()
+
+	if err := sink.Send(ctx, event); err != nil {
+		return fmt.Errorf("%s: %w", sink.Name(), err)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/toozej/kmhd2spotify/internal/types"
+	"github.com/toozej/kmhd2spotify/pkg/config"
+)
+
+// recordingSink records delivered events and optionally fails
+type recordingSink struct {
+	name   string
+	err    error
+	events []Event
+}
+
+func (s *recordingSink) Name() string { return s.name }
+
+func (s *recordingSink) Send(ctx context.Context, event Event) error {
+	s.events = append(s.events, event)
+	return s.err
+}
+
+func newTestNotifier() *Notifier {
+	n := NewNotifier(time.Second)
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	n.logger = logger.WithField("component", "notifier")
+	return n
+}
+
+func testEvent(eventType EventType, artist string) Event {
+	return Event{
+		Type:     eventType,
+		Song:     types.Song{Artist: artist, Title: "So What", Album: "Kind of Blue"},
+		Track:    &types.Track{ID: "track123", Name: "So What"},
+		Playlist: &types.Playlist{ID: "playlist123", Name: "KMHD-2025-10"},
+		Time:     time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestFilter_Match(t *testing.T) {
+	all := NewFilter([]EventType{EventSongAdded}, nil)
+	assert.True(t, all.Match(testEvent(EventSongAdded, "Anyone")))
+	assert.False(t, all.Match(testEvent(EventNowPlaying, "Anyone")))
+
+	favourites := NewFilter([]EventType{EventSongAdded, EventNowPlaying}, []string{" Miles Davis ", "john coltrane"})
+	assert.True(t, favourites.Match(testEvent(EventSongAdded, "miles davis")))
+	assert.True(t, favourites.Match(testEvent(EventNowPlaying, "John Coltrane")))
+	assert.False(t, favourites.Match(testEvent(EventSongAdded, "Herbie Hancock")))
+}
+
+func TestFilterFromConfig(t *testing.T) {
+	filter := FilterFromConfig(config.NotifyFilterConfig{
+		Events:  []string{"now_playing"},
+		Artists: []string{"Miles Davis"},
+	})
+	assert.True(t, filter.Match(testEvent(EventNowPlaying, "Miles Davis")))
+	assert.False(t, filter.Match(testEvent(EventSongAdded, "Miles Davis")))
+}
+
+func TestNotifier_NotifyRoutesBySinkFilter(t *testing.T) {
+	n := newTestNotifier()
+	everything := &recordingSink{name: "everything"}
+	favourites := &recordingSink{name: "favourites"}
+	n.AddSink(everything, NewFilter([]EventType{EventSongAdded}, nil))
+	n.AddSink(favourites, NewFilter([]EventType{EventSongAdded}, []string{"Miles Davis"}))
+
+	assert.Equal(t, 2, n.Notify(testEvent(EventSongAdded, "Miles Davis")))
+	assert.Equal(t, 1, n.Notify(testEvent(EventSongAdded, "Herbie Hancock")))
+	assert.Equal(t, 0, n.Notify(testEvent(EventNowPlaying, "Miles Davis")))
+
+	assert.Len(t, everything.events, 2)
+	assert.Len(t, favourites.events, 1)
+	assert.Equal(t, []string{"everything", "favourites"}, n.Sinks())
+}
+
+func TestNotifier_FailingSinkDoesNotBlockOthers(t *testing.T) {
+	n := newTestNotifier()
+	failing := &recordingSink{name: "failing", err: errors.New("boom")}
+	working := &recordingSink{name: "working"}
+	n.AddSink(failing, NewFilter([]EventType{EventSongAdded}, nil))
+	n.AddSink(working, NewFilter([]EventType{EventSongAdded}, nil))
+
+	assert.Equal(t, 1, n.Notify(testEvent(EventSongAdded, "Miles Davis")))
+	assert.Len(t, failing.events, 1)
+	assert.Len(t, working.events, 1)
+}
+
+func TestNotifier_NilIsNoop(t *testing.T) {
+	var n *Notifier
+	assert.Equal(t, 0, n.Notify(testEvent(EventSongAdded, "Miles Davis")))
+	assert.Nil(t, n.Sinks())
+}
+
+func TestNew_EnablesConfiguredSinks(t *testing.T) {
+	n := New(config.NotifyConfig{
+		Webhook: config.WebhookNotifyConfig{URL: "https://example.com/hook"},
+		Gotify:  config.PushNotifyConfig{URL: "https://gotify.example.com", Token: "token"},
+	})
+	assert.Equal(t, []string{"webhook", "gotify"}, n.Sinks())
+	assert.Equal(t, 10*time.Second, n.timeout)
+
+	assert.Empty(t, New(config.NotifyConfig{}).Sinks())
+}
+
+func TestEvent_Text(t *testing.T) {
+	event := testEvent(EventSongAdded, "Miles Davis")
+	assert.Equal(t, "Added to KMHD-2025-10", event.Title())
+	assert.Equal(t, "Miles Davis - So What (Kind of Blue)", event.Message())
+	assert.Equal(t, "https://open.spotify.com/track/track123", event.TrackURL())
+
+	nowPlaying := Event{Type: EventNowPlaying, Song: types.Song{Artist: "Miles Davis", Title: "So What"}}
+	assert.Equal(t, "Now playing on KMHD", nowPlaying.Title())
+	assert.Empty(t, nowPlaying.TrackURL())
+}
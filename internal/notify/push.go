@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// NtfySink publishes events to an ntfy topic.
+type NtfySink struct {
+	topicURL string
+	token    string
+	priority int
+	client   *http.Client
+}
+
+// NewNtfySink creates an ntfy sink for the given topic URL (e.g. https://ntfy.sh/my-topic).
+func NewNtfySink(topicURL, token string, priority int) *NtfySink {
+	return &NtfySink{topicURL: topicURL, token: token, priority: priority, client: &http.Client{}}
+}
+
+// Name identifies the sink in logs.
+func (s *NtfySink) Name() string {
+	return "ntfy"
+}
+
+// Send publishes the event to the ntfy topic.
+func (s *NtfySink) Send(ctx context.Context, event Event) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.topicURL, strings.NewReader(event.Message()))
+	if err != nil {
+		return fmt.Errorf("failed to create ntfy request: %w", err)
+	}
+	req.Header.Set("Title", event.Title())
+	req.Header.Set("Tags", "musical_note")
+	if url := event.TrackURL(); url != "" {
+		req.Header.Set("Click", url)
+	}
+	if s.priority > 0 {
+		req.Header.Set("Priority", strconv.Itoa(s.priority))
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	return doPush(s.client, req)
+}
+
+// GotifySink publishes events to a Gotify server.
+type GotifySink struct {
+	serverURL string
+	token     string
+	priority  int
+	client    *http.Client
+}
+
+// gotifyMessage is the Gotify message API request body.
+type gotifyMessage struct {
+	Title    string         `json:"title"`
+	Message  string         `json:"message"`
+	Priority int            `json:"priority,omitempty"`
+	Extras   map[string]any `json:"extras,omitempty"`
+}
+
+// NewGotifySink creates a Gotify sink for the given server URL and application token.
+func NewGotifySink(serverURL, token string, priority int) *GotifySink {
+	return &GotifySink{
+		serverURL: strings.TrimSuffix(serverURL, "/"),
+		token:     token,
+		priority:  priority,
+		client:    &http.Client{},
+	}
+}
+
+// Name identifies the sink in logs.
+func (s *GotifySink) Name() string {
+	return "gotify"
+}
+
+// Send publishes the event to the Gotify message API.
+func (s *GotifySink) Send(ctx context.Context, event Event) error {
+	message := gotifyMessage{
+		Title:    event.Title(),
+		Message:  event.Message(),
+		Priority: s.priority,
+	}
+	if url := event.TrackURL(); url != "" {
+		message.Extras = map[string]any{
+			"client::notification": map[string]any{"click": map[string]string{"url": url}},
+		}
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal gotify message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.serverURL+"/message", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create gotify request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", s.token)
+
+	return doPush(s.client, req)
+}
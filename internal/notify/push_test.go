@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNtfySink_Send(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, "/kmhd", r.URL.Path)
+		assert.Equal(t, "Miles Davis - So What (Kind of Blue)", string(body))
+		assert.Equal(t, "Added to KMHD-2025-10", r.Header.Get("Title"))
+		assert.Equal(t, "https://open.spotify.com/track/track123", r.Header.Get("Click"))
+		assert.Equal(t, "4", r.Header.Get("Priority"))
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sink := NewNtfySink(server.URL+"/kmhd", "secret", 4)
+	require.NoError(t, sink.Send(context.Background(), testEvent(EventSongAdded, "Miles Davis")))
+}
+
+func TestGotifySink_Send(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/message", r.URL.Path)
+		assert.Equal(t, "app-token", r.Header.Get("X-Gotify-Key"))
+
+		var message gotifyMessage
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&message))
+		assert.Equal(t, "Now playing on KMHD", message.Title)
+		assert.Equal(t, "Miles Davis - So What (Kind of Blue)", message.Message)
+		assert.Equal(t, 5, message.Priority)
+		assert.Contains(t, message.Extras, "client::notification")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sink := NewGotifySink(server.URL+"/", "app-token", 5)
+	require.NoError(t, sink.Send(context.Background(), testEvent(EventNowPlaying, "Miles Davis")))
+}
+
+func TestGotifySink_SendFailsOnUnauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	err := NewGotifySink(server.URL, "bad", 0).Send(context.Background(), testEvent(EventSongAdded, "Miles Davis"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 401")
+}
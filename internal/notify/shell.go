@@ -0,0 +1,71 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ShellSink runs a shell command for each event.
+//
+// The command receives the event as JSON on stdin and as KMHD_* environment
+// variables, so simple hooks such as notify-send need no JSON parsing:
+//
+//	notify-send "$KMHD_TITLE" "$KMHD_MESSAGE"
+type ShellSink struct {
+	command string
+}
+
+// NewShellSink creates a shell sink that runs the command with "sh -c".
+func NewShellSink(command string) *ShellSink {
+	return &ShellSink{command: command}
+}
+
+// Name identifies the sink in logs.
+func (s *ShellSink) Name() string {
+	return "shell"
+}
+
+// Send runs the command for the event.
+func (s *ShellSink) Send(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", s.command) // #nosec G204 -- command comes from user configuration
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(), shellEnv(event)...)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("command failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// shellEnv returns the environment variables describing the event.
+func shellEnv(event Event) []string {
+	env := []string{
+		"KMHD_EVENT=" + string(event.Type),
+		"KMHD_TITLE=" + event.Title(),
+		"KMHD_MESSAGE=" + event.Message(),
+		"KMHD_ARTIST=" + event.Song.Artist,
+		"KMHD_SONG=" + event.Song.Title,
+		"KMHD_ALBUM=" + event.Song.Album,
+	}
+	if event.Track != nil {
+		env = append(env,
+			"KMHD_SPOTIFY_TRACK_ID="+event.Track.ID,
+			"KMHD_SPOTIFY_TRACK_URL="+event.TrackURL(),
+		)
+	}
+	if event.Playlist != nil {
+		env = append(env, "KMHD_SPOTIFY_PLAYLIST="+event.Playlist.Name)
+	}
+	return env
+}
@@ -0,0 +1,40 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShellSink_Send(t *testing.T) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, "env")
+	stdinFile := filepath.Join(dir, "stdin")
+
+	sink := NewShellSink(`printf '%s|%s|%s' "$KMHD_EVENT" "$KMHD_ARTIST" "$KMHD_SPOTIFY_TRACK_URL" > "$OUT_ENV"; cat > "$OUT_STDIN"`)
+	t.Setenv("OUT_ENV", envFile)
+	t.Setenv("OUT_STDIN", stdinFile)
+
+	require.NoError(t, sink.Send(context.Background(), testEvent(EventSongAdded, "Miles Davis")))
+
+	env, err := os.ReadFile(envFile)
+	require.NoError(t, err)
+	assert.Equal(t, "song_added|Miles Davis|https://open.spotify.com/track/track123", string(env))
+
+	stdin, err := os.ReadFile(stdinFile)
+	require.NoError(t, err)
+	var event Event
+	require.NoError(t, json.Unmarshal(stdin, &event))
+	assert.Equal(t, "So What", event.Song.Title)
+}
+
+func TestShellSink_SendReportsFailures(t *testing.T) {
+	err := NewShellSink("echo nope >&2; exit 3").Send(context.Background(), testEvent(EventSongAdded, "Miles Davis"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "nope")
+}
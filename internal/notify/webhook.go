@@ -0,0 +1,71 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// WebhookSink POSTs each event as a JSON document to a URL.
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// WebhookPayload is the JSON document sent by the webhook sink.
+type WebhookPayload struct {
+	Event
+	Title    string `json:"title"`
+	Message  string `json:"message"`
+	TrackURL string `json:"track_url,omitempty"`
+}
+
+// NewWebhookSink creates a webhook sink for the given URL.
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{url: url, client: &http.Client{}}
+}
+
+// Name identifies the sink in logs.
+func (s *WebhookSink) Name() string {
+	return "webhook"
+}
+
+// Send POSTs the event to the webhook URL.
+func (s *WebhookSink) Send(ctx context.Context, event Event) error {
+	body, err := json.Marshal(WebhookPayload{
+		Event:    event,
+		Title:    event.Title(),
+		Message:  event.Message(),
+		TrackURL: event.TrackURL(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "kmhd2spotify/1.0")
+
+	return doPush(s.client, req)
+}
+
+// doPush executes a notification request and checks for a successful status.
+func doPush(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req) // #nosec G704 -- URL comes from user configuration
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("server returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSink_Send(t *testing.T) {
+	var received map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL)
+	require.NoError(t, sink.Send(context.Background(), testEvent(EventSongAdded, "Miles Davis")))
+
+	assert.Equal(t, "song_added", received["type"])
+	assert.Equal(t, "Miles Davis - So What (Kind of Blue)", received["message"])
+	assert.Equal(t, "https://open.spotify.com/track/track123", received["track_url"])
+	song, ok := received["song"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "Miles Davis", song["artist"])
+	track, ok := received["track"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "track123", track["id"])
+}
+
+func TestWebhookSink_SendFailsOnErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := NewWebhookSink(server.URL).Send(context.Background(), testEvent(EventSongAdded, "Miles Davis"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 500")
+}
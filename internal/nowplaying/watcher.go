@@ -0,0 +1,167 @@
+// Package nowplaying tracks the song currently playing on KMHD.
+//
+// The Watcher polls the KMHD playlist around each track's expected end, derived
+// from the start time and duration reported by KMHD, and emits an Event whenever
+// the current song changes. Between track changes it stays idle, so following the
+// station costs roughly one request per song rather than one per fixed interval.
+package nowplaying
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+const (
+	// DefaultEndGrace is how long after a track's expected end the playlist is polled,
+	// giving KMHD time to publish the next track.
+	DefaultEndGrace = 10 * time.Second
+	// DefaultRetryInterval is how often the playlist is polled once a track has
+	// overrun its expected end without a new track appearing.
+	DefaultRetryInterval = 30 * time.Second
+	// DefaultFallbackInterval is how often the playlist is polled when the current
+	// track's duration is unknown or nothing is playing.
+	DefaultFallbackInterval = 2 * time.Minute
+	// DefaultMaxInterval caps how long the watcher waits between polls.
+	DefaultMaxInterval = 15 * time.Minute
+)
+
+// Fetcher fetches the KMHD playlist for a given date.
+type Fetcher interface {
+	FetchPlaylist(date time.Time) (*types.SongCollection, error)
+}
+
+// Event is emitted when the currently playing song changes.
+type Event struct {
+	// Song is the song that started playing.
+	Song types.Song `json:"song"`
+	// Previous is the song that was playing before, or nil for the first event.
+	Previous *types.Song `json:"previous,omitempty"`
+	// ExpectedEnd is when Song is expected to finish, or the zero time if unknown.
+	ExpectedEnd time.Time `json:"expected_end,omitempty"`
+	// DetectedAt is when the change was observed.
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// Handler is called for each now-playing change.
+type Handler func(Event)
+
+// Watcher polls KMHD and reports changes to the currently playing song.
+type Watcher struct {
+	fetcher Fetcher
+	logger  *log.Entry
+
+	// now and after are replaceable for tests
+	now   func() time.Time
+	after func(time.Duration) <-chan time.Time
+
+	EndGrace         time.Duration
+	RetryInterval    time.Duration
+	FallbackInterval time.Duration
+	MaxInterval      time.Duration
+}
+
+// NewWatcher creates a watcher that reads the playlist from the given fetcher.
+func NewWatcher(fetcher Fetcher) *Watcher {
+	return &Watcher{
+		fetcher:          fetcher,
+		logger:           log.WithField("component", "nowplaying_watcher"),
+		now:              time.Now,
+		after:            time.After,
+		EndGrace:         DefaultEndGrace,
+		RetryInterval:    DefaultRetryInterval,
+		FallbackInterval: DefaultFallbackInterval,
+		MaxInterval:      DefaultMaxInterval,
+	}
+}
+
+// Current returns the song playing right now, or nil if nothing has started yet.
+// Shortly after midnight the previous day's playlist is consulted as well, since
+// the current track may have started before the day changed.
+func (w *Watcher) Current() (*types.Song, error) {
+	now := w.now()
+
+	collection, err := w.fetcher.FetchPlaylist(now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch KMHD playlist: %w", err)
+	}
+	if song := collection.MostRecentAt(now); song != nil {
+		return song, nil
+	}
+
+	previous, err := w.fetcher.FetchPlaylist(now.AddDate(0, 0, -1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch previous KMHD playlist: %w", err)
+	}
+	return previous.MostRecentAt(now), nil
+}
+
+// Watch polls KMHD until the context is cancelled, calling handler whenever the
+// current song changes. The first song observed is always reported. Fetch errors
+// are logged and retried at the fallback interval.
+func (w *Watcher) Watch(ctx context.Context, handler Handler) error {
+	var current *types.Song
+
+	for {
+		song, err := w.Current()
+		if err != nil {
+			w.logger.WithError(err).Warn("Failed to fetch now playing")
+		} else if song != nil && !sameSong(current, song) {
+			event := Event{
+				Song:        *song,
+				Previous:    current,
+				ExpectedEnd: song.ExpectedEnd(),
+				DetectedAt:  w.now(),
+			}
+			w.logger.WithField("song", song.String()).Debug("Now playing changed")
+			handler(event)
+			current = song
+		}
+
+		wait := w.nextPoll(current, err != nil)
+		w.logger.WithField("wait", wait).Debug("Waiting for next now playing poll")
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-w.after(wait):
+		}
+	}
+}
+
+// nextPoll determines how long to wait before polling again.
+func (w *Watcher) nextPoll(current *types.Song, failed bool) time.Duration {
+	if failed || current == nil {
+		return w.FallbackInterval
+	}
+
+	end := current.ExpectedEnd()
+	if end.IsZero() {
+		return w.FallbackInterval
+	}
+
+	wait := end.Add(w.EndGrace).Sub(w.now())
+	if wait < w.RetryInterval {
+		// The track has (nearly) ended but KMHD hasn't published the next one yet
+		return w.RetryInterval
+	}
+	if wait > w.MaxInterval {
+		return w.MaxInterval
+	}
+	return wait
+}
+
+// sameSong reports whether two songs are the same play.
+func sameSong(a, b *types.Song) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.KMHDID != "" && b.KMHDID != "" {
+		return a.KMHDID == b.KMHDID
+	}
+	return a.PlayedAt.Equal(b.PlayedAt) && a.Artist == b.Artist && a.Title == b.Title
+}
@@ -0,0 +1,198 @@
+package nowplaying
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+var testStart = time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)
+
+// scriptedFetcher returns the playlist as it looked at the fetcher's current time
+type scriptedFetcher struct {
+	now      *time.Time
+	songs    []types.Song
+	err      error
+	requests []time.Time
+}
+
+func (f *scriptedFetcher) FetchPlaylist(date time.Time) (*types.SongCollection, error) {
+	f.requests = append(f.requests, date)
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	collection := &types.SongCollection{}
+	for _, song := range f.songs {
+		if !song.PlayedAt.After(*f.now) && song.PlayedAt.YearDay() == date.YearDay() {
+			collection.AddSong(song)
+		}
+	}
+	return collection, nil
+}
+
+// newTestWatcher creates a watcher whose clock only advances when it waits
+func newTestWatcher(fetcher *scriptedFetcher, waits *[]time.Duration, stopAfter int, cancel context.CancelFunc) *Watcher {
+	watcher := NewWatcher(fetcher)
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	watcher.logger = logger.WithField("component", "nowplaying_watcher")
+
+	watcher.now = func() time.Time { return *fetcher.now }
+	watcher.after = func(d time.Duration) <-chan time.Time {
+		*waits = append(*waits, d)
+		*fetcher.now = fetcher.now.Add(d)
+		if len(*waits) >= stopAfter {
+			cancel()
+			return nil // blocks forever, so Watch returns on the cancelled context
+		}
+		ch := make(chan time.Time, 1)
+		ch <- *fetcher.now
+		return ch
+	}
+	return watcher
+}
+
+func song(title string, offset, duration time.Duration, id string) types.Song {
+	return types.Song{
+		Artist:   "Artist",
+		Title:    title,
+		PlayedAt: testStart.Add(offset),
+		Duration: duration,
+		KMHDID:   id,
+	}
+}
+
+func TestWatcher_Current(t *testing.T) {
+	now := testStart.Add(5 * time.Minute)
+	fetcher := &scriptedFetcher{
+		now: &now,
+		songs: []types.Song{
+			song("First", 0, 3*time.Minute, "1"),
+			song("Second", 3*time.Minute, 4*time.Minute, "2"),
+		},
+	}
+	watcher := NewWatcher(fetcher)
+	watcher.now = func() time.Time { return now }
+
+	current, err := watcher.Current()
+	require.NoError(t, err)
+	require.NotNil(t, current)
+	assert.Equal(t, "Second", current.Title)
+}
+
+func TestWatcher_CurrentFallsBackToPreviousDay(t *testing.T) {
+	now := time.Date(2025, 10, 19, 0, 1, 0, 0, time.UTC)
+	fetcher := &scriptedFetcher{
+		now:   &now,
+		songs: []types.Song{{Artist: "Artist", Title: "Late Night", PlayedAt: time.Date(2025, 10, 18, 23, 58, 0, 0, time.UTC)}},
+	}
+	watcher := NewWatcher(fetcher)
+	watcher.now = func() time.Time { return now }
+
+	current, err := watcher.Current()
+	require.NoError(t, err)
+	require.NotNil(t, current)
+	assert.Equal(t, "Late Night", current.Title)
+	assert.Len(t, fetcher.requests, 2)
+}
+
+func TestWatcher_WatchEmitsChangesAndPollsAroundTrackEnd(t *testing.T) {
+	now := testStart.Add(time.Minute)
+	fetcher := &scriptedFetcher{
+		now: &now,
+		songs: []types.Song{
+			song("First", 0, 3*time.Minute, "1"),
+			// KMHD publishes the second track 20s late
+			song("Second", 3*time.Minute+20*time.Second, 4*time.Minute, "2"),
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var waits []time.Duration
+	watcher := newTestWatcher(fetcher, &waits, 3, cancel)
+
+	var events []Event
+	err := watcher.Watch(ctx, func(event Event) { events = append(events, event) })
+	require.ErrorIs(t, err, context.Canceled)
+
+	require.Len(t, events, 2)
+	assert.Equal(t, "First", events[0].Song.Title)
+	assert.Nil(t, events[0].Previous)
+	assert.Equal(t, testStart.Add(3*time.Minute), events[0].ExpectedEnd)
+	assert.Equal(t, "Second", events[1].Song.Title)
+	require.NotNil(t, events[1].Previous)
+	assert.Equal(t, "First", events[1].Previous.Title)
+
+	assert.Equal(t, []time.Duration{
+		2*time.Minute + DefaultEndGrace, // until the first track's expected end plus grace
+		DefaultRetryInterval,            // the next track hasn't appeared yet
+		4*time.Minute - 10*time.Second,  // until the second track's expected end plus grace
+	}, waits)
+}
+
+func TestWatcher_WatchUsesFallbackWithoutDuration(t *testing.T) {
+	now := testStart.Add(time.Minute)
+	fetcher := &scriptedFetcher{
+		now:   &now,
+		songs: []types.Song{song("Unknown Length", 0, 0, "")},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var waits []time.Duration
+	watcher := newTestWatcher(fetcher, &waits, 2, cancel)
+
+	var events []Event
+	err := watcher.Watch(ctx, func(event Event) { events = append(events, event) })
+	require.ErrorIs(t, err, context.Canceled)
+
+	assert.Len(t, events, 1, "the same song is only reported once")
+	assert.Equal(t, []time.Duration{DefaultFallbackInterval, DefaultFallbackInterval}, waits)
+}
+
+func TestWatcher_WatchSurvivesFetchErrors(t *testing.T) {
+	now := testStart
+	fetcher := &scriptedFetcher{now: &now, err: errors.New("kmhd down")}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var waits []time.Duration
+	watcher := newTestWatcher(fetcher, &waits, 2, cancel)
+
+	err := watcher.Watch(ctx, func(Event) { t.Fatal("no event expected") })
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []time.Duration{DefaultFallbackInterval, DefaultFallbackInterval}, waits)
+}
+
+func TestWatcher_NextPollIsCapped(t *testing.T) {
+	now := testStart
+	watcher := NewWatcher(&scriptedFetcher{now: &now})
+	watcher.now = func() time.Time { return now }
+
+	long := song("Long Set", 0, time.Hour, "1")
+	assert.Equal(t, DefaultMaxInterval, watcher.nextPoll(&long, false))
+}
+
+func TestSameSong(t *testing.T) {
+	a := song("Title", 0, 0, "1")
+	b := song("Title", 0, 0, "2")
+	c := song("Title", 0, 0, "")
+
+	assert.True(t, sameSong(nil, nil))
+	assert.False(t, sameSong(nil, &a))
+	assert.False(t, sameSong(&a, &b), "different KMHD IDs are different plays")
+	assert.True(t, sameSong(&a, &c), "falls back to start time and metadata")
+}
@@ -85,34 +85,61 @@ func (p *PlaylistService) AddArtistToPlaylist(artistName, playlistID string, for
 		}, nil
 	}
 
+	return p.AddArtistTracksToPlaylist(*artist, tracks, playlistID, force)
+}
+
+// ExistingArtistTracks returns a duplicate result listing the artist's tracks already in
+// the playlist, or nil if there are none or the check fails
+func (p *PlaylistService) ExistingArtistTracks(artist types.Artist, playlistID string) *types.AddResult {
+	if p.duplicate == nil {
+		return nil
+	}
+	duplicateResult, err := p.duplicate.CheckArtistInPlaylist(playlistID, artist.ID)
+	if err != nil {
+		p.logger.WithError(err).WithFields(log.Fields{
+			"component":   "playlist_service",
+			"operation":   "duplicate_check",
+			"artist_id":   artist.ID,
+			"playlist_id": playlistID,
+		}).Warn("Failed to check for duplicates, proceeding anyway")
+		return nil
+	}
+	if duplicateResult == nil || !duplicateResult.HasDuplicates {
+		return nil
+	}
+
+	p.logger.WithFields(log.Fields{
+		"component":      "playlist_service",
+		"operation":      "duplicate_check",
+		"artist_id":      artist.ID,
+		"artist_name":    artist.Name,
+		"playlist_id":    playlistID,
+		"last_added":     duplicateResult.LastAdded,
+		"has_duplicates": true,
+	}).Info("Artist tracks already exist in playlist")
+
+	result := &types.AddResult{
+		Success:        false,
+		Artist:         artist,
+		WasDuplicate:   true,
+		Message:        duplicateResult.Message,
+		ExistingTracks: duplicateResult.DuplicateItems,
+	}
+	if !duplicateResult.LastAdded.IsZero() {
+		lastAdded := duplicateResult.LastAdded
+		result.LastAdded = &lastAdded
+	}
+	return result
+}
+
+// AddArtistTracksToPlaylist adds the given tracks of an already resolved artist to a
+// playlist, unless the artist is already in the playlist and force is not set
+func (p *PlaylistService) AddArtistTracksToPlaylist(artist types.Artist, tracks []types.Track, playlistID string, force bool) (*types.AddResult, error) {
 	// Check for duplicates if not forced
 	var wasDuplicate bool
-	if !force && p.duplicate != nil {
-		duplicateResult, err := p.duplicate.CheckArtistInPlaylist(playlistID, artist.ID)
-		if err != nil {
-			p.logger.WithError(err).WithFields(log.Fields{
-				"component":   "playlist_service",
-				"operation":   "duplicate_check",
-				"artist_id":   artist.ID,
-				"playlist_id": playlistID,
-			}).Warn("Failed to check for duplicates, proceeding anyway")
-		} else if duplicateResult != nil && duplicateResult.HasDuplicates {
-			p.logger.WithFields(log.Fields{
-				"component":      "playlist_service",
-				"operation":      "duplicate_check",
-				"artist_id":      artist.ID,
-				"artist_name":    artist.Name,
-				"playlist_id":    playlistID,
-				"last_added":     duplicateResult.LastAdded,
-				"has_duplicates": true,
-			}).Info("Artist tracks already exist in playlist")
-
-			return &types.AddResult{
-				Success:      false,
-				Artist:       *artist,
-				WasDuplicate: true,
-				Message:      duplicateResult.Message,
-			}, nil
+	if !force {
+		if existing := p.ExistingArtistTracks(artist, playlistID); existing != nil {
+			return existing, nil
 		}
 	}
 
@@ -125,7 +152,7 @@ func (p *PlaylistService) AddArtistToPlaylist(artistName, playlistID string, for
 	}
 
 	// Add tracks to playlist in batch with error handling
-	err = p.spotify.AddTracksToPlaylist(playlistID, trackIDs)
+	err := p.spotify.AddTracksToPlaylist(playlistID, trackIDs)
 	if err != nil {
 		p.logger.WithError(err).WithFields(log.Fields{
 			"component":   "playlist_service",
@@ -149,7 +176,7 @@ func (p *PlaylistService) AddArtistToPlaylist(artistName, playlistID string, for
 
 		return &types.AddResult{
 			Success:      false,
-			Artist:       *artist,
+			Artist:       artist,
 			TracksAdded:  tracks,
 			WasDuplicate: wasDuplicate,
 			Message:      errorMessage,
@@ -167,52 +194,60 @@ func (p *PlaylistService) AddArtistToPlaylist(artistName, playlistID string, for
 
 	return &types.AddResult{
 		Success:      true,
-		Artist:       *artist,
+		Artist:       artist,
 		TracksAdded:  tracks,
 		WasDuplicate: wasDuplicate,
 		Message:      "Successfully added " + artist.Name + "'s top tracks to playlist",
 	}, nil
 }
 
-// GetIncomingPlaylists gets playlists from the "Incoming" folder
+// GetIncomingPlaylists gets the user's incoming playlists, those marked as incoming by
+// the configured name prefix or description tag
 func (p *PlaylistService) GetIncomingPlaylists() ([]types.Playlist, error) {
 	p.logger.WithFields(log.Fields{
-		"component":   "playlist_service",
-		"operation":   "get_incoming_playlists",
-		"folder_name": "Incoming",
-	}).Debug("Fetching playlists from 'Incoming' folder")
+		"component": "playlist_service",
+		"operation": "get_incoming_playlists",
+	}).Debug("Fetching incoming playlists")
 
-	playlists, err := p.spotify.GetUserPlaylists("Incoming")
+	playlists, err := p.spotify.GetUserPlaylists("")
 	if err != nil {
 		p.logger.WithError(err).WithFields(log.Fields{
-			"component":   "playlist_service",
-			"operation":   "get_incoming_playlists",
-			"folder_name": "Incoming",
-		}).Error("Failed to fetch playlists from Incoming folder")
+			"component": "playlist_service",
+			"operation": "get_incoming_playlists",
+		}).Error("Failed to fetch incoming playlists")
 		return nil, err
 	}
 
-	playlistNames := make([]string, len(playlists))
-	for i, playlist := range playlists {
-		playlistNames[i] = playlist.Name
+	incoming := make([]types.Playlist, 0)
+	playlistNames := make([]string, 0)
+	for _, playlist := range playlists {
+		if playlist.IsIncoming {
+			incoming = append(incoming, playlist)
+			playlistNames = append(playlistNames, playlist.Name)
+		}
 	}
 
 	p.logger.WithFields(log.Fields{
 		"component":      "playlist_service",
 		"operation":      "get_incoming_playlists",
-		"folder_name":    "Incoming",
-		"playlist_count": len(playlists),
+		"playlist_count": len(incoming),
 		"playlist_names": playlistNames,
-	}).Info("Successfully fetched Incoming playlists")
-	return playlists, nil
+	}).Info("Successfully fetched incoming playlists")
+	return incoming, nil
 }
 
 // GetTop5Tracks gets the top 5 tracks for an artist
 func (p *PlaylistService) GetTop5Tracks(artistID string) ([]types.Track, error) {
+	return p.GetTopTracks(artistID, 5)
+}
+
+// GetTopTracks gets up to count top tracks for an artist
+func (p *PlaylistService) GetTopTracks(artistID string, count int) ([]types.Track, error) {
 	p.logger.WithFields(log.Fields{
 		"component": "playlist_service",
 		"operation": "get_top_tracks",
 		"artist_id": artistID,
+		"count":     count,
 	}).Debug("Fetching top tracks for artist")
 
 	tracks, err := p.spotify.GetArtistTopTracks(artistID)
@@ -224,6 +259,9 @@ func (p *PlaylistService) GetTop5Tracks(artistID string) ([]types.Track, error)
 		}).Error("Failed to fetch top tracks for artist")
 		return nil, err
 	}
+	if len(tracks) > count {
+		tracks = tracks[:count]
+	}
 
 	p.logger.WithFields(log.Fields{
 		"component":   "playlist_service",
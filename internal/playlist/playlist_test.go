@@ -3,6 +3,7 @@ package playlist
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -19,6 +20,10 @@ func (m *MockSpotifyService) SearchArtist(query string) (*types.Artist, error) {
 	return nil, errors.New("not implemented in mock")
 }
 
+func (m *MockSpotifyService) SearchArtists(query string, limit int) ([]types.Artist, error) {
+	return nil, errors.New("not implemented in mock")
+}
+
 func (m *MockSpotifyService) GetArtistTopTracks(artistID string) ([]types.Track, error) {
 	return nil, errors.New("not implemented in mock")
 }
@@ -61,6 +66,38 @@ func (m *MockSpotifyService) CreatePlaylist(name, description string, public boo
 	}, nil
 }
 
+func (m *MockSpotifyService) GetPlaylistTracks(playlistID string) ([]types.Track, error) {
+	return nil, nil
+}
+
+func (m *MockSpotifyService) GetPlaylistItems(playlistID string) ([]types.PlaylistItem, error) {
+	return nil, nil
+}
+
+func (m *MockSpotifyService) RemoveTracksFromPlaylist(playlistID string, trackIDs []string) error {
+	return nil
+}
+
+func (m *MockSpotifyService) RemoveTrackPositions(playlistID string, positions map[string][]int) error {
+	return nil
+}
+
+func (m *MockSpotifyService) ReorderPlaylist(playlistID string, rangeStart, rangeLength, insertBefore int) error {
+	return nil
+}
+
+func (m *MockSpotifyService) UpdatePlaylist(playlistID string, update types.PlaylistUpdate) error {
+	return nil
+}
+
+func (m *MockSpotifyService) SetPlaylistCover(playlistID string, jpeg []byte) error {
+	return nil
+}
+
+func (m *MockSpotifyService) DeletePlaylist(playlistID string) error {
+	return nil
+}
+
 // MockDuplicateDetector is a mock implementation of DuplicateDetector
 type MockDuplicateDetector struct{}
 
@@ -121,6 +158,18 @@ func TestPlaylistService_GetIncomingPlaylists(t *testing.T) {
 			},
 			expectedError: false,
 		},
+		{
+			name: "only playlists marked as incoming",
+			mockPlaylists: []types.Playlist{
+				{ID: "playlist1", Name: "Incoming: Jazz", IsIncoming: true},
+				{ID: "playlist2", Name: "KMHD-2025-10"},
+				{ID: "playlist3", Name: "Saturday Finds", Description: "#incoming", IsIncoming: true},
+			},
+			expectedResult: []types.Playlist{
+				{ID: "playlist1", Name: "Incoming: Jazz", IsIncoming: true},
+				{ID: "playlist3", Name: "Saturday Finds", Description: "#incoming", IsIncoming: true},
+			},
+		},
 		{
 			name:           "empty incoming folder",
 			mockPlaylists:  []types.Playlist{},
@@ -463,6 +512,13 @@ func (m *EnhancedMockSpotifyService) SearchArtist(query string) (*types.Artist,
 	return m.artist, m.artistError
 }
 
+func (m *EnhancedMockSpotifyService) SearchArtists(query string, limit int) ([]types.Artist, error) {
+	if m.artistError != nil {
+		return nil, m.artistError
+	}
+	return []types.Artist{*m.artist}, nil
+}
+
 func (m *EnhancedMockSpotifyService) GetArtistTopTracks(artistID string) ([]types.Track, error) {
 	return m.tracks, m.tracksError
 }
@@ -502,6 +558,38 @@ func (m *EnhancedMockSpotifyService) CreatePlaylist(name, description string, pu
 	}, nil
 }
 
+func (m *EnhancedMockSpotifyService) GetPlaylistTracks(playlistID string) ([]types.Track, error) {
+	return nil, nil
+}
+
+func (m *EnhancedMockSpotifyService) GetPlaylistItems(playlistID string) ([]types.PlaylistItem, error) {
+	return nil, nil
+}
+
+func (m *EnhancedMockSpotifyService) RemoveTracksFromPlaylist(playlistID string, trackIDs []string) error {
+	return nil
+}
+
+func (m *EnhancedMockSpotifyService) RemoveTrackPositions(playlistID string, positions map[string][]int) error {
+	return nil
+}
+
+func (m *EnhancedMockSpotifyService) ReorderPlaylist(playlistID string, rangeStart, rangeLength, insertBefore int) error {
+	return nil
+}
+
+func (m *EnhancedMockSpotifyService) UpdatePlaylist(playlistID string, update types.PlaylistUpdate) error {
+	return nil
+}
+
+func (m *EnhancedMockSpotifyService) SetPlaylistCover(playlistID string, jpeg []byte) error {
+	return nil
+}
+
+func (m *EnhancedMockSpotifyService) DeletePlaylist(playlistID string) error {
+	return nil
+}
+
 // EnhancedMockDuplicateDetector provides more control over duplicate detection
 type EnhancedMockDuplicateDetector struct {
 	result *types.DuplicateResult
@@ -780,6 +868,60 @@ func TestPlaylistService_OverrideButtonFunctionality(t *testing.T) {
 	}
 }
 
+// TestPlaylistService_AddArtistToPlaylist_ExistingTracks tests that a duplicate result
+// reports when the artist's tracks were really added to the playlist
+func TestPlaylistService_AddArtistToPlaylist_ExistingTracks(t *testing.T) {
+	lastAdded := time.Date(2025, 10, 5, 21, 30, 0, 0, time.UTC)
+	existing := []types.PlaylistItem{
+		{Track: types.Track{ID: "track7", Name: "Deep Cut"}, AddedAt: lastAdded},
+		{Track: types.Track{ID: "track9", Name: "Old Favourite"}, AddedAt: lastAdded.AddDate(0, -1, 0)},
+	}
+
+	mockSpotify := &EnhancedMockSpotifyService{
+		artist: &types.Artist{ID: "artist123", Name: "Test Artist"},
+		tracks: []types.Track{{ID: "track1", Name: "Song 1"}},
+	}
+
+	tests := []struct {
+		name              string
+		result            *types.DuplicateResult
+		expectedLastAdded *time.Time
+	}{
+		{
+			name: "known added_at",
+			result: &types.DuplicateResult{
+				HasDuplicates:  true,
+				LastAdded:      lastAdded,
+				DuplicateItems: existing,
+			},
+			expectedLastAdded: &lastAdded,
+		},
+		{
+			name: "unknown added_at",
+			result: &types.DuplicateResult{
+				HasDuplicates:  true,
+				DuplicateItems: existing[:1],
+			},
+		},
+	}
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewPlaylistService(mockSpotify, &EnhancedMockDuplicateDetector{result: tt.result}, logger)
+
+			result, err := service.AddArtistToPlaylist("Test Artist", "playlist123", false)
+
+			assert.NoError(t, err)
+			assert.True(t, result.WasDuplicate)
+			assert.Equal(t, tt.expectedLastAdded, result.LastAdded)
+			assert.Equal(t, tt.result.DuplicateItems, result.ExistingTracks)
+		})
+	}
+}
+
 // TestPlaylistService_APIOverrideParameter tests API-style override with force parameter
 func TestPlaylistService_APIOverrideParameter(t *testing.T) {
 	tests := []struct {
@@ -926,3 +1068,24 @@ func TestPlaylistService_GetTop5Tracks(t *testing.T) {
 		})
 	}
 }
+
+func TestPlaylistService_GetTopTracks(t *testing.T) {
+	mockSpotify := &EnhancedMockSpotifyService{
+		tracks: []types.Track{{ID: "track1"}, {ID: "track2"}, {ID: "track3"}},
+	}
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	service := NewPlaylistService(mockSpotify, nil, logger)
+
+	tracks, err := service.GetTopTracks("artist123", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, mockSpotify.tracks[:2], tracks)
+
+	tracks, err = service.GetTop5Tracks("artist123")
+	assert.NoError(t, err)
+	assert.Equal(t, mockSpotify.tracks, tracks)
+
+	mockSpotify.tracksError = errors.New("spotify API error")
+	_, err = service.GetTopTracks("artist123", 2)
+	assert.Error(t, err)
+}
@@ -0,0 +1,468 @@
+// Package queue provides a durable retry queue for failed Spotify operations.
+//
+// Operations that fail during a sync are persisted to a JSON file in the state
+// directory and retried with exponential backoff. Operations that exhaust their
+// attempts are moved to a dead-letter list where they can be inspected, retried
+// manually, or dropped.
+package queue
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/kmhd2spotify/internal/filelock"
+	"github.com/toozej/kmhd2spotify/internal/types"
+	"github.com/toozej/kmhd2spotify/pkg/config"
+)
+
+// Operation identifies the Spotify operation that should be retried.
+type Operation string
+
+const (
+	// OperationAddTrack checks for and adds a single track to a playlist.
+	OperationAddTrack Operation = "add_track"
+)
+
+// Item represents a failed operation waiting to be retried.
+type Item struct {
+	ID        string     `json:"id"`
+	Operation Operation  `json:"operation"`
+	Song      types.Song `json:"song"`
+	TrackID   string     `json:"track_id"`
+	TrackName string     `json:"track_name"`
+	// Track is the matched Spotify track, kept for duplicate checks by recording.
+	// Items queued by earlier versions only have TrackID and TrackName.
+	Track        *types.Track `json:"track,omitempty"`
+	PlaylistID   string       `json:"playlist_id"`
+	PlaylistName string       `json:"playlist_name"`
+	Profile      string       `json:"profile,omitempty"`
+	Attempts     int          `json:"attempts"`
+	LastError    string       `json:"last_error"`
+	CreatedAt    time.Time    `json:"created_at"`
+	NextAttempt  time.Time    `json:"next_attempt"`
+}
+
+// Policy defines how often and how many times failed operations are retried.
+type Policy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// PolicyFromConfig builds a retry policy from the queue configuration.
+func PolicyFromConfig(cfg config.QueueConfig) Policy {
+	return Policy{
+		MaxAttempts: cfg.MaxAttempts,
+		BaseDelay:   cfg.BaseDelay,
+		MaxDelay:    cfg.MaxDelay,
+	}
+}
+
+// Backoff returns the delay before the next attempt after the given number of
+// failed attempts: BaseDelay doubled for every attempt after the first, capped at MaxDelay.
+func (p Policy) Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	delay := p.BaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// state is the on-disk representation of the queue.
+type state struct {
+	Pending    []Item `json:"pending"`
+	DeadLetter []Item `json:"dead_letter"`
+}
+
+// Queue is a file-backed retry queue with a dead-letter list.
+type Queue struct {
+	mu     sync.Mutex
+	path   string
+	policy Policy
+	now    func() time.Time
+	state  state
+	logger *log.Entry
+}
+
+// New opens the retry queue stored at path, creating an empty queue if the file does not exist.
+// The queue file may be shared with other processes, such as 'queue drop' run while a
+// continuous sync is retrying: every change is made under a file lock after re-reading it.
+func New(path string, policy Policy) (*Queue, error) {
+	q := &Queue{
+		path:   path,
+		policy: policy,
+		now:    time.Now,
+		logger: log.WithField("component", "retry_queue"),
+	}
+
+	unlock, err := q.lockUnsafe()
+	if err != nil {
+		return nil, err
+	}
+	unlock()
+	return q, nil
+}
+
+// SetClock replaces the clock used for scheduling retries. It is intended for tests.
+func (q *Queue) SetClock(now func() time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.now = now
+}
+
+// Enqueue records a failed operation. The failure that caused the enqueue counts as
+// the first attempt. An item for the same operation, track, and playlist that is
+// already pending is not duplicated.
+func (q *Queue) Enqueue(item Item, cause error) (Item, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	unlock, err := q.lockUnsafe()
+	if err != nil {
+		return Item{}, err
+	}
+	defer unlock()
+
+	for _, pending := range q.state.Pending {
+		if pending.Operation == item.Operation && pending.TrackID == item.TrackID && pending.PlaylistID == item.PlaylistID {
+			q.logger.WithFields(log.Fields{
+				"item_id":     pending.ID,
+				"track_id":    item.TrackID,
+				"playlist_id": item.PlaylistID,
+			}).Debug("Operation already queued for retry")
+			return pending, nil
+		}
+	}
+
+	id, err := newID()
+	if err != nil {
+		return Item{}, err
+	}
+
+	now := q.now()
+	item.ID = id
+	item.CreatedAt = now
+	item.Attempts = 1
+	if cause != nil {
+		item.LastError = cause.Error()
+	}
+
+	item = q.schedule(item)
+
+	q.logger.WithFields(log.Fields{
+		"item_id":      item.ID,
+		"operation":    item.Operation,
+		"track_id":     item.TrackID,
+		"playlist_id":  item.PlaylistID,
+		"next_attempt": item.NextAttempt,
+	}).Info("Queued failed operation for retry")
+
+	return item, q.saveUnsafe()
+}
+
+// Due returns the pending items whose next attempt time has been reached.
+func (q *Queue) Due() []Item {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.refreshUnsafe()
+
+	now := q.now()
+	var due []Item
+	for _, item := range q.state.Pending {
+		if !item.NextAttempt.After(now) {
+			due = append(due, item)
+		}
+	}
+	return due
+}
+
+// Succeed removes a pending item after its operation completed successfully.
+func (q *Queue) Succeed(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	unlock, err := q.lockUnsafe()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, ok := q.removePending(id); !ok {
+		return fmt.Errorf("queue item %s not found", id)
+	}
+
+	q.logger.WithField("item_id", id).Info("Retried operation succeeded")
+	return q.saveUnsafe()
+}
+
+// Fail records another failed attempt for a pending item, rescheduling it or
+// moving it to the dead-letter list once MaxAttempts is reached.
+func (q *Queue) Fail(id string, cause error) (Item, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	unlock, err := q.lockUnsafe()
+	if err != nil {
+		return Item{}, err
+	}
+	defer unlock()
+
+	item, ok := q.removePending(id)
+	if !ok {
+		return Item{}, fmt.Errorf("queue item %s not found", id)
+	}
+
+	item.Attempts++
+	if cause != nil {
+		item.LastError = cause.Error()
+	}
+
+	item = q.schedule(item)
+	return item, q.saveUnsafe()
+}
+
+// Requeue makes an item due immediately. Dead-letter items are moved back to the
+// pending list with their attempt count reset.
+func (q *Queue) Requeue(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	unlock, err := q.lockUnsafe()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	now := q.now()
+	for i := range q.state.Pending {
+		if q.state.Pending[i].ID == id {
+			q.state.Pending[i].NextAttempt = now
+			return q.saveUnsafe()
+		}
+	}
+
+	for i, item := range q.state.DeadLetter {
+		if item.ID == id {
+			q.state.DeadLetter = append(q.state.DeadLetter[:i], q.state.DeadLetter[i+1:]...)
+			item.Attempts = 0
+			item.NextAttempt = now
+			q.state.Pending = append(q.state.Pending, item)
+			q.logger.WithField("item_id", id).Info("Moved dead-letter item back to retry queue")
+			return q.saveUnsafe()
+		}
+	}
+
+	return fmt.Errorf("queue item %s not found", id)
+}
+
+// RequeueAll makes every pending and dead-letter item due immediately.
+func (q *Queue) RequeueAll() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	unlock, err := q.lockUnsafe()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	now := q.now()
+	for i := range q.state.Pending {
+		q.state.Pending[i].NextAttempt = now
+	}
+	for _, item := range q.state.DeadLetter {
+		item.Attempts = 0
+		item.NextAttempt = now
+		q.state.Pending = append(q.state.Pending, item)
+	}
+	q.state.DeadLetter = nil
+
+	return q.saveUnsafe()
+}
+
+// Drop removes an item from either the pending or the dead-letter list.
+func (q *Queue) Drop(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	unlock, err := q.lockUnsafe()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, ok := q.removePending(id); ok {
+		return q.saveUnsafe()
+	}
+
+	for i, item := range q.state.DeadLetter {
+		if item.ID == id {
+			q.state.DeadLetter = append(q.state.DeadLetter[:i], q.state.DeadLetter[i+1:]...)
+			return q.saveUnsafe()
+		}
+	}
+
+	return fmt.Errorf("queue item %s not found", id)
+}
+
+// DropDeadLetter removes every item from the dead-letter list and returns how many were dropped.
+func (q *Queue) DropDeadLetter() (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	unlock, err := q.lockUnsafe()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	count := len(q.state.DeadLetter)
+	q.state.DeadLetter = nil
+	return count, q.saveUnsafe()
+}
+
+// Pending returns a copy of the items waiting to be retried.
+func (q *Queue) Pending() []Item {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.refreshUnsafe()
+	return append([]Item(nil), q.state.Pending...)
+}
+
+// DeadLetter returns a copy of the items that exhausted their attempts.
+func (q *Queue) DeadLetter() []Item {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.refreshUnsafe()
+	return append([]Item(nil), q.state.DeadLetter...)
+}
+
+// schedule appends the item to the pending list with its next attempt time, or to
+// the dead-letter list if it has exhausted its attempts, and returns the scheduled
+// item. The caller must hold the lock.
+func (q *Queue) schedule(item Item) Item {
+	if item.Attempts >= q.policy.MaxAttempts {
+		item.NextAttempt = time.Time{}
+		q.state.DeadLetter = append(q.state.DeadLetter, item)
+		q.logger.WithFields(log.Fields{
+			"item_id":    item.ID,
+			"attempts":   item.Attempts,
+			"last_error": item.LastError,
+		}).Warn("Operation exhausted its retry attempts, moved to dead-letter list")
+		return item
+	}
+
+	item.NextAttempt = q.now().Add(q.policy.Backoff(item.Attempts))
+	q.state.Pending = append(q.state.Pending, item)
+	return item
+}
+
+// removePending removes and returns a pending item. The caller must hold the lock.
+func (q *Queue) removePending(id string) (Item, bool) {
+	for i, item := range q.state.Pending {
+		if item.ID == id {
+			q.state.Pending = append(q.state.Pending[:i], q.state.Pending[i+1:]...)
+			return item, true
+		}
+	}
+	return Item{}, false
+}
+
+// lockUnsafe takes the queue file lock and re-reads the queue, so changes made by
+// another process sharing the file are seen. The returned function releases the lock.
+// This should only be called when the caller already holds the mutex.
+func (q *Queue) lockUnsafe() (func(), error) {
+	unlock, err := filelock.Lock(q.path + ".lock")
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock retry queue: %w", err)
+	}
+	if err := q.load(); err != nil {
+		unlock()
+		return nil, err
+	}
+	return unlock, nil
+}
+
+// refreshUnsafe re-reads the queue for a read-only query, keeping the queue in memory
+// if that fails. This should only be called when the caller already holds the mutex.
+func (q *Queue) refreshUnsafe() {
+	unlock, err := q.lockUnsafe()
+	if err != nil {
+		q.logger.WithError(err).Warn("Failed to re-read retry queue file")
+		return
+	}
+	unlock()
+}
+
+// load reads the queue state from disk, replacing the queue in memory.
+func (q *Queue) load() error {
+	data, err := os.ReadFile(q.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			q.state = state{}
+			return nil
+		}
+		return fmt.Errorf("failed to read retry queue file: %w", err)
+	}
+
+	var loaded state
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return fmt.Errorf("failed to parse retry queue file: %w", err)
+	}
+	q.state = loaded
+
+	q.logger.WithFields(log.Fields{
+		"pending":     len(q.state.Pending),
+		"dead_letter": len(q.state.DeadLetter),
+	}).Debug("Loaded retry queue from file")
+	return nil
+}
+
+// saveUnsafe writes the queue state to disk without acquiring locks.
+// This should only be called when the caller already holds the lock.
+func (q *Queue) saveUnsafe() error {
+	data, err := json.MarshalIndent(q.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal retry queue: %w", err)
+	}
+
+	// Write to temporary file first, then rename for atomic operation
+	tempFile := q.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("failed to write retry queue file: %w", err)
+	}
+
+	if err := os.Rename(tempFile, q.path); err != nil {
+		_ = os.Remove(tempFile) // Clean up temp file
+		return fmt.Errorf("failed to rename retry queue file: %w", err)
+	}
+
+	return nil
+}
+
+// newID generates a short random identifier for a queue item.
+func newID() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate queue item ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,228 @@
+package queue
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+// fakeClock is a manually advanced clock for deterministic scheduling tests
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestQueue(t *testing.T, policy Policy) (*Queue, *fakeClock, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "retry_queue.json")
+	q, err := New(path, policy)
+	require.NoError(t, err)
+
+	clock := &fakeClock{now: time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)}
+	q.SetClock(clock.Now)
+
+	return q, clock, path
+}
+
+func testItem(trackID string) Item {
+	return Item{
+		Operation:    OperationAddTrack,
+		Song:         types.Song{Artist: "Miles Davis", Title: "So What"},
+		TrackID:      trackID,
+		TrackName:    "So What",
+		PlaylistID:   "playlist1",
+		PlaylistName: "KMHD-2025-10",
+	}
+}
+
+func TestPolicyBackoff(t *testing.T) {
+	policy := Policy{MaxAttempts: 10, BaseDelay: time.Minute, MaxDelay: 10 * time.Minute}
+
+	tests := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{attempts: 0, expected: time.Minute},
+		{attempts: 1, expected: time.Minute},
+		{attempts: 2, expected: 2 * time.Minute},
+		{attempts: 3, expected: 4 * time.Minute},
+		{attempts: 4, expected: 8 * time.Minute},
+		{attempts: 5, expected: 10 * time.Minute},
+		{attempts: 50, expected: 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, policy.Backoff(tt.attempts), "attempts=%d", tt.attempts)
+	}
+}
+
+func TestEnqueueSchedulesFirstRetry(t *testing.T) {
+	q, clock, _ := newTestQueue(t, Policy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour})
+
+	item, err := q.Enqueue(testItem("track1"), errors.New("rate limited"))
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, item.ID)
+	assert.Equal(t, 1, item.Attempts)
+	assert.Equal(t, "rate limited", item.LastError)
+	assert.Equal(t, clock.now.Add(time.Minute), item.NextAttempt)
+	assert.Len(t, q.Pending(), 1)
+
+	// Not due until the backoff has elapsed
+	assert.Empty(t, q.Due())
+	clock.Advance(time.Minute)
+	assert.Len(t, q.Due(), 1)
+}
+
+func TestEnqueueDeduplicatesPendingItems(t *testing.T) {
+	q, _, _ := newTestQueue(t, Policy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour})
+
+	first, err := q.Enqueue(testItem("track1"), errors.New("boom"))
+	require.NoError(t, err)
+	second, err := q.Enqueue(testItem("track1"), errors.New("boom"))
+	require.NoError(t, err)
+
+	assert.Equal(t, first.ID, second.ID)
+	assert.Len(t, q.Pending(), 1)
+}
+
+func TestFailMovesToDeadLetterAfterMaxAttempts(t *testing.T) {
+	q, clock, _ := newTestQueue(t, Policy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour})
+
+	item, err := q.Enqueue(testItem("track1"), errors.New("first"))
+	require.NoError(t, err)
+
+	clock.Advance(time.Minute)
+	updated, err := q.Fail(item.ID, errors.New("second"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated.Attempts)
+	assert.Equal(t, clock.now.Add(2*time.Minute), updated.NextAttempt)
+	assert.Len(t, q.Pending(), 1)
+
+	clock.Advance(2 * time.Minute)
+	updated, err = q.Fail(item.ID, errors.New("third"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, updated.Attempts)
+	assert.True(t, updated.NextAttempt.IsZero())
+
+	assert.Empty(t, q.Pending())
+	require.Len(t, q.DeadLetter(), 1)
+	assert.Equal(t, "third", q.DeadLetter()[0].LastError)
+}
+
+func TestSucceedRemovesItem(t *testing.T) {
+	q, _, _ := newTestQueue(t, Policy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour})
+
+	item, err := q.Enqueue(testItem("track1"), errors.New("boom"))
+	require.NoError(t, err)
+
+	require.NoError(t, q.Succeed(item.ID))
+	assert.Empty(t, q.Pending())
+	assert.Error(t, q.Succeed(item.ID))
+}
+
+func TestRequeueDeadLetterItem(t *testing.T) {
+	q, clock, _ := newTestQueue(t, Policy{MaxAttempts: 1, BaseDelay: time.Minute, MaxDelay: time.Hour})
+
+	item, err := q.Enqueue(testItem("track1"), errors.New("boom"))
+	require.NoError(t, err)
+	require.Len(t, q.DeadLetter(), 1)
+
+	require.NoError(t, q.Requeue(item.ID))
+	assert.Empty(t, q.DeadLetter())
+
+	due := q.Due()
+	require.Len(t, due, 1)
+	assert.Equal(t, 0, due[0].Attempts)
+	assert.Equal(t, clock.now, due[0].NextAttempt)
+
+	assert.Error(t, q.Requeue("missing"))
+}
+
+func TestRequeueAll(t *testing.T) {
+	q, _, _ := newTestQueue(t, Policy{MaxAttempts: 2, BaseDelay: time.Hour, MaxDelay: time.Hour})
+
+	_, err := q.Enqueue(testItem("track1"), errors.New("boom"))
+	require.NoError(t, err)
+	dead, err := q.Enqueue(testItem("track2"), errors.New("boom"))
+	require.NoError(t, err)
+	_, err = q.Fail(dead.ID, errors.New("boom"))
+	require.NoError(t, err)
+
+	assert.Empty(t, q.Due())
+	require.NoError(t, q.RequeueAll())
+	assert.Len(t, q.Due(), 2)
+	assert.Empty(t, q.DeadLetter())
+}
+
+func TestDrop(t *testing.T) {
+	q, _, _ := newTestQueue(t, Policy{MaxAttempts: 1, BaseDelay: time.Minute, MaxDelay: time.Hour})
+
+	item, err := q.Enqueue(testItem("track1"), errors.New("boom"))
+	require.NoError(t, err)
+	_, err = q.Enqueue(testItem("track2"), errors.New("boom"))
+	require.NoError(t, err)
+	require.Len(t, q.DeadLetter(), 2)
+
+	require.NoError(t, q.Drop(item.ID))
+	assert.Len(t, q.DeadLetter(), 1)
+	assert.Error(t, q.Drop(item.ID))
+
+	count, err := q.DropDeadLetter()
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Empty(t, q.DeadLetter())
+}
+
+func TestQueuePersistence(t *testing.T) {
+	policy := Policy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour}
+	q, _, path := newTestQueue(t, policy)
+
+	item, err := q.Enqueue(testItem("track1"), errors.New("boom"))
+	require.NoError(t, err)
+
+	reopened, err := New(path, policy)
+	require.NoError(t, err)
+
+	pending := reopened.Pending()
+	require.Len(t, pending, 1)
+	assert.Equal(t, item.ID, pending[0].ID)
+	assert.Equal(t, "Miles Davis", pending[0].Song.Artist)
+	assert.Equal(t, "track1", pending[0].TrackID)
+}
+
+func TestQueueSharedBetweenProcesses(t *testing.T) {
+	policy := Policy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour}
+	daemon, _, path := newTestQueue(t, policy)
+
+	dropped, err := daemon.Enqueue(testItem("track1"), errors.New("boom"))
+	require.NoError(t, err)
+
+	// 'queue drop' runs in another process while the daemon keeps queueing
+	cli, err := New(path, policy)
+	require.NoError(t, err)
+	require.NoError(t, cli.Drop(dropped.ID))
+
+	kept, err := daemon.Enqueue(testItem("track2"), errors.New("boom"))
+	require.NoError(t, err)
+
+	pending := daemon.Pending()
+	require.Len(t, pending, 1, "dropped items don't come back")
+	assert.Equal(t, kept.ID, pending[0].ID)
+	assert.Equal(t, pending, cli.Pending())
+	assert.ErrorContains(t, daemon.Succeed(dropped.ID), "not found")
+}
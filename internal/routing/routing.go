@@ -0,0 +1,244 @@
+// Package routing decides which playlists each synced song is added to.
+//
+// A Router evaluates the routing rules from ROUTING_RULES_FILE for every song. Each
+// matching route renders its playlist name template, e.g. "{prefix} {year} Q{quarter}",
+// from the time the song was played, so a song can be routed to several playlists
+// such as a quarterly playlist, a favourites playlist and a per-show playlist.
+package routing
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/toozej/kmhd2spotify/internal/types"
+	"github.com/toozej/kmhd2spotify/pkg/config"
+)
+
+// Route is a playlist a song was routed to.
+type Route struct {
+	// Name is the name of the route that matched.
+	Name string
+	// Playlist is the rendered playlist name.
+	Playlist string
+}
+
+// Router routes songs to playlists using parsed routing rules.
+type Router struct {
+	location *time.Location
+	shows    []show
+	routes   []route
+	now      func() time.Time
+}
+
+// schedule is a parsed set of days and a daily time slot. A zero schedule matches any time.
+type schedule struct {
+	days      []time.Weekday
+	window    config.TimeWindow
+	hasWindow bool
+}
+
+// show is a parsed show rule.
+type show struct {
+	name     string
+	schedule schedule
+}
+
+// route is a parsed route rule.
+type route struct {
+	name          string
+	playlist      string
+	artists       []string
+	filterArtists bool
+	genreContains string
+	show          string
+	schedule      schedule
+	profiles      []string
+	stop          bool
+}
+
+// New creates a router from routing rules validated by the config package.
+func New(rules *config.RoutingRules) (*Router, error) {
+	if rules == nil {
+		return nil, fmt.Errorf("no routing rules configured")
+	}
+
+	timezone := rules.Timezone
+	if timezone == "" {
+		timezone = config.DefaultRoutingTimezone
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load routing timezone %q: %w", timezone, err)
+	}
+
+	router := &Router{location: location, now: time.Now}
+
+	for _, rule := range rules.Shows {
+		showSchedule, err := parseSchedule(rule.Days, rule.Between)
+		if err != nil {
+			return nil, fmt.Errorf("show %q: %w", rule.Name, err)
+		}
+		router.shows = append(router.shows, show{name: rule.Name, schedule: showSchedule})
+	}
+
+	for _, rule := range rules.Routes {
+		routeSchedule, err := parseSchedule(rule.When.Days, rule.When.Between)
+		if err != nil {
+			return nil, fmt.Errorf("route %q: %w", rule.DisplayName(), err)
+		}
+
+		artists := append([]string{}, rule.When.Artists...)
+		if rule.When.ArtistList != "" {
+			list, ok := rules.Lists[rule.When.ArtistList]
+			if !ok {
+				return nil, fmt.Errorf("route %q: unknown artist list %q", rule.DisplayName(), rule.When.ArtistList)
+			}
+			artists = append(artists, list...)
+		}
+		for i, artist := range artists {
+			artists[i] = strings.ToLower(strings.TrimSpace(artist))
+		}
+
+		router.routes = append(router.routes, route{
+			name:          rule.DisplayName(),
+			playlist:      rule.Playlist,
+			artists:       artists,
+			filterArtists: len(rule.When.Artists) > 0 || rule.When.ArtistList != "",
+			genreContains: strings.ToLower(strings.TrimSpace(rule.When.GenreContains)),
+			show:          rule.When.Show,
+			schedule:      routeSchedule,
+			profiles:      rule.Profiles,
+			stop:          rule.Stop,
+		})
+	}
+
+	return router, nil
+}
+
+// parseSchedule parses the days and time slot of a show or route condition.
+func parseSchedule(days []string, between string) (schedule, error) {
+	var parsed schedule
+	for _, day := range days {
+		weekday, err := config.ParseWeekday(day)
+		if err != nil {
+			return schedule{}, err
+		}
+		parsed.days = append(parsed.days, weekday)
+	}
+	if between != "" {
+		window, err := config.ParseTimeWindow(between)
+		if err != nil {
+			return schedule{}, err
+		}
+		parsed.window = window
+		parsed.hasWindow = true
+	}
+	return parsed, nil
+}
+
+// matches reports whether the time falls in the schedule. The part of a time slot that
+// wraps past midnight belongs to the day it started on, so a Friday 22:00-02:00 slot
+// includes Saturday 01:00.
+func (s schedule) matches(t time.Time) bool {
+	day := t.Weekday()
+	if s.hasWindow {
+		inside, previousDay := s.window.Contains(t)
+		if !inside {
+			return false
+		}
+		if previousDay {
+			day = (day + 6) % 7
+		}
+	}
+	return len(s.days) == 0 || slices.Contains(s.days, day)
+}
+
+// Route returns the playlists the song is routed to for the given profile (empty for
+// the default account). Playlist names are deduplicated in route order.
+func (r *Router) Route(song types.Song, profile, prefix string) []Route {
+	playedAt := song.PlayedAt
+	if playedAt.IsZero() {
+		playedAt = r.now()
+	}
+	playedAt = playedAt.In(r.location)
+	showName := r.ShowAt(playedAt)
+
+	var routes []Route
+	seen := make(map[string]bool)
+	for _, rt := range r.routes {
+		if !rt.matches(song, profile, playedAt, showName) {
+			continue
+		}
+
+		playlist := strings.TrimSpace(render(rt.playlist, placeholderValues(playedAt, prefix, profile, showName)))
+		if playlist != "" && !seen[playlist] {
+			seen[playlist] = true
+			routes = append(routes, Route{Name: rt.name, Playlist: playlist})
+		}
+		if rt.stop {
+			break
+		}
+	}
+	return routes
+}
+
+// ShowAt returns the name of the first show scheduled at the given time, or an empty
+// string if no show is on.
+func (r *Router) ShowAt(t time.Time) string {
+	t = t.In(r.location)
+	for _, s := range r.shows {
+		if s.schedule.matches(t) {
+			return s.name
+		}
+	}
+	return ""
+}
+
+// matches reports whether the route applies to the song.
+func (rt route) matches(song types.Song, profile string, playedAt time.Time, showName string) bool {
+	if len(rt.profiles) > 0 && !slices.Contains(rt.profiles, profile) {
+		return false
+	}
+	if rt.filterArtists && !slices.Contains(rt.artists, strings.ToLower(strings.TrimSpace(song.Artist))) {
+		return false
+	}
+	if rt.genreContains != "" && !strings.Contains(strings.ToLower(song.Genre), rt.genreContains) {
+		return false
+	}
+	if rt.show != "" && (showName == "" || (rt.show != "*" && rt.show != showName)) {
+		return false
+	}
+	// A playlist named after the show can only be rendered during a show
+	if strings.Contains(rt.playlist, "{show}") && showName == "" {
+		return false
+	}
+	return rt.schedule.matches(playedAt)
+}
+
+// placeholderValues returns the values of the playlist template placeholders.
+func placeholderValues(playedAt time.Time, prefix, profile, showName string) map[string]string {
+	weekYear, week := playedAt.ISOWeek()
+	return map[string]string{
+		"prefix":     prefix,
+		"profile":    profile,
+		"year":       fmt.Sprintf("%04d", playedAt.Year()),
+		"month":      fmt.Sprintf("%02d", int(playedAt.Month())),
+		"month_name": playedAt.Month().String(),
+		"quarter":    fmt.Sprintf("%d", (int(playedAt.Month())-1)/3+1),
+		"week":       fmt.Sprintf("%02d", week),
+		"week_year":  fmt.Sprintf("%04d", weekYear),
+		"date":       playedAt.Format("2006-01-02"),
+		"show":       showName,
+	}
+}
+
+// render replaces the placeholders in a playlist name template.
+func render(template string, values map[string]string) string {
+	pairs := make([]string, 0, len(values)*2)
+	for name, value := range values {
+		pairs = append(pairs, "{"+name+"}", value)
+	}
+	return strings.NewReplacer(pairs...).Replace(template)
+}
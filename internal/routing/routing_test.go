@@ -0,0 +1,156 @@
+package routing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/types"
+	"github.com/toozej/kmhd2spotify/pkg/config"
+)
+
+func newTestRouter(t *testing.T, rules config.RoutingRules) *Router {
+	t.Helper()
+	if rules.Timezone == "" {
+		rules.Timezone = "UTC"
+	}
+	router, err := New(&rules)
+	require.NoError(t, err)
+	return router
+}
+
+func playlistNames(routes []Route) []string {
+	var names []string
+	for _, route := range routes {
+		names = append(names, route.Playlist)
+	}
+	return names
+}
+
+func TestRouteRendersTemplates(t *testing.T) {
+	router := newTestRouter(t, config.RoutingRules{
+		Routes: []config.RouteRule{
+			{Playlist: "{prefix}-{year}-{month}"},
+			{Playlist: "{prefix} {year} Q{quarter}"},
+			{Playlist: "{prefix} {week_year}-W{week}"},
+			{Playlist: "{prefix} {month_name} {date} {profile}"},
+			{Playlist: "KMHD Rolling"},
+		},
+	})
+
+	song := types.Song{Artist: "Miles Davis", PlayedAt: time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)}
+	assert.Equal(t, []string{
+		"KMHD-2025-03",
+		"KMHD 2025 Q1",
+		"KMHD 2025-W09",
+		"KMHD March 2025-03-01 alice",
+		"KMHD Rolling",
+	}, playlistNames(router.Route(song, "alice", "KMHD")))
+}
+
+func TestRouteConditions(t *testing.T) {
+	router := newTestRouter(t, config.RoutingRules{
+		Lists: map[string][]string{"favourites": {"Miles Davis", " john coltrane "}},
+		Routes: []config.RouteRule{
+			{Name: "favourites", Playlist: "Favourites", When: config.RouteCondition{ArtistList: "favourites"}},
+			{Playlist: "Latin Jazz", When: config.RouteCondition{GenreContains: "latin"}},
+			{Playlist: "Late Night", When: config.RouteCondition{Between: "22:00-02:00"}},
+			{Playlist: "Weekend", When: config.RouteCondition{Days: []string{"sat", "sun"}}},
+		},
+	})
+
+	friday := time.Date(2025, time.October, 17, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		song     types.Song
+		expected []string
+	}{
+		{"favourite artist", types.Song{Artist: "John Coltrane", PlayedAt: friday}, []string{"Favourites"}},
+		{"genre", types.Song{Artist: "Tito Puente", Genre: "Latin Jazz", PlayedAt: friday}, []string{"Latin Jazz"}},
+		{"late night", types.Song{Artist: "Bill Evans", PlayedAt: friday.Add(11 * time.Hour)}, []string{"Late Night"}},
+		{"late night after midnight", types.Song{Artist: "Bill Evans", PlayedAt: friday.Add(13 * time.Hour)}, []string{"Late Night", "Weekend"}},
+		{"no match", types.Song{Artist: "Bill Evans", PlayedAt: friday}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, playlistNames(router.Route(tt.song, "", "KMHD")))
+		})
+	}
+}
+
+func TestRouteShows(t *testing.T) {
+	router := newTestRouter(t, config.RoutingRules{
+		Shows: []config.ShowRule{
+			{Name: "Friday Night Jazz", Days: []string{"fri"}, Between: "22:00-02:00"},
+			{Name: "Morning Jazz", Between: "06:00-10:00"},
+		},
+		Routes: []config.RouteRule{
+			{Playlist: "{prefix} {show}"},
+			{Playlist: "Friday Nights", When: config.RouteCondition{Show: "Friday Night Jazz"}},
+		},
+	})
+
+	saturdayEarly := time.Date(2025, time.October, 18, 1, 0, 0, 0, time.UTC)
+	assert.Equal(t, "Friday Night Jazz", router.ShowAt(saturdayEarly))
+	assert.Equal(t, []string{"KMHD Friday Night Jazz", "Friday Nights"},
+		playlistNames(router.Route(types.Song{PlayedAt: saturdayEarly}, "", "KMHD")))
+
+	saturdayLate := time.Date(2025, time.October, 18, 23, 0, 0, 0, time.UTC)
+	assert.Empty(t, router.ShowAt(saturdayLate))
+	assert.Empty(t, router.Route(types.Song{PlayedAt: saturdayLate}, "", "KMHD"))
+
+	morning := time.Date(2025, time.October, 18, 7, 0, 0, 0, time.UTC)
+	assert.Equal(t, []string{"KMHD Morning Jazz"}, playlistNames(router.Route(types.Song{PlayedAt: morning}, "", "KMHD")))
+}
+
+func TestRouteProfilesStopAndDeduplication(t *testing.T) {
+	router := newTestRouter(t, config.RoutingRules{
+		Routes: []config.RouteRule{
+			{Playlist: "Alice Only", Profiles: []string{"alice"}},
+			{Playlist: "{prefix}"},
+			{Playlist: "KMHD", Stop: true},
+			{Playlist: "Never"},
+		},
+	})
+
+	song := types.Song{PlayedAt: time.Date(2025, time.October, 17, 12, 0, 0, 0, time.UTC)}
+	assert.Equal(t, []string{"Alice Only", "KMHD"}, playlistNames(router.Route(song, "alice", "KMHD")))
+	assert.Equal(t, []string{"KMHD"}, playlistNames(router.Route(song, "bob", "KMHD")))
+}
+
+func TestRouteUsesTimezoneAndCurrentTime(t *testing.T) {
+	router := newTestRouter(t, config.RoutingRules{
+		Timezone: "America/Los_Angeles",
+		Routes:   []config.RouteRule{{Playlist: "{date}"}},
+	})
+	router.now = func() time.Time { return time.Date(2025, time.November, 1, 3, 0, 0, 0, time.UTC) }
+
+	// 03:00 UTC is still the previous evening in Portland
+	assert.Equal(t, []string{"2025-10-31"}, playlistNames(router.Route(types.Song{}, "", "")))
+}
+
+func TestRenderSupportsEveryPlaceholder(t *testing.T) {
+	values := placeholderValues(time.Now(), "KMHD", "alice", "Morning Jazz")
+	for _, placeholder := range config.RoutePlaceholders {
+		_, ok := values[placeholder]
+		assert.True(t, ok, "placeholder {%s} is not rendered", placeholder)
+	}
+	assert.Len(t, values, len(config.RoutePlaceholders))
+}
+
+func TestNewRejectsInvalidRules(t *testing.T) {
+	_, err := New(nil)
+	assert.Error(t, err)
+
+	_, err = New(&config.RoutingRules{Timezone: "Nowhere/Special"})
+	assert.Error(t, err)
+
+	_, err = New(&config.RoutingRules{Routes: []config.RouteRule{{Playlist: "x", When: config.RouteCondition{Between: "late"}}}})
+	assert.Error(t, err)
+
+	_, err = New(&config.RoutingRules{Routes: []config.RouteRule{{Playlist: "x", When: config.RouteCondition{ArtistList: "missing"}}}})
+	assert.Error(t, err)
+}
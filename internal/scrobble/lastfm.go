@@ -0,0 +1,198 @@
+package scrobble
+
+import (
+	"context"
+	"crypto/md5" // #nosec G501 -- the Last.fm API signature is defined as an MD5 hash
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/toozej/kmhd2spotify/internal/types"
+	"github.com/toozej/kmhd2spotify/pkg/config"
+)
+
+// lastFMMaxBatch is the most scrobbles the Last.fm API accepts in one submission.
+const lastFMMaxBatch = 50
+
+// lastFMInvalidParameters is the Last.fm API error code for invalid parameters.
+const lastFMInvalidParameters = 6
+
+// LastFM scrobbles to the Last.fm API or a compatible server.
+type LastFM struct {
+	apiURL    string
+	apiKey    string
+	apiSecret string
+	username  string
+	password  string
+	client    *http.Client
+
+	mu         sync.Mutex
+	sessionKey string
+}
+
+// lastFMResponse is the JSON response of the Last.fm API.
+type lastFMResponse struct {
+	Error   int    `json:"error"`
+	Message string `json:"message"`
+	Session struct {
+		Key string `json:"key"`
+	} `json:"session"`
+	Scrobbles struct {
+		Attr struct {
+			Accepted int `json:"accepted"`
+			Ignored  int `json:"ignored"`
+		} `json:"@attr"`
+	} `json:"scrobbles"`
+}
+
+// NewLastFM creates a Last.fm service from its configuration.
+func NewLastFM(cfg config.LastFMConfig) *LastFM {
+	return &LastFM{
+		apiURL:     cfg.URL,
+		apiKey:     cfg.APIKey,
+		apiSecret:  cfg.APISecret,
+		username:   cfg.Username,
+		password:   cfg.Password,
+		sessionKey: cfg.SessionKey,
+		client:     &http.Client{},
+	}
+}
+
+// Name identifies the service in logs and the queue file.
+func (l *LastFM) Name() string {
+	return "lastfm"
+}
+
+// MaxBatch is the largest number of scrobbles the Last.fm API accepts in one submission.
+func (l *LastFM) MaxBatch() int {
+	return lastFMMaxBatch
+}
+
+// Submit scrobbles the plays. They are marked as not chosen by the user, since KMHD
+// picked them.
+func (l *LastFM) Submit(ctx context.Context, songs []types.Song) error {
+	sessionKey, err := l.session(ctx)
+	if err != nil {
+		return err
+	}
+
+	params := url.Values{}
+	params.Set("method", "track.scrobble")
+	params.Set("sk", sessionKey)
+	for i, song := range songs {
+		index := "[" + strconv.Itoa(i) + "]"
+		params.Set("artist"+index, song.Artist)
+		params.Set("track"+index, song.Title)
+		params.Set("timestamp"+index, strconv.FormatInt(song.PlayedAt.Unix(), 10))
+		params.Set("chosenByUser"+index, "0")
+		if song.Album != "" {
+			params.Set("album"+index, song.Album)
+		}
+		if song.Duration > 0 {
+			params.Set("duration"+index, strconv.Itoa(int(song.Duration.Seconds())))
+		}
+	}
+
+	response, err := l.call(ctx, params)
+	if err != nil {
+		return err
+	}
+	if ignored := response.Scrobbles.Attr.Ignored; ignored > 0 {
+		return &RejectedError{Reason: fmt.Sprintf("%d of %d scrobbles ignored", ignored, len(songs))}
+	}
+	return nil
+}
+
+// session returns the configured session key, or requests one with the username and
+// password the first time it is needed.
+func (l *LastFM) session(ctx context.Context) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.sessionKey != "" {
+		return l.sessionKey, nil
+	}
+
+	params := url.Values{}
+	params.Set("method", "auth.getMobileSession")
+	params.Set("username", l.username)
+	params.Set("password", l.password)
+	response, err := l.call(ctx, params)
+	if err != nil {
+		return "", fmt.Errorf("failed to get Last.fm session: %w", err)
+	}
+	if response.Session.Key == "" {
+		return "", fmt.Errorf("failed to get Last.fm session: no session key returned")
+	}
+
+	l.sessionKey = response.Session.Key
+	return l.sessionKey, nil
+}
+
+// call signs and POSTs an API method call and decodes its response.
+func (l *LastFM) call(ctx context.Context, params url.Values) (*lastFMResponse, error) {
+	params.Set("api_key", l.apiKey)
+	params.Set("api_sig", l.signature(params))
+	params.Set("format", "json")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.apiURL, strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Last.fm request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", "kmhd2spotify/1.0")
+
+	resp, err := l.client.Do(req) // #nosec G704 -- URL comes from user configuration
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var response lastFMResponse
+	if err := json.Unmarshal(data, &response); err != nil && resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if response.Error == lastFMInvalidParameters {
+		return nil, &RejectedError{Reason: response.Message}
+	}
+	if response.Error != 0 {
+		return nil, fmt.Errorf("error %d: %s", response.Error, response.Message)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("server returned status %d", resp.StatusCode)
+	}
+	return &response, nil
+}
+
+// signature computes the api_sig of the parameters: the MD5 hash of every name and
+// value in name order, followed by the shared secret.
+func (l *LastFM) signature(params url.Values) string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		if name != "format" && name != "callback" && name != "api_sig" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteString(params.Get(name))
+	}
+	b.WriteString(l.apiSecret)
+
+	sum := md5.Sum([]byte(b.String())) // #nosec G401 -- required by the Last.fm API
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,97 @@
+package scrobble
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/types"
+	"github.com/toozej/kmhd2spotify/pkg/config"
+)
+
+func TestLastFM_Signature(t *testing.T) {
+	service := NewLastFM(config.LastFMConfig{APISecret: "secret"})
+	params := url.Values{"method": {"auth.getMobileSession"}, "api_key": {"key"}, "format": {"json"}}
+	// md5("api_keykeymethodauth.getMobileSessionsecret")
+	assert.Equal(t, "018322def6bdaf0b7eba8f03ac376100", service.signature(params))
+}
+
+func TestLastFM_Submit(t *testing.T) {
+	var calls []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		calls = append(calls, r.PostForm)
+
+		params := url.Values{}
+		for name, values := range r.PostForm {
+			params[name] = values
+		}
+		service := NewLastFM(config.LastFMConfig{APISecret: "secret"})
+		assert.Equal(t, service.signature(params), r.PostForm.Get("api_sig"))
+
+		switch r.PostForm.Get("method") {
+		case "auth.getMobileSession":
+			_, _ = w.Write([]byte(`{"session":{"name":"listener","key":"session-key","subscriber":0}}`))
+		case "track.scrobble":
+			_, _ = w.Write([]byte(`{"scrobbles":{"@attr":{"accepted":2,"ignored":0}}}`))
+		}
+	}))
+	defer server.Close()
+
+	service := NewLastFM(config.LastFMConfig{URL: server.URL, APIKey: "key", APISecret: "secret", Username: "listener", Password: "hunter2"})
+	assert.Equal(t, "lastfm", service.Name())
+	assert.Equal(t, 50, service.MaxBatch())
+
+	playedAt := time.Date(2025, time.October, 17, 20, 0, 0, 0, time.UTC)
+	songs := []types.Song{
+		{Artist: "Miles Davis", Title: "So What", Album: "Kind of Blue", PlayedAt: playedAt, Duration: 9 * time.Minute},
+		{Artist: "John Coltrane", Title: "Giant Steps", PlayedAt: playedAt.Add(10 * time.Minute)},
+	}
+	require.NoError(t, service.Submit(context.Background(), songs))
+	require.NoError(t, service.Submit(context.Background(), songs[:1]))
+
+	require.Len(t, calls, 3, "the session is requested once")
+	assert.Equal(t, "hunter2", calls[0].Get("password"))
+	scrobble := calls[1]
+	assert.Equal(t, "session-key", scrobble.Get("sk"))
+	assert.Equal(t, "json", scrobble.Get("format"))
+	assert.Equal(t, "So What", scrobble.Get("track[0]"))
+	assert.Equal(t, "Kind of Blue", scrobble.Get("album[0]"))
+	assert.Equal(t, "540", scrobble.Get("duration[0]"))
+	assert.Equal(t, "1760731200", scrobble.Get("timestamp[0]"))
+	assert.Equal(t, "0", scrobble.Get("chosenByUser[1]"))
+	assert.Empty(t, scrobble.Get("album[1]"))
+}
+
+func TestLastFM_SubmitErrors(t *testing.T) {
+	response := `{"error":6,"message":"Invalid parameters"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	service := NewLastFM(config.LastFMConfig{URL: server.URL, APIKey: "key", APISecret: "secret", SessionKey: "session"})
+	songs := []types.Song{{Artist: "Miles Davis", Title: "So What", PlayedAt: time.Now()}}
+
+	var rejected *RejectedError
+	err := service.Submit(context.Background(), songs)
+	require.True(t, errors.As(err, &rejected))
+
+	response = `{"scrobbles":{"@attr":{"accepted":0,"ignored":1}}}`
+	err = service.Submit(context.Background(), songs)
+	require.True(t, errors.As(err, &rejected))
+	assert.Contains(t, rejected.Reason, "1 of 1")
+
+	response = `{"error":11,"message":"Service Offline"}`
+	err = service.Submit(context.Background(), songs)
+	require.Error(t, err)
+	assert.False(t, errors.As(err, &rejected), "unavailable services are retried")
+	assert.Contains(t, err.Error(), "Service Offline")
+}
@@ -0,0 +1,124 @@
+package scrobble
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+// listenBrainzMaxBatch is the most listens ListenBrainz accepts in one submission.
+const listenBrainzMaxBatch = 1000
+
+// ListenBrainz submits listens to the ListenBrainz API.
+type ListenBrainz struct {
+	apiURL string
+	token  string
+	client *http.Client
+}
+
+// listenBrainzSubmission is the request body of the submit-listens endpoint.
+type listenBrainzSubmission struct {
+	ListenType string               `json:"listen_type"`
+	Payload    []listenBrainzListen `json:"payload"`
+}
+
+// listenBrainzListen is a single listen.
+type listenBrainzListen struct {
+	ListenedAt    int64                     `json:"listened_at"`
+	TrackMetadata listenBrainzTrackMetadata `json:"track_metadata"`
+}
+
+// listenBrainzTrackMetadata describes the track of a listen.
+type listenBrainzTrackMetadata struct {
+	ArtistName     string         `json:"artist_name"`
+	TrackName      string         `json:"track_name"`
+	ReleaseName    string         `json:"release_name,omitempty"`
+	AdditionalInfo map[string]any `json:"additional_info"`
+}
+
+// NewListenBrainz creates a ListenBrainz service for the API root (such as
+// https://api.listenbrainz.org) and user token.
+func NewListenBrainz(apiURL, token string) *ListenBrainz {
+	return &ListenBrainz{
+		apiURL: strings.TrimSuffix(apiURL, "/"),
+		token:  token,
+		client: &http.Client{},
+	}
+}
+
+// Name identifies the service in logs and the queue file.
+func (l *ListenBrainz) Name() string {
+	return "listenbrainz"
+}
+
+// MaxBatch is the largest number of listens ListenBrainz accepts in one submission.
+func (l *ListenBrainz) MaxBatch() int {
+	return listenBrainzMaxBatch
+}
+
+// Submit submits the plays as listens. A single play is submitted as a "single"
+// listen and several as an "import".
+func (l *ListenBrainz) Submit(ctx context.Context, songs []types.Song) error {
+	submission := listenBrainzSubmission{ListenType: "import"}
+	if len(songs) == 1 {
+		submission.ListenType = "single"
+	}
+	for _, song := range songs {
+		info := map[string]any{
+			"submission_client": "kmhd2spotify",
+			"music_service":     "kmhd.org",
+			"origin_url":        "https://www.kmhd.org",
+		}
+		if song.Duration > 0 {
+			info["duration_ms"] = song.Duration.Milliseconds()
+		}
+		submission.Payload = append(submission.Payload, listenBrainzListen{
+			ListenedAt: song.PlayedAt.Unix(),
+			TrackMetadata: listenBrainzTrackMetadata{
+				ArtistName:     song.Artist,
+				TrackName:      song.Title,
+				ReleaseName:    song.Album,
+				AdditionalInfo: info,
+			},
+		})
+	}
+
+	body, err := json.Marshal(submission)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ListenBrainz listens: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.apiURL+"/1/submit-listens", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create ListenBrainz request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Token "+l.token)
+
+	resp, err := l.client.Do(req) // #nosec G704 -- URL comes from user configuration
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return nil
+	case resp.StatusCode == http.StatusBadRequest:
+		// Invalid listens are rejected; any other failure may succeed later
+		var apiError struct {
+			Error string `json:"error"`
+		}
+		_ = json.Unmarshal(data, &apiError)
+		return &RejectedError{Reason: apiError.Error}
+	default:
+		return fmt.Errorf("server returned status %d", resp.StatusCode)
+	}
+}
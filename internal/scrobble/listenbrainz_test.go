@@ -0,0 +1,69 @@
+package scrobble
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+func TestListenBrainz_Submit(t *testing.T) {
+	playedAt := time.Date(2025, time.October, 17, 20, 0, 0, 0, time.UTC)
+	var received listenBrainzSubmission
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/1/submit-listens", r.URL.Path)
+		assert.Equal(t, "Token secret-token", r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer server.Close()
+
+	service := NewListenBrainz(server.URL+"/", "secret-token")
+	assert.Equal(t, "listenbrainz", service.Name())
+	assert.Equal(t, 1000, service.MaxBatch())
+
+	songs := []types.Song{
+		{Artist: "Miles Davis", Title: "So What", Album: "Kind of Blue", PlayedAt: playedAt, Duration: 9 * time.Minute},
+		{Artist: "John Coltrane", Title: "Giant Steps", PlayedAt: playedAt.Add(10 * time.Minute)},
+	}
+	require.NoError(t, service.Submit(context.Background(), songs))
+	assert.Equal(t, "import", received.ListenType)
+	require.Len(t, received.Payload, 2)
+	assert.Equal(t, playedAt.Unix(), received.Payload[0].ListenedAt)
+	assert.Equal(t, "Kind of Blue", received.Payload[0].TrackMetadata.ReleaseName)
+	assert.InDelta(t, 540000, received.Payload[0].TrackMetadata.AdditionalInfo["duration_ms"], 0.1)
+	assert.Equal(t, "kmhd2spotify", received.Payload[1].TrackMetadata.AdditionalInfo["submission_client"])
+
+	require.NoError(t, service.Submit(context.Background(), songs[:1]))
+	assert.Equal(t, "single", received.ListenType)
+}
+
+func TestListenBrainz_SubmitErrors(t *testing.T) {
+	status := http.StatusBadRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"code":400,"error":"invalid listened_at"}`))
+	}))
+	defer server.Close()
+
+	service := NewListenBrainz(server.URL, "token")
+	songs := []types.Song{{Artist: "Miles Davis", Title: "So What", PlayedAt: time.Now()}}
+
+	err := service.Submit(context.Background(), songs)
+	var rejected *RejectedError
+	require.True(t, errors.As(err, &rejected))
+	assert.Equal(t, "invalid listened_at", rejected.Reason)
+
+	status = http.StatusServiceUnavailable
+	err = service.Submit(context.Background(), songs)
+	require.Error(t, err)
+	assert.False(t, errors.As(err, &rejected), "server errors are retried")
+}
@@ -0,0 +1,430 @@
+// Package scrobble submits KMHD plays as scrobbles to ListenBrainz and Last.fm-compatible
+// services.
+//
+// A Scrobbler queues every finished play that started within its schedule, once per
+// play, and submits the queue to each service in batches. The queue is persisted to a
+// JSON file in the state directory, so scrobbles made while a service is unreachable
+// are resubmitted by a later flush instead of being lost. Scrobblers in several
+// processes, such as a sync and 'now --follow', can share the file: each change
+// re-reads it under a file lock, so plays are queued and submitted once.
+package scrobble
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/kmhd2spotify/internal/filelock"
+	"github.com/toozej/kmhd2spotify/internal/types"
+	"github.com/toozej/kmhd2spotify/pkg/config"
+)
+
+// FileName is the name of the scrobble queue file within the state directory.
+const FileName = "scrobbles.json"
+
+// MinDuration is the shortest play that is scrobbled. Scrobbling services ignore
+// tracks shorter than 30 seconds.
+const MinDuration = 30 * time.Second
+
+// Service submits scrobbles to a single scrobbling service.
+type Service interface {
+	// Name identifies the service in logs and the queue file.
+	Name() string
+	// MaxBatch is the largest number of plays the service accepts in one submission.
+	MaxBatch() int
+	// Submit scrobbles the plays.
+	Submit(ctx context.Context, songs []types.Song) error
+}
+
+// RejectedError is returned by a service that refused a submission because of its
+// content, so submitting it again cannot succeed.
+type RejectedError struct {
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *RejectedError) Error() string {
+	return "submission rejected: " + e.Reason
+}
+
+// Schedule limits scrobbling to plays that started in its time slots and on its days.
+// The zero Schedule contains every time.
+type Schedule struct {
+	windows  []config.TimeWindow
+	days     []time.Weekday
+	location *time.Location
+}
+
+// NewSchedule creates a schedule from time slots such as "19:00-23:00", day names and a
+// timezone. Empty slots or days leave that part of the schedule unrestricted.
+func NewSchedule(slots, days []string, timezone string) (Schedule, error) {
+	schedule := Schedule{location: time.Local}
+	if timezone != "" {
+		location, err := time.LoadLocation(timezone)
+		if err != nil {
+			return Schedule{}, fmt.Errorf("invalid scrobble timezone %q: %w", timezone, err)
+		}
+		schedule.location = location
+	}
+	for _, slot := range slots {
+		window, err := config.ParseTimeWindow(slot)
+		if err != nil {
+			return Schedule{}, err
+		}
+		schedule.windows = append(schedule.windows, window)
+	}
+	for _, day := range days {
+		weekday, err := config.ParseWeekday(day)
+		if err != nil {
+			return Schedule{}, err
+		}
+		schedule.days = append(schedule.days, weekday)
+	}
+	return schedule, nil
+}
+
+// Contains reports whether a play starting at t is scrobbled. The part of a time slot
+// that wraps past midnight belongs to the day it started on.
+func (s Schedule) Contains(t time.Time) bool {
+	if s.location != nil {
+		t = t.In(s.location)
+	}
+	if len(s.windows) == 0 {
+		return len(s.days) == 0 || slices.Contains(s.days, t.Weekday())
+	}
+	for _, window := range s.windows {
+		inside, previousDay := window.Contains(t)
+		if !inside {
+			continue
+		}
+		day := t.Weekday()
+		if previousDay {
+			day = (day + 6) % 7
+		}
+		if len(s.days) == 0 || slices.Contains(s.days, day) {
+			return true
+		}
+	}
+	return false
+}
+
+// state is the persisted scrobble queue.
+type state struct {
+	// Until is the start of the latest play that was considered. Earlier plays are
+	// never queued again.
+	Until time.Time `json:"until"`
+	// Pending are the plays waiting to be submitted, by service name.
+	Pending map[string][]types.Song `json:"pending"`
+}
+
+// Scrobbler queues KMHD plays and submits them to its services.
+type Scrobbler struct {
+	mu        sync.Mutex
+	path      string
+	services  []Service
+	schedule  Schedule
+	batchSize int
+	timeout   time.Duration
+	now       func() time.Time
+	state     state
+	logger    *log.Entry
+}
+
+// New creates a scrobbler for the services enabled in the configuration, with its
+// queue stored at path. It returns nil if no service is enabled.
+func New(cfg config.ScrobbleConfig, path string) (*Scrobbler, error) {
+	var services []Service
+	if cfg.ListenBrainz.Token != "" {
+		services = append(services, NewListenBrainz(cfg.ListenBrainz.URL, cfg.ListenBrainz.Token))
+	}
+	if cfg.LastFM.APIKey != "" {
+		services = append(services, NewLastFM(cfg.LastFM))
+	}
+	if len(services) == 0 {
+		return nil, nil
+	}
+
+	schedule, err := NewSchedule(cfg.Schedule, cfg.Days, cfg.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	return NewScrobbler(path, services, schedule, cfg.BatchSize, cfg.Timeout)
+}
+
+// NewScrobbler creates a scrobbler for the services with its queue stored at path. When
+// the queue file doesn't exist yet, only plays starting from now are scrobbled.
+func NewScrobbler(path string, services []Service, schedule Schedule, batchSize int, timeout time.Duration) (*Scrobbler, error) {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	s := &Scrobbler{
+		path:      path,
+		services:  services,
+		schedule:  schedule,
+		batchSize: batchSize,
+		timeout:   timeout,
+		now:       time.Now,
+		state:     state{Pending: make(map[string][]types.Song)},
+		logger:    log.WithField("component", "scrobbler"),
+	}
+
+	unlock, err := s.lockUnsafe()
+	if err != nil {
+		return nil, err
+	}
+	unlock()
+	return s, nil
+}
+
+// SetClock replaces the clock used to decide which plays have finished. It is intended
+// for tests.
+func (s *Scrobbler) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// Services returns the names of the services scrobbles are submitted to.
+// A nil Scrobbler has no services.
+func (s *Scrobbler) Services() []string {
+	if s == nil {
+		return nil
+	}
+	names := make([]string, 0, len(s.services))
+	for _, service := range s.services {
+		names = append(names, service.Name())
+	}
+	return names
+}
+
+// Add queues the plays that have finished, started within the schedule and were not
+// considered before, and returns how many were queued. A play has finished once a
+// later play started or its expected end has passed.
+func (s *Scrobbler) Add(songs []types.Song) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := s.lockUnsafe()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	now := s.now()
+	var latest time.Time
+	for _, song := range songs {
+		if song.PlayedAt.After(latest) {
+			latest = song.PlayedAt
+		}
+	}
+
+	sorted := slices.Clone(songs)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].PlayedAt.Before(sorted[j].PlayedAt) })
+
+	queued := 0
+	until := s.state.Until
+	for _, song := range sorted {
+		if !song.IsValid() || song.PlayedAt.IsZero() || !song.PlayedAt.After(s.state.Until) {
+			continue
+		}
+		end := song.ExpectedEnd()
+		if !song.PlayedAt.Before(latest) && (end.IsZero() || end.After(now)) {
+			continue
+		}
+		until = song.PlayedAt
+
+		if !s.schedule.Contains(song.PlayedAt) || (song.Duration > 0 && song.Duration < MinDuration) {
+			s.logger.WithField("song", song.String()).Debug("Not scrobbling play outside the schedule or too short")
+			continue
+		}
+
+		song.RawText = ""
+		for _, service := range s.services {
+			s.state.Pending[service.Name()] = append(s.state.Pending[service.Name()], song)
+		}
+		queued++
+	}
+
+	if until.Equal(s.state.Until) {
+		return 0, nil
+	}
+	s.state.Until = until
+	if err := s.saveUnsafe(); err != nil {
+		return 0, err
+	}
+	return queued, nil
+}
+
+// Flush submits the queued plays to every service in batches and returns how many
+// submissions of a play succeeded. A service that fails keeps its remaining plays for
+// the next flush; batches a service rejects are dropped.
+func (s *Scrobbler) Flush() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := s.lockUnsafe()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	submitted := 0
+	var errs []error
+	for _, service := range s.services {
+		name := service.Name()
+		size := s.batchSize
+		if max := service.MaxBatch(); size < 1 || size > max {
+			size = max
+		}
+
+		for len(s.state.Pending[name]) > 0 {
+			pending := s.state.Pending[name]
+			batch := pending[:min(size, len(pending))]
+
+			err := s.submit(service, batch)
+			var rejected *RejectedError
+			if err != nil && !errors.As(err, &rejected) {
+				s.logger.WithError(err).WithFields(log.Fields{
+					"service": name,
+					"pending": len(pending),
+				}).Warn("Failed to submit scrobbles, keeping them queued")
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				break
+			}
+
+			if rejected != nil {
+				s.logger.WithError(err).WithFields(log.Fields{
+					"service": name,
+					"plays":   len(batch),
+				}).Warn("Dropping scrobbles rejected by the service")
+			} else {
+				submitted += len(batch)
+				s.logger.WithFields(log.Fields{
+					"service": name,
+					"plays":   len(batch),
+				}).Debug("Submitted scrobbles")
+			}
+			s.state.Pending[name] = pending[len(batch):]
+			if err := s.saveUnsafe(); err != nil {
+				return submitted, err
+			}
+		}
+		if len(s.state.Pending[name]) == 0 {
+			delete(s.state.Pending, name)
+		}
+	}
+
+	return submitted, errors.Join(errs...)
+}
+
+// Pending returns the number of queued plays of every service.
+func (s *Scrobbler) Pending() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshUnsafe()
+
+	pending := make(map[string]int)
+	for _, service := range s.services {
+		pending[service.Name()] = len(s.state.Pending[service.Name()])
+	}
+	return pending
+}
+
+// Until returns the start of the latest play that was considered for scrobbling.
+func (s *Scrobbler) Until() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshUnsafe()
+	return s.state.Until
+}
+
+// submit submits a batch to a service within the scrobbler's timeout.
+func (s *Scrobbler) submit(service Service, batch []types.Song) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	return service.Submit(ctx, batch)
+}
+
+// lockUnsafe takes the queue file lock and re-reads the queue, so changes made by
+// another process sharing the file are seen. The returned function releases the lock.
+// This should only be called when the caller already holds the mutex.
+func (s *Scrobbler) lockUnsafe() (func(), error) {
+	unlock, err := filelock.Lock(s.path + ".lock")
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock scrobble queue: %w", err)
+	}
+	if err := s.load(); err != nil {
+		unlock()
+		return nil, err
+	}
+	return unlock, nil
+}
+
+// refreshUnsafe re-reads the queue for a read-only query, keeping the queue in memory
+// if that fails. This should only be called when the caller already holds the mutex.
+func (s *Scrobbler) refreshUnsafe() {
+	unlock, err := s.lockUnsafe()
+	if err != nil {
+		s.logger.WithError(err).Warn("Failed to re-read scrobble queue file")
+		return
+	}
+	unlock()
+}
+
+// load reads the queue from disk, replacing the queue in memory. A new queue starts at
+// the current time, so plays from before scrobbling was enabled are not submitted.
+func (s *Scrobbler) load() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			s.state = state{Until: s.now(), Pending: make(map[string][]types.Song)}
+			return s.saveUnsafe()
+		}
+		return fmt.Errorf("failed to read scrobble queue file: %w", err)
+	}
+
+	var loaded state
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return fmt.Errorf("failed to parse scrobble queue file: %w", err)
+	}
+	if loaded.Pending == nil {
+		loaded.Pending = make(map[string][]types.Song)
+	}
+	s.state = loaded
+
+	s.logger.WithFields(log.Fields{
+		"until":   s.state.Until,
+		"pending": len(s.state.Pending),
+	}).Debug("Loaded scrobble queue from file")
+	return nil
+}
+
+// saveUnsafe writes the queue to disk without acquiring locks.
+// This should only be called when the caller already holds the lock.
+func (s *Scrobbler) saveUnsafe() error {
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal scrobble queue: %w", err)
+	}
+
+	// Write to temporary file first, then rename for atomic operation
+	tempFile := s.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("failed to write scrobble queue file: %w", err)
+	}
+
+	if err := os.Rename(tempFile, s.path); err != nil {
+		_ = os.Remove(tempFile) // Clean up temp file
+		return fmt.Errorf("failed to rename scrobble queue file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,242 @@
+package scrobble
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/types"
+	"github.com/toozej/kmhd2spotify/pkg/config"
+)
+
+var pacific, _ = time.LoadLocation("America/Los_Angeles")
+
+// fakeService records submitted batches and fails while err is set
+type fakeService struct {
+	name     string
+	maxBatch int
+	err      error
+	batches  [][]types.Song
+}
+
+func (f *fakeService) Name() string  { return f.name }
+func (f *fakeService) MaxBatch() int { return f.maxBatch }
+
+func (f *fakeService) Submit(ctx context.Context, songs []types.Song) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.batches = append(f.batches, songs)
+	return nil
+}
+
+func newTestScrobbler(t *testing.T, path string, start time.Time, services ...Service) *Scrobbler {
+	t.Helper()
+	s, err := NewScrobbler(path, services, Schedule{}, 2, time.Second)
+	require.NoError(t, err)
+	s.state.Until = start
+	require.NoError(t, s.saveUnsafe())
+	return s
+}
+
+func TestNewSchedule(t *testing.T) {
+	schedule, err := NewSchedule([]string{"19:00-23:00", "23:30-01:00"}, []string{"fri"}, "America/Los_Angeles")
+	require.NoError(t, err)
+
+	friday := time.Date(2025, time.October, 17, 0, 0, 0, 0, pacific)
+	assert.True(t, schedule.Contains(friday.Add(20*time.Hour)))
+	assert.False(t, schedule.Contains(friday.Add(18*time.Hour)))
+	assert.False(t, schedule.Contains(friday.Add(23*time.Hour+15*time.Minute)))
+	assert.True(t, schedule.Contains(friday.Add(24*time.Hour+30*time.Minute)), "Saturday 00:30 belongs to Friday's slot")
+	assert.False(t, schedule.Contains(friday.Add(-30*time.Minute)), "Friday 00:30 belongs to Thursday's slot")
+	assert.False(t, schedule.Contains(friday.AddDate(0, 0, 1).Add(20*time.Hour)))
+	assert.True(t, schedule.Contains(friday.Add(20*time.Hour).UTC()), "times are compared in the schedule's timezone")
+
+	days, err := NewSchedule(nil, []string{"sat", "sun"}, "America/Los_Angeles")
+	require.NoError(t, err)
+	assert.True(t, days.Contains(friday.AddDate(0, 0, 1).Add(3*time.Hour)))
+	assert.False(t, days.Contains(friday.Add(3*time.Hour)))
+
+	assert.True(t, Schedule{}.Contains(friday))
+
+	_, err = NewSchedule([]string{"7pm-11pm"}, nil, "")
+	assert.Error(t, err)
+	_, err = NewSchedule(nil, []string{"someday"}, "")
+	assert.Error(t, err)
+	_, err = NewSchedule(nil, nil, "Mars/Olympus_Mons")
+	assert.Error(t, err)
+}
+
+func TestNew(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+
+	s, err := New(config.ScrobbleConfig{Timezone: "Local"}, path)
+	require.NoError(t, err)
+	assert.Nil(t, s, "no service is enabled")
+	assert.Empty(t, s.Services())
+
+	s, err = New(config.ScrobbleConfig{
+		ListenBrainz: config.ListenBrainzConfig{Token: "token", URL: "https://api.listenbrainz.org"},
+		LastFM:       config.LastFMConfig{APIKey: "key", APISecret: "secret", SessionKey: "session"},
+		Timezone:     "Local",
+		BatchSize:    50,
+	}, path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"listenbrainz", "lastfm"}, s.Services())
+	assert.WithinDuration(t, time.Now(), s.Until(), time.Minute, "a new queue starts now")
+	assert.FileExists(t, path)
+}
+
+func TestScrobblerAdd(t *testing.T) {
+	start := time.Date(2025, time.October, 17, 19, 0, 0, 0, pacific)
+	service := &fakeService{name: "fake", maxBatch: 10}
+	s := newTestScrobbler(t, filepath.Join(t.TempDir(), FileName), start, service)
+	s.now = func() time.Time { return start.Add(20 * time.Minute) }
+
+	songs := []types.Song{
+		{Artist: "Before", Title: "Enabled", PlayedAt: start.Add(-5 * time.Minute)},
+		{Artist: "John Coltrane", Title: "Giant Steps", PlayedAt: start.Add(5 * time.Minute), RawText: "raw"},
+		{Artist: "Miles Davis", Title: "So What", PlayedAt: start.Add(time.Minute)},
+		{Artist: "Station", Title: "ID", PlayedAt: start.Add(10 * time.Minute), Duration: 10 * time.Second},
+		{Artist: "", Title: "Invalid", PlayedAt: start.Add(11 * time.Minute)},
+		{Artist: "Bill Evans", Title: "Peace Piece", PlayedAt: start.Add(12 * time.Minute), Duration: 6 * time.Minute},
+		{Artist: "Now", Title: "Playing", PlayedAt: start.Add(18 * time.Minute), Duration: 5 * time.Minute},
+	}
+
+	queued, err := s.Add(songs)
+	require.NoError(t, err)
+	assert.Equal(t, 3, queued)
+	pending := s.state.Pending["fake"]
+	require.Len(t, pending, 3)
+	assert.Equal(t, "So What", pending[0].Title, "plays are queued in play order")
+	assert.Empty(t, pending[1].RawText)
+	assert.True(t, start.Add(12*time.Minute).Equal(s.Until()), "the playing song is considered once it ends")
+
+	queued, err = s.Add(songs)
+	require.NoError(t, err)
+	assert.Zero(t, queued, "plays are only queued once")
+
+	// The playing song ends
+	s.now = func() time.Time { return start.Add(24 * time.Minute) }
+	queued, err = s.Add(songs)
+	require.NoError(t, err)
+	assert.Equal(t, 1, queued)
+	assert.Equal(t, map[string]int{"fake": 4}, s.Pending())
+}
+
+func TestScrobblerAddOutsideSchedule(t *testing.T) {
+	start := time.Date(2025, time.October, 17, 18, 0, 0, 0, pacific)
+	service := &fakeService{name: "fake", maxBatch: 10}
+	s := newTestScrobbler(t, filepath.Join(t.TempDir(), FileName), start, service)
+	schedule, err := NewSchedule([]string{"19:00-23:00"}, nil, "America/Los_Angeles")
+	require.NoError(t, err)
+	s.schedule = schedule
+
+	queued, err := s.Add([]types.Song{
+		{Artist: "Early", Title: "Song", PlayedAt: start.Add(30 * time.Minute)},
+		{Artist: "Evening", Title: "Song", PlayedAt: start.Add(70 * time.Minute)},
+		{Artist: "Next", Title: "Song", PlayedAt: start.Add(75 * time.Minute)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, queued)
+	assert.Equal(t, "Evening", s.state.Pending["fake"][0].Artist)
+	assert.True(t, start.Add(70*time.Minute).Equal(s.Until()))
+}
+
+func TestScrobblerFlush(t *testing.T) {
+	start := time.Date(2025, time.October, 17, 19, 0, 0, 0, pacific)
+	path := filepath.Join(t.TempDir(), FileName)
+	online := &fakeService{name: "online", maxBatch: 10}
+	offline := &fakeService{name: "offline", maxBatch: 10, err: errors.New("connection refused")}
+	s := newTestScrobbler(t, path, start, online, offline)
+	s.now = func() time.Time { return start.Add(time.Hour) }
+
+	var songs []types.Song
+	for i := 1; i <= 5; i++ {
+		songs = append(songs, types.Song{Artist: "Artist", Title: string(rune('A' + i)), PlayedAt: start.Add(time.Duration(i) * time.Minute), Duration: time.Minute})
+	}
+	_, err := s.Add(songs)
+	require.NoError(t, err)
+
+	submitted, err := s.Flush()
+	assert.Error(t, err)
+	assert.Equal(t, 5, submitted)
+	assert.Len(t, online.batches, 3, "batches are limited to the batch size")
+	assert.Equal(t, map[string]int{"online": 0, "offline": 5}, s.Pending())
+
+	// The queue survives a restart and is resubmitted once the service is back
+	reopened, err := NewScrobbler(path, []Service{online, offline}, Schedule{}, 50, time.Second)
+	require.NoError(t, err)
+	assert.True(t, start.Add(5*time.Minute).Equal(reopened.Until()))
+	offline.err = nil
+	submitted, err = reopened.Flush()
+	require.NoError(t, err)
+	assert.Equal(t, 5, submitted)
+	assert.Len(t, offline.batches, 1)
+	assert.Equal(t, map[string]int{"online": 0, "offline": 0}, reopened.Pending())
+}
+
+func TestScrobblersSharingQueue(t *testing.T) {
+	start := time.Date(2025, time.October, 17, 19, 0, 0, 0, pacific)
+	path := filepath.Join(t.TempDir(), FileName)
+	syncService := &fakeService{name: "fake", maxBatch: 10}
+	followService := &fakeService{name: "fake", maxBatch: 10}
+	sync := newTestScrobbler(t, path, start, syncService)
+	follow, err := NewScrobbler(path, []Service{followService}, Schedule{}, 2, time.Second)
+	require.NoError(t, err)
+	clock := func() time.Time { return start.Add(time.Hour) }
+	sync.SetClock(clock)
+	follow.SetClock(clock)
+
+	songs := []types.Song{
+		{Artist: "Miles Davis", Title: "So What", PlayedAt: start.Add(time.Minute), Duration: time.Minute},
+		{Artist: "John Coltrane", Title: "Giant Steps", PlayedAt: start.Add(2 * time.Minute), Duration: time.Minute},
+	}
+	queued, err := sync.Add(songs)
+	require.NoError(t, err)
+	assert.Equal(t, 2, queued)
+	queued, err = follow.Add(songs)
+	require.NoError(t, err)
+	assert.Zero(t, queued, "plays queued by the other scrobbler aren't queued again")
+
+	// Plays queued by one scrobbler are submitted once, by whichever flushes first
+	submitted, err := follow.Flush()
+	require.NoError(t, err)
+	assert.Equal(t, 2, submitted)
+	submitted, err = sync.Flush()
+	require.NoError(t, err)
+	assert.Zero(t, submitted)
+	assert.Empty(t, syncService.batches)
+
+	// A flush doesn't drop plays the other scrobbler queued since
+	queued, err = follow.Add(append(songs, types.Song{Artist: "Bill Evans", Title: "Peace Piece", PlayedAt: start.Add(3 * time.Minute), Duration: time.Minute}))
+	require.NoError(t, err)
+	assert.Equal(t, 1, queued)
+	assert.Equal(t, map[string]int{"fake": 1}, sync.Pending())
+	submitted, err = sync.Flush()
+	require.NoError(t, err)
+	assert.Equal(t, 1, submitted)
+	assert.Equal(t, map[string]int{"fake": 0}, follow.Pending())
+}
+
+func TestScrobblerFlushDropsRejectedBatches(t *testing.T) {
+	start := time.Date(2025, time.October, 17, 19, 0, 0, 0, pacific)
+	service := &fakeService{name: "fake", maxBatch: 1, err: &RejectedError{Reason: "invalid listen"}}
+	s := newTestScrobbler(t, filepath.Join(t.TempDir(), FileName), start, service)
+
+	_, err := s.Add([]types.Song{
+		{Artist: "Miles Davis", Title: "So What", PlayedAt: start.Add(time.Minute)},
+		{Artist: "John Coltrane", Title: "Giant Steps", PlayedAt: start.Add(2 * time.Minute)},
+	})
+	require.NoError(t, err)
+
+	submitted, err := s.Flush()
+	require.NoError(t, err)
+	assert.Zero(t, submitted)
+	assert.Equal(t, map[string]int{"fake": 0}, s.Pending())
+}
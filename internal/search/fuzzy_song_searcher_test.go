@@ -19,6 +19,14 @@ func (m *MockSongSpotifyService) SearchArtist(query string) (*types.Artist, erro
 	}, nil
 }
 
+func (m *MockSongSpotifyService) SearchArtists(query string, limit int) ([]types.Artist, error) {
+	artist, err := m.SearchArtist(query)
+	if err != nil {
+		return nil, err
+	}
+	return []types.Artist{*artist}, nil
+}
+
 func (m *MockSongSpotifyService) GetArtistTopTracks(artistID string) ([]types.Track, error) {
 	return []types.Track{
 		{
@@ -131,6 +139,38 @@ func (m *MockSongSpotifyService) CreatePlaylist(name, description string, public
 	}, nil
 }
 
+func (m *MockSongSpotifyService) GetPlaylistTracks(playlistID string) ([]types.Track, error) {
+	return nil, nil
+}
+
+func (m *MockSongSpotifyService) GetPlaylistItems(playlistID string) ([]types.PlaylistItem, error) {
+	return nil, nil
+}
+
+func (m *MockSongSpotifyService) RemoveTracksFromPlaylist(playlistID string, trackIDs []string) error {
+	return nil
+}
+
+func (m *MockSongSpotifyService) RemoveTrackPositions(playlistID string, positions map[string][]int) error {
+	return nil
+}
+
+func (m *MockSongSpotifyService) ReorderPlaylist(playlistID string, rangeStart, rangeLength, insertBefore int) error {
+	return nil
+}
+
+func (m *MockSongSpotifyService) UpdatePlaylist(playlistID string, update types.PlaylistUpdate) error {
+	return nil
+}
+
+func (m *MockSongSpotifyService) SetPlaylistCover(playlistID string, jpeg []byte) error {
+	return nil
+}
+
+func (m *MockSongSpotifyService) DeletePlaylist(playlistID string) error {
+	return nil
+}
+
 func TestFuzzySongSearcher_FindBestSongMatch(t *testing.T) {
 	mockSpotify := &MockSongSpotifyService{}
 	logger := logrus.New()
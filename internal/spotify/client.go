@@ -1,14 +1,22 @@
 package spotify
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
 	"fmt"
-	"os"
+	"net/http"
+	"sort"
+	"strings"
 	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/sirupsen/logrus"
+	"github.com/toozej/kmhd2spotify/internal/metrics"
 	"github.com/toozej/kmhd2spotify/pkg/config"
 	"github.com/zmb3/spotify/v2"
 	spotifyauth "github.com/zmb3/spotify/v2/auth"
@@ -25,11 +33,17 @@ type Client struct {
 	ctx        context.Context
 	auth       *spotifyauth.Authenticator
 	isUserAuth bool
+	usePKCE    bool
 	authURL    string
 	state      string
-	tokenFile  string
+	verifier   string
+	store      TokenStore
 }
 
+// ErrInvalidAuthState is returned when an authorization callback does not carry the
+// state of the current authentication attempt.
+var ErrInvalidAuthState = errors.New("invalid OAuth state parameter")
+
 // TokenData represents the stored token information
 type TokenData struct {
 	AccessToken  string    `json:"access_token"`  // #nosec G117 -- OAuth token, expected to be stored
@@ -38,13 +52,20 @@ type TokenData struct {
 	Expiry       time.Time `json:"expiry"`
 }
 
-// NewClient creates a new Spotify client with user authentication flow
+// NewClient creates a new Spotify client with user authentication flow.
+//
+// Without a client secret (or with UsePKCE set), the client authenticates as a public
+// client using PKCE, so the secret never needs to be present on the machine.
 func NewClient(cfg config.SpotifyConfig, logger *logrus.Logger) (*Client, error) {
-	if cfg.ClientID == "" || cfg.ClientSecret == "" {
-		return nil, fmt.Errorf("spotify client ID and secret are required")
+	if cfg.ClientID == "" {
+		return nil, fmt.Errorf("spotify client ID is required")
 	}
 
-	ctx := context.Background()
+	// Route all Spotify requests, including token exchange and refresh, through the
+	// instrumented transport so API calls are counted in the metrics
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{
+		Transport: metrics.NewSpotifyTransport(nil),
+	})
 
 	// Set up Authorization Code flow for user authentication following the library examples
 	auth := spotifyauth.New(
@@ -54,34 +75,22 @@ func NewClient(cfg config.SpotifyConfig, logger *logrus.Logger) (*Client, error)
 			spotifyauth.ScopePlaylistReadPrivate,
 			spotifyauth.ScopePlaylistModifyPrivate,
 			spotifyauth.ScopePlaylistModifyPublic,
+			spotifyauth.ScopeImageUpload,
 		),
 		spotifyauth.WithClientID(cfg.ClientID),
 		spotifyauth.WithClientSecret(cfg.ClientSecret),
 	)
 
-	// Generate state for security
-	state := "kmhd2spotify-auth-state"
-
-	// Use the library's AuthURL method as shown in examples
-	authURL := auth.AuthURL(state)
-
-	logger.WithFields(logrus.Fields{
-		"client_id":    cfg.ClientID,
-		"redirect_url": cfg.RedirectURL,
-		"state":        state,
-		"auth_url":     authURL,
-	}).Info("Generated Spotify auth URL using library method")
-
 	// Validate redirect URL is configured
 	if cfg.RedirectURL == "" {
 		logger.Error("RedirectURL is empty! Check SPOTIFY_REDIRECT_URL environment variable")
 		return nil, fmt.Errorf("redirect URL is required but not configured")
 	}
 
-	// Get token file path from config
-	tokenFile, err := cfg.GetTokenFilePath()
+	// Set up the configured token store
+	store, err := NewTokenStore(cfg)
 	if err != nil {
-		logger.WithError(err).Warn("Could not determine token file path, authentication will be required each time")
+		logger.WithError(err).Warn("Could not set up token store, authentication will be required each time")
 	}
 
 	client := &Client{
@@ -92,15 +101,15 @@ func NewClient(cfg config.SpotifyConfig, logger *logrus.Logger) (*Client, error)
 		ctx:        ctx,
 		auth:       auth,
 		isUserAuth: false,
-		authURL:    authURL,
-		state:      state,
-		tokenFile:  tokenFile,
+		usePKCE:    cfg.UsePKCE || cfg.ClientSecret == "",
+		store:      store,
 	}
+	client.startAuthAttemptUnsafe()
 
 	// Try to load existing token
-	if tokenFile != "" {
+	if store != nil {
 		if client.loadToken() {
-			logger.WithField("token_file", tokenFile).Info("Loaded existing Spotify authentication token")
+			logger.WithField("token_store", store.Name()).Info("Loaded existing Spotify authentication token")
 
 			// Test the token by creating a client and checking if it works
 			if client.validateStoredToken() {
@@ -110,7 +119,7 @@ func NewClient(cfg config.SpotifyConfig, logger *logrus.Logger) (*Client, error)
 				logger.Info("🔄 Existing token is invalid or expired, will attempt refresh or re-authentication")
 			}
 		} else {
-			logger.WithField("token_file", tokenFile).Debug("No existing token found")
+			logger.WithField("token_store", store.Name()).Debug("No existing token found")
 		}
 	}
 
@@ -120,11 +129,43 @@ func NewClient(cfg config.SpotifyConfig, logger *logrus.Logger) (*Client, error)
 	return client, nil
 }
 
-// GetAuthURL returns the URL for user authentication
+// GetAuthURL returns the URL for user authentication. The URL belongs to the current
+// authentication attempt; once an attempt has been used, a new one is started.
 func (c *Client) GetAuthURL() string {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if c.state == "" {
+		c.startAuthAttemptUnsafe()
+	}
 	return c.authURL
 }
 
+// startAuthAttemptUnsafe starts a new authentication attempt with a random state and,
+// when using PKCE, a fresh code verifier. Callers must hold tokenMu or own the client.
+func (c *Client) startAuthAttemptUnsafe() {
+	// The state ties the callback to this attempt, so a code injected by anyone else
+	// who can reach the callback is rejected
+	c.state = rand.Text()
+
+	// Use the library's AuthURL method as shown in examples, adding a PKCE challenge
+	// when authenticating without the client secret
+	if c.usePKCE {
+		c.verifier = oauth2.GenerateVerifier()
+		c.authURL = c.auth.AuthURL(c.state, oauth2.S256ChallengeOption(c.verifier))
+	} else {
+		c.verifier = ""
+		c.authURL = c.auth.AuthURL(c.state)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"client_id":    c.config.ClientID,
+		"redirect_url": c.config.RedirectURL,
+		"pkce":         c.usePKCE,
+		"auth_url":     c.authURL,
+	}).Info("Generated Spotify auth URL using library method")
+}
+
 // IsAuthenticated returns whether the user is authenticated
 func (c *Client) IsAuthenticated() bool {
 	c.tokenMu.RLock()
@@ -137,14 +178,22 @@ func (c *Client) CompleteAuth(code, state string) error {
 	c.tokenMu.Lock()
 	defer c.tokenMu.Unlock()
 
-	if state != c.state {
-		return fmt.Errorf("invalid state parameter")
+	if c.state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(c.state)) != 1 {
+		return ErrInvalidAuthState
 	}
 
 	c.logger.Debug("Completing Spotify authentication")
 
 	// Exchange authorization code for token using the library method
-	token, err := c.auth.Exchange(c.ctx, code)
+	var opts []oauth2.AuthCodeOption
+	if c.verifier != "" {
+		opts = append(opts, oauth2.VerifierOption(c.verifier))
+	}
+
+	// Each attempt's state and verifier are single-use
+	c.state, c.verifier, c.authURL = "", "", ""
+
+	token, err := c.auth.Exchange(c.ctx, code, opts...)
 	if err != nil {
 		return fmt.Errorf("failed to exchange code for token: %w", err)
 	}
@@ -174,7 +223,7 @@ func (c *Client) CompleteAuth(code, state string) error {
 	if err := c.saveTokenUnsafe(); err != nil {
 		c.logger.WithError(err).Warn("Failed to save authentication token, will require re-authentication next time")
 	} else {
-		c.logger.WithField("token_file", c.tokenFile).Info("💾 Authentication token saved successfully - no re-authentication needed next time!")
+		c.logger.WithField("token_store", c.store.Name()).Info("💾 Authentication token saved successfully - no re-authentication needed next time!")
 	}
 
 	return nil
@@ -222,6 +271,27 @@ func (c *Client) RefreshToken() error {
 
 // SearchArtist searches for an artist by name and returns the best match
 func (c *Client) SearchArtist(query string) (*Artist, error) {
+	artists, err := c.SearchArtists(query, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	// Return the first (most relevant) result following library patterns
+	artist := &artists[0]
+
+	c.logger.WithFields(logrus.Fields{
+		"query":       query,
+		"artist_id":   artist.ID,
+		"artist_name": artist.Name,
+		"genres":      artist.Genres,
+	}).Debug("Artist found using Spotify library")
+
+	return artist, nil
+}
+
+// SearchArtists searches for artists by name and returns up to limit matches, most
+// relevant first
+func (c *Client) SearchArtists(query string, limit int) ([]Artist, error) {
 	if !c.IsAuthenticated() {
 		return nil, fmt.Errorf("user not authenticated to Spotify")
 	}
@@ -230,10 +300,13 @@ func (c *Client) SearchArtist(query string) (*Artist, error) {
 		return nil, fmt.Errorf("failed to refresh token: %w", err)
 	}
 
-	c.logger.WithField("query", query).Debug("Searching for artist using Spotify library")
+	c.logger.WithFields(logrus.Fields{
+		"query": query,
+		"limit": limit,
+	}).Debug("Searching for artists using Spotify library")
 
 	// Use the library's Search method following the examples
-	results, err := c.client.Search(c.ctx, query, spotify.SearchTypeArtist)
+	results, err := c.client.Search(c.ctx, query, spotify.SearchTypeArtist, spotify.Limit(limit))
 	if err != nil {
 		c.logger.WithError(err).WithField("query", query).Error("Failed to search for artist")
 		return nil, fmt.Errorf("failed to search for artist: %w", err)
@@ -244,24 +317,22 @@ func (c *Client) SearchArtist(query string) (*Artist, error) {
 		return nil, fmt.Errorf("no artists found for query: %s", query)
 	}
 
-	// Return the first (most relevant) result following library patterns
-	spotifyArtist := results.Artists.Artists[0]
-
-	artist := &Artist{
-		ID:     string(spotifyArtist.ID),
-		Name:   spotifyArtist.Name,
-		URI:    string(spotifyArtist.URI),
-		Genres: spotifyArtist.Genres,
+	spotifyArtists := results.Artists.Artists
+	if len(spotifyArtists) > limit {
+		spotifyArtists = spotifyArtists[:limit]
+	}
+	artists := make([]Artist, len(spotifyArtists))
+	for i, spotifyArtist := range spotifyArtists {
+		artists[i] = Artist{
+			ID:         string(spotifyArtist.ID),
+			Name:       spotifyArtist.Name,
+			URI:        string(spotifyArtist.URI),
+			Genres:     spotifyArtist.Genres,
+			Popularity: int(spotifyArtist.Popularity),
+		}
 	}
 
-	c.logger.WithFields(logrus.Fields{
-		"query":       query,
-		"artist_id":   artist.ID,
-		"artist_name": artist.Name,
-		"genres":      artist.Genres,
-	}).Debug("Artist found using Spotify library")
-
-	return artist, nil
+	return artists, nil
 }
 
 // GetArtistTopTracks retrieves the top tracks for an artist (limited to 5)
@@ -298,29 +369,7 @@ func (c *Client) GetArtistTopTracks(artistID string) ([]Track, error) {
 	trackNames := make([]string, maxTracks)
 
 	for i, spotifyTrack := range topTracks[:maxTracks] {
-		// Convert Spotify artists to our Artist type
-		artists := make([]Artist, len(spotifyTrack.Artists))
-		for j, spotifyArtist := range spotifyTrack.Artists {
-			artists[j] = Artist{
-				ID:     string(spotifyArtist.ID),
-				Name:   spotifyArtist.Name,
-				URI:    string(spotifyArtist.URI),
-				Genres: []string{}, // SimpleArtist doesn't include genres, would need full artist lookup
-			}
-		}
-
-		tracks[i] = Track{
-			ID:       string(spotifyTrack.ID),
-			Name:     spotifyTrack.Name,
-			URI:      string(spotifyTrack.URI),
-			Artists:  artists,
-			Duration: int(spotifyTrack.Duration),
-			Album: Album{
-				ID:   string(spotifyTrack.Album.ID),
-				Name: spotifyTrack.Album.Name,
-				Type: string(spotifyTrack.Album.AlbumType),
-			},
-		}
+		tracks[i] = convertFullTrack(spotifyTrack)
 		trackNames[i] = spotifyTrack.Name
 	}
 
@@ -365,6 +414,17 @@ func (c *Client) GetUserPlaylists(folderName string) ([]Playlist, error) {
 	}
 
 	allPlaylists := playlistPage.Playlists
+	for {
+		err = c.client.NextPage(c.ctx, playlistPage)
+		if errors.Is(err, spotify.ErrNoMorePages) {
+			break
+		}
+		if err != nil {
+			c.logger.WithError(err).Error("Failed to get next page of user playlists")
+			return nil, fmt.Errorf("failed to get user playlists: %w", err)
+		}
+		allPlaylists = append(allPlaylists, playlistPage.Playlists...)
+	}
 	c.logger.WithField("total_playlists", len(allPlaylists)).Debug("Retrieved user playlists")
 
 	// Convert to our Playlist type and filter to user-owned only
@@ -372,22 +432,75 @@ func (c *Client) GetUserPlaylists(folderName string) ([]Playlist, error) {
 	for _, spotifyPlaylist := range allPlaylists {
 		// Only include playlists owned by the user
 		if spotifyPlaylist.Owner.ID == currentUser.ID {
-			playlist := Playlist{
-				ID:         string(spotifyPlaylist.ID),
-				Name:       spotifyPlaylist.Name,
-				URI:        string(spotifyPlaylist.URI),
-				TrackCount: int(spotifyPlaylist.Tracks.Total),
-				EmbedURL:   fmt.Sprintf("https://open.spotify.com/embed/playlist/%s", spotifyPlaylist.ID),
-				IsIncoming: false,
+			playlist := c.convertPlaylist(spotifyPlaylist)
+			if folderName != "" && !c.inFolder(playlist, folderName) {
+				continue
 			}
 			userPlaylists = append(userPlaylists, playlist)
 		}
 	}
 
-	c.logger.WithField("user_playlists", len(userPlaylists)).Debug("Filtered to user-owned playlists")
+	c.logger.WithFields(logrus.Fields{
+		"user_playlists": len(userPlaylists),
+		"folder_name":    folderName,
+	}).Debug("Filtered to user-owned playlists")
 	return userPlaylists, nil
 }
 
+// convertPlaylist converts a Spotify library playlist to our Playlist type.
+func (c *Client) convertPlaylist(spotifyPlaylist spotify.SimplePlaylist) Playlist {
+	playlist := Playlist{
+		ID:          string(spotifyPlaylist.ID),
+		Name:        spotifyPlaylist.Name,
+		Description: spotifyPlaylist.Description,
+		URI:         string(spotifyPlaylist.URI),
+		TrackCount:  int(spotifyPlaylist.Tracks.Total),
+		EmbedURL:    fmt.Sprintf("https://open.spotify.com/embed/playlist/%s", spotifyPlaylist.ID),
+	}
+	playlist.IsIncoming = matchesFolder(playlist, c.config.IncomingPrefix, c.config.IncomingTag)
+	playlist.Public = spotifyPlaylist.IsPublic
+	return playlist
+}
+
+// inFolder reports whether a playlist belongs to the named folder. The Spotify Web API
+// doesn't expose playlist folders, so a folder is a naming convention: playlists whose
+// name starts with the folder name or whose description is tagged #<folder>. The
+// incoming folder uses the configured incoming prefix and tag.
+func (c *Client) inFolder(playlist Playlist, folderName string) bool {
+	if strings.EqualFold(folderName, c.config.IncomingPrefix) {
+		return playlist.IsIncoming
+	}
+	return matchesFolder(playlist, folderName, "#"+folderName)
+}
+
+// matchesFolder reports whether a playlist's name starts with the prefix, as a whole
+// word, or its description contains the tag, ignoring case.
+func matchesFolder(playlist Playlist, prefix, tag string) bool {
+	return hasWord(playlist.Name, prefix, true) || hasWord(playlist.Description, tag, false)
+}
+
+// hasWord reports whether s contains word, ignoring case, not followed by a letter or
+// digit, so the prefix "Incoming" doesn't match "Incomings". With atStart, word must
+// start s.
+func hasWord(s, word string, atStart bool) bool {
+	if word == "" {
+		return false
+	}
+	s, word = strings.ToLower(s), strings.ToLower(word)
+	for offset := 0; ; {
+		index := strings.Index(s[offset:], word)
+		if index < 0 || (atStart && offset+index > 0) {
+			return false
+		}
+		end := offset + index + len(word)
+		next, _ := utf8.DecodeRuneInString(s[end:])
+		if end == len(s) || (!unicode.IsLetter(next) && !unicode.IsDigit(next)) {
+			return true
+		}
+		offset = offset + index + 1
+	}
+}
+
 // AddTracksToPlaylist adds tracks to a specified playlist
 func (c *Client) AddTracksToPlaylist(playlistID string, trackIDs []string) error {
 	if len(trackIDs) == 0 {
@@ -414,15 +527,18 @@ func (c *Client) AddTracksToPlaylist(playlistID string, trackIDs []string) error
 		spotifyIDs[i] = spotify.ID(trackID)
 	}
 
-	// Add tracks to playlist using library method
-	_, err := c.client.AddTracksToPlaylist(c.ctx, spotify.ID(playlistID), spotifyIDs...)
-	if err != nil {
-		c.logger.WithError(err).WithFields(logrus.Fields{
-			"playlist_id": playlistID,
-			"track_count": len(trackIDs),
-			"track_ids":   trackIDs,
-		}).Error("Failed to add tracks to playlist")
-		return fmt.Errorf("failed to add tracks to playlist %s: %w", playlistID, err)
+	// Add tracks to playlist using library method, in batches as the API accepts at most
+	// 100 tracks per request
+	for start := 0; start < len(spotifyIDs); start += playlistWriteBatchSize {
+		end := min(start+playlistWriteBatchSize, len(spotifyIDs))
+		if _, err := c.client.AddTracksToPlaylist(c.ctx, spotify.ID(playlistID), spotifyIDs[start:end]...); err != nil {
+			c.logger.WithError(err).WithFields(logrus.Fields{
+				"playlist_id": playlistID,
+				"track_count": len(trackIDs),
+				"track_ids":   trackIDs,
+			}).Error("Failed to add tracks to playlist")
+			return fmt.Errorf("failed to add tracks to playlist %s: %w", playlistID, err)
+		}
 	}
 
 	c.logger.WithFields(logrus.Fields{
@@ -501,6 +617,345 @@ func (c *Client) CheckTracksInPlaylist(playlistID string, trackIDs []string) ([]
 	return results, nil
 }
 
+// playlistWriteBatchSize is the maximum number of tracks Spotify accepts in a single
+// playlist write request.
+const playlistWriteBatchSize = 100
+
+// GetPlaylistTracks returns all tracks in a playlist, in playlist order
+func (c *Client) GetPlaylistTracks(playlistID string) ([]Track, error) {
+	items, err := c.GetPlaylistItems(playlistID)
+	if err != nil {
+		return nil, err
+	}
+
+	tracks := make([]Track, len(items))
+	for i, item := range items {
+		tracks[i] = item.Track
+	}
+	return tracks, nil
+}
+
+// GetPlaylistItems returns all tracks in a playlist and when each was added, in
+// playlist order. Local files and episodes are skipped.
+func (c *Client) GetPlaylistItems(playlistID string) ([]PlaylistItem, error) {
+	if !c.IsAuthenticated() {
+		return nil, fmt.Errorf("user not authenticated to Spotify")
+	}
+
+	if err := c.RefreshToken(); err != nil {
+		return nil, fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	c.logger.WithField("playlist_id", playlistID).Debug("Getting playlist items using Spotify library")
+
+	page, err := c.client.GetPlaylistItems(c.ctx, spotify.ID(playlistID))
+	if err != nil {
+		c.logger.WithError(err).WithField("playlist_id", playlistID).Error("Failed to get playlist items")
+		return nil, fmt.Errorf("failed to get playlist items: %w", err)
+	}
+
+	var items []PlaylistItem
+	for {
+		for _, item := range page.Items {
+			if item.Track.Track == nil || item.Track.Track.ID == "" {
+				continue
+			}
+			playlistItem := PlaylistItem{Track: convertFullTrack(*item.Track.Track)}
+			if addedAt, err := time.Parse(time.RFC3339, item.AddedAt); err == nil {
+				playlistItem.AddedAt = addedAt
+			}
+			items = append(items, playlistItem)
+		}
+
+		err = c.client.NextPage(c.ctx, page)
+		if errors.Is(err, spotify.ErrNoMorePages) {
+			break
+		}
+		if err != nil {
+			c.logger.WithError(err).WithField("playlist_id", playlistID).Error("Failed to get next page of playlist items")
+			return nil, fmt.Errorf("failed to get playlist items: %w", err)
+		}
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"playlist_id": playlistID,
+		"item_count":  len(items),
+	}).Debug("Retrieved playlist items using Spotify library")
+
+	return items, nil
+}
+
+// RemoveTracksFromPlaylist removes every occurrence of the tracks from a playlist
+func (c *Client) RemoveTracksFromPlaylist(playlistID string, trackIDs []string) error {
+	if len(trackIDs) == 0 {
+		return fmt.Errorf("no tracks provided to remove")
+	}
+
+	if !c.IsAuthenticated() {
+		return fmt.Errorf("user not authenticated to Spotify")
+	}
+
+	if err := c.RefreshToken(); err != nil {
+		return fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"playlist_id": playlistID,
+		"track_count": len(trackIDs),
+		"track_ids":   trackIDs,
+	}).Debug("Removing tracks from playlist using Spotify library")
+
+	for start := 0; start < len(trackIDs); start += playlistWriteBatchSize {
+		end := min(start+playlistWriteBatchSize, len(trackIDs))
+		spotifyIDs := make([]spotify.ID, 0, end-start)
+		for _, trackID := range trackIDs[start:end] {
+			spotifyIDs = append(spotifyIDs, spotify.ID(trackID))
+		}
+
+		if _, err := c.client.RemoveTracksFromPlaylist(c.ctx, spotify.ID(playlistID), spotifyIDs...); err != nil {
+			c.logger.WithError(err).WithFields(logrus.Fields{
+				"playlist_id": playlistID,
+				"track_count": len(trackIDs),
+			}).Error("Failed to remove tracks from playlist")
+			return fmt.Errorf("failed to remove tracks from playlist %s: %w", playlistID, err)
+		}
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"playlist_id": playlistID,
+		"track_count": len(trackIDs),
+	}).Debug("Successfully removed tracks from playlist using Spotify library")
+
+	return nil
+}
+
+// RemoveTrackPositions removes the occurrences of tracks at the given 0-based playlist
+// positions, keyed by track ID, leaving other occurrences of the same tracks in place
+func (c *Client) RemoveTrackPositions(playlistID string, positions map[string][]int) error {
+	type occurrence struct {
+		trackID  string
+		position int
+	}
+	var occurrences []occurrence
+	for trackID, trackPositions := range positions {
+		for _, position := range trackPositions {
+			occurrences = append(occurrences, occurrence{trackID: trackID, position: position})
+		}
+	}
+	if len(occurrences) == 0 {
+		return fmt.Errorf("no tracks provided to remove")
+	}
+
+	if !c.IsAuthenticated() {
+		return fmt.Errorf("user not authenticated to Spotify")
+	}
+
+	if err := c.RefreshToken(); err != nil {
+		return fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"playlist_id":      playlistID,
+		"occurrence_count": len(occurrences),
+	}).Debug("Removing track positions from playlist using Spotify library")
+
+	// Remove from the end of the playlist first, so positions in later batches still
+	// refer to the same tracks
+	sort.Slice(occurrences, func(i, j int) bool { return occurrences[i].position > occurrences[j].position })
+	for start := 0; start < len(occurrences); start += playlistWriteBatchSize {
+		end := min(start+playlistWriteBatchSize, len(occurrences))
+
+		batch := make(map[string][]int)
+		var order []string
+		for _, o := range occurrences[start:end] {
+			if _, ok := batch[o.trackID]; !ok {
+				order = append(order, o.trackID)
+			}
+			batch[o.trackID] = append(batch[o.trackID], o.position)
+		}
+		tracks := make([]spotify.TrackToRemove, 0, len(order))
+		for _, trackID := range order {
+			tracks = append(tracks, spotify.NewTrackToRemove(trackID, batch[trackID]))
+		}
+
+		if _, err := c.client.RemoveTracksFromPlaylistOpt(c.ctx, spotify.ID(playlistID), tracks, ""); err != nil {
+			c.logger.WithError(err).WithFields(logrus.Fields{
+				"playlist_id":      playlistID,
+				"occurrence_count": len(occurrences),
+			}).Error("Failed to remove track positions from playlist")
+			return fmt.Errorf("failed to remove tracks from playlist %s: %w", playlistID, err)
+		}
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"playlist_id":      playlistID,
+		"occurrence_count": len(occurrences),
+	}).Debug("Successfully removed track positions from playlist using Spotify library")
+
+	return nil
+}
+
+// ReorderPlaylist moves rangeLength tracks starting at position rangeStart so they are
+// inserted before the track at position insertBefore (positions are 0-based, as
+// before the move)
+func (c *Client) ReorderPlaylist(playlistID string, rangeStart, rangeLength, insertBefore int) error {
+	if !c.IsAuthenticated() {
+		return fmt.Errorf("user not authenticated to Spotify")
+	}
+
+	if err := c.RefreshToken(); err != nil {
+		return fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"playlist_id":   playlistID,
+		"range_start":   rangeStart,
+		"range_length":  rangeLength,
+		"insert_before": insertBefore,
+	}).Debug("Reordering playlist using Spotify library")
+
+	_, err := c.client.ReorderPlaylistTracks(c.ctx, spotify.ID(playlistID), spotify.PlaylistReorderOptions{
+		RangeStart:   spotify.Numeric(rangeStart),
+		RangeLength:  spotify.Numeric(rangeLength),
+		InsertBefore: spotify.Numeric(insertBefore),
+	})
+	if err != nil {
+		c.logger.WithError(err).WithField("playlist_id", playlistID).Error("Failed to reorder playlist")
+		return fmt.Errorf("failed to reorder playlist %s: %w", playlistID, err)
+	}
+
+	return nil
+}
+
+// convertFullTrack converts a Spotify library track to our Track type
+func convertFullTrack(spotifyTrack spotify.FullTrack) Track {
+	artists := make([]Artist, len(spotifyTrack.Artists))
+	for i, spotifyArtist := range spotifyTrack.Artists {
+		artists[i] = Artist{
+			ID:     string(spotifyArtist.ID),
+			Name:   spotifyArtist.Name,
+			URI:    string(spotifyArtist.URI),
+			Genres: []string{}, // SimpleArtist doesn't include genres, would need full artist lookup
+		}
+	}
+
+	return Track{
+		ID:       string(spotifyTrack.ID),
+		Name:     spotifyTrack.Name,
+		URI:      string(spotifyTrack.URI),
+		Artists:  artists,
+		Duration: int(spotifyTrack.Duration),
+		Album: Album{
+			ID:   string(spotifyTrack.Album.ID),
+			Name: spotifyTrack.Album.Name,
+			Type: string(spotifyTrack.Album.AlbumType),
+		},
+		ISRC: trackISRC(spotifyTrack),
+	}
+}
+
+// trackISRC returns the International Standard Recording Code of a track, if Spotify
+// reported one.
+func trackISRC(spotifyTrack spotify.FullTrack) string {
+	if isrc := spotifyTrack.ExternalIDs["isrc"]; isrc != "" {
+		return isrc
+	}
+	return spotifyTrack.SimpleTrack.ExternalIDs.ISRC
+}
+
+// UpdatePlaylist changes the name, description and/or visibility of a playlist. Empty
+// fields and a nil Public are left unchanged.
+func (c *Client) UpdatePlaylist(playlistID string, update PlaylistUpdate) error {
+	if update.Name == "" && update.Description == "" && update.Public == nil {
+		return fmt.Errorf("no playlist changes provided")
+	}
+
+	if !c.IsAuthenticated() {
+		return fmt.Errorf("user not authenticated to Spotify")
+	}
+
+	if err := c.RefreshToken(); err != nil {
+		return fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"playlist_id": playlistID,
+		"name":        update.Name,
+		"description": update.Description,
+		"public":      update.Public,
+	}).Debug("Updating playlist details using Spotify library")
+
+	var err error
+	if update.Public != nil {
+		err = c.client.ChangePlaylistNameAccessAndDescription(c.ctx, spotify.ID(playlistID), update.Name, update.Description, *update.Public)
+	} else {
+		if update.Name != "" {
+			err = c.client.ChangePlaylistName(c.ctx, spotify.ID(playlistID), update.Name)
+		}
+		if err == nil && update.Description != "" {
+			err = c.client.ChangePlaylistDescription(c.ctx, spotify.ID(playlistID), update.Description)
+		}
+	}
+	if err != nil {
+		c.logger.WithError(err).WithField("playlist_id", playlistID).Error("Failed to update playlist details")
+		return fmt.Errorf("failed to update playlist %s: %w", playlistID, err)
+	}
+
+	c.logger.WithField("playlist_id", playlistID).Debug("Successfully updated playlist details using Spotify library")
+	return nil
+}
+
+// SetPlaylistCover replaces the cover image of a playlist with a JPEG image. This
+// requires the ugc-image-upload scope.
+func (c *Client) SetPlaylistCover(playlistID string, jpeg []byte) error {
+	if len(jpeg) == 0 {
+		return fmt.Errorf("no cover image provided")
+	}
+
+	if !c.IsAuthenticated() {
+		return fmt.Errorf("user not authenticated to Spotify")
+	}
+
+	if err := c.RefreshToken(); err != nil {
+		return fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"playlist_id": playlistID,
+		"image_bytes": len(jpeg),
+	}).Debug("Uploading playlist cover using Spotify library")
+
+	if err := c.client.SetPlaylistImage(c.ctx, spotify.ID(playlistID), bytes.NewReader(jpeg)); err != nil {
+		c.logger.WithError(err).WithField("playlist_id", playlistID).Error("Failed to upload playlist cover")
+		return fmt.Errorf("failed to upload cover of playlist %s: %w", playlistID, err)
+	}
+
+	c.logger.WithField("playlist_id", playlistID).Debug("Successfully uploaded playlist cover using Spotify library")
+	return nil
+}
+
+// DeletePlaylist deletes a playlist the user owns. Spotify has no real deletion: the
+// playlist is unfollowed, which removes it from the user's library.
+func (c *Client) DeletePlaylist(playlistID string) error {
+	if !c.IsAuthenticated() {
+		return fmt.Errorf("user not authenticated to Spotify")
+	}
+
+	if err := c.RefreshToken(); err != nil {
+		return fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	c.logger.WithField("playlist_id", playlistID).Debug("Unfollowing playlist using Spotify library")
+
+	if err := c.client.UnfollowPlaylist(c.ctx, spotify.ID(playlistID)); err != nil {
+		c.logger.WithError(err).WithField("playlist_id", playlistID).Error("Failed to delete playlist")
+		return fmt.Errorf("failed to delete playlist %s: %w", playlistID, err)
+	}
+
+	c.logger.WithField("playlist_id", playlistID).Debug("Successfully unfollowed playlist using Spotify library")
+	return nil
+}
+
 // CreatePlaylist creates a new playlist with the given name and description
 func (c *Client) CreatePlaylist(name, description string, public bool) (*Playlist, error) {
 	if !c.IsAuthenticated() {
@@ -534,14 +989,8 @@ func (c *Client) CreatePlaylist(name, description string, public bool) (*Playlis
 		return nil, fmt.Errorf("failed to create playlist %s: %w", name, err)
 	}
 
-	playlist := &Playlist{
-		ID:         string(spotifyPlaylist.ID),
-		Name:       spotifyPlaylist.Name,
-		URI:        string(spotifyPlaylist.URI),
-		TrackCount: int(spotifyPlaylist.Tracks.Total),
-		EmbedURL:   fmt.Sprintf("https://open.spotify.com/embed/playlist/%s", spotifyPlaylist.ID),
-		IsIncoming: false,
-	}
+	converted := c.convertPlaylist(spotifyPlaylist.SimplePlaylist)
+	playlist := &converted
 
 	c.logger.WithFields(logrus.Fields{
 		"playlist_id":   playlist.ID,
@@ -552,29 +1001,23 @@ func (c *Client) CreatePlaylist(name, description string, public bool) (*Playlis
 	return playlist, nil
 }
 
-// loadToken attempts to load a stored token from disk
+// loadToken attempts to load a stored token from the token store
 func (c *Client) loadToken() bool {
-	if c.tokenFile == "" {
+	if c.store == nil {
 		return false
 	}
 
 	c.tokenMu.Lock()
 	defer c.tokenMu.Unlock()
 
-	data, err := os.ReadFile(c.tokenFile)
+	tokenData, err := c.store.Load()
 	if err != nil {
-		if !os.IsNotExist(err) {
-			c.logger.WithError(err).Debug("Failed to read token file")
+		if !errors.Is(err, ErrNoStoredToken) {
+			c.logger.WithError(err).Warn("Failed to load stored token")
 		}
 		return false
 	}
 
-	var tokenData TokenData
-	if err := json.Unmarshal(data, &tokenData); err != nil {
-		c.logger.WithError(err).Debug("Failed to parse token file")
-		return false
-	}
-
 	// Convert to oauth2.Token
 	c.token = &oauth2.Token{
 		AccessToken:  tokenData.AccessToken,
@@ -583,14 +1026,14 @@ func (c *Client) loadToken() bool {
 		Expiry:       tokenData.Expiry,
 	}
 
-	c.logger.Debug("Successfully loaded token from file")
+	c.logger.Debug("Successfully loaded token from store")
 	return true
 }
 
-// saveTokenUnsafe saves the current token to disk without acquiring locks
+// saveTokenUnsafe saves the current token to the token store without acquiring locks
 // This should only be called when the caller already holds the appropriate lock
 func (c *Client) saveTokenUnsafe() error {
-	if c.tokenFile == "" || c.token == nil {
+	if c.store == nil || c.token == nil {
 		return nil
 	}
 
@@ -600,48 +1043,123 @@ func (c *Client) saveTokenUnsafe() error {
 		TokenType:    c.token.TokenType,
 		Expiry:       c.token.Expiry,
 	}
+	if err := c.store.Save(tokenData); err != nil {
+		return err
+	}
+
+	c.logger.Debug("Successfully saved token to store")
+	return nil
+}
+
+// ExportToken returns the current token so it can be transferred to another machine.
+func (c *Client) ExportToken() (*TokenData, error) {
+	c.tokenMu.RLock()
+	defer c.tokenMu.RUnlock()
+
+	if !c.isUserAuth || c.token == nil {
+		return nil, fmt.Errorf("user not authenticated to Spotify")
+	}
+
+	return &TokenData{
+		AccessToken:  c.token.AccessToken,
+		RefreshToken: c.token.RefreshToken,
+		TokenType:    c.token.TokenType,
+		Expiry:       c.token.Expiry,
+	}, nil
+}
+
+// ImportToken validates the given token (refreshing it if it has expired), then
+// uses and stores it, replacing any existing token.
+func (c *Client) ImportToken(tokenData TokenData) error {
+	token := &oauth2.Token{
+		AccessToken:  tokenData.AccessToken,
+		RefreshToken: tokenData.RefreshToken,
+		TokenType:    tokenData.TokenType,
+		Expiry:       tokenData.Expiry,
+	}
 
-	// #nosec G117 - we need to store access token as JSON for future use
-	data, err := json.MarshalIndent(tokenData, "", "  ")
+	testClient, current, err := c.checkToken(token)
 	if err != nil {
-		return fmt.Errorf("failed to marshal token data: %w", err)
+		return err
 	}
 
-	// Write to temporary file first, then rename for atomic operation
-	tempFile := c.tokenFile + ".tmp"
-	if err := os.WriteFile(tempFile, data, 0600); err != nil {
-		return fmt.Errorf("failed to write token file: %w", err)
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	// Store the token as refreshed by the check, so an expired import isn't saved
+	c.token = current
+	c.client = testClient
+	c.isUserAuth = true
+
+	if err := c.saveTokenUnsafe(); err != nil {
+		return fmt.Errorf("failed to save imported token: %w", err)
 	}
+	return nil
+}
 
-	if err := os.Rename(tempFile, c.tokenFile); err != nil {
-		_ = os.Remove(tempFile) // Clean up temp file
-		return fmt.Errorf("failed to rename token file: %w", err)
+// ValidateToken checks that the current token still grants API access, refreshing it
+// first if needed. It fails when the user is not authenticated or the refresh token
+// has been revoked.
+func (c *Client) ValidateToken() error {
+	if !c.IsAuthenticated() {
+		return fmt.Errorf("user not authenticated to Spotify")
 	}
 
-	c.logger.Debug("Successfully saved token to file")
+	if err := c.RefreshToken(); err != nil {
+		return fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	c.tokenMu.RLock()
+	token := c.token
+	c.tokenMu.RUnlock()
+
+	if _, _, err := c.checkToken(token); err != nil {
+		return err
+	}
 	return nil
 }
 
-// validateStoredToken checks if the stored token is valid by making a test API call
-func (c *Client) validateStoredToken() bool {
-	if c.token == nil {
-		return false
+// checkToken makes a test API call with the given token and returns a client using it,
+// along with the token the client now holds, which was refreshed if it had expired.
+func (c *Client) checkToken(token *oauth2.Token) (*spotify.Client, *oauth2.Token, error) {
+	if token == nil {
+		return nil, nil, fmt.Errorf("no token available")
 	}
 
-	// Create a client with the stored token
-	httpClient := c.auth.Client(c.ctx, c.token)
+	// Create a client with the token
+	httpClient := c.auth.Client(c.ctx, token)
 	testClient := spotify.New(httpClient)
 
 	// Try to get current user info to validate the token
-	_, err := testClient.CurrentUser(c.ctx)
+	if _, err := testClient.CurrentUser(c.ctx); err != nil {
+		return nil, nil, fmt.Errorf("token validation failed: %w", err)
+	}
+
+	current, err := testClient.Token()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read validated token: %w", err)
+	}
+	return testClient, current, nil
+}
+
+// validateStoredToken checks if the stored token is valid by making a test API call
+func (c *Client) validateStoredToken() bool {
+	testClient, current, err := c.checkToken(c.token)
 	if err != nil {
 		c.logger.WithError(err).Debug("Stored token validation failed")
 		return false
 	}
 
-	// Token is valid, set up the client
+	// Token is valid, set up the client and keep a token refreshed by the check
+	refreshed := current.AccessToken != c.token.AccessToken
+	c.token = current
 	c.client = testClient
 	c.isUserAuth = true
+	if refreshed {
+		if err := c.saveTokenUnsafe(); err != nil {
+			c.logger.WithError(err).Warn("Failed to save refreshed token")
+		}
+	}
 	c.logger.Debug("Stored token validation successful")
 	return true
 }
@@ -1,8 +1,21 @@
 package spotify
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/zmb3/spotify/v2"
+	"golang.org/x/oauth2"
 
 	"github.com/toozej/kmhd2spotify/pkg/config"
 )
@@ -87,3 +100,276 @@ func findSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+// fakeSpotifyTransport answers token exchanges and the current-user check locally,
+// recording the token requests it receives
+type fakeSpotifyTransport struct {
+	tokenRequests []url.Values
+}
+
+func (f *fakeSpotifyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body string
+	switch {
+	case req.URL.Host == "accounts.spotify.com" && req.URL.Path == "/api/token":
+		if err := req.ParseForm(); err != nil {
+			return nil, err
+		}
+		f.tokenRequests = append(f.tokenRequests, req.PostForm)
+		body = `{"access_token":"access","refresh_token":"refresh","token_type":"Bearer","expires_in":3600}`
+	case req.URL.Path == "/v1/me":
+		body = `{"id":"user","display_name":"User"}`
+	default:
+		return nil, fmt.Errorf("unexpected request to %s", req.URL)
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+// newTestAuthClient creates a client whose Spotify requests go to a fake transport
+func newTestAuthClient(t *testing.T, clientSecret string) (*Client, *fakeSpotifyTransport) {
+	t.Helper()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	client, err := NewClient(config.SpotifyConfig{
+		ClientID:      "test-id",
+		ClientSecret:  clientSecret,
+		RedirectURL:   "http://127.0.0.1:8080/callback",
+		TokenFilePath: filepath.Join(t.TempDir(), "token.json"),
+	}, logger)
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	transport := &fakeSpotifyTransport{}
+	client.ctx = context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: transport})
+	return client, transport
+}
+
+// authURLQuery returns the query parameters of the client's current auth URL
+func authURLQuery(t *testing.T, client *Client) url.Values {
+	t.Helper()
+	parsed, err := url.Parse(client.GetAuthURL())
+	if err != nil {
+		t.Fatalf("failed to parse auth URL: %v", err)
+	}
+	return parsed.Query()
+}
+
+func TestClientAuthStateIsRandomPerAttempt(t *testing.T) {
+	first, _ := newTestAuthClient(t, "test-secret")
+	second, _ := newTestAuthClient(t, "test-secret")
+
+	firstState := authURLQuery(t, first).Get("state")
+	secondState := authURLQuery(t, second).Get("state")
+
+	if len(firstState) < 20 {
+		t.Errorf("expected a long random state, got %q", firstState)
+	}
+	if firstState == secondState {
+		t.Errorf("expected different states per attempt, both were %q", firstState)
+	}
+	if firstState == "kmhd2spotify-auth-state" {
+		t.Error("expected state not to be the old constant")
+	}
+	if got := authURLQuery(t, first).Get("state"); got != firstState {
+		t.Errorf("expected GetAuthURL() to keep the current attempt, state changed from %q to %q", firstState, got)
+	}
+}
+
+func TestClientCompleteAuthRejectsForgedState(t *testing.T) {
+	client, transport := newTestAuthClient(t, "test-secret")
+	state := authURLQuery(t, client).Get("state")
+
+	forged := []string{"", "kmhd2spotify-auth-state", state + "x", state[:len(state)-1]}
+	for _, forgedState := range forged {
+		err := client.CompleteAuth("attacker-code", forgedState)
+		if !errors.Is(err, ErrInvalidAuthState) {
+			t.Errorf("CompleteAuth(state=%q) = %v, expected ErrInvalidAuthState", forgedState, err)
+		}
+	}
+
+	if len(transport.tokenRequests) != 0 {
+		t.Errorf("expected no token exchange for forged callbacks, got %d", len(transport.tokenRequests))
+	}
+	if client.IsAuthenticated() {
+		t.Error("expected client to remain unauthenticated")
+	}
+
+	// The legitimate callback still succeeds after forged ones
+	if err := client.CompleteAuth("real-code", state); err != nil {
+		t.Fatalf("CompleteAuth() unexpected error: %v", err)
+	}
+	if !client.IsAuthenticated() {
+		t.Error("expected client to be authenticated")
+	}
+	if len(transport.tokenRequests) != 1 || transport.tokenRequests[0].Get("code") != "real-code" {
+		t.Errorf("expected one token exchange for the real code, got %v", transport.tokenRequests)
+	}
+}
+
+func TestClientCompleteAuthStateIsSingleUse(t *testing.T) {
+	client, transport := newTestAuthClient(t, "test-secret")
+	state := authURLQuery(t, client).Get("state")
+
+	if err := client.CompleteAuth("real-code", state); err != nil {
+		t.Fatalf("CompleteAuth() unexpected error: %v", err)
+	}
+
+	// Replaying the callback must not exchange another code
+	if err := client.CompleteAuth("replayed-code", state); !errors.Is(err, ErrInvalidAuthState) {
+		t.Errorf("CompleteAuth() replay = %v, expected ErrInvalidAuthState", err)
+	}
+	if len(transport.tokenRequests) != 1 {
+		t.Errorf("expected one token exchange, got %d", len(transport.tokenRequests))
+	}
+
+	// A new attempt gets a new state
+	if newState := authURLQuery(t, client).Get("state"); newState == state || newState == "" {
+		t.Errorf("expected a new state for the next attempt, got %q", newState)
+	}
+}
+
+func TestClientCompleteAuthPKCE(t *testing.T) {
+	client, transport := newTestAuthClient(t, "")
+	query := authURLQuery(t, client)
+
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		t.Fatalf("expected an S256 code challenge in auth URL, got %v", query)
+	}
+
+	if err := client.CompleteAuth("real-code", query.Get("state")); err != nil {
+		t.Fatalf("CompleteAuth() unexpected error: %v", err)
+	}
+	if len(transport.tokenRequests) != 1 {
+		t.Fatalf("expected one token exchange, got %d", len(transport.tokenRequests))
+	}
+
+	// The verifier sent with the code must hash to the challenge from the auth URL
+	verifier := transport.tokenRequests[0].Get("code_verifier")
+	if verifier == "" {
+		t.Fatal("expected code_verifier in token exchange")
+	}
+	if got := oauth2.S256ChallengeFromVerifier(verifier); got != query.Get("code_challenge") {
+		t.Errorf("code_verifier does not match challenge: %q != %q", got, query.Get("code_challenge"))
+	}
+	if transport.tokenRequests[0].Get("client_secret") != "" {
+		t.Error("expected no client secret in PKCE token exchange")
+	}
+}
+
+func TestConvertFullTrackISRC(t *testing.T) {
+	var track spotify.FullTrack
+	data := `{"id": "track1", "name": "Naima", "duration_ms": 261000, "external_ids": {"isrc": "USAT20001234"}}`
+	if err := json.Unmarshal([]byte(data), &track); err != nil {
+		t.Fatalf("failed to parse track: %v", err)
+	}
+
+	converted := convertFullTrack(track)
+	if converted.ISRC != "USAT20001234" {
+		t.Errorf("expected ISRC USAT20001234, got %q", converted.ISRC)
+	}
+	if converted.Duration != 261000 {
+		t.Errorf("expected duration 261000, got %d", converted.Duration)
+	}
+
+	if isrc := convertFullTrack(spotify.FullTrack{}).ISRC; isrc != "" {
+		t.Errorf("expected no ISRC, got %q", isrc)
+	}
+}
+
+func TestClientIncomingPlaylists(t *testing.T) {
+	client := &Client{config: config.SpotifyConfig{IncomingPrefix: "Incoming", IncomingTag: "#incoming"}}
+
+	tests := []struct {
+		name        string
+		description string
+		incoming    bool
+		inJazz      bool
+	}{
+		{name: "Incoming: Jazz", incoming: true},
+		{name: "incoming - new finds", incoming: true},
+		{name: "Incoming", incoming: true},
+		{name: "Incomings", incoming: false},
+		{name: "My Incoming Playlist", incoming: false},
+		{name: "Saturday Finds", description: "To listen to #Incoming", incoming: true},
+		{name: "Saturday Finds", description: "#incomingish", incoming: false},
+		{name: "Jazz Standards", incoming: false, inJazz: true},
+		{name: "Late Night", description: "#jazz #incoming", incoming: true, inJazz: true},
+		{name: "Jazzy", incoming: false},
+	}
+
+	for _, tt := range tests {
+		spotifyPlaylist := spotify.SimplePlaylist{ID: "playlist1", Name: tt.name, Description: tt.description}
+		playlist := client.convertPlaylist(spotifyPlaylist)
+		if playlist.IsIncoming != tt.incoming {
+			t.Errorf("%q (%q): expected IsIncoming %v, got %v", tt.name, tt.description, tt.incoming, playlist.IsIncoming)
+		}
+		if playlist.Description != tt.description {
+			t.Errorf("%q: expected description %q, got %q", tt.name, tt.description, playlist.Description)
+		}
+		if got := client.inFolder(playlist, "incoming"); got != tt.incoming {
+			t.Errorf("%q (%q): expected in incoming folder %v, got %v", tt.name, tt.description, tt.incoming, got)
+		}
+		if got := client.inFolder(playlist, "Jazz"); got != tt.inJazz {
+			t.Errorf("%q (%q): expected in Jazz folder %v, got %v", tt.name, tt.description, tt.inJazz, got)
+		}
+	}
+
+	client.config.IncomingTag = ""
+	if client.convertPlaylist(spotify.SimplePlaylist{Name: "Finds", Description: "#incoming"}).IsIncoming {
+		t.Error("expected no incoming playlists by description without a tag")
+	}
+}
+
+func TestClientPlaylistUpdates(t *testing.T) {
+	client := &Client{}
+
+	if !client.convertPlaylist(spotify.SimplePlaylist{Name: "KMHD-2025-10", IsPublic: true}).Public {
+		t.Error("expected public playlist to be converted as public")
+	}
+
+	if err := client.UpdatePlaylist("playlist1", PlaylistUpdate{}); err == nil || err.Error() != "no playlist changes provided" {
+		t.Errorf("expected error for empty update, got %v", err)
+	}
+	if err := client.UpdatePlaylist("playlist1", PlaylistUpdate{Name: "KMHD-2025"}); err == nil {
+		t.Error("expected error when not authenticated")
+	}
+	if err := client.SetPlaylistCover("playlist1", nil); err == nil || err.Error() != "no cover image provided" {
+		t.Errorf("expected error for empty cover, got %v", err)
+	}
+}
+
+func TestClientImportTokenSavesRefreshedToken(t *testing.T) {
+	client, transport := newTestAuthClient(t, "test-secret")
+
+	err := client.ImportToken(TokenData{
+		AccessToken:  "expired",
+		RefreshToken: "old-refresh",
+		TokenType:    "Bearer",
+		Expiry:       time.Now().Add(-time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("ImportToken() unexpected error: %v", err)
+	}
+	if len(transport.tokenRequests) != 1 || transport.tokenRequests[0].Get("refresh_token") != "old-refresh" {
+		t.Fatalf("expected one refresh of the imported token, got %v", transport.tokenRequests)
+	}
+
+	saved, err := client.store.Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if saved.AccessToken != "access" || saved.RefreshToken != "refresh" {
+		t.Errorf("expected the refreshed token to be saved, got %+v", saved)
+	}
+	if !saved.Expiry.After(time.Now()) {
+		t.Errorf("expected the saved token to be unexpired, got expiry %v", saved.Expiry)
+	}
+}
@@ -8,10 +8,11 @@ import (
 
 // Artist represents a Spotify artist
 type Artist struct {
-	ID     string   `json:"id"`
-	Name   string   `json:"name"`
-	URI    string   `json:"uri"`
-	Genres []string `json:"genres"`
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	URI        string   `json:"uri"`
+	Genres     []string `json:"genres"`
+	Popularity int      `json:"popularity,omitempty"`
 }
 
 // Album represents a Spotify album
@@ -29,16 +30,33 @@ type Track struct {
 	Artists  []Artist `json:"artists"`
 	Duration int      `json:"duration_ms"`
 	Album    Album    `json:"album"`
+	ISRC     string   `json:"isrc,omitempty"`
+}
+
+// PlaylistItem is a track in a playlist and when it was added
+type PlaylistItem struct {
+	Track   Track     `json:"track"`
+	AddedAt time.Time `json:"added_at"`
 }
 
 // Playlist represents a Spotify playlist
 type Playlist struct {
-	ID         string `json:"id"`
-	Name       string `json:"name"`
-	URI        string `json:"uri"`
-	TrackCount int    `json:"track_count"`
-	EmbedURL   string `json:"embed_url"`
-	IsIncoming bool   `json:"is_incoming"`
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	URI         string `json:"uri"`
+	TrackCount  int    `json:"track_count"`
+	EmbedURL    string `json:"embed_url"`
+	IsIncoming  bool   `json:"is_incoming"`
+	Public      bool   `json:"public"`
+}
+
+// PlaylistUpdate holds changes to a playlist's details. Empty fields and a nil Public
+// are left unchanged.
+type PlaylistUpdate struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	Public      *bool  `json:"public,omitempty"`
 }
 
 // SpotifyService defines the interface for Spotify operations
@@ -2,6 +2,7 @@ package spotify
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/sirupsen/logrus"
 	"github.com/toozej/kmhd2spotify/internal/types"
@@ -38,6 +39,44 @@ func (s *Service) CompleteAuth(code, state string) error {
 	return s.client.CompleteAuth(code, state)
 }
 
+// ValidateToken checks that the stored token still grants API access
+func (s *Service) ValidateToken() error {
+	if s.client == nil {
+		return errors.New("spotify client not available")
+	}
+	return s.client.ValidateToken()
+}
+
+// ExportToken returns the current token as a portable blob for ImportToken on another machine
+func (s *Service) ExportToken() (string, error) {
+	if s.client == nil {
+		return "", errors.New("spotify client not available")
+	}
+
+	token, err := s.client.ExportToken()
+	if err != nil {
+		return "", err
+	}
+	return EncodeTokenBlob(TokenBlob{ClientID: s.client.config.ClientID, Token: *token})
+}
+
+// ImportToken validates and stores a token blob produced by ExportToken
+func (s *Service) ImportToken(encoded string) error {
+	if s.client == nil {
+		return errors.New("spotify client not available")
+	}
+
+	blob, err := DecodeTokenBlob(encoded)
+	if err != nil {
+		return err
+	}
+	if blob.ClientID != s.client.config.ClientID {
+		return fmt.Errorf("token was issued to Spotify client %q, but SPOTIFY_CLIENT_ID is %q", blob.ClientID, s.client.config.ClientID)
+	}
+
+	return s.client.ImportToken(blob.Token)
+}
+
 // NewService creates a new Spotify service that implements types.SpotifyService
 func NewService(cfg config.SpotifyConfig, logger *logrus.Logger) *Service {
 	logger.WithFields(logrus.Fields{
@@ -93,13 +132,58 @@ func (s *Service) SearchArtist(query string) (*types.Artist, error) {
 	}).Info("Artist search completed successfully")
 
 	return &types.Artist{
-		ID:     artist.ID,
-		Name:   artist.Name,
-		URI:    artist.URI,
-		Genres: artist.Genres,
+		ID:         artist.ID,
+		Name:       artist.Name,
+		URI:        artist.URI,
+		Genres:     artist.Genres,
+		Popularity: artist.Popularity,
 	}, nil
 }
 
+// SearchArtists searches for artists by name and returns up to limit matches
+func (s *Service) SearchArtists(query string, limit int) ([]types.Artist, error) {
+	if s.client == nil {
+		return nil, errors.New("spotify client not available")
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"component": "spotify_service",
+		"operation": "search_artists",
+		"query":     query,
+		"limit":     limit,
+	}).Debug("Searching for artists")
+
+	artists, err := s.client.SearchArtists(query, limit)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"component": "spotify_service",
+			"operation": "search_artists",
+			"query":     query,
+		}).WithError(err).Error("Failed to search for artists")
+		return nil, err
+	}
+
+	serverArtists := make([]types.Artist, len(artists))
+	for i, artist := range artists {
+		serverArtists[i] = types.Artist{
+			ID:         artist.ID,
+			Name:       artist.Name,
+			URI:        artist.URI,
+			Genres:     artist.Genres,
+			Popularity: artist.Popularity,
+		}
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"component":    "spotify_service",
+		"operation":    "search_artists",
+		"query":        query,
+		"artist_count": len(serverArtists),
+	}).Info("Artist search completed successfully")
+
+	return serverArtists, nil
+}
+
 // GetArtistTopTracks retrieves the top 5 tracks for an artist
 func (s *Service) GetArtistTopTracks(artistID string) ([]types.Track, error) {
 	if s.client == nil {
@@ -125,29 +209,7 @@ func (s *Service) GetArtistTopTracks(artistID string) ([]types.Track, error) {
 	serverTracks := make([]types.Track, len(tracks))
 	trackNames := make([]string, len(tracks))
 	for i, track := range tracks {
-		// Convert artists
-		artists := make([]types.Artist, len(track.Artists))
-		for j, artist := range track.Artists {
-			artists[j] = types.Artist{
-				ID:     artist.ID,
-				Name:   artist.Name,
-				URI:    artist.URI,
-				Genres: artist.Genres,
-			}
-		}
-
-		serverTracks[i] = types.Track{
-			ID:       track.ID,
-			Name:     track.Name,
-			URI:      track.URI,
-			Artists:  artists,
-			Duration: track.Duration,
-			Album: types.Album{
-				ID:   track.Album.ID,
-				Name: track.Album.Name,
-				Type: track.Album.Type,
-			},
-		}
+		serverTracks[i] = toTypesTrack(track)
 		trackNames[i] = track.Name
 	}
 
@@ -188,12 +250,14 @@ func (s *Service) GetUserPlaylists(folderName string) ([]types.Playlist, error)
 	playlistNames := make([]string, len(playlists))
 	for i, playlist := range playlists {
 		serverPlaylists[i] = types.Playlist{
-			ID:         playlist.ID,
-			Name:       playlist.Name,
-			URI:        playlist.URI,
-			TrackCount: playlist.TrackCount,
-			EmbedURL:   playlist.EmbedURL,
-			IsIncoming: playlist.IsIncoming,
+			ID:          playlist.ID,
+			Name:        playlist.Name,
+			Description: playlist.Description,
+			URI:         playlist.URI,
+			TrackCount:  playlist.TrackCount,
+			EmbedURL:    playlist.EmbedURL,
+			IsIncoming:  playlist.IsIncoming,
+			Public:      playlist.Public,
 		}
 		playlistNames[i] = playlist.Name
 	}
@@ -286,6 +350,254 @@ func (s *Service) CheckTracksInPlaylist(playlistID string, trackIDs []string) ([
 	return results, nil
 }
 
+// GetPlaylistTracks returns the tracks of a playlist in playlist order
+func (s *Service) GetPlaylistTracks(playlistID string) ([]types.Track, error) {
+	if s.client == nil {
+		return nil, errors.New("spotify client not available")
+	}
+
+	tracks, err := s.client.GetPlaylistTracks(playlistID)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"component":   "spotify_service",
+			"operation":   "get_playlist_tracks",
+			"playlist_id": playlistID,
+		}).WithError(err).Error("Failed to get playlist tracks")
+		return nil, err
+	}
+
+	serverTracks := make([]types.Track, len(tracks))
+	for i, track := range tracks {
+		serverTracks[i] = toTypesTrack(track)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"component":   "spotify_service",
+		"operation":   "get_playlist_tracks",
+		"playlist_id": playlistID,
+		"track_count": len(serverTracks),
+	}).Debug("Retrieved playlist tracks")
+
+	return serverTracks, nil
+}
+
+// GetPlaylistItems returns the tracks of a playlist and when each was added, in
+// playlist order
+func (s *Service) GetPlaylistItems(playlistID string) ([]types.PlaylistItem, error) {
+	if s.client == nil {
+		return nil, errors.New("spotify client not available")
+	}
+
+	items, err := s.client.GetPlaylistItems(playlistID)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"component":   "spotify_service",
+			"operation":   "get_playlist_items",
+			"playlist_id": playlistID,
+		}).WithError(err).Error("Failed to get playlist items")
+		return nil, err
+	}
+
+	serverItems := make([]types.PlaylistItem, len(items))
+	for i, item := range items {
+		serverItems[i] = types.PlaylistItem{Track: toTypesTrack(item.Track), AddedAt: item.AddedAt}
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"component":   "spotify_service",
+		"operation":   "get_playlist_items",
+		"playlist_id": playlistID,
+		"item_count":  len(serverItems),
+	}).Debug("Retrieved playlist items")
+
+	return serverItems, nil
+}
+
+// RemoveTracksFromPlaylist removes every occurrence of the tracks from a playlist
+func (s *Service) RemoveTracksFromPlaylist(playlistID string, trackIDs []string) error {
+	if s.client == nil {
+		return errors.New("spotify client not available")
+	}
+
+	if err := s.client.RemoveTracksFromPlaylist(playlistID, trackIDs); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"component":   "spotify_service",
+			"operation":   "remove_tracks",
+			"playlist_id": playlistID,
+			"track_count": len(trackIDs),
+		}).WithError(err).Error("Failed to remove tracks from playlist")
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"component":   "spotify_service",
+		"operation":   "remove_tracks",
+		"playlist_id": playlistID,
+		"track_count": len(trackIDs),
+	}).Info("Successfully removed tracks from playlist")
+
+	return nil
+}
+
+// RemoveTrackPositions removes the occurrences of tracks at the given playlist positions
+func (s *Service) RemoveTrackPositions(playlistID string, positions map[string][]int) error {
+	if s.client == nil {
+		return errors.New("spotify client not available")
+	}
+
+	if err := s.client.RemoveTrackPositions(playlistID, positions); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"component":   "spotify_service",
+			"operation":   "remove_track_positions",
+			"playlist_id": playlistID,
+			"track_count": len(positions),
+		}).WithError(err).Error("Failed to remove track positions from playlist")
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"component":   "spotify_service",
+		"operation":   "remove_track_positions",
+		"playlist_id": playlistID,
+		"track_count": len(positions),
+	}).Info("Successfully removed track positions from playlist")
+
+	return nil
+}
+
+// ReorderPlaylist moves rangeLength tracks starting at position rangeStart so they are
+// inserted before the track at position insertBefore
+func (s *Service) ReorderPlaylist(playlistID string, rangeStart, rangeLength, insertBefore int) error {
+	if s.client == nil {
+		return errors.New("spotify client not available")
+	}
+
+	if err := s.client.ReorderPlaylist(playlistID, rangeStart, rangeLength, insertBefore); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"component":   "spotify_service",
+			"operation":   "reorder_playlist",
+			"playlist_id": playlistID,
+		}).WithError(err).Error("Failed to reorder playlist")
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"component":     "spotify_service",
+		"operation":     "reorder_playlist",
+		"playlist_id":   playlistID,
+		"range_start":   rangeStart,
+		"range_length":  rangeLength,
+		"insert_before": insertBefore,
+	}).Debug("Reordered playlist")
+
+	return nil
+}
+
+// UpdatePlaylist changes the name, description and/or visibility of a playlist
+func (s *Service) UpdatePlaylist(playlistID string, update types.PlaylistUpdate) error {
+	if s.client == nil {
+		return errors.New("spotify client not available")
+	}
+
+	err := s.client.UpdatePlaylist(playlistID, PlaylistUpdate{
+		Name:        update.Name,
+		Description: update.Description,
+		Public:      update.Public,
+	})
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"component":   "spotify_service",
+			"operation":   "update_playlist",
+			"playlist_id": playlistID,
+		}).WithError(err).Error("Failed to update playlist")
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"component":   "spotify_service",
+		"operation":   "update_playlist",
+		"playlist_id": playlistID,
+	}).Info("Updated playlist")
+
+	return nil
+}
+
+// SetPlaylistCover replaces the cover image of a playlist with a JPEG image
+func (s *Service) SetPlaylistCover(playlistID string, jpeg []byte) error {
+	if s.client == nil {
+		return errors.New("spotify client not available")
+	}
+
+	if err := s.client.SetPlaylistCover(playlistID, jpeg); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"component":   "spotify_service",
+			"operation":   "set_playlist_cover",
+			"playlist_id": playlistID,
+		}).WithError(err).Error("Failed to set playlist cover")
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"component":   "spotify_service",
+		"operation":   "set_playlist_cover",
+		"playlist_id": playlistID,
+		"image_bytes": len(jpeg),
+	}).Info("Set playlist cover")
+
+	return nil
+}
+
+// DeletePlaylist removes a playlist from the user's library
+func (s *Service) DeletePlaylist(playlistID string) error {
+	if s.client == nil {
+		return errors.New("spotify client not available")
+	}
+
+	if err := s.client.DeletePlaylist(playlistID); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"component":   "spotify_service",
+			"operation":   "delete_playlist",
+			"playlist_id": playlistID,
+		}).WithError(err).Error("Failed to delete playlist")
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"component":   "spotify_service",
+		"operation":   "delete_playlist",
+		"playlist_id": playlistID,
+	}).Info("Deleted playlist")
+
+	return nil
+}
+
+// toTypesTrack converts a client track to the shared track type
+func toTypesTrack(track Track) types.Track {
+	artists := make([]types.Artist, len(track.Artists))
+	for i, artist := range track.Artists {
+		artists[i] = types.Artist{
+			ID:     artist.ID,
+			Name:   artist.Name,
+			URI:    artist.URI,
+			Genres: artist.Genres,
+		}
+	}
+
+	return types.Track{
+		ID:       track.ID,
+		Name:     track.Name,
+		URI:      track.URI,
+		Artists:  artists,
+		Duration: track.Duration,
+		Album: types.Album{
+			ID:   track.Album.ID,
+			Name: track.Album.Name,
+			Type: track.Album.Type,
+		},
+		ISRC: track.ISRC,
+	}
+}
+
 // CreatePlaylist creates a new playlist with the given name and description
 func (s *Service) CreatePlaylist(name, description string, public bool) (*types.Playlist, error) {
 	if s.client == nil {
@@ -318,11 +630,13 @@ func (s *Service) CreatePlaylist(name, description string, public bool) (*types.
 	}).Info("Successfully created playlist")
 
 	return &types.Playlist{
-		ID:         playlist.ID,
-		Name:       playlist.Name,
-		URI:        playlist.URI,
-		TrackCount: playlist.TrackCount,
-		EmbedURL:   playlist.EmbedURL,
-		IsIncoming: playlist.IsIncoming,
+		ID:          playlist.ID,
+		Name:        playlist.Name,
+		Description: playlist.Description,
+		URI:         playlist.URI,
+		TrackCount:  playlist.TrackCount,
+		EmbedURL:    playlist.EmbedURL,
+		IsIncoming:  playlist.IsIncoming,
+		Public:      playlist.Public,
 	}, nil
 }
@@ -1,9 +1,12 @@
 package spotify
 
 import (
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/sirupsen/logrus"
+	"github.com/toozej/kmhd2spotify/internal/types"
 	"github.com/toozej/kmhd2spotify/pkg/config"
 )
 
@@ -111,6 +114,32 @@ func TestService_SearchArtist(t *testing.T) {
 	}
 }
 
+func TestService_SearchArtists(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	service := &Service{logger: logger}
+	if _, err := service.SearchArtists("test artist", 5); err == nil {
+		t.Error("SearchArtists() expected error without a client")
+	}
+
+	service = NewService(config.SpotifyConfig{
+		ClientID:      "invalid-id",
+		RedirectURL:   "http://localhost:8080/callback",
+		TokenFilePath: filepath.Join(t.TempDir(), "token.json"),
+	}, logger)
+	if service.client == nil {
+		return
+	}
+	artists, err := service.SearchArtists("test artist", 5)
+	if err == nil {
+		t.Error("SearchArtists() expected error when not authenticated")
+	}
+	if artists != nil {
+		t.Error("SearchArtists() expected nil artists with error")
+	}
+}
+
 func TestService_GetUserPlaylists(t *testing.T) {
 	logger := logrus.New()
 	logger.SetLevel(logrus.ErrorLevel)
@@ -204,6 +233,66 @@ func TestService_CheckTracksInPlaylist(t *testing.T) {
 	}
 }
 
+func TestService_PlaylistEditingRequiresAuth(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	cfg := config.SpotifyConfig{
+		ClientID:      "invalid-id",
+		ClientSecret:  "invalid-secret",
+		TokenFilePath: filepath.Join(t.TempDir(), "token.json"),
+	}
+
+	service := NewService(cfg, logger)
+	if service.client == nil {
+		// Expected with invalid credentials
+		return
+	}
+
+	// Test with empty tracks (should error before credentials)
+	err := service.RemoveTracksFromPlaylist("test-playlist", []string{})
+	if err == nil || err.Error() != "no tracks provided to remove" {
+		t.Errorf("RemoveTracksFromPlaylist() error = %v, want no tracks provided to remove", err)
+	}
+	err = service.RemoveTrackPositions("test-playlist", map[string][]int{})
+	if err == nil || err.Error() != "no tracks provided to remove" {
+		t.Errorf("RemoveTrackPositions() error = %v, want no tracks provided to remove", err)
+	}
+	err = service.UpdatePlaylist("test-playlist", types.PlaylistUpdate{})
+	if err == nil || err.Error() != "no playlist changes provided" {
+		t.Errorf("UpdatePlaylist() error = %v, want no playlist changes provided", err)
+	}
+	err = service.SetPlaylistCover("test-playlist", nil)
+	if err == nil || err.Error() != "no cover image provided" {
+		t.Errorf("SetPlaylistCover() error = %v, want no cover image provided", err)
+	}
+
+	if _, err := service.GetPlaylistTracks("test-playlist"); err == nil {
+		t.Error("GetPlaylistTracks() expected error when not authenticated")
+	}
+	if _, err := service.GetPlaylistItems("test-playlist"); err == nil {
+		t.Error("GetPlaylistItems() expected error when not authenticated")
+	}
+	if err := service.RemoveTracksFromPlaylist("test-playlist", []string{"track1"}); err == nil {
+		t.Error("RemoveTracksFromPlaylist() expected error when not authenticated")
+	}
+	if err := service.RemoveTrackPositions("test-playlist", map[string][]int{"track1": {2}}); err == nil {
+		t.Error("RemoveTrackPositions() expected error when not authenticated")
+	}
+	if err := service.ReorderPlaylist("test-playlist", 1, 1, 0); err == nil {
+		t.Error("ReorderPlaylist() expected error when not authenticated")
+	}
+	if err := service.UpdatePlaylist("test-playlist", types.PlaylistUpdate{Name: "Renamed"}); err == nil {
+		t.Error("UpdatePlaylist() expected error when not authenticated")
+	}
+	if err := service.SetPlaylistCover("test-playlist", []byte{0xff, 0xd8, 0xff}); err == nil {
+		t.Error("SetPlaylistCover() expected error when not authenticated")
+	}
+	if err := service.DeletePlaylist("test-playlist"); err == nil {
+		t.Error("DeletePlaylist() expected error when not authenticated")
+	}
+}
+
 // Test the top 5 tracks limitation logic with mock data
 func TestTop5TracksLimitation(t *testing.T) {
 	// This test verifies the logic for limiting tracks to 5
@@ -309,3 +398,106 @@ func TestNewService(t *testing.T) {
 		})
 	}
 }
+
+func TestService_ValidateToken(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	// Without a client the token can never be valid
+	service := &Service{client: nil, logger: logger}
+	if err := service.ValidateToken(); err == nil {
+		t.Error("ValidateToken() expected error without client")
+	}
+
+	// A client that has not completed authentication has no valid token
+	service = NewService(config.SpotifyConfig{
+		ClientID:      "test-id",
+		ClientSecret:  "test-secret",
+		RedirectURL:   "http://127.0.0.1:8080/callback",
+		TokenFilePath: t.TempDir() + "/token.json",
+	}, logger)
+	if service.client == nil {
+		t.Fatal("NewService() expected a client")
+	}
+	if err := service.ValidateToken(); err == nil {
+		t.Error("ValidateToken() expected error before authentication")
+	}
+}
+
+func TestService_ExportImportToken(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	// Without a client there is nothing to export or import into
+	service := &Service{client: nil, logger: logger}
+	if _, err := service.ExportToken(); err == nil {
+		t.Error("ExportToken() expected error without client")
+	}
+	if err := service.ImportToken("anything"); err == nil {
+		t.Error("ImportToken() expected error without client")
+	}
+
+	service = NewService(config.SpotifyConfig{
+		ClientID:      "test-id",
+		ClientSecret:  "test-secret",
+		RedirectURL:   "http://127.0.0.1:8080/callback",
+		TokenFilePath: t.TempDir() + "/token.json",
+	}, logger)
+	if service.client == nil {
+		t.Fatal("NewService() expected a client")
+	}
+
+	if _, err := service.ExportToken(); err == nil {
+		t.Error("ExportToken() expected error before authentication")
+	}
+
+	// A token issued to another Spotify app is rejected before any API call
+	blob, err := EncodeTokenBlob(TokenBlob{ClientID: "other-id", Token: TokenData{RefreshToken: "refresh"}})
+	if err != nil {
+		t.Fatalf("EncodeTokenBlob() unexpected error: %v", err)
+	}
+	if err := service.ImportToken(blob); err == nil || !strings.Contains(err.Error(), "other-id") {
+		t.Errorf("ImportToken() expected client ID mismatch error, got %v", err)
+	}
+
+	if err := service.ImportToken("garbage"); err == nil {
+		t.Error("ImportToken() expected error for invalid blob")
+	}
+}
+
+func TestService_GetAuthURL_PKCE(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	tests := []struct {
+		name         string
+		clientSecret string
+		usePKCE      bool
+		wantPKCE     bool
+	}{
+		{name: "client secret", clientSecret: "test-secret", wantPKCE: false},
+		{name: "no client secret", clientSecret: "", wantPKCE: true},
+		{name: "forced PKCE", clientSecret: "test-secret", usePKCE: true, wantPKCE: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewService(config.SpotifyConfig{
+				ClientID:      "test-id",
+				ClientSecret:  tt.clientSecret,
+				UsePKCE:       tt.usePKCE,
+				RedirectURL:   "http://127.0.0.1:8080/callback",
+				TokenFilePath: t.TempDir() + "/token.json",
+			}, logger)
+			if service.client == nil {
+				t.Fatal("NewService() expected a client")
+			}
+
+			authURL := service.GetAuthURL()
+			hasChallenge := strings.Contains(authURL, "code_challenge=") && strings.Contains(authURL, "code_challenge_method=S256")
+			if hasChallenge != tt.wantPKCE {
+				t.Errorf("GetAuthURL() = %q, expected PKCE challenge: %v", authURL, tt.wantPKCE)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package spotify
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// tokenBlobPrefix identifies and versions exported token blobs.
+const tokenBlobPrefix = "kmhd2spotify-token-v1:"
+
+// TokenBlob is a portable Spotify token, for authenticating on one machine and
+// running on another. Refresh tokens are bound to the Spotify app that issued them,
+// so the blob records its client ID.
+type TokenBlob struct {
+	ClientID string    `json:"client_id"`
+	Token    TokenData `json:"token"`
+}
+
+// EncodeTokenBlob encodes a token as a single-line string that is safe to copy and paste.
+func EncodeTokenBlob(blob TokenBlob) (string, error) {
+	// #nosec G117 - the blob intentionally carries the token for transfer
+	data, err := json.Marshal(blob)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal token blob: %w", err)
+	}
+	return tokenBlobPrefix + base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeTokenBlob decodes a string produced by EncodeTokenBlob.
+func DecodeTokenBlob(encoded string) (*TokenBlob, error) {
+	encoded = strings.TrimSpace(encoded)
+	if !strings.HasPrefix(encoded, tokenBlobPrefix) {
+		return nil, fmt.Errorf("not a kmhd2spotify token blob")
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(encoded, tokenBlobPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode token blob: %w", err)
+	}
+
+	var blob TokenBlob
+	if err := json.Unmarshal(data, &blob); err != nil {
+		return nil, fmt.Errorf("failed to parse token blob: %w", err)
+	}
+	if blob.Token.RefreshToken == "" {
+		return nil, fmt.Errorf("token blob has no refresh token")
+	}
+	return &blob, nil
+}
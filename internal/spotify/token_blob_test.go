@@ -0,0 +1,63 @@
+package spotify
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenBlobRoundTrip(t *testing.T) {
+	blob := TokenBlob{
+		ClientID: "test-id",
+		Token: TokenData{
+			AccessToken:  "access",
+			RefreshToken: "refresh",
+			TokenType:    "Bearer",
+			Expiry:       time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		},
+	}
+
+	encoded, err := EncodeTokenBlob(blob)
+	if err != nil {
+		t.Fatalf("EncodeTokenBlob() unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(encoded, tokenBlobPrefix) {
+		t.Errorf("EncodeTokenBlob() = %q, expected prefix %q", encoded, tokenBlobPrefix)
+	}
+	if strings.ContainsAny(encoded, " \n") {
+		t.Errorf("EncodeTokenBlob() = %q, expected a single token", encoded)
+	}
+
+	decoded, err := DecodeTokenBlob("  " + encoded + "\n")
+	if err != nil {
+		t.Fatalf("DecodeTokenBlob() unexpected error: %v", err)
+	}
+	if decoded.ClientID != blob.ClientID || decoded.Token.RefreshToken != blob.Token.RefreshToken || !decoded.Token.Expiry.Equal(blob.Token.Expiry) {
+		t.Errorf("DecodeTokenBlob() = %+v, expected %+v", decoded, blob)
+	}
+}
+
+func TestDecodeTokenBlobErrors(t *testing.T) {
+	noRefresh, err := EncodeTokenBlob(TokenBlob{ClientID: "test-id", Token: TokenData{AccessToken: "access"}})
+	if err != nil {
+		t.Fatalf("EncodeTokenBlob() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{name: "missing prefix", encoded: "eyJjbGllbnRfaWQiOiJ4In0"},
+		{name: "invalid base64", encoded: tokenBlobPrefix + "!!!"},
+		{name: "invalid json", encoded: tokenBlobPrefix + "bm90IGpzb24"},
+		{name: "missing refresh token", encoded: noRefresh},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeTokenBlob(tt.encoded); err == nil {
+				t.Errorf("DecodeTokenBlob(%q) expected error", tt.encoded)
+			}
+		})
+	}
+}
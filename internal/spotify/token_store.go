@@ -0,0 +1,321 @@
+package spotify
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/toozej/kmhd2spotify/pkg/config"
+)
+
+// Token store backends selectable with SPOTIFY_TOKEN_STORE.
+const (
+	TokenStoreFile      = "file"
+	TokenStoreEncrypted = "encrypted"
+	TokenStoreSecret    = "secret"
+)
+
+// ErrNoStoredToken is returned by TokenStore.Load when no token has been stored yet.
+var ErrNoStoredToken = errors.New("no stored token")
+
+// TokenStore persists the Spotify token between runs.
+type TokenStore interface {
+	// Name describes the store for logging.
+	Name() string
+	// Load returns the stored token, or ErrNoStoredToken if there is none.
+	Load() (*TokenData, error)
+	// Save stores the token, replacing any previous one.
+	Save(token TokenData) error
+}
+
+// NewTokenStore creates the token store selected in the configuration.
+func NewTokenStore(cfg config.SpotifyConfig) (TokenStore, error) {
+	tokenFile, err := cfg.GetTokenFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.TokenStore {
+	case "", TokenStoreFile:
+		return NewFileTokenStore(tokenFile), nil
+	case TokenStoreEncrypted:
+		passphrase, err := cfg.GetTokenKey()
+		if err != nil {
+			return nil, err
+		}
+		return NewEncryptedFileTokenStore(tokenFile, passphrase)
+	case TokenStoreSecret:
+		return NewSecretTokenStore(cfg.TokenSecretPath, NewFileTokenStore(tokenFile)), nil
+	default:
+		return nil, fmt.Errorf("unknown token store %q", cfg.TokenStore)
+	}
+}
+
+// FileTokenStore stores the token as plain JSON in a file readable only by the owner.
+type FileTokenStore struct {
+	path string
+}
+
+// NewFileTokenStore creates a plain file token store.
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{path: path}
+}
+
+// Name describes the store for logging.
+func (s *FileTokenStore) Name() string {
+	return "file:" + s.path
+}
+
+// Load reads the token from the file.
+func (s *FileTokenStore) Load() (*TokenData, error) {
+	data, err := readTokenFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+	return parseTokenData(data)
+}
+
+// Save writes the token to the file.
+func (s *FileTokenStore) Save(token TokenData) error {
+	// #nosec G117 - we need to store access token as JSON for future use
+	data, err := json.MarshalIndent(token, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal token data: %w", err)
+	}
+	return writeTokenFile(s.path, data)
+}
+
+// defaultKeyIterations is the PBKDF2-SHA256 iteration count for new encrypted token files.
+const defaultKeyIterations = 600000
+
+// encryptedToken is the on-disk format of an encrypted token file. The KDF
+// parameters are stored alongside the ciphertext so they can change over time.
+type encryptedToken struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// EncryptedFileTokenStore stores the token in a file encrypted with AES-256-GCM,
+// using a key derived from a passphrase with PBKDF2-SHA256.
+type EncryptedFileTokenStore struct {
+	path       string
+	passphrase string
+	iterations int
+}
+
+// NewEncryptedFileTokenStore creates an encrypted file token store.
+func NewEncryptedFileTokenStore(path, passphrase string) (*EncryptedFileTokenStore, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("encrypted token store requires SPOTIFY_TOKEN_KEY or SPOTIFY_TOKEN_KEY_FILE")
+	}
+	return &EncryptedFileTokenStore{path: path, passphrase: passphrase, iterations: defaultKeyIterations}, nil
+}
+
+// Name describes the store for logging.
+func (s *EncryptedFileTokenStore) Name() string {
+	return "encrypted:" + s.path
+}
+
+// Load reads and decrypts the token. A plain token file left by the file store is
+// accepted too, so switching to encryption doesn't require re-authentication; it is
+// encrypted on the next save.
+func (s *EncryptedFileTokenStore) Load() (*TokenData, error) {
+	data, err := readTokenFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var envelope encryptedToken
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to parse encrypted token file: %w", err)
+	}
+	if envelope.Ciphertext == nil {
+		return parseTokenData(data)
+	}
+	if envelope.Version != 1 || envelope.KDF != "pbkdf2-sha256" {
+		return nil, fmt.Errorf("unsupported encrypted token format (version %d, kdf %q)", envelope.Version, envelope.KDF)
+	}
+
+	gcm, err := s.cipher(envelope.Salt, envelope.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, envelope.Nonce, envelope.Ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt token file, check SPOTIFY_TOKEN_KEY: %w", err)
+	}
+	return parseTokenData(plaintext)
+}
+
+// Save encrypts and writes the token with a fresh salt and nonce.
+func (s *EncryptedFileTokenStore) Save(token TokenData) error {
+	// #nosec G117 - the token is encrypted before being written
+	plaintext, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("failed to marshal token data: %w", err)
+	}
+
+	envelope := encryptedToken{
+		Version:    1,
+		KDF:        "pbkdf2-sha256",
+		Iterations: s.iterations,
+		Salt:       make([]byte, 16),
+	}
+	if _, err := rand.Read(envelope.Salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	gcm, err := s.cipher(envelope.Salt, envelope.Iterations)
+	if err != nil {
+		return err
+	}
+	envelope.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(envelope.Nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	envelope.Ciphertext = gcm.Seal(nil, envelope.Nonce, plaintext, nil)
+
+	data, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal encrypted token: %w", err)
+	}
+	return writeTokenFile(s.path, data)
+}
+
+// cipher derives the key for the given salt and returns an AES-GCM cipher.
+func (s *EncryptedFileTokenStore) cipher(salt []byte, iterations int) (cipher.AEAD, error) {
+	if iterations < 1 {
+		return nil, fmt.Errorf("invalid key derivation iteration count %d", iterations)
+	}
+	key, err := pbkdf2.Key(sha256.New, s.passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive token key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// SecretTokenStore reads the token from a read-only mount such as a Docker secret,
+// and keeps refreshed tokens in a separate writable cache. The secret may hold the
+// token as JSON or a blob from 'kmhd2spotify auth export'.
+type SecretTokenStore struct {
+	secretPath string
+	cache      TokenStore
+}
+
+// NewSecretTokenStore creates a token store backed by a read-only secret and a writable cache.
+func NewSecretTokenStore(secretPath string, cache TokenStore) *SecretTokenStore {
+	return &SecretTokenStore{secretPath: secretPath, cache: cache}
+}
+
+// Name describes the store for logging.
+func (s *SecretTokenStore) Name() string {
+	return "secret:" + s.secretPath + " (cache " + s.cache.Name() + ")"
+}
+
+// Load returns whichever of the secret and the cached token expires later, so a
+// refreshed token in the cache is preferred until the secret is replaced with a
+// newer one.
+func (s *SecretTokenStore) Load() (*TokenData, error) {
+	secret, secretErr := s.loadSecret()
+	cached, cacheErr := s.cache.Load()
+
+	switch {
+	case secretErr != nil && cacheErr != nil:
+		if errors.Is(secretErr, ErrNoStoredToken) {
+			return nil, cacheErr
+		}
+		return nil, secretErr
+	case secretErr != nil:
+		return cached, nil
+	case cacheErr != nil:
+		return secret, nil
+	case cached.Expiry.After(secret.Expiry):
+		return cached, nil
+	default:
+		return secret, nil
+	}
+}
+
+// Save writes the token to the cache; the secret is never modified.
+func (s *SecretTokenStore) Save(token TokenData) error {
+	return s.cache.Save(token)
+}
+
+// loadSecret reads the token from the secret mount.
+func (s *SecretTokenStore) loadSecret() (*TokenData, error) {
+	data, err := readTokenFile(s.secretPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, tokenBlobPrefix) {
+		blob, err := DecodeTokenBlob(trimmed)
+		if err != nil {
+			return nil, err
+		}
+		return &blob.Token, nil
+	}
+	return parseTokenData(data)
+}
+
+// readTokenFile reads a token file, returning ErrNoStoredToken if it doesn't exist.
+func readTokenFile(path string) ([]byte, error) {
+	if path == "" {
+		return nil, ErrNoStoredToken
+	}
+
+	data, err := os.ReadFile(path) // #nosec G304 -- path comes from configuration
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoStoredToken
+		}
+		return nil, fmt.Errorf("failed to read token file: %w", err)
+	}
+	return data, nil
+}
+
+// parseTokenData parses a JSON token.
+func parseTokenData(data []byte) (*TokenData, error) {
+	var token TokenData
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("failed to parse token file: %w", err)
+	}
+	if token.AccessToken == "" && token.RefreshToken == "" {
+		return nil, fmt.Errorf("token file contains no token")
+	}
+	return &token, nil
+}
+
+// writeTokenFile writes a token file atomically with owner-only permissions.
+func writeTokenFile(path string, data []byte) error {
+	if path == "" {
+		return nil
+	}
+
+	// Write to temporary file first, then rename for atomic operation
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+
+	if err := os.Rename(tempFile, path); err != nil {
+		_ = os.Remove(tempFile) // Clean up temp file
+		return fmt.Errorf("failed to rename token file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package spotify
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toozej/kmhd2spotify/pkg/config"
+)
+
+// testToken returns a token expiring at the given time
+func testToken(refresh string, expiry time.Time) TokenData {
+	return TokenData{
+		AccessToken:  "access-" + refresh,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		Expiry:       expiry.UTC(),
+	}
+}
+
+// newTestEncryptedStore creates an encrypted store with a fast key derivation for tests
+func newTestEncryptedStore(t *testing.T, path, passphrase string) *EncryptedFileTokenStore {
+	t.Helper()
+	store, err := NewEncryptedFileTokenStore(path, passphrase)
+	if err != nil {
+		t.Fatalf("NewEncryptedFileTokenStore() unexpected error: %v", err)
+	}
+	store.iterations = 1000
+	return store
+}
+
+func TestFileTokenStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token.json")
+	store := NewFileTokenStore(path)
+
+	if _, err := store.Load(); !errors.Is(err, ErrNoStoredToken) {
+		t.Errorf("Load() = %v, expected ErrNoStoredToken", err)
+	}
+
+	token := testToken("refresh", time.Now().Add(time.Hour))
+	if err := store.Save(token); err != nil {
+		t.Fatalf("Save() unexpected error: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("expected token file: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600, got %v", info.Mode().Perm())
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if loaded.RefreshToken != token.RefreshToken || !loaded.Expiry.Equal(token.Expiry) {
+		t.Errorf("Load() = %+v, expected %+v", loaded, token)
+	}
+}
+
+func TestEncryptedFileTokenStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token.json")
+	store := newTestEncryptedStore(t, path, "correct horse")
+
+	token := testToken("secret-refresh", time.Now().Add(time.Hour))
+	if err := store.Save(token); err != nil {
+		t.Fatalf("Save() unexpected error: %v", err)
+	}
+
+	// The token must not be readable from the file
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read token file: %v", err)
+	}
+	if strings.Contains(string(data), "secret-refresh") || strings.Contains(string(data), "access-") {
+		t.Errorf("expected token file to be encrypted, got %s", data)
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if loaded.RefreshToken != token.RefreshToken {
+		t.Errorf("Load() refresh token = %q, expected %q", loaded.RefreshToken, token.RefreshToken)
+	}
+
+	// A wrong passphrase cannot decrypt it
+	wrong := newTestEncryptedStore(t, path, "wrong")
+	if _, err := wrong.Load(); err == nil {
+		t.Error("Load() with wrong passphrase expected error")
+	}
+}
+
+func TestEncryptedFileTokenStoreMigratesPlainToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token.json")
+	token := testToken("plain-refresh", time.Now().Add(time.Hour))
+	if err := NewFileTokenStore(path).Save(token); err != nil {
+		t.Fatalf("Save() unexpected error: %v", err)
+	}
+
+	store := newTestEncryptedStore(t, path, "passphrase")
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error for plain token: %v", err)
+	}
+	if loaded.RefreshToken != "plain-refresh" {
+		t.Errorf("Load() refresh token = %q, expected plain-refresh", loaded.RefreshToken)
+	}
+
+	if err := store.Save(*loaded); err != nil {
+		t.Fatalf("Save() unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "plain-refresh") {
+		t.Error("expected token to be encrypted after saving")
+	}
+}
+
+func TestNewEncryptedFileTokenStoreRequiresPassphrase(t *testing.T) {
+	if _, err := NewEncryptedFileTokenStore("token.json", ""); err == nil {
+		t.Error("NewEncryptedFileTokenStore() expected error without passphrase")
+	}
+}
+
+func TestSecretTokenStore(t *testing.T) {
+	dir := t.TempDir()
+	secretPath := filepath.Join(dir, "secret")
+	cachePath := filepath.Join(dir, "cache.json")
+	store := NewSecretTokenStore(secretPath, NewFileTokenStore(cachePath))
+
+	if _, err := store.Load(); !errors.Is(err, ErrNoStoredToken) {
+		t.Errorf("Load() = %v, expected ErrNoStoredToken", err)
+	}
+
+	// The secret may be an exported blob
+	now := time.Now()
+	blob, err := EncodeTokenBlob(TokenBlob{ClientID: "test-id", Token: testToken("from-secret", now.Add(time.Hour))})
+	if err != nil {
+		t.Fatalf("EncodeTokenBlob() unexpected error: %v", err)
+	}
+	if err := os.WriteFile(secretPath, []byte(blob+"\n"), 0400); err != nil {
+		t.Fatalf("failed to write secret: %v", err)
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if loaded.RefreshToken != "from-secret" {
+		t.Errorf("Load() refresh token = %q, expected from-secret", loaded.RefreshToken)
+	}
+
+	// Refreshed tokens go to the cache and are preferred while newer
+	if err := store.Save(testToken("refreshed", now.Add(2*time.Hour))); err != nil {
+		t.Fatalf("Save() unexpected error: %v", err)
+	}
+	secretData, _ := os.ReadFile(secretPath)
+	if strings.TrimSpace(string(secretData)) != blob {
+		t.Error("expected secret to be left unchanged")
+	}
+	loaded, err = store.Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if loaded.RefreshToken != "refreshed" {
+		t.Errorf("Load() refresh token = %q, expected refreshed", loaded.RefreshToken)
+	}
+
+	// A replaced secret with a later expiry wins over the cache
+	if err := os.Chmod(secretPath, 0600); err != nil {
+		t.Fatalf("failed to chmod secret: %v", err)
+	}
+	if err := NewFileTokenStore(secretPath).Save(testToken("new-secret", now.Add(3*time.Hour))); err != nil {
+		t.Fatalf("failed to replace secret: %v", err)
+	}
+	loaded, err = store.Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if loaded.RefreshToken != "new-secret" {
+		t.Errorf("Load() refresh token = %q, expected new-secret", loaded.RefreshToken)
+	}
+}
+
+func TestNewTokenStore(t *testing.T) {
+	dir := t.TempDir()
+	base := config.SpotifyConfig{TokenFilePath: filepath.Join(dir, "token.json")}
+
+	tests := []struct {
+		name       string
+		modify     func(cfg *config.SpotifyConfig)
+		wantPrefix string
+		wantErr    bool
+	}{
+		{name: "default", modify: func(cfg *config.SpotifyConfig) {}, wantPrefix: "file:"},
+		{name: "file", modify: func(cfg *config.SpotifyConfig) { cfg.TokenStore = TokenStoreFile }, wantPrefix: "file:"},
+		{name: "encrypted", modify: func(cfg *config.SpotifyConfig) {
+			cfg.TokenStore = TokenStoreEncrypted
+			cfg.TokenKey = "passphrase"
+		}, wantPrefix: "encrypted:"},
+		{name: "encrypted without key", modify: func(cfg *config.SpotifyConfig) { cfg.TokenStore = TokenStoreEncrypted }, wantErr: true},
+		{name: "secret", modify: func(cfg *config.SpotifyConfig) {
+			cfg.TokenStore = TokenStoreSecret
+			cfg.TokenSecretPath = filepath.Join(dir, "secret")
+		}, wantPrefix: "secret:"},
+		{name: "unknown", modify: func(cfg *config.SpotifyConfig) { cfg.TokenStore = "vault" }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			tt.modify(&cfg)
+
+			store, err := NewTokenStore(cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("NewTokenStore() expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewTokenStore() unexpected error: %v", err)
+			}
+			if !strings.HasPrefix(store.Name(), tt.wantPrefix) {
+				t.Errorf("NewTokenStore() = %s, expected %s store", store.Name(), tt.wantPrefix)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package stats
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+// htmlTemplate renders a report as a self-contained page. Charts are inline SVG and
+// styles are inline, so the page works offline and can be shared as a single file.
+const htmlTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>KMHD listening statistics</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2rem auto; max-width: 960px; padding: 0 1rem; color: #1d1d1f; background: #fafafa; }
+h1 { margin-bottom: 0.25rem; }
+h2 { margin-top: 2.5rem; border-bottom: 1px solid #ddd; padding-bottom: 0.25rem; }
+.meta { color: #666; margin-top: 0; }
+.cards { display: grid; grid-template-columns: repeat(auto-fit, minmax(160px, 1fr)); gap: 1rem; margin-top: 1.5rem; }
+.card { background: #fff; border: 1px solid #e5e5e5; border-radius: 8px; padding: 1rem; }
+.card .value { font-size: 1.6rem; font-weight: 600; }
+.card .label { color: #666; font-size: 0.85rem; }
+table { border-collapse: collapse; width: 100%; background: #fff; }
+th, td { text-align: left; padding: 0.4rem 0.6rem; border-bottom: 1px solid #eee; }
+td.num, th.num { text-align: right; font-variant-numeric: tabular-nums; }
+svg text { font-size: 10px; fill: #555; }
+.bar { fill: #1db954; }
+.bar.failure { fill: #e0564c; }
+.empty { color: #666; }
+</style>
+</head>
+<body>
+<h1>📻 KMHD listening statistics</h1>
+<p class="meta">{{if .Plays}}{{date .From}} to {{date .Until}} · {{end}}{{.Timezone}} · generated {{datetime .GeneratedAt}}</p>
+
+<div class="cards">
+<div class="card"><div class="value">{{.Plays}}</div><div class="label">plays</div></div>
+<div class="card"><div class="value">{{.UniqueArtists}}</div><div class="label">unique artists</div></div>
+<div class="card"><div class="value">{{.UniqueRecordings}}</div><div class="label">unique recordings</div></div>
+<div class="card"><div class="value">{{percent .RepeatRate}}</div><div class="label">repeat rate</div></div>
+<div class="card"><div class="value">{{percent .MatchFailureRate}}</div><div class="label">match failure rate</div></div>
+<div class="card"><div class="value">{{printf "%.2f" .AverageConfidence}}</div><div class="label">average match confidence</div></div>
+</div>
+
+<h2>Plays by hour of day</h2>
+{{template "columns" .PlaysByHour}}
+
+<h2>Plays by day of week</h2>
+{{template "columns" .PlaysByWeekday}}
+
+<h2>Months</h2>
+{{if .Months}}
+<table>
+<tr><th>Month</th><th class="num">Plays</th><th class="num">Unique artists</th><th class="num">Matched</th><th class="num">Avg confidence</th><th>Match failure rate</th></tr>
+{{range .Months}}
+<tr><td>{{.Month}}</td><td class="num">{{.Plays}}</td><td class="num">{{.UniqueArtists}}</td><td class="num">{{.MatchedPlays}}</td><td class="num">{{printf "%.2f" .AverageConfidence}}</td>
+<td><svg width="200" height="14" role="img" aria-label="{{percent .MatchFailureRate}}"><rect class="bar failure" x="0" y="2" height="10" width="{{scale .MatchFailureRate 1 160}}"></rect><text x="{{add (scale .MatchFailureRate 1 160) 4}}" y="11">{{percent .MatchFailureRate}}</text></svg></td></tr>
+{{end}}
+</table>
+{{else}}<p class="empty">No plays recorded yet.</p>{{end}}
+
+<h2>Longest-running albums</h2>
+{{if .LongestRunning}}
+<table>
+<tr><th>Album</th><th>Artist</th><th class="num">Plays</th><th>First played</th><th>Last played</th><th class="num">Days</th></tr>
+{{range .LongestRunning}}<tr><td>{{.Name}}</td><td>{{.Artist}}</td><td class="num">{{.Plays}}</td><td>{{date .FirstPlayed}}</td><td>{{date .LastPlayed}}</td><td class="num">{{.Days}}</td></tr>
+{{end}}
+</table>
+{{else}}<p class="empty">No album has been played more than once yet.</p>{{end}}
+
+<h2>New to KMHD this month</h2>
+{{if .NewArtists}}
+<table>
+<tr><th>Artist</th><th class="num">Plays</th><th>First played</th></tr>
+{{range .NewArtists}}<tr><td>{{.Name}}</td><td class="num">{{.Plays}}</td><td>{{datetime .FirstPlayed}}</td></tr>
+{{end}}
+</table>
+{{else}}<p class="empty">No new artists this month.</p>{{end}}
+</body>
+</html>
+
+{{define "columns"}}{{$max := maxPlays .}}{{$width := len .}}
+<svg viewBox="0 0 {{mul $width 36}} 150" width="100%" role="img">
+{{range $i, $bucket := .}}<g transform="translate({{mul $i 36}},0)">
+<rect class="bar" x="4" y="{{sub 120 (scale $bucket.Plays $max 110)}}" width="28" height="{{scale $bucket.Plays $max 110}}"><title>{{$bucket.Label}}: {{$bucket.Plays}} plays</title></rect>
+<text x="18" y="{{sub 116 (scale $bucket.Plays $max 110)}}" text-anchor="middle">{{$bucket.Plays}}</text>
+<text x="18" y="136" text-anchor="middle">{{short $bucket.Label}}</text>
+</g>
+{{end}}</svg>
+{{end}}`
+
+// reportTemplate is the parsed HTML report template.
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"date":     func(t time.Time) string { return t.Format("2006-01-02") },
+	"datetime": func(t time.Time) string { return t.Format("2006-01-02 15:04 MST") },
+	"percent":  func(rate float64) string { return fmt.Sprintf("%.1f%%", rate*100) },
+	"maxPlays": maxPlays,
+	"scale":    scale,
+	"add":      func(a, b float64) float64 { return a + b },
+	"sub":      func(a, b float64) float64 { return a - b },
+	"mul":      func(a, b int) int { return a * b },
+	"short":    shortLabel,
+}).Parse(htmlTemplate))
+
+// WriteHTML writes the report as a self-contained HTML page with charts.
+func WriteHTML(w io.Writer, report Report) error {
+	if err := reportTemplate.Execute(w, report); err != nil {
+		return fmt.Errorf("failed to render HTML report: %w", err)
+	}
+	return nil
+}
+
+// maxPlays returns the largest play count of the buckets.
+func maxPlays(buckets []Bucket) int {
+	largest := 0
+	for _, bucket := range buckets {
+		largest = max(largest, bucket.Plays)
+	}
+	return largest
+}
+
+// scale maps value out of total onto a length of size, for drawing bars.
+func scale(value, total any, size float64) float64 {
+	v, t := toFloat(value), toFloat(total)
+	if t <= 0 {
+		return 0
+	}
+	return v / t * size
+}
+
+// shortLabel abbreviates a bucket label to fit under its column: hours to the hour
+// and days to three letters.
+func shortLabel(label string) string {
+	if hour, _, ok := strings.Cut(label, ":"); ok {
+		return hour
+	}
+	if len(label) > 3 {
+		return label[:3]
+	}
+	return label
+}
+
+// toFloat converts the numbers used in the template to float64.
+func toFloat(value any) float64 {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case float64:
+		return v
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,43 @@
+package stats
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/history"
+)
+
+func TestWriteHTML(t *testing.T) {
+	now := time.Date(2025, time.October, 17, 12, 0, 0, 0, time.UTC)
+	report := Compute([]history.Play{
+		play("Miles Davis", "So What", "Kind of Blue", now.Add(-48*time.Hour), 0.9),
+		play("Miles Davis", "So What", "Kind of Blue", now.Add(-time.Hour), 0.9),
+		play("<script>alert(1)</script>", "Evil", "", now, 0),
+	}, time.Time{}, now, time.UTC)
+
+	var out bytes.Buffer
+	require.NoError(t, WriteHTML(&out, report))
+	page := out.String()
+
+	assert.Contains(t, page, "<!DOCTYPE html>")
+	assert.Contains(t, page, "<svg")
+	assert.Contains(t, page, "Kind of Blue")
+	assert.Contains(t, page, "33.3%")
+	assert.NotContains(t, page, "<script>", "artist names are escaped")
+	assert.NotContains(t, page, "http", "the report loads nothing from the network")
+}
+
+func TestWriteHTMLEmpty(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, WriteHTML(&out, Compute(nil, time.Time{}, time.Now(), time.UTC)))
+	assert.Contains(t, out.String(), "No plays recorded yet.")
+}
+
+func TestShortLabel(t *testing.T) {
+	assert.Equal(t, "07", shortLabel("07:00"))
+	assert.Equal(t, "Wed", shortLabel("Wednesday"))
+}
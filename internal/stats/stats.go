@@ -0,0 +1,277 @@
+// Package stats computes listening statistics from the KMHD play history.
+package stats
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/toozej/kmhd2spotify/internal/history"
+)
+
+// Bucket is the number of plays in an hour of the day or a day of the week.
+type Bucket struct {
+	Label string `json:"label"`
+	Plays int    `json:"plays"`
+}
+
+// Month summarizes the plays of a calendar month.
+type Month struct {
+	Month             string  `json:"month"`
+	Plays             int     `json:"plays"`
+	UniqueArtists     int     `json:"unique_artists"`
+	MatchedPlays      int     `json:"matched_plays"`
+	MatchFailureRate  float64 `json:"match_failure_rate"`
+	AverageConfidence float64 `json:"average_confidence"`
+}
+
+// Album is an album and how long it stayed in KMHD's rotation.
+type Album struct {
+	Name        string    `json:"name"`
+	Artist      string    `json:"artist"`
+	Plays       int       `json:"plays"`
+	FirstPlayed time.Time `json:"first_played"`
+	LastPlayed  time.Time `json:"last_played"`
+	Days        int       `json:"days"`
+}
+
+// Artist is an artist and when KMHD first played them.
+type Artist struct {
+	Name        string    `json:"name"`
+	Plays       int       `json:"plays"`
+	FirstPlayed time.Time `json:"first_played"`
+}
+
+// Report holds the listening statistics of a play history.
+type Report struct {
+	GeneratedAt       time.Time `json:"generated_at"`
+	Timezone          string    `json:"timezone"`
+	From              time.Time `json:"from"`
+	Until             time.Time `json:"until"`
+	Plays             int       `json:"plays"`
+	UniqueArtists     int       `json:"unique_artists"`
+	UniqueRecordings  int       `json:"unique_recordings"`
+	RepeatRate        float64   `json:"repeat_rate"`
+	MatchedPlays      int       `json:"matched_plays"`
+	MatchFailureRate  float64   `json:"match_failure_rate"`
+	AverageConfidence float64   `json:"average_confidence"`
+	PlaysByHour       []Bucket  `json:"plays_by_hour"`
+	PlaysByWeekday    []Bucket  `json:"plays_by_weekday"`
+	Months            []Month   `json:"months"`
+	LongestRunning    []Album   `json:"longest_running_albums"`
+	NewArtists        []Artist  `json:"new_artists_this_month"`
+}
+
+// weekdays lists the days of the week starting on Monday.
+var weekdays = []time.Weekday{
+	time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday,
+}
+
+// monthStats accumulates the plays of a month.
+type monthStats struct {
+	plays      int
+	artists    map[string]bool
+	matched    int
+	imported   int
+	attempted  int
+	failed     int
+	confidence float64
+}
+
+// Compute calculates the statistics of the plays from since onwards, or of all plays if
+// since is zero. Hours, days and months are those of the given location, and "new this
+// month" refers to the month of now. The plays should be the whole history: whether an
+// artist is new or a play is a repeat of a recording (artist and title) depends on
+// earlier plays too. Albums count as running from their first to their last play, and
+// are included if they were played since then.
+func Compute(plays []history.Play, since, now time.Time, location *time.Location) Report {
+	sorted := make([]history.Play, len(plays))
+	copy(sorted, plays)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Song.PlayedAt.Before(sorted[j].Song.PlayedAt)
+	})
+
+	report := Report{
+		GeneratedAt:    now,
+		Timezone:       location.String(),
+		PlaysByHour:    make([]Bucket, 24),
+		PlaysByWeekday: make([]Bucket, len(weekdays)),
+	}
+	for hour := range report.PlaysByHour {
+		report.PlaysByHour[hour].Label = time.Date(2000, 1, 1, hour, 0, 0, 0, time.UTC).Format("15:04")
+	}
+	for i, day := range weekdays {
+		report.PlaysByWeekday[i].Label = day.String()
+	}
+	// Recordings, artists and albums are tracked over the whole history, the remaining
+	// statistics only over the plays since the start of the report
+	recordings := make(map[string]bool)
+	reportRecordings := make(map[string]bool)
+	artists := make(map[string]*Artist)
+	reportArtists := make(map[string]bool)
+	albums := make(map[string]*Album)
+	months := make(map[string]*monthStats)
+	var monthOrder []string
+	var confidence float64
+	repeats, imported, attempted, failed := 0, 0, 0, 0
+
+	for _, play := range sorted {
+		song := play.Song
+		local := song.PlayedAt.In(location)
+		artistKey := strings.ToLower(strings.TrimSpace(song.Artist))
+		recording := artistKey + "|" + strings.ToLower(strings.TrimSpace(song.Title))
+		repeat := recordings[recording]
+		recordings[recording] = true
+
+		if artist, ok := artists[artistKey]; ok {
+			artist.Plays++
+		} else {
+			artists[artistKey] = &Artist{Name: strings.TrimSpace(song.Artist), Plays: 1, FirstPlayed: song.PlayedAt}
+		}
+
+		if albumName := strings.TrimSpace(song.Album); albumName != "" {
+			albumKey := strings.ToLower(albumName) + "|" + artistKey
+			if album, ok := albums[albumKey]; ok {
+				album.Plays++
+				album.LastPlayed = song.PlayedAt
+			} else {
+				albums[albumKey] = &Album{Name: albumName, Artist: strings.TrimSpace(song.Artist), Plays: 1, FirstPlayed: song.PlayedAt, LastPlayed: song.PlayedAt}
+			}
+		}
+
+		if song.PlayedAt.Before(since) {
+			continue
+		}
+		if report.Plays == 0 {
+			report.From = song.PlayedAt
+		}
+		report.Until = song.PlayedAt
+		report.Plays++
+		report.PlaysByHour[local.Hour()].Plays++
+		report.PlaysByWeekday[(int(local.Weekday())+6)%7].Plays++
+		reportArtists[artistKey] = true
+		reportRecordings[recording] = true
+		if repeat {
+			repeats++
+		}
+
+		monthKey := local.Format("2006-01")
+		month, ok := months[monthKey]
+		if !ok {
+			month = &monthStats{artists: make(map[string]bool)}
+			months[monthKey] = month
+			monthOrder = append(monthOrder, monthKey)
+		}
+		month.plays++
+		month.artists[artistKey] = true
+
+		if play.Match != nil && play.Match.TrackID != "" {
+			report.MatchedPlays++
+			confidence += play.Match.Confidence
+			month.matched++
+			month.confidence += play.Match.Confidence
+		}
+		if play.Imported {
+			imported++
+			month.imported++
+		}
+
+		// Match quality is measured on plays that were searched for on Spotify: matched
+		// plays that weren't imported, and plays marked unmatched
+		if play.Match != nil && play.Match.TrackID != "" {
+			if !play.Imported {
+				attempted++
+				month.attempted++
+			}
+		} else if play.Unmatched {
+			attempted++
+			failed++
+			month.attempted++
+			month.failed++
+		}
+	}
+
+	report.UniqueArtists = len(reportArtists)
+	report.UniqueRecordings = len(reportRecordings)
+	report.RepeatRate = ratio(float64(repeats), report.Plays)
+	// Imported plays were only synced because they matched, and plays that were never
+	// searched for (e.g. of excluded artists) can't have failed, so neither counts
+	report.MatchFailureRate = ratio(float64(failed), attempted)
+	report.AverageConfidence = ratio(confidence, report.MatchedPlays-imported)
+
+	for _, key := range monthOrder {
+		month := months[key]
+		report.Months = append(report.Months, Month{
+			Month:             key,
+			Plays:             month.plays,
+			UniqueArtists:     len(month.artists),
+			MatchedPlays:      month.matched,
+			MatchFailureRate:  ratio(float64(month.failed), month.attempted),
+			AverageConfidence: ratio(month.confidence, month.matched-month.imported),
+		})
+	}
+
+	// Albums played only once have not run at all
+	for _, album := range albums {
+		if album.Plays < 2 || album.LastPlayed.Before(since) {
+			continue
+		}
+		album.Days = calendarDays(album.FirstPlayed, album.LastPlayed, location)
+		report.LongestRunning = append(report.LongestRunning, *album)
+	}
+	sort.Slice(report.LongestRunning, func(i, j int) bool {
+		a, b := report.LongestRunning[i], report.LongestRunning[j]
+		if span := a.LastPlayed.Sub(a.FirstPlayed) - b.LastPlayed.Sub(b.FirstPlayed); span != 0 {
+			return span > 0
+		}
+		if a.Plays != b.Plays {
+			return a.Plays > b.Plays
+		}
+		return a.Name < b.Name
+	})
+
+	thisMonth := now.In(location).Format("2006-01")
+	for _, artist := range artists {
+		if artist.FirstPlayed.In(location).Format("2006-01") == thisMonth {
+			report.NewArtists = append(report.NewArtists, *artist)
+		}
+	}
+	sort.Slice(report.NewArtists, func(i, j int) bool {
+		a, b := report.NewArtists[i], report.NewArtists[j]
+		if a.Plays != b.Plays {
+			return a.Plays > b.Plays
+		}
+		return a.Name < b.Name
+	})
+
+	return report
+}
+
+// Top returns the report with at most n longest-running albums and new artists. A
+// non-positive n keeps everything.
+func (r Report) Top(n int) Report {
+	if n <= 0 {
+		return r
+	}
+	r.LongestRunning = r.LongestRunning[:min(n, len(r.LongestRunning))]
+	r.NewArtists = r.NewArtists[:min(n, len(r.NewArtists))]
+	return r
+}
+
+// calendarDays returns the number of calendar days from one time to another in the
+// location, counting both the first and the last day.
+func calendarDays(from, until time.Time, location *time.Location) int {
+	day := func(t time.Time) time.Time {
+		local := t.In(location)
+		return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return int(day(until).Sub(day(from)).Hours()/24) + 1
+}
+
+// ratio divides value by total, returning 0 for an empty total.
+func ratio(value float64, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return value / float64(total)
+}
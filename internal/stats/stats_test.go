@@ -0,0 +1,163 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/toozej/kmhd2spotify/internal/history"
+	"github.com/toozej/kmhd2spotify/internal/types"
+)
+
+var pacific = time.FixedZone("PDT", -7*3600)
+
+func play(artist, title, album string, playedAt time.Time, confidence float64) history.Play {
+	p := history.Play{Song: types.Song{Artist: artist, Title: title, Album: album, PlayedAt: playedAt}}
+	if confidence > 0 {
+		p.Match = &history.Match{TrackID: artist + title, Confidence: confidence}
+	} else {
+		p.Unmatched = true
+	}
+	return p
+}
+
+func TestCompute(t *testing.T) {
+	// Friday 2025-09-12 and Monday 2025-10-06, both in Pacific time
+	september := time.Date(2025, time.September, 12, 21, 0, 0, 0, pacific)
+	october := time.Date(2025, time.October, 6, 8, 0, 0, 0, pacific)
+	now := time.Date(2025, time.October, 17, 12, 0, 0, 0, pacific)
+
+	plays := []history.Play{
+		play("Miles Davis", "So What", "Kind of Blue", october, 0.8),
+		play("Miles Davis", "So What", "Kind of Blue", september, 0.9),
+		play("John Coltrane", "Giant Steps", "Giant Steps", september.Add(time.Hour), 0),
+		play("Esperanza Spalding", "Formwela 1", "Songwrights Apothecary Lab", october.Add(time.Hour), 0.7),
+		play("miles davis", "Freddie Freeloader", "Kind of Blue", october.Add(2*time.Hour), 0.6),
+	}
+
+	report := Compute(plays, time.Time{}, now, pacific)
+
+	assert.Equal(t, 5, report.Plays)
+	assert.Equal(t, 3, report.UniqueArtists)
+	assert.Equal(t, 4, report.UniqueRecordings)
+	assert.InDelta(t, 0.2, report.RepeatRate, 0.001)
+	assert.Equal(t, 4, report.MatchedPlays)
+	assert.InDelta(t, 0.2, report.MatchFailureRate, 0.001)
+	assert.InDelta(t, 0.75, report.AverageConfidence, 0.001)
+	assert.Equal(t, september, report.From)
+
+	assert.Equal(t, "21:00", report.PlaysByHour[21].Label)
+	assert.Equal(t, 1, report.PlaysByHour[21].Plays)
+	assert.Equal(t, 1, report.PlaysByHour[8].Plays)
+	assert.Equal(t, "Monday", report.PlaysByWeekday[0].Label)
+	assert.Equal(t, 3, report.PlaysByWeekday[0].Plays)
+	assert.Equal(t, 2, report.PlaysByWeekday[4].Plays)
+
+	require.Len(t, report.Months, 2)
+	assert.Equal(t, Month{Month: "2025-09", Plays: 2, UniqueArtists: 2, MatchedPlays: 1, MatchFailureRate: 0.5, AverageConfidence: 0.9}, report.Months[0])
+	assert.Equal(t, "2025-10", report.Months[1].Month)
+	assert.Zero(t, report.Months[1].MatchFailureRate)
+
+	require.Len(t, report.LongestRunning, 1, "albums played once are not running")
+	assert.Equal(t, "Kind of Blue", report.LongestRunning[0].Name)
+	assert.Equal(t, 3, report.LongestRunning[0].Plays)
+	assert.Equal(t, 25, report.LongestRunning[0].Days)
+
+	require.Len(t, report.NewArtists, 1)
+	assert.Equal(t, "Esperanza Spalding", report.NewArtists[0].Name)
+}
+
+func TestComputeSince(t *testing.T) {
+	september := time.Date(2025, time.September, 12, 21, 0, 0, 0, pacific)
+	october := time.Date(2025, time.October, 6, 8, 0, 0, 0, pacific)
+	now := time.Date(2025, time.October, 17, 12, 0, 0, 0, pacific)
+
+	plays := []history.Play{
+		play("Miles Davis", "So What", "Kind of Blue", september, 0.9),
+		play("John Coltrane", "Giant Steps", "Giant Steps", september.Add(time.Hour), 0),
+		play("Miles Davis", "So What", "Kind of Blue", october, 0.8),
+		play("Esperanza Spalding", "Formwela 1", "Songwrights Apothecary Lab", october.Add(time.Hour), 0.7),
+	}
+
+	report := Compute(plays, october.Add(-time.Hour), now, pacific)
+
+	assert.Equal(t, 2, report.Plays)
+	assert.Equal(t, 2, report.UniqueArtists)
+	assert.Equal(t, 2, report.UniqueRecordings)
+	assert.InDelta(t, 0.5, report.RepeatRate, 0.001, "plays before the report still make later plays repeats")
+	assert.Zero(t, report.MatchFailureRate)
+	assert.Equal(t, october, report.From)
+	require.Len(t, report.Months, 1)
+	assert.Equal(t, "2025-10", report.Months[0].Month)
+
+	require.Len(t, report.LongestRunning, 1)
+	assert.Equal(t, 25, report.LongestRunning[0].Days, "albums run from their first play")
+
+	require.Len(t, report.NewArtists, 1, "artists first played before the report are not new")
+	assert.Equal(t, "Esperanza Spalding", report.NewArtists[0].Name)
+}
+
+func TestComputeImportedPlays(t *testing.T) {
+	now := time.Date(2025, time.October, 17, 12, 0, 0, 0, pacific)
+	imported := history.Play{
+		Song:     types.Song{Artist: "Miles Davis", Title: "So What", PlayedAt: now.AddDate(0, -1, 0)},
+		Match:    &history.Match{TrackID: "track1"},
+		Imported: true,
+	}
+	plays := []history.Play{
+		imported,
+		play("John Coltrane", "Giant Steps", "Giant Steps", now.Add(-time.Hour), 0.8),
+		play("Kenny G", "Songbird", "Duotones", now.Add(-2*time.Hour), 0),
+	}
+
+	report := Compute(plays, time.Time{}, now, pacific)
+
+	assert.Equal(t, 3, report.Plays)
+	assert.Equal(t, 2, report.MatchedPlays)
+	assert.InDelta(t, 0.5, report.MatchFailureRate, 0.001, "imported plays don't count towards match quality")
+	assert.InDelta(t, 0.8, report.AverageConfidence, 0.001)
+	require.Len(t, report.Months, 2)
+	assert.Zero(t, report.Months[0].MatchFailureRate)
+	assert.Zero(t, report.Months[0].AverageConfidence)
+}
+
+func TestComputeUnsearchedPlays(t *testing.T) {
+	now := time.Date(2025, time.October, 17, 12, 0, 0, 0, pacific)
+	excluded := history.Play{Song: types.Song{Artist: "Kenny G", Title: "Songbird", PlayedAt: now.Add(-3 * time.Hour)}}
+	plays := []history.Play{
+		excluded,
+		play("John Coltrane", "Giant Steps", "Giant Steps", now.Add(-time.Hour), 0.8),
+		play("Sun Ra", "Space Is the Place", "Space Is the Place", now.Add(-2*time.Hour), 0),
+	}
+
+	report := Compute(plays, time.Time{}, now, pacific)
+
+	assert.Equal(t, 3, report.Plays)
+	assert.InDelta(t, 0.5, report.MatchFailureRate, 0.001, "plays never searched for don't count towards match quality")
+	require.Len(t, report.Months, 1)
+	assert.InDelta(t, 0.5, report.Months[0].MatchFailureRate, 0.001)
+}
+
+func TestComputeEmpty(t *testing.T) {
+	report := Compute(nil, time.Time{}, time.Now(), time.UTC)
+
+	assert.Zero(t, report.Plays)
+	assert.Zero(t, report.RepeatRate)
+	assert.Len(t, report.PlaysByHour, 24)
+	assert.Len(t, report.PlaysByWeekday, 7)
+	assert.Empty(t, report.Months)
+}
+
+func TestTop(t *testing.T) {
+	report := Report{
+		LongestRunning: []Album{{Name: "A"}, {Name: "B"}},
+		NewArtists:     []Artist{{Name: "A"}, {Name: "B"}, {Name: "C"}},
+	}
+
+	top := report.Top(1)
+	assert.Len(t, top.LongestRunning, 1)
+	assert.Len(t, top.NewArtists, 1)
+	assert.Len(t, report.Top(0).NewArtists, 3)
+}
@@ -1,6 +1,7 @@
 package types
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 )
@@ -8,11 +9,20 @@ import (
 // SpotifyService defines the interface for Spotify API operations
 type SpotifyService interface {
 	SearchArtist(query string) (*Artist, error)
+	SearchArtists(query string, limit int) ([]Artist, error)
 	GetArtistTopTracks(artistID string) ([]Track, error)
 	GetUserPlaylists(folderName string) ([]Playlist, error)
 	AddTracksToPlaylist(playlistID string, trackIDs []string) error
 	CheckTracksInPlaylist(playlistID string, trackIDs []string) ([]bool, error)
 	CreatePlaylist(name, description string, public bool) (*Playlist, error)
+	GetPlaylistTracks(playlistID string) ([]Track, error)
+	GetPlaylistItems(playlistID string) ([]PlaylistItem, error)
+	RemoveTracksFromPlaylist(playlistID string, trackIDs []string) error
+	RemoveTrackPositions(playlistID string, positions map[string][]int) error
+	ReorderPlaylist(playlistID string, rangeStart, rangeLength, insertBefore int) error
+	UpdatePlaylist(playlistID string, update PlaylistUpdate) error
+	SetPlaylistCover(playlistID string, jpeg []byte) error
+	DeletePlaylist(playlistID string) error
 	GetAuthURL() string
 	IsAuthenticated() bool
 	CompleteAuth(code, state string) error
@@ -42,10 +52,11 @@ type KMHDScraper interface {
 
 // Artist represents a Spotify artist
 type Artist struct {
-	ID     string   `json:"id"`
-	Name   string   `json:"name"`
-	URI    string   `json:"uri"`
-	Genres []string `json:"genres"`
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	URI        string   `json:"uri"`
+	Genres     []string `json:"genres"`
+	Popularity int      `json:"popularity,omitempty"`
 }
 
 // Album represents a Spotify album
@@ -63,16 +74,33 @@ type Track struct {
 	Artists  []Artist `json:"artists"`
 	Duration int      `json:"duration_ms"`
 	Album    Album    `json:"album"`
+	ISRC     string   `json:"isrc,omitempty"`
+}
+
+// PlaylistItem is a track in a Spotify playlist and when it was added
+type PlaylistItem struct {
+	Track   Track     `json:"track"`
+	AddedAt time.Time `json:"added_at"`
 }
 
 // Playlist represents a Spotify playlist
 type Playlist struct {
-	ID         string `json:"id"`
-	Name       string `json:"name"`
-	URI        string `json:"uri"`
-	TrackCount int    `json:"track_count"`
-	EmbedURL   string `json:"embed_url"`
-	IsIncoming bool   `json:"is_incoming"`
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	URI         string `json:"uri"`
+	TrackCount  int    `json:"track_count"`
+	EmbedURL    string `json:"embed_url"`
+	IsIncoming  bool   `json:"is_incoming"`
+	Public      bool   `json:"public"`
+}
+
+// PlaylistUpdate holds changes to a playlist's details. Empty fields and a nil Public
+// are left unchanged.
+type PlaylistUpdate struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	Public      *bool  `json:"public,omitempty"`
 }
 
 // AddResult represents the result of adding an artist to a playlist
@@ -83,6 +111,11 @@ type AddResult struct {
 	Playlist     Playlist `json:"playlist"`
 	WasDuplicate bool     `json:"was_duplicate"`
 	Message      string   `json:"message"`
+
+	// LastAdded and ExistingTracks describe the artist's tracks already in the playlist
+	// when the artist wasn't added because of them.
+	LastAdded      *time.Time     `json:"last_added,omitempty"`
+	ExistingTracks []PlaylistItem `json:"existing_tracks,omitempty"`
 }
 
 // DuplicateResult represents the result of duplicate detection
@@ -91,7 +124,12 @@ type DuplicateResult struct {
 	DuplicateTracks []Track   `json:"duplicate_tracks"`
 	LastAdded       time.Time `json:"last_added"`
 	ArtistName      string    `json:"artist_name"`
+	PlaylistName    string    `json:"playlist_name,omitempty"` // other playlist the duplicates were found in
 	Message         string    `json:"message"`
+
+	// DuplicateItems are the duplicate tracks with when they were added to the playlist,
+	// for checks that scan the playlist's items.
+	DuplicateItems []PlaylistItem `json:"duplicate_items,omitempty"`
 }
 
 // API request/response models
@@ -133,6 +171,41 @@ type Song struct {
 	Album    string    `json:"album"`
 	PlayedAt time.Time `json:"played_at"`
 	RawText  string    `json:"raw_text"`
+	// KMHDID is the KMHD identifier of the play, if provided by the API
+	KMHDID string `json:"kmhd_id,omitempty"`
+	// Duration is the track length reported by KMHD, if provided by the API. It is
+	// encoded as duration_ms, like a Track's duration.
+	Duration time.Duration `json:"-"`
+	// Genre is the primary genre reported by KMHD, if provided by the API
+	Genre string `json:"genre,omitempty"`
+	// ArtworkURL is the 100x100 album artwork reported by KMHD, if provided by the API
+	ArtworkURL string `json:"artwork_url,omitempty"`
+}
+
+// songJSON is the JSON form of a song, with the duration in milliseconds.
+type songJSON struct {
+	jsonSong
+	DurationMS int64 `json:"duration_ms,omitempty"`
+}
+
+// jsonSong has the fields of a song without its methods, so marshalling it doesn't
+// recurse.
+type jsonSong Song
+
+// MarshalJSON encodes the song with its duration in milliseconds as duration_ms.
+func (s Song) MarshalJSON() ([]byte, error) {
+	return json.Marshal(songJSON{jsonSong: jsonSong(s), DurationMS: s.Duration.Milliseconds()})
+}
+
+// UnmarshalJSON decodes a song encoded by MarshalJSON.
+func (s *Song) UnmarshalJSON(data []byte) error {
+	var decoded songJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*s = Song(decoded.jsonSong)
+	s.Duration = time.Duration(decoded.DurationMS) * time.Millisecond
+	return nil
 }
 
 // IsValid checks if the song has the minimum required fields
@@ -140,6 +213,15 @@ func (s *Song) IsValid() bool {
 	return s.Artist != "" && s.Title != ""
 }
 
+// ExpectedEnd returns when the song is expected to finish playing, or the zero time
+// if the song has no start time or duration
+func (s *Song) ExpectedEnd() time.Time {
+	if s.PlayedAt.IsZero() || s.Duration <= 0 {
+		return time.Time{}
+	}
+	return s.PlayedAt.Add(s.Duration)
+}
+
 // String returns a string representation of the song
 func (s *Song) String() string {
 	if s.Album != "" {
@@ -159,3 +241,19 @@ type SongCollection struct {
 func (sc *SongCollection) AddSong(song Song) {
 	sc.Songs = append(sc.Songs, song)
 }
+
+// MostRecentAt returns the song that started most recently at or before the given time,
+// or nil if no song in the collection had started by then
+func (sc *SongCollection) MostRecentAt(now time.Time) *Song {
+	var current *Song
+	for i := range sc.Songs {
+		song := &sc.Songs[i]
+		if song.PlayedAt.After(now) {
+			continue
+		}
+		if current == nil || song.PlayedAt.After(current.PlayedAt) {
+			current = song
+		}
+	}
+	return current
+}
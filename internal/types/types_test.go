@@ -1,10 +1,12 @@
 package types
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSong_IsValid(t *testing.T) {
@@ -255,3 +257,49 @@ func BenchmarkSongCollection_AddSong(b *testing.B) {
 		collection.AddSong(song)
 	}
 }
+
+func TestSong_ExpectedEnd(t *testing.T) {
+	start := time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	song := Song{PlayedAt: start, Duration: 3 * time.Minute}
+	assert.Equal(t, start.Add(3*time.Minute), song.ExpectedEnd())
+
+	assert.True(t, (&Song{PlayedAt: start}).ExpectedEnd().IsZero())
+	assert.True(t, (&Song{Duration: time.Minute}).ExpectedEnd().IsZero())
+}
+
+func TestSong_JSON(t *testing.T) {
+	song := Song{
+		Artist:   "Miles Davis",
+		Title:    "So What",
+		PlayedAt: time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC),
+		Duration: 562 * time.Second,
+	}
+
+	data, err := json.Marshal(song)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"duration_ms":562000`)
+	assert.NotContains(t, string(data), `"duration":`)
+
+	var decoded Song
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, song, decoded)
+
+	data, err = json.Marshal(Song{Artist: "Miles Davis", Title: "So What"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "duration")
+}
+
+func TestSongCollection_MostRecentAt(t *testing.T) {
+	start := time.Date(2025, 10, 18, 12, 0, 0, 0, time.UTC)
+	collection := &SongCollection{Songs: []Song{
+		{Title: "Second", PlayedAt: start.Add(3 * time.Minute)},
+		{Title: "First", PlayedAt: start},
+		{Title: "Upcoming", PlayedAt: start.Add(10 * time.Minute)},
+	}}
+
+	assert.Nil(t, collection.MostRecentAt(start.Add(-time.Minute)))
+	assert.Equal(t, "First", collection.MostRecentAt(start.Add(time.Minute)).Title)
+	assert.Equal(t, "Second", collection.MostRecentAt(start.Add(5*time.Minute)).Title)
+	assert.Equal(t, "Upcoming", collection.MostRecentAt(start.Add(time.Hour)).Title)
+}
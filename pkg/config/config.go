@@ -29,7 +29,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 	"github.com/joho/godotenv"
@@ -40,6 +42,26 @@ type Config struct {
 	Spotify SpotifyConfig `envPrefix:"SPOTIFY_"`
 	KMHD    KMHDConfig    `envPrefix:"KMHD_"`
 	Server  ServerConfig  `envPrefix:"SERVER_"`
+	State   StateConfig   `envPrefix:"STATE_"`
+	Queue   QueueConfig   `envPrefix:"QUEUE_"`
+	Notify  NotifyConfig  `envPrefix:"NOTIFY_"`
+	Routing RoutingConfig `envPrefix:"ROUTING_"`
+	Rolling RollingConfig `envPrefix:"ROLLING_"`
+
+	// Chart holds the configuration of the most played chart playlist.
+	Chart ChartConfig `envPrefix:"CHART_"`
+
+	// Cover holds the configuration of generated playlist covers.
+	Cover CoverConfig `envPrefix:"COVER_"`
+
+	// Scrobble holds the configuration for submitting KMHD plays as scrobbles.
+	Scrobble ScrobbleConfig `envPrefix:"SCROBBLE_"`
+
+	// Duplicate holds the policy deciding when a track is already in a playlist.
+	Duplicate DuplicateConfig `envPrefix:"DUPLICATE_"`
+
+	// Profiles are the named Spotify accounts listed in SPOTIFY_PROFILES.
+	Profiles []ProfileConfig `env:"-"`
 }
 
 // SpotifyConfig represents the configuration for Spotify API integration.
@@ -58,11 +80,43 @@ type SpotifyConfig struct {
 
 	// PlaylistNamePrefix is the prefix for monthly Spotify playlists to sync KMHD songs to.
 	// Monthly playlists will be created with format: "{prefix}-YYYY-MM" (e.g., "KMHD-2025-10")
+	// unless routing rules are configured, which can use it as {prefix}.
 	PlaylistNamePrefix string `env:"PLAYLIST_NAME_PREFIX"`
 
+	// IncomingPrefix marks playlists whose name starts with it as incoming playlists, the
+	// inboxes artists are added to, e.g. "Incoming: Jazz" or "Incoming - New Finds".
+	IncomingPrefix string `env:"INCOMING_PREFIX" envDefault:"Incoming"`
+
+	// IncomingTag marks playlists whose description contains it as incoming playlists,
+	// whatever their name.
+	IncomingTag string `env:"INCOMING_TAG" envDefault:"#incoming"`
+
+	// UsePKCE authenticates with PKCE (Proof Key for Code Exchange). PKCE is always used
+	// when ClientSecret is empty, so the secret isn't needed on headless servers.
+	UsePKCE bool `env:"USE_PKCE"`
+
 	// TokenFilePath is the path where the Spotify authentication token is stored.
 	// If not specified, defaults to ~/.config/kmhd2spotify/spotify_token.json
 	TokenFilePath string `env:"TOKEN_FILE_PATH" envDefault:"~/.config/kmhd2spotify/spotify_token.json"`
+
+	// TokenStore selects how the token is stored: "file" (plain JSON at TokenFilePath),
+	// "encrypted" (AES-GCM encrypted at TokenFilePath using TokenKey or TokenKeyFile), or
+	// "secret" (read-only TokenSecretPath, with refreshed tokens cached at TokenFilePath).
+	TokenStore string `env:"TOKEN_STORE" envDefault:"file"`
+
+	// TokenKey is the passphrase for the encrypted token store.
+	TokenKey string `env:"TOKEN_KEY"` // #nosec G117 -- passphrase, expected in config
+
+	// TokenKeyFile is a file containing the passphrase for the encrypted token store,
+	// used instead of TokenKey (e.g. a Docker secret).
+	TokenKeyFile string `env:"TOKEN_KEY_FILE"`
+
+	// TokenSecretPath is the read-only token file used by the secret token store.
+	TokenSecretPath string `env:"TOKEN_SECRET_PATH" envDefault:"/run/secrets/spotify_token"`
+
+	// Profiles lists named Spotify accounts to sync to instead of the single default
+	// account. Each profile is configured with SPOTIFY_PROFILE_<NAME>_ variables.
+	Profiles []string `env:"PROFILES" envSeparator:","`
 }
 
 // KMHDConfig represents the configuration for KMHD JSON API integration.
@@ -75,12 +129,292 @@ type KMHDConfig struct {
 
 	// HTTPTimeout is the timeout for HTTP requests in seconds.
 	HTTPTimeout int `env:"HTTP_TIMEOUT" envDefault:"30"`
+
+	// RetryAttempts is the total number of attempts made for a single playlist fetch.
+	RetryAttempts int `env:"RETRY_ATTEMPTS" envDefault:"3"`
+
+	// RetryBaseDelay is the delay before the first retry. Each subsequent retry doubles it.
+	RetryBaseDelay time.Duration `env:"RETRY_BASE_DELAY" envDefault:"2s"`
+
+	// RetryJitter is the maximum random delay added to each retry to avoid synchronized retries.
+	RetryJitter time.Duration `env:"RETRY_JITTER" envDefault:"1s"`
+
+	// RetryMaxDelay caps the delay before a retry, including a delay requested by the
+	// server with Retry-After.
+	RetryMaxDelay time.Duration `env:"RETRY_MAX_DELAY" envDefault:"5m"`
+
+	// RetryStatuses are the HTTP status codes that are considered transient and retried.
+	// Network errors are always retried.
+	RetryStatuses []int `env:"RETRY_STATUSES" envDefault:"429,502,503,504" envSeparator:","`
+
+	// BreakerThreshold is the number of consecutive failed fetches that opens the circuit breaker.
+	BreakerThreshold int `env:"BREAKER_THRESHOLD" envDefault:"3"`
+
+	// BreakerCooldown is how long the circuit breaker stays open before allowing a trial fetch.
+	// The cooldown doubles each time a trial fetch fails, up to BreakerMaxCooldown.
+	BreakerCooldown time.Duration `env:"BREAKER_COOLDOWN" envDefault:"30m"`
+
+	// BreakerMaxCooldown caps the circuit breaker cooldown.
+	BreakerMaxCooldown time.Duration `env:"BREAKER_MAX_COOLDOWN" envDefault:"6h"`
+
+	// CacheEnabled enables the per-day response cache in the state directory. Cached days are
+	// revalidated with conditional requests, and completed past days are never re-downloaded.
+	CacheEnabled bool `env:"CACHE_ENABLED" envDefault:"true"`
+
+	// CacheMaxAge is how long a cached response is kept after it was last fetched or
+	// revalidated. Older responses are removed from the cache. Zero keeps them forever.
+	CacheMaxAge time.Duration `env:"CACHE_MAX_AGE" envDefault:"720h"`
 }
 
 // ServerConfig represents the server configuration.
 type ServerConfig struct {
 	Host string `env:"HOST" envDefault:"127.0.0.1"`
 	Port int    `env:"PORT" envDefault:"8080"`
+
+	// MetricsEnabled serves Prometheus metrics on /metrics while syncing continuously.
+	MetricsEnabled bool `env:"METRICS_ENABLED" envDefault:"true"`
+}
+
+// StateConfig represents the configuration for locally persisted application state.
+type StateConfig struct {
+	// Dir is the directory where local state files (e.g. the retry queue) are stored.
+	// If not specified, defaults to ~/.config/kmhd2spotify
+	Dir string `env:"DIR" envDefault:"~/.config/kmhd2spotify"`
+}
+
+// RollingConfig represents the configuration for the rolling playlist of recent plays.
+type RollingConfig struct {
+	// PlaylistName is the name of a playlist that only holds tracks played on KMHD in the
+	// last Days days, ordered by when they were last played. Disabled if empty.
+	PlaylistName string `env:"PLAYLIST_NAME"`
+
+	// Days is the length of the rolling window.
+	Days int `env:"DAYS" envDefault:"7"`
+}
+
+// Window returns the length of the rolling window.
+func (r RollingConfig) Window() time.Duration {
+	return time.Duration(r.Days) * 24 * time.Hour
+}
+
+// ChartPeriods maps each chart period to how far back it counts plays.
+var ChartPeriods = map[string]time.Duration{
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"year":  365 * 24 * time.Hour,
+}
+
+// ChartConfig represents the configuration for the most played chart playlist.
+type ChartConfig struct {
+	// PlaylistName is the name of a playlist holding the tracks KMHD played most in
+	// the chart period, ranked by play count. Disabled if empty.
+	PlaylistName string `env:"PLAYLIST_NAME"`
+
+	// Period is the period plays are counted over: week, month or year.
+	Period string `env:"PERIOD" envDefault:"month"`
+
+	// Size is the number of tracks in the chart playlist.
+	Size int `env:"SIZE" envDefault:"50"`
+}
+
+// CoverConfig represents the configuration of the covers generated for new playlists.
+type CoverConfig struct {
+	// Generate uploads a generated cover, a collage of the album artwork of recent KMHD
+	// plays labelled with the month or playlist name, when a playlist is created.
+	Generate bool `env:"GENERATE" envDefault:"true"`
+
+	// ArtworkTimeout is the timeout for downloading all album covers of the collage.
+	// Covers without artwork are typographic.
+	ArtworkTimeout time.Duration `env:"ARTWORK_TIMEOUT" envDefault:"10s"`
+}
+
+// Duplicate policies.
+const (
+	// DuplicatePolicyPlaylist skips tracks that are already in the target playlist.
+	DuplicatePolicyPlaylist = "playlist"
+	// DuplicatePolicyPrefix also skips tracks that were added to any playlist with the
+	// playlist name prefix, such as an earlier monthly playlist.
+	DuplicatePolicyPrefix = "prefix"
+	// DuplicatePolicyWindow also skips tracks that were added to any playlist with the
+	// playlist name prefix within the duplicate window.
+	DuplicatePolicyWindow = "window"
+)
+
+// DuplicateConfig represents the policy deciding when a track is a duplicate.
+type DuplicateConfig struct {
+	// Policy is playlist, prefix or window.
+	Policy string `env:"POLICY" envDefault:"playlist"`
+
+	// Window is how far back the window policy looks, in days ("90d") or months ("3m").
+	Window string `env:"WINDOW" envDefault:"90d"`
+
+	// DurationTolerance is how much the durations of two tracks with the same artist
+	// and title but no common ISRC may differ for them to be the same recording.
+	DurationTolerance time.Duration `env:"DURATION_TOLERANCE" envDefault:"3s"`
+}
+
+// WindowStart returns when the duplicate window that ends at now started, or the zero
+// time if the policy isn't limited to a window.
+func (d DuplicateConfig) WindowStart(now time.Time) (time.Time, error) {
+	if d.Policy != DuplicatePolicyWindow {
+		return time.Time{}, nil
+	}
+	days, months, err := ParseDuplicateWindow(d.Window)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return now.AddDate(0, -months, -days), nil
+}
+
+// ParseDuplicateWindow parses a duplicate window of days ("90d") or months ("3m").
+func ParseDuplicateWindow(s string) (days, months int, err error) {
+	value := strings.ToLower(strings.TrimSpace(s))
+	if len(value) < 2 {
+		return 0, 0, fmt.Errorf("invalid duplicate window %q, expected days (90d) or months (3m)", s)
+	}
+	n, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || n < 1 {
+		return 0, 0, fmt.Errorf("invalid duplicate window %q, expected days (90d) or months (3m)", s)
+	}
+	switch value[len(value)-1] {
+	case 'd':
+		return n, 0, nil
+	case 'm':
+		return 0, n, nil
+	default:
+		return 0, 0, fmt.Errorf("invalid duplicate window %q, expected days (90d) or months (3m)", s)
+	}
+}
+
+// ScrobbleConfig represents the configuration for scrobbling KMHD plays.
+//
+// Each service is enabled by setting its credentials. Plays are only scrobbled if they
+// started within the schedule, so scrobbles can be limited to when you're listening.
+type ScrobbleConfig struct {
+	// ListenBrainz submits listens to the ListenBrainz API.
+	ListenBrainz ListenBrainzConfig `envPrefix:"LISTENBRAINZ_"`
+
+	// LastFM scrobbles to Last.fm or a Last.fm-compatible server.
+	LastFM LastFMConfig `envPrefix:"LASTFM_"`
+
+	// Schedule lists the daily time slots (such as "19:00-23:00") in which plays are
+	// scrobbled. If empty, plays are scrobbled at any time of day.
+	Schedule []string `env:"SCHEDULE" envSeparator:","`
+
+	// Days restricts scrobbling to these days of the week (such as "mon,tue").
+	// If empty, plays are scrobbled on every day.
+	Days []string `env:"DAYS" envSeparator:","`
+
+	// Timezone is the IANA timezone of the schedule.
+	Timezone string `env:"TIMEZONE" envDefault:"Local"`
+
+	// BatchSize is the maximum number of scrobbles submitted in one request.
+	BatchSize int `env:"BATCH_SIZE" envDefault:"50"`
+
+	// Timeout is the timeout for a single submission.
+	Timeout time.Duration `env:"TIMEOUT" envDefault:"10s"`
+}
+
+// ListenBrainzConfig represents the configuration for submitting listens to ListenBrainz.
+type ListenBrainzConfig struct {
+	// Token is the ListenBrainz user token. Submission is disabled if empty.
+	Token string `env:"TOKEN"` // #nosec G117 -- ListenBrainz user token, expected in config
+
+	// URL is the ListenBrainz API root, for self-hosted instances.
+	URL string `env:"URL" envDefault:"https://api.listenbrainz.org"`
+}
+
+// LastFMConfig represents the configuration for scrobbling to a Last.fm-compatible API.
+type LastFMConfig struct {
+	// APIKey is the API key of your Last.fm API account. Scrobbling is disabled if empty.
+	APIKey string `env:"API_KEY"`
+
+	// APISecret is the shared secret of your Last.fm API account.
+	APISecret string `env:"API_SECRET"` // #nosec G117 -- Last.fm shared secret, expected in config
+
+	// SessionKey is the session key of the user to scrobble as. If empty, a session is
+	// requested with Username and Password.
+	SessionKey string `env:"SESSION_KEY"` // #nosec G117 -- Last.fm session key, expected in config
+
+	// Username and Password are used to request a session if SessionKey is empty.
+	Username string `env:"USERNAME"`
+	Password string `env:"PASSWORD"` // #nosec G117 -- Last.fm password, expected in config
+
+	// URL is the API root, such as https://libre.fm/2.0/ for a compatible server.
+	URL string `env:"URL" envDefault:"https://ws.audioscrobbler.com/2.0/"`
+}
+
+// QueueConfig represents the retry policy for failed Spotify operations.
+type QueueConfig struct {
+	// MaxAttempts is the number of attempts (including the initial failure) before
+	// an operation is moved to the dead-letter list.
+	MaxAttempts int `env:"MAX_ATTEMPTS" envDefault:"5"`
+
+	// BaseDelay is the delay before the first retry. Each subsequent retry doubles it.
+	BaseDelay time.Duration `env:"BASE_DELAY" envDefault:"5m"`
+
+	// MaxDelay caps the exponential backoff delay between retries.
+	MaxDelay time.Duration `env:"MAX_DELAY" envDefault:"6h"`
+}
+
+// NotifyConfig represents the configuration for notification sinks.
+//
+// Each sink is enabled by setting its URL or command and has its own filter.
+type NotifyConfig struct {
+	// Webhook POSTs a JSON document describing each event.
+	Webhook WebhookNotifyConfig `envPrefix:"WEBHOOK_"`
+
+	// Shell runs a command for each event.
+	Shell ShellNotifyConfig `envPrefix:"SHELL_"`
+
+	// Ntfy publishes a push notification to an ntfy topic.
+	Ntfy PushNotifyConfig `envPrefix:"NTFY_"`
+
+	// Gotify publishes a push notification to a Gotify server.
+	Gotify PushNotifyConfig `envPrefix:"GOTIFY_"`
+
+	// Timeout is the timeout for delivering a single notification.
+	Timeout time.Duration `env:"TIMEOUT" envDefault:"10s"`
+}
+
+// NotifyFilterConfig selects which events a notification sink receives.
+type NotifyFilterConfig struct {
+	// Events are the event types delivered to the sink: song_added and/or now_playing.
+	Events []string `env:"EVENTS" envDefault:"song_added" envSeparator:","`
+
+	// Artists restricts notifications to songs by these artists (case-insensitive).
+	// If empty, songs by any artist are delivered.
+	Artists []string `env:"ARTISTS" envSeparator:","`
+}
+
+// WebhookNotifyConfig represents the configuration for the webhook notification sink.
+type WebhookNotifyConfig struct {
+	// URL is the endpoint that receives the JSON POST. The sink is disabled if empty.
+	URL string `env:"URL"`
+
+	Filter NotifyFilterConfig
+}
+
+// ShellNotifyConfig represents the configuration for the shell command notification sink.
+type ShellNotifyConfig struct {
+	// Command is run with "sh -c" for each event. The sink is disabled if empty.
+	Command string `env:"COMMAND"`
+
+	Filter NotifyFilterConfig
+}
+
+// PushNotifyConfig represents the configuration for an ntfy or Gotify push notification sink.
+type PushNotifyConfig struct {
+	// URL is the ntfy topic URL or the Gotify server URL. The sink is disabled if empty.
+	URL string `env:"URL"`
+
+	// Token is the ntfy access token or the Gotify application token.
+	Token string `env:"TOKEN"` // #nosec G117 -- push service token, expected in config
+
+	// Priority is the notification priority (ntfy: 1-5, Gotify: 0-10). Zero uses the server default.
+	Priority int `env:"PRIORITY"`
+
+	Filter NotifyFilterConfig
 }
 
 // GetEnvVars loads and returns the application configuration from environment
@@ -162,6 +496,14 @@ func GetEnvVars() Config {
 		fmt.Printf("Error parsing configuration from environment: %s\n", err)
 		os.Exit(1)
 	}
+	if err := parseProfiles(&conf); err != nil {
+		fmt.Printf("Error parsing profile configuration from environment: %s\n", err)
+		os.Exit(1)
+	}
+	if err := loadRoutingRules(&conf); err != nil {
+		fmt.Printf("Error loading routing rules: %s\n", err)
+		os.Exit(1)
+	}
 
 	// Validate configuration
 	if err := validateConfig(&conf); err != nil {
@@ -187,29 +529,126 @@ func (s ServerConfig) Address() string {
 // GetTokenFilePath returns the resolved token file path, handling tilde expansion
 // and ensuring the directory exists.
 func (s SpotifyConfig) GetTokenFilePath() (string, error) {
-	tokenPath := s.TokenFilePath
+	absPath, err := expandPath(s.TokenFilePath)
+	if err != nil {
+		return "", err
+	}
+
+	// Ensure the directory exists
+	tokenDir := filepath.Dir(absPath)
+	if err := os.MkdirAll(tokenDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create token directory %s: %w", tokenDir, err)
+	}
+
+	return absPath, nil
+}
+
+// GetTokenKey returns the passphrase for the encrypted token store, reading it from
+// TokenKeyFile if set.
+func (s SpotifyConfig) GetTokenKey() (string, error) {
+	if s.TokenKeyFile == "" {
+		return s.TokenKey, nil
+	}
+
+	keyFile, err := expandPath(s.TokenKeyFile)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(keyFile) // #nosec G304 -- path comes from configuration
+	if err != nil {
+		return "", fmt.Errorf("failed to read token key file %s: %w", keyFile, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// GetFilePath returns the resolved path of the named file within the state
+// directory, handling tilde expansion and ensuring the directory exists.
+func (s StateConfig) GetFilePath(name string) (string, error) {
+	stateDir, err := expandPath(s.Dir)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(stateDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create state directory %s: %w", stateDir, err)
+	}
+
+	return filepath.Join(stateDir, name), nil
+}
+
+// validateTokenStore validates the token store selection of a Spotify configuration.
+func validateTokenStore(spotify SpotifyConfig) []string {
+	switch spotify.TokenStore {
+	case "", "file", "secret":
+	case "encrypted":
+		if spotify.TokenKey == "" && spotify.TokenKeyFile == "" {
+			return []string{"encrypted token store requires SPOTIFY_TOKEN_KEY or SPOTIFY_TOKEN_KEY_FILE"}
+		}
+	default:
+		return []string{fmt.Sprintf("Spotify token store %q must be file, encrypted or secret", spotify.TokenStore)}
+	}
+	return nil
+}
+
+// validateScrobble validates the scrobble schedule and the enabled services.
+func validateScrobble(scrobble ScrobbleConfig) []string {
+	var errors []string
+	for _, slot := range scrobble.Schedule {
+		if _, err := ParseTimeWindow(slot); err != nil {
+			errors = append(errors, fmt.Sprintf("scrobble schedule: %s", err))
+		}
+	}
+	for _, day := range scrobble.Days {
+		if _, err := ParseWeekday(day); err != nil {
+			errors = append(errors, fmt.Sprintf("scrobble days: %s", err))
+		}
+	}
+	if _, err := time.LoadLocation(scrobble.Timezone); err != nil {
+		errors = append(errors, fmt.Sprintf("scrobble timezone %q is invalid: %s", scrobble.Timezone, err))
+	}
+	if scrobble.BatchSize < 1 {
+		errors = append(errors, "scrobble batch size must be at least 1")
+	}
+	if scrobble.Timeout <= 0 {
+		errors = append(errors, "scrobble timeout must be greater than 0")
+	}
+
+	if scrobble.ListenBrainz.Token != "" && scrobble.ListenBrainz.URL == "" {
+		errors = append(errors, "ListenBrainz scrobbling requires SCROBBLE_LISTENBRAINZ_URL")
+	}
+	lastFM := scrobble.LastFM
+	if lastFM.APIKey != "" {
+		if lastFM.APISecret == "" {
+			errors = append(errors, "Last.fm scrobbling requires SCROBBLE_LASTFM_API_SECRET")
+		}
+		if lastFM.SessionKey == "" && (lastFM.Username == "" || lastFM.Password == "") {
+			errors = append(errors, "Last.fm scrobbling requires SCROBBLE_LASTFM_SESSION_KEY or SCROBBLE_LASTFM_USERNAME and SCROBBLE_LASTFM_PASSWORD")
+		}
+		if lastFM.URL == "" {
+			errors = append(errors, "Last.fm scrobbling requires SCROBBLE_LASTFM_URL")
+		}
+	}
+	return errors
+}
 
+// expandPath expands a leading tilde to the user's home directory and
+// converts the result to an absolute path.
+func expandPath(path string) (string, error) {
 	// Handle tilde expansion
-	if strings.HasPrefix(tokenPath, "~/") {
+	if strings.HasPrefix(path, "~/") {
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
 			return "", fmt.Errorf("failed to get user home directory: %w", err)
 		}
-		tokenPath = filepath.Join(homeDir, tokenPath[2:])
+		path = filepath.Join(homeDir, path[2:])
 	}
 
 	// Convert to absolute path
-	absPath, err := filepath.Abs(tokenPath)
+	absPath, err := filepath.Abs(path)
 	if err != nil {
 		return "", fmt.Errorf("failed to resolve absolute path: %w", err)
 	}
 
-	// Ensure the directory exists
-	tokenDir := filepath.Dir(absPath)
-	if err := os.MkdirAll(tokenDir, 0700); err != nil {
-		return "", fmt.Errorf("failed to create token directory %s: %w", tokenDir, err)
-	}
-
 	return absPath, nil
 }
 
@@ -227,8 +666,30 @@ func validateConfig(conf *Config) error {
 		fmt.Println("Warning: SPOTIFY_CLIENT_ID is not set. The application will not be able to connect to Spotify.")
 		fmt.Println("Please set your Spotify credentials to use the application.")
 	}
-	if conf.Spotify.ClientSecret == "" {
-		fmt.Println("Warning: SPOTIFY_CLIENT_SECRET is not set. The application will not be able to connect to Spotify.")
+	// An empty SPOTIFY_CLIENT_SECRET is valid: the client then authenticates with PKCE
+	errors = append(errors, validateTokenStore(conf.Spotify)...)
+	errors = append(errors, validateProfiles(conf)...)
+	errors = append(errors, validateRouting(conf)...)
+
+	// Validate notification configuration
+	if conf.Notify.Timeout <= 0 {
+		errors = append(errors, "notification timeout must be greater than 0")
+	}
+	sinkFilters := []struct {
+		name   string
+		filter NotifyFilterConfig
+	}{
+		{"webhook", conf.Notify.Webhook.Filter},
+		{"shell", conf.Notify.Shell.Filter},
+		{"ntfy", conf.Notify.Ntfy.Filter},
+		{"gotify", conf.Notify.Gotify.Filter},
+	}
+	for _, sink := range sinkFilters {
+		for _, event := range sink.filter.Events {
+			if event = strings.TrimSpace(event); event != "song_added" && event != "now_playing" {
+				errors = append(errors, fmt.Sprintf("%s notification event %q must be song_added or now_playing", sink.name, event))
+			}
+		}
 	}
 
 	// Validate KMHD configuration
@@ -238,6 +699,78 @@ func validateConfig(conf *Config) error {
 	if conf.KMHD.HTTPTimeout <= 0 {
 		errors = append(errors, "KMHD HTTP timeout must be greater than 0")
 	}
+	if conf.KMHD.RetryAttempts < 1 {
+		errors = append(errors, "KMHD retry attempts must be at least 1")
+	}
+	if conf.KMHD.RetryBaseDelay < 0 || conf.KMHD.RetryJitter < 0 {
+		errors = append(errors, "KMHD retry delays must not be negative")
+	}
+	if conf.KMHD.RetryMaxDelay <= 0 {
+		errors = append(errors, "KMHD retry max delay must be greater than 0")
+	}
+	for _, status := range conf.KMHD.RetryStatuses {
+		if status < 100 || status > 599 {
+			errors = append(errors, fmt.Sprintf("KMHD retry status %d is not a valid HTTP status code", status))
+		}
+	}
+	if conf.KMHD.BreakerThreshold < 1 {
+		errors = append(errors, "KMHD breaker threshold must be at least 1")
+	}
+	if conf.KMHD.BreakerCooldown <= 0 {
+		errors = append(errors, "KMHD breaker cooldown must be greater than 0")
+	}
+	if conf.KMHD.BreakerMaxCooldown < conf.KMHD.BreakerCooldown {
+		errors = append(errors, "KMHD breaker max cooldown must not be less than the cooldown")
+	}
+	if conf.KMHD.CacheMaxAge < 0 {
+		errors = append(errors, "KMHD cache max age must not be negative")
+	}
+
+	// Validate rolling playlist configuration
+	if conf.Rolling.PlaylistName != "" && conf.Rolling.Days < 1 {
+		errors = append(errors, "rolling playlist days must be at least 1")
+	}
+
+	// Validate chart configuration
+	if _, ok := ChartPeriods[conf.Chart.Period]; !ok {
+		errors = append(errors, fmt.Sprintf("chart period %q must be week, month or year", conf.Chart.Period))
+	}
+	if conf.Chart.Size < 1 {
+		errors = append(errors, "chart size must be at least 1")
+	}
+
+	// Validate cover configuration
+	if conf.Cover.Generate && conf.Cover.ArtworkTimeout <= 0 {
+		errors = append(errors, "cover artwork timeout must be greater than 0")
+	}
+
+	// Validate scrobble configuration
+	errors = append(errors, validateScrobble(conf.Scrobble)...)
+
+	// Validate duplicate policy
+	switch conf.Duplicate.Policy {
+	case DuplicatePolicyPlaylist, DuplicatePolicyPrefix:
+	case DuplicatePolicyWindow:
+		if _, _, err := ParseDuplicateWindow(conf.Duplicate.Window); err != nil {
+			errors = append(errors, err.Error())
+		}
+	default:
+		errors = append(errors, fmt.Sprintf("duplicate policy %q must be playlist, prefix or window", conf.Duplicate.Policy))
+	}
+	if conf.Duplicate.DurationTolerance < 0 {
+		errors = append(errors, "duplicate duration tolerance must not be negative")
+	}
+
+	// Validate retry queue configuration
+	if conf.Queue.MaxAttempts < 1 {
+		errors = append(errors, "queue max attempts must be at least 1")
+	}
+	if conf.Queue.BaseDelay <= 0 {
+		errors = append(errors, "queue base delay must be greater than 0")
+	}
+	if conf.Queue.MaxDelay < conf.Queue.BaseDelay {
+		errors = append(errors, "queue max delay must not be less than the base delay")
+	}
 
 	if len(errors) > 0 {
 		return fmt.Errorf("configuration errors:\n- %s", strings.Join(errors, "\n- "))
@@ -4,7 +4,9 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/caarlos0/env/v11"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -145,3 +147,212 @@ func TestSpotifyConfig_GetTokenFilePath(t *testing.T) {
 		})
 	}
 }
+
+func TestSpotifyConfig_IncomingFromEnv(t *testing.T) {
+	var conf Config
+	assert.NoError(t, env.Parse(&conf))
+	assert.Equal(t, "Incoming", conf.Spotify.IncomingPrefix)
+	assert.Equal(t, "#incoming", conf.Spotify.IncomingTag)
+
+	t.Setenv("SPOTIFY_INCOMING_PREFIX", "Inbox")
+	t.Setenv("SPOTIFY_INCOMING_TAG", "[inbox]")
+
+	conf = Config{}
+	assert.NoError(t, env.Parse(&conf))
+	assert.Equal(t, "Inbox", conf.Spotify.IncomingPrefix)
+	assert.Equal(t, "[inbox]", conf.Spotify.IncomingTag)
+}
+
+func TestNotifyConfig_FromEnv(t *testing.T) {
+	t.Setenv("NOTIFY_WEBHOOK_URL", "https://example.com/hook")
+	t.Setenv("NOTIFY_WEBHOOK_ARTISTS", "Miles Davis,John Coltrane")
+	t.Setenv("NOTIFY_NTFY_URL", "https://ntfy.sh/kmhd")
+	t.Setenv("NOTIFY_NTFY_EVENTS", "song_added, now_playing")
+
+	var conf Config
+	assert.NoError(t, env.Parse(&conf))
+
+	assert.Equal(t, "https://example.com/hook", conf.Notify.Webhook.URL)
+	assert.Equal(t, []string{"Miles Davis", "John Coltrane"}, conf.Notify.Webhook.Filter.Artists)
+	assert.Equal(t, []string{"song_added"}, conf.Notify.Webhook.Filter.Events)
+	assert.Equal(t, []string{"song_added", " now_playing"}, conf.Notify.Ntfy.Filter.Events)
+	assert.Empty(t, conf.Notify.Shell.Command)
+	assert.NoError(t, validateConfig(&conf))
+
+	conf.Notify.Gotify.Filter.Events = []string{"song_removed"}
+	assert.Error(t, validateConfig(&conf))
+}
+
+func TestSpotifyConfig_GetTokenKey(t *testing.T) {
+	cfg := SpotifyConfig{TokenKey: "from-env"}
+	key, err := cfg.GetTokenKey()
+	assert.NoError(t, err)
+	assert.Equal(t, "from-env", key)
+
+	keyFile := filepath.Join(t.TempDir(), "token_key")
+	assert.NoError(t, os.WriteFile(keyFile, []byte("from-file\n"), 0600))
+	cfg.TokenKeyFile = keyFile
+	key, err = cfg.GetTokenKey()
+	assert.NoError(t, err)
+	assert.Equal(t, "from-file", key)
+
+	cfg.TokenKeyFile = filepath.Join(t.TempDir(), "missing")
+	_, err = cfg.GetTokenKey()
+	assert.Error(t, err)
+}
+
+func TestValidateConfig_TokenStore(t *testing.T) {
+	var conf Config
+	assert.NoError(t, env.Parse(&conf))
+	assert.Equal(t, "file", conf.Spotify.TokenStore)
+	assert.NoError(t, validateConfig(&conf))
+
+	conf.Spotify.TokenStore = "encrypted"
+	assert.Error(t, validateConfig(&conf))
+	conf.Spotify.TokenKey = "passphrase"
+	assert.NoError(t, validateConfig(&conf))
+
+	conf.Spotify.TokenStore = "secret"
+	assert.NoError(t, validateConfig(&conf))
+
+	conf.Spotify.TokenStore = "vault"
+	assert.Error(t, validateConfig(&conf))
+}
+
+func TestValidateConfig_RetryMaxDelay(t *testing.T) {
+	var conf Config
+	assert.NoError(t, env.Parse(&conf))
+	assert.Equal(t, 5*time.Minute, conf.KMHD.RetryMaxDelay)
+	assert.NoError(t, validateConfig(&conf))
+
+	conf.KMHD.RetryMaxDelay = 0
+	assert.Error(t, validateConfig(&conf))
+}
+
+func TestValidateConfig_CacheMaxAge(t *testing.T) {
+	var conf Config
+	assert.NoError(t, env.Parse(&conf))
+	assert.Equal(t, 30*24*time.Hour, conf.KMHD.CacheMaxAge)
+	assert.NoError(t, validateConfig(&conf))
+
+	conf.KMHD.CacheMaxAge = 0
+	assert.NoError(t, validateConfig(&conf))
+	conf.KMHD.CacheMaxAge = -time.Hour
+	assert.Error(t, validateConfig(&conf))
+}
+
+func TestValidateConfig_Rolling(t *testing.T) {
+	var conf Config
+	assert.NoError(t, env.Parse(&conf))
+	assert.Equal(t, 7, conf.Rolling.Days)
+	assert.Equal(t, 7*24*time.Hour, conf.Rolling.Window())
+	assert.NoError(t, validateConfig(&conf))
+
+	conf.Rolling.PlaylistName = "KMHD Last 7 Days"
+	conf.Rolling.Days = 0
+	assert.Error(t, validateConfig(&conf))
+	conf.Rolling.Days = 1
+	assert.NoError(t, validateConfig(&conf))
+}
+
+func TestValidateConfig_Cover(t *testing.T) {
+	var conf Config
+	assert.NoError(t, env.Parse(&conf))
+	assert.True(t, conf.Cover.Generate)
+	assert.Equal(t, 10*time.Second, conf.Cover.ArtworkTimeout)
+	assert.NoError(t, validateConfig(&conf))
+
+	conf.Cover.ArtworkTimeout = 0
+	assert.Error(t, validateConfig(&conf))
+	conf.Cover.Generate = false
+	assert.NoError(t, validateConfig(&conf))
+}
+
+func TestValidateConfig_Chart(t *testing.T) {
+	var conf Config
+	assert.NoError(t, env.Parse(&conf))
+	assert.Equal(t, "month", conf.Chart.Period)
+	assert.Equal(t, 50, conf.Chart.Size)
+	assert.NoError(t, validateConfig(&conf))
+
+	conf.Chart.Period = "decade"
+	assert.Error(t, validateConfig(&conf))
+	conf.Chart.Period = "week"
+	conf.Chart.Size = 0
+	assert.Error(t, validateConfig(&conf))
+}
+
+func TestValidateConfig_Scrobble(t *testing.T) {
+	var conf Config
+	assert.NoError(t, env.Parse(&conf))
+	assert.Equal(t, "https://api.listenbrainz.org", conf.Scrobble.ListenBrainz.URL)
+	assert.Equal(t, "https://ws.audioscrobbler.com/2.0/", conf.Scrobble.LastFM.URL)
+	assert.Equal(t, 50, conf.Scrobble.BatchSize)
+	assert.NoError(t, validateConfig(&conf))
+
+	conf.Scrobble.Schedule = []string{"19:00-23:00", "06:00-08:00"}
+	conf.Scrobble.Days = []string{"mon", "friday"}
+	conf.Scrobble.Timezone = "America/Los_Angeles"
+	conf.Scrobble.ListenBrainz.Token = "token"
+	assert.NoError(t, validateConfig(&conf))
+
+	for name, modify := range map[string]func(*ScrobbleConfig){
+		"bad slot":         func(c *ScrobbleConfig) { c.Schedule = []string{"7pm-11pm"} },
+		"bad day":          func(c *ScrobbleConfig) { c.Days = []string{"someday"} },
+		"bad timezone":     func(c *ScrobbleConfig) { c.Timezone = "Mars/Olympus_Mons" },
+		"zero batch size":  func(c *ScrobbleConfig) { c.BatchSize = 0 },
+		"lastfm no secret": func(c *ScrobbleConfig) { c.LastFM.APIKey = "key"; c.LastFM.SessionKey = "session" },
+		"lastfm no session": func(c *ScrobbleConfig) {
+			c.LastFM.APIKey, c.LastFM.APISecret, c.LastFM.Username = "key", "secret", "user"
+		},
+	} {
+		scrobble := conf.Scrobble
+		modify(&scrobble)
+		assert.NotEmpty(t, validateScrobble(scrobble), name)
+	}
+
+	conf.Scrobble.LastFM = LastFMConfig{APIKey: "key", APISecret: "secret", Username: "user", Password: "pass", URL: "https://libre.fm/2.0/"}
+	assert.NoError(t, validateConfig(&conf))
+}
+
+func TestValidateConfig_Duplicate(t *testing.T) {
+	var conf Config
+	assert.NoError(t, env.Parse(&conf))
+	assert.Equal(t, DuplicatePolicyPlaylist, conf.Duplicate.Policy)
+	assert.NoError(t, validateConfig(&conf))
+
+	conf.Duplicate.Policy = DuplicatePolicyWindow
+	for _, window := range []string{"90d", "3m", "1M"} {
+		conf.Duplicate.Window = window
+		assert.NoError(t, validateConfig(&conf), window)
+	}
+	for _, window := range []string{"", "0d", "3w", "d", "-1m"} {
+		conf.Duplicate.Window = window
+		assert.Error(t, validateConfig(&conf), window)
+	}
+
+	conf.Duplicate.Policy = "everywhere"
+	conf.Duplicate.Window = "90d"
+	assert.Error(t, validateConfig(&conf))
+
+	conf.Duplicate.Policy = DuplicatePolicyPlaylist
+	assert.Equal(t, 3*time.Second, conf.Duplicate.DurationTolerance)
+	conf.Duplicate.DurationTolerance = -time.Second
+	assert.Error(t, validateConfig(&conf))
+}
+
+func TestDuplicateConfig_WindowStart(t *testing.T) {
+	now := time.Date(2025, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	start, err := DuplicateConfig{Policy: DuplicatePolicyPrefix, Window: "3m"}.WindowStart(now)
+	assert.NoError(t, err)
+	assert.True(t, start.IsZero())
+
+	start, err = DuplicateConfig{Policy: DuplicatePolicyWindow, Window: "3m"}.WindowStart(now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 7, 17, 12, 0, 0, 0, time.UTC), start)
+
+	start, err = DuplicateConfig{Policy: DuplicatePolicyWindow, Window: "10d"}.WindowStart(now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 10, 7, 12, 0, 0, 0, time.UTC), start)
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/caarlos0/env/v11"
+)
+
+// profileNamePattern restricts profile names to characters that are safe in
+// environment variable names and file paths.
+var profileNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ProfileConfig represents a named Spotify account that synced songs are fanned out to.
+//
+// Profiles are listed in SPOTIFY_PROFILES and configured with variables prefixed
+// SPOTIFY_PROFILE_<NAME>_ (e.g. SPOTIFY_PROFILE_ALICE_PLAYLIST_NAME_PREFIX). Unset
+// values fall back to the SPOTIFY_ settings; the token is stored per profile.
+type ProfileConfig struct {
+	// Name identifies the profile, e.g. for 'kmhd2spotify auth --profile <name>'.
+	Name string `env:"-"`
+
+	// PlaylistNamePrefix is the prefix for the profile's monthly playlists.
+	PlaylistNamePrefix string `env:"PLAYLIST_NAME_PREFIX"`
+
+	// TokenFilePath is where the profile's token is stored. If not specified, defaults to
+	// profiles/<name>/ next to SPOTIFY_TOKEN_FILE_PATH.
+	TokenFilePath string `env:"TOKEN_FILE_PATH"`
+
+	// TokenStore, TokenKey, TokenKeyFile and TokenSecretPath override the SPOTIFY_ token
+	// store settings. The default secret path is SPOTIFY_TOKEN_SECRET_PATH suffixed with _<name>.
+	TokenStore      string `env:"TOKEN_STORE"`
+	TokenKey        string `env:"TOKEN_KEY"` // #nosec G117 -- passphrase, expected in config
+	TokenKeyFile    string `env:"TOKEN_KEY_FILE"`
+	TokenSecretPath string `env:"TOKEN_SECRET_PATH"`
+
+	// Artists restricts the profile to songs by these artists (case-insensitive).
+	// If empty, songs by any artist are synced.
+	Artists []string `env:"ARTISTS" envSeparator:","`
+
+	// ExcludeArtists are artists whose songs are never synced to the profile.
+	ExcludeArtists []string `env:"EXCLUDE_ARTISTS" envSeparator:","`
+}
+
+// ProfileEnvPrefix returns the environment variable prefix for the named profile.
+func ProfileEnvPrefix(name string) string {
+	return "SPOTIFY_PROFILE_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+}
+
+// parseProfiles parses the configuration of each profile listed in SPOTIFY_PROFILES.
+// Names such as "a-b" and "a_b" share an environment variable prefix, so they are rejected.
+func parseProfiles(conf *Config) error {
+	conf.Profiles = nil
+	prefixes := make(map[string]string)
+	for _, name := range conf.Spotify.Profiles {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		prefix := ProfileEnvPrefix(name)
+		if other, ok := prefixes[prefix]; ok && other != name {
+			return fmt.Errorf("profiles %q and %q would both be configured with %s variables, rename one of them", other, name, prefix)
+		}
+		prefixes[prefix] = name
+
+		profile := ProfileConfig{Name: name}
+		if err := env.ParseWithOptions(&profile, env.Options{Prefix: prefix}); err != nil {
+			return fmt.Errorf("failed to parse profile %q: %w", name, err)
+		}
+		conf.Profiles = append(conf.Profiles, profile)
+	}
+	return nil
+}
+
+// ProfileNames returns the names of the configured profiles, or a single empty name
+// for the default account when no profiles are configured.
+func (c Config) ProfileNames() []string {
+	if len(c.Profiles) == 0 {
+		return []string{""}
+	}
+
+	names := make([]string, len(c.Profiles))
+	for i, profile := range c.Profiles {
+		names[i] = profile.Name
+	}
+	return names
+}
+
+// Profile returns the named profile. The empty name is the default account, which
+// has no filters.
+func (c Config) Profile(name string) (ProfileConfig, error) {
+	if name == "" {
+		return ProfileConfig{}, nil
+	}
+	for _, profile := range c.Profiles {
+		if profile.Name == name {
+			return profile, nil
+		}
+	}
+	return ProfileConfig{}, fmt.Errorf("unknown profile %q (configured profiles: %s)", name, strings.Join(c.ProfileNames(), ", "))
+}
+
+// SpotifyConfigForProfile returns the Spotify configuration for the named profile,
+// applying the profile's overrides to the SPOTIFY_ settings. The empty name returns
+// the SPOTIFY_ settings unchanged.
+func (c Config) SpotifyConfigForProfile(name string) (SpotifyConfig, error) {
+	profile, err := c.Profile(name)
+	if err != nil {
+		return SpotifyConfig{}, err
+	}
+	if name == "" {
+		return c.Spotify, nil
+	}
+
+	spotify := c.Spotify
+	spotify.Profiles = nil
+	spotify.TokenFilePath = filepath.Join(filepath.Dir(c.Spotify.TokenFilePath), "profiles", name, filepath.Base(c.Spotify.TokenFilePath))
+	spotify.TokenSecretPath = c.Spotify.TokenSecretPath + "_" + name
+
+	overrides := []struct {
+		target *string
+		value  string
+	}{
+		{&spotify.PlaylistNamePrefix, profile.PlaylistNamePrefix},
+		{&spotify.TokenFilePath, profile.TokenFilePath},
+		{&spotify.TokenStore, profile.TokenStore},
+		{&spotify.TokenKey, profile.TokenKey},
+		{&spotify.TokenKeyFile, profile.TokenKeyFile},
+		{&spotify.TokenSecretPath, profile.TokenSecretPath},
+	}
+	for _, override := range overrides {
+		if override.value != "" {
+			*override.target = override.value
+		}
+	}
+	return spotify, nil
+}
+
+// validateProfiles validates the profile names and each profile's token store.
+func validateProfiles(conf *Config) []string {
+	var errors []string
+
+	seen := make(map[string]bool)
+	for _, profile := range conf.Profiles {
+		if !profileNamePattern.MatchString(profile.Name) {
+			errors = append(errors, fmt.Sprintf("profile name %q must contain only lowercase letters, digits, '-' and '_'", profile.Name))
+			continue
+		}
+		if seen[profile.Name] {
+			errors = append(errors, fmt.Sprintf("profile %q is listed more than once", profile.Name))
+			continue
+		}
+		seen[profile.Name] = true
+
+		spotify, err := conf.SpotifyConfigForProfile(profile.Name)
+		if err != nil {
+			errors = append(errors, err.Error())
+			continue
+		}
+		for _, tokenErr := range validateTokenStore(spotify) {
+			errors = append(errors, fmt.Sprintf("profile %q: %s", profile.Name, tokenErr))
+		}
+	}
+
+	return errors
+}
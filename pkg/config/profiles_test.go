@@ -0,0 +1,104 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/caarlos0/env/v11"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProfiles(t *testing.T) {
+	t.Setenv("SPOTIFY_PLAYLIST_NAME_PREFIX", "KMHD")
+	t.Setenv("SPOTIFY_TOKEN_FILE_PATH", "/data/spotify_token.json")
+	t.Setenv("SPOTIFY_PROFILES", "alice, bob-2")
+	t.Setenv("SPOTIFY_PROFILE_ALICE_PLAYLIST_NAME_PREFIX", "Alice KMHD")
+	t.Setenv("SPOTIFY_PROFILE_ALICE_ARTISTS", "Miles Davis,John Coltrane")
+	t.Setenv("SPOTIFY_PROFILE_BOB_2_EXCLUDE_ARTISTS", "Kenny G")
+	t.Setenv("SPOTIFY_PROFILE_BOB_2_TOKEN_STORE", "encrypted")
+	t.Setenv("SPOTIFY_PROFILE_BOB_2_TOKEN_KEY", "bob-passphrase")
+
+	var conf Config
+	require.NoError(t, env.Parse(&conf))
+	require.NoError(t, parseProfiles(&conf))
+	require.NoError(t, validateConfig(&conf))
+
+	assert.Equal(t, []string{"alice", "bob-2"}, conf.ProfileNames())
+
+	alice, err := conf.Profile("alice")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Miles Davis", "John Coltrane"}, alice.Artists)
+
+	aliceSpotify, err := conf.SpotifyConfigForProfile("alice")
+	require.NoError(t, err)
+	assert.Equal(t, "Alice KMHD", aliceSpotify.PlaylistNamePrefix)
+	assert.Equal(t, "/data/profiles/alice/spotify_token.json", aliceSpotify.TokenFilePath)
+	assert.Equal(t, "/run/secrets/spotify_token_alice", aliceSpotify.TokenSecretPath)
+	assert.Equal(t, "file", aliceSpotify.TokenStore)
+	assert.Empty(t, aliceSpotify.Profiles)
+
+	bobSpotify, err := conf.SpotifyConfigForProfile("bob-2")
+	require.NoError(t, err)
+	assert.Equal(t, "KMHD", bobSpotify.PlaylistNamePrefix)
+	assert.Equal(t, "encrypted", bobSpotify.TokenStore)
+	assert.Equal(t, "bob-passphrase", bobSpotify.TokenKey)
+
+	_, err = conf.SpotifyConfigForProfile("carol")
+	assert.Error(t, err)
+}
+
+func TestParseProfiles_SharedPrefix(t *testing.T) {
+	conf := Config{Spotify: SpotifyConfig{Profiles: []string{"bob-2", "alice", "bob_2"}}}
+	err := parseProfiles(&conf)
+	assert.ErrorContains(t, err, `profiles "bob-2" and "bob_2" would both be configured with SPOTIFY_PROFILE_BOB_2_ variables`)
+
+	// Listing the same name twice is reported by validation instead
+	conf = Config{Spotify: SpotifyConfig{Profiles: []string{"alice", "alice"}}}
+	require.NoError(t, parseProfiles(&conf))
+	assert.Len(t, conf.Profiles, 2)
+}
+
+func TestDefaultProfile(t *testing.T) {
+	conf := Config{Spotify: SpotifyConfig{PlaylistNamePrefix: "KMHD", TokenFilePath: "/data/token.json"}}
+
+	assert.Equal(t, []string{""}, conf.ProfileNames())
+
+	spotify, err := conf.SpotifyConfigForProfile("")
+	require.NoError(t, err)
+	assert.Equal(t, conf.Spotify, spotify)
+
+	profile, err := conf.Profile("")
+	require.NoError(t, err)
+	assert.Empty(t, profile.Artists)
+}
+
+func TestValidateProfiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		profiles []ProfileConfig
+		wantErr  bool
+	}{
+		{name: "valid", profiles: []ProfileConfig{{Name: "alice"}, {Name: "bob"}}},
+		{name: "invalid name", profiles: []ProfileConfig{{Name: "Alice Smith"}}, wantErr: true},
+		{name: "duplicate", profiles: []ProfileConfig{{Name: "alice"}, {Name: "alice"}}, wantErr: true},
+		{name: "encrypted without key", profiles: []ProfileConfig{{Name: "alice", TokenStore: "encrypted"}}, wantErr: true},
+		{name: "unknown token store", profiles: []ProfileConfig{{Name: "alice", TokenStore: "vault"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := Config{Spotify: SpotifyConfig{TokenStore: "file"}, Profiles: tt.profiles}
+			errs := validateProfiles(&conf)
+			if tt.wantErr {
+				assert.NotEmpty(t, errs)
+			} else {
+				assert.Empty(t, errs)
+			}
+		})
+	}
+}
+
+func TestProfileEnvPrefix(t *testing.T) {
+	assert.Equal(t, "SPOTIFY_PROFILE_ALICE_", ProfileEnvPrefix("alice"))
+	assert.Equal(t, "SPOTIFY_PROFILE_BOB_2_", ProfileEnvPrefix("bob-2"))
+}
@@ -0,0 +1,365 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultRoutingTimezone is the timezone used for routing rules that don't set one,
+// KMHD's own timezone so show schedules match the station's.
+const DefaultRoutingTimezone = "America/Los_Angeles"
+
+// RoutePlaceholders are the placeholders that may be used in route playlist templates.
+var RoutePlaceholders = []string{
+	"prefix",     // SPOTIFY_PLAYLIST_NAME_PREFIX (or the profile's prefix)
+	"profile",    // profile name, empty for the default account
+	"year",       // four-digit year the song was played
+	"month",      // two-digit month, e.g. 03
+	"month_name", // month name, e.g. March
+	"quarter",    // quarter of the year, 1-4
+	"week",       // two-digit ISO week, e.g. 09
+	"week_year",  // ISO week-numbering year
+	"date",       // date the song was played, e.g. 2025-03-01
+	"show",       // name of the show the song was played in
+}
+
+// placeholderPattern matches placeholders such as {year} in route playlist templates.
+var placeholderPattern = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// weekdays maps the day names accepted in routing rules to weekdays.
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// RoutingConfig represents the configuration for routing songs to playlists.
+type RoutingConfig struct {
+	// RulesFile is a YAML file defining where songs are synced to. If not specified,
+	// songs are synced to the monthly "{prefix}-YYYY-MM" playlist.
+	RulesFile string `env:"RULES_FILE"`
+
+	// Rules are the routing rules loaded from RulesFile, or nil if none are configured.
+	Rules *RoutingRules `env:"-"`
+}
+
+// RoutingRules define the playlists each song is synced to. Every route whose
+// conditions match the song adds it to the route's playlist, so a song can be routed
+// to several playlists.
+//
+// Example:
+//
+//	timezone: America/Los_Angeles
+//	lists:
+//	  favourites: [Miles Davis, John Coltrane]
+//	shows:
+//	  - name: Late Night Jazz
+//	    between: "22:00-02:00"
+//	routes:
+//	  - playlist: "{prefix} {year} Q{quarter}"
+//	  - playlist: Favourites
+//	    when: {artist_list: favourites}
+//	  - playlist: Latin Jazz
+//	    when: {genre_contains: Latin}
+//	  - playlist: "{prefix} {show}"
+//	    when: {show: "*"}
+type RoutingRules struct {
+	// Timezone is the IANA timezone in which play times are evaluated and playlist
+	// names are rendered. Defaults to DefaultRoutingTimezone.
+	Timezone string `yaml:"timezone"`
+
+	// Lists are named artist lists that routes can refer to with artist_list.
+	Lists map[string][]string `yaml:"lists"`
+
+	// Shows are named time slots that routes can match and name playlists after.
+	Shows []ShowRule `yaml:"shows"`
+
+	// Routes are evaluated in order for every song.
+	Routes []RouteRule `yaml:"routes"`
+}
+
+// ShowRule is a named time slot on the station's schedule.
+type ShowRule struct {
+	Name string `yaml:"name"`
+
+	// Days are the days the show starts on (sun, mon, ..., sat). If empty, every day.
+	Days []string `yaml:"days"`
+
+	// Between is the show's time slot, e.g. "22:00-02:00". Slots may wrap past midnight.
+	Between string `yaml:"between"`
+}
+
+// RouteRule routes songs matching its conditions to a playlist.
+type RouteRule struct {
+	// Name describes the route in logs. Defaults to the playlist template.
+	Name string `yaml:"name"`
+
+	// Playlist is the playlist name template, e.g. "{prefix}-{year}-{month}" or a
+	// fixed name for a single rolling playlist. See RoutePlaceholders.
+	Playlist string `yaml:"playlist"`
+
+	// When holds the conditions a song must meet. A route without conditions matches every song.
+	When RouteCondition `yaml:"when"`
+
+	// Profiles restricts the route to these profiles. If empty, the route applies to every account.
+	Profiles []string `yaml:"profiles"`
+
+	// Stop skips the remaining routes when this route matches.
+	Stop bool `yaml:"stop"`
+}
+
+// RouteCondition holds the conditions of a route. All set conditions must match.
+type RouteCondition struct {
+	// Artists matches songs by any of these artists (case-insensitive).
+	Artists []string `yaml:"artists"`
+
+	// ArtistList matches songs by any artist in the named list.
+	ArtistList string `yaml:"artist_list"`
+
+	// GenreContains matches songs whose KMHD genre contains the text (case-insensitive).
+	GenreContains string `yaml:"genre_contains"`
+
+	// Show matches songs played during the named show, or during any show if "*".
+	Show string `yaml:"show"`
+
+	// Days matches songs played on these days (sun, mon, ..., sat).
+	Days []string `yaml:"days"`
+
+	// Between matches songs played in the time slot, e.g. "22:00-02:00".
+	Between string `yaml:"between"`
+}
+
+// DisplayName returns the route's name, or its playlist template if it has none.
+func (r RouteRule) DisplayName() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return r.Playlist
+}
+
+// TimeWindow is a daily time slot as offsets from midnight. End may be before Start
+// for slots that wrap past midnight.
+type TimeWindow struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// ParseTimeWindow parses a time slot such as "22:00-02:00".
+func ParseTimeWindow(s string) (TimeWindow, error) {
+	startText, endText, ok := strings.Cut(s, "-")
+	if !ok {
+		return TimeWindow{}, fmt.Errorf("time slot %q must be formatted as HH:MM-HH:MM", s)
+	}
+
+	start, err := parseClock(startText)
+	if err != nil {
+		return TimeWindow{}, fmt.Errorf("time slot %q: %w", s, err)
+	}
+	end, err := parseClock(endText)
+	if err != nil {
+		return TimeWindow{}, fmt.Errorf("time slot %q: %w", s, err)
+	}
+	if start == end {
+		return TimeWindow{}, fmt.Errorf("time slot %q is empty", s)
+	}
+	return TimeWindow{Start: start, End: end}, nil
+}
+
+// Contains reports whether the time of day of t falls in the slot, and whether it falls
+// in the part of a slot that wraps past midnight, which belongs to the previous day.
+func (w TimeWindow) Contains(t time.Time) (inside, previousDay bool) {
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	switch {
+	case w.Start < w.End:
+		return offset >= w.Start && offset < w.End, false
+	case offset >= w.Start:
+		return true, false
+	case offset < w.End:
+		return true, true
+	default:
+		return false, false
+	}
+}
+
+// parseClock parses a time of day such as "22:00" as an offset from midnight.
+// "24:00" is accepted as the end of the day.
+func parseClock(s string) (time.Duration, error) {
+	hoursText, minutesText, ok := strings.Cut(strings.TrimSpace(s), ":")
+	hours, hoursErr := strconv.Atoi(hoursText)
+	minutes, minutesErr := strconv.Atoi(minutesText)
+	if !ok || hoursErr != nil || minutesErr != nil || len(minutesText) != 2 ||
+		hours < 0 || minutes < 0 || minutes > 59 || hours > 24 || (hours == 24 && minutes != 0) {
+		return 0, fmt.Errorf("invalid time of day %q", s)
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute, nil
+}
+
+// ParseWeekday parses a day name used in routing rules, such as "mon" or "Monday".
+func ParseWeekday(s string) (time.Weekday, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if len(name) >= 3 {
+		if day, ok := weekdays[name[:3]]; ok && strings.HasPrefix(strings.ToLower(day.String()), name) {
+			return day, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid day %q", s)
+}
+
+// LoadRoutingRules reads and parses a routing rules file. Unknown fields are rejected
+// so typos don't silently disable a route.
+func LoadRoutingRules(path string) (*RoutingRules, error) {
+	rulesFile, err := expandPath(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(rulesFile) // #nosec G304 -- path comes from configuration
+	if err != nil {
+		return nil, fmt.Errorf("failed to read routing rules file: %w", err)
+	}
+
+	var rules RoutingRules
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&rules); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse routing rules file %s: %w", rulesFile, err)
+	}
+	return &rules, nil
+}
+
+// loadRoutingRules loads the routing rules file configured in ROUTING_RULES_FILE.
+func loadRoutingRules(conf *Config) error {
+	conf.Routing.Rules = nil
+	if conf.Routing.RulesFile == "" {
+		return nil
+	}
+
+	rules, err := LoadRoutingRules(conf.Routing.RulesFile)
+	if err != nil {
+		return err
+	}
+	conf.Routing.Rules = rules
+	return nil
+}
+
+// validateRouting validates the routing rules, if any are configured.
+func validateRouting(conf *Config) []string {
+	rules := conf.Routing.Rules
+	if rules == nil {
+		return nil
+	}
+
+	var errors []string
+	if rules.Timezone != "" {
+		if _, err := time.LoadLocation(rules.Timezone); err != nil {
+			errors = append(errors, fmt.Sprintf("routing timezone %q is invalid: %s", rules.Timezone, err))
+		}
+	}
+
+	shows := make(map[string]bool)
+	for i, show := range rules.Shows {
+		name := fmt.Sprintf("routing show %d (%s)", i+1, show.Name)
+		if show.Name == "" {
+			errors = append(errors, fmt.Sprintf("routing show %d must have a name", i+1))
+		} else if shows[show.Name] {
+			errors = append(errors, fmt.Sprintf("routing show %q is defined more than once", show.Name))
+		}
+		shows[show.Name] = true
+		if show.Between == "" {
+			errors = append(errors, fmt.Sprintf("%s must set between", name))
+		}
+		errors = append(errors, validateSchedule(name, show.Days, show.Between)...)
+	}
+
+	if len(rules.Routes) == 0 {
+		errors = append(errors, "routing rules file defines no routes")
+	}
+	profiles := conf.ProfileNames()
+	for i, route := range rules.Routes {
+		name := fmt.Sprintf("routing route %d (%s)", i+1, route.DisplayName())
+		errors = append(errors, validatePlaylistTemplate(name, route.Playlist, conf)...)
+
+		for _, profile := range route.Profiles {
+			if len(conf.Profiles) == 0 {
+				errors = append(errors, fmt.Sprintf("%s restricts profiles but SPOTIFY_PROFILES is not set", name))
+				break
+			}
+			if !slices.Contains(profiles, profile) {
+				errors = append(errors, fmt.Sprintf("%s refers to unknown profile %q", name, profile))
+			}
+		}
+
+		when := route.When
+		if when.ArtistList != "" {
+			if _, ok := rules.Lists[when.ArtistList]; !ok {
+				errors = append(errors, fmt.Sprintf("%s refers to unknown artist list %q", name, when.ArtistList))
+			}
+		}
+		if when.Show != "" && when.Show != "*" && !shows[when.Show] {
+			errors = append(errors, fmt.Sprintf("%s refers to unknown show %q", name, when.Show))
+		}
+		errors = append(errors, validateSchedule(name, when.Days, when.Between)...)
+	}
+
+	return errors
+}
+
+// validateSchedule validates the days and time slot of a show or route condition.
+func validateSchedule(name string, days []string, between string) []string {
+	var errors []string
+	for _, day := range days {
+		if _, err := ParseWeekday(day); err != nil {
+			errors = append(errors, fmt.Sprintf("%s: %s", name, err))
+		}
+	}
+	if between != "" {
+		if _, err := ParseTimeWindow(between); err != nil {
+			errors = append(errors, fmt.Sprintf("%s: %s", name, err))
+		}
+	}
+	return errors
+}
+
+// validatePlaylistTemplate validates a route's playlist name template. Routes using
+// {prefix} need a playlist name prefix for every account.
+func validatePlaylistTemplate(name, template string, conf *Config) []string {
+	if strings.TrimSpace(template) == "" {
+		return []string{fmt.Sprintf("%s must set playlist", name)}
+	}
+
+	var errors []string
+	for _, match := range placeholderPattern.FindAllStringSubmatch(template, -1) {
+		placeholder := match[1]
+		if !slices.Contains(RoutePlaceholders, placeholder) {
+			errors = append(errors, fmt.Sprintf("%s uses unknown placeholder {%s} (valid: %s)", name, placeholder, strings.Join(RoutePlaceholders, ", ")))
+			continue
+		}
+		if placeholder != "prefix" {
+			continue
+		}
+		for _, profile := range conf.ProfileNames() {
+			spotify, err := conf.SpotifyConfigForProfile(profile)
+			if err == nil && spotify.PlaylistNamePrefix == "" {
+				errors = append(errors, fmt.Sprintf("%s uses {prefix} but SPOTIFY_PLAYLIST_NAME_PREFIX is not set%s", name, profileErrorSuffix(profile)))
+			}
+		}
+	}
+	return errors
+}
+
+// profileErrorSuffix names the profile in validation errors, or returns an empty
+// string for the default account.
+func profileErrorSuffix(profile string) string {
+	if profile == "" {
+		return ""
+	}
+	return fmt.Sprintf(" for profile %q", profile)
+}
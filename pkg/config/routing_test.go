@@ -0,0 +1,169 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeRulesFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "routes.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoadRoutingRules(t *testing.T) {
+	t.Setenv("SPOTIFY_PLAYLIST_NAME_PREFIX", "KMHD")
+	t.Setenv("ROUTING_RULES_FILE", writeRulesFile(t, `
+timezone: America/Los_Angeles
+lists:
+  favourites: [Miles Davis, John Coltrane]
+shows:
+  - name: Late Night Jazz
+    days: [fri, saturday]
+    between: "22:00-02:00"
+routes:
+  - playlist: "{prefix} {year} Q{quarter}"
+  - name: favourites
+    playlist: Favourites
+    when:
+      artist_list: favourites
+  - playlist: Latin Jazz
+    when: {genre_contains: Latin}
+    stop: true
+  - playlist: "{prefix} {show}"
+    when: {show: "*"}
+`))
+
+	var conf Config
+	require.NoError(t, env.Parse(&conf))
+	require.NoError(t, loadRoutingRules(&conf))
+	require.NoError(t, validateConfig(&conf))
+
+	rules := conf.Routing.Rules
+	require.NotNil(t, rules)
+	assert.Equal(t, "America/Los_Angeles", rules.Timezone)
+	assert.Equal(t, []string{"Miles Davis", "John Coltrane"}, rules.Lists["favourites"])
+	require.Len(t, rules.Shows, 1)
+	assert.Equal(t, []string{"fri", "saturday"}, rules.Shows[0].Days)
+	require.Len(t, rules.Routes, 4)
+	assert.Equal(t, "favourites", rules.Routes[1].DisplayName())
+	assert.Equal(t, "Latin Jazz", rules.Routes[2].DisplayName())
+	assert.True(t, rules.Routes[2].Stop)
+	assert.Equal(t, "*", rules.Routes[3].When.Show)
+}
+
+func TestLoadRoutingRulesErrors(t *testing.T) {
+	conf := Config{Routing: RoutingConfig{}}
+	require.NoError(t, loadRoutingRules(&conf))
+	assert.Nil(t, conf.Routing.Rules)
+
+	_, err := LoadRoutingRules(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+
+	// Typos in field names are rejected
+	_, err = LoadRoutingRules(writeRulesFile(t, "routes:\n  - playlsit: Favourites\n"))
+	assert.Error(t, err)
+}
+
+func TestValidateRouting(t *testing.T) {
+	tests := []struct {
+		name   string
+		rules  RoutingRules
+		prefix string
+		errors int
+	}{
+		{"valid", RoutingRules{Routes: []RouteRule{{Playlist: "{prefix}-{year}-{month}"}}}, "KMHD", 0},
+		{"no routes", RoutingRules{}, "KMHD", 1},
+		{"missing playlist", RoutingRules{Routes: []RouteRule{{}}}, "KMHD", 1},
+		{"unknown placeholder", RoutingRules{Routes: []RouteRule{{Playlist: "{prefix} {decade}"}}}, "KMHD", 1},
+		{"prefix without prefix", RoutingRules{Routes: []RouteRule{{Playlist: "{prefix}"}}}, "", 1},
+		{"invalid timezone", RoutingRules{Timezone: "Mars/Olympus", Routes: []RouteRule{{Playlist: "x"}}}, "", 1},
+		{"unknown list", RoutingRules{Routes: []RouteRule{{Playlist: "x", When: RouteCondition{ArtistList: "nope"}}}}, "", 1},
+		{"unknown show", RoutingRules{Routes: []RouteRule{{Playlist: "x", When: RouteCondition{Show: "nope"}}}}, "", 1},
+		{"invalid schedule", RoutingRules{Routes: []RouteRule{{Playlist: "x", When: RouteCondition{Days: []string{"someday"}, Between: "22:00"}}}}, "", 2},
+		{"profiles without profiles", RoutingRules{Routes: []RouteRule{{Playlist: "x", Profiles: []string{"alice"}}}}, "", 1},
+		{"invalid shows", RoutingRules{Shows: []ShowRule{{Between: "22:00-02:00"}, {Name: "a"}}, Routes: []RouteRule{{Playlist: "x"}}}, "", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := tt.rules
+			conf := &Config{
+				Spotify: SpotifyConfig{PlaylistNamePrefix: tt.prefix},
+				Routing: RoutingConfig{Rules: &rules},
+			}
+			assert.Len(t, validateRouting(conf), tt.errors)
+		})
+	}
+}
+
+func TestValidateRoutingProfiles(t *testing.T) {
+	conf := &Config{
+		Spotify:  SpotifyConfig{PlaylistNamePrefix: ""},
+		Profiles: []ProfileConfig{{Name: "alice", PlaylistNamePrefix: "Alice"}, {Name: "bob"}},
+		Routing: RoutingConfig{Rules: &RoutingRules{Routes: []RouteRule{
+			{Playlist: "{prefix}", Profiles: []string{"alice", "carol"}},
+		}}},
+	}
+
+	// carol is unknown and bob has no prefix
+	assert.Len(t, validateRouting(conf), 2)
+}
+
+func TestParseTimeWindow(t *testing.T) {
+	window, err := ParseTimeWindow("22:00-02:30")
+	require.NoError(t, err)
+	assert.Equal(t, TimeWindow{Start: 22 * time.Hour, End: 2*time.Hour + 30*time.Minute}, window)
+
+	window, err = ParseTimeWindow("18:00-24:00")
+	require.NoError(t, err)
+	assert.Equal(t, 24*time.Hour, window.End)
+
+	for _, invalid := range []string{"", "22:00", "25:00-02:00", "22:60-23:00", "10:00-10:00", "22-02", "9:5-10:00"} {
+		_, err := ParseTimeWindow(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestTimeWindowContains(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2025, time.October, 17, hour, minute, 0, 0, time.UTC)
+	}
+
+	evening := TimeWindow{Start: 19 * time.Hour, End: 23 * time.Hour}
+	inside, previousDay := evening.Contains(at(19, 0))
+	assert.True(t, inside)
+	assert.False(t, previousDay)
+	inside, _ = evening.Contains(at(23, 0))
+	assert.False(t, inside, "the end is exclusive")
+
+	overnight := TimeWindow{Start: 22 * time.Hour, End: 2 * time.Hour}
+	inside, previousDay = overnight.Contains(at(23, 30))
+	assert.True(t, inside)
+	assert.False(t, previousDay)
+	inside, previousDay = overnight.Contains(at(1, 30))
+	assert.True(t, inside)
+	assert.True(t, previousDay)
+	inside, _ = overnight.Contains(at(12, 0))
+	assert.False(t, inside)
+}
+
+func TestParseWeekday(t *testing.T) {
+	for input, expected := range map[string]time.Weekday{"mon": time.Monday, "Saturday": time.Saturday, " SUN ": time.Sunday, "thurs": time.Thursday} {
+		day, err := ParseWeekday(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, day, input)
+	}
+
+	for _, invalid := range []string{"", "mo", "monkey", "weekday"} {
+		_, err := ParseWeekday(invalid)
+		assert.Error(t, err, invalid)
+	}
+}